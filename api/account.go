@@ -7,10 +7,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
 )
 
+// customers open accounts for themselves, without naming the owner
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
+	Owner    string `json:"owner"`
 	Currency string `json:"currency" binding:"required"`
 }
 
@@ -18,18 +20,23 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	var request createAccountRequest
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithBindError(ctx, err)
 		return
 	}
 
 	if !currency.IsSupportedCurrency(request.Currency) {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is an unsupported currency.", request.Currency)})
+		abortWithProblem(ctx, http.StatusBadRequest, codeUnsupportedCurrency, fmt.Sprintf("%s is an unsupported currency.", request.Currency))
 		return
 	}
 
-	createdAccount, err := server.store.CreateAccount(ctx, request.Owner, 0, request.Currency)
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	createdAccount, err := server.store.CreateAccount(ctx, owner, 0, request.Currency)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		server.abortWithInternalError(ctx, err)
 		return
 	}
 
@@ -44,17 +51,17 @@ func (server *Server) getAccountByID(ctx *gin.Context) {
 	var request getAccountByIDRequest
 
 	if err := ctx.ShouldBindUri(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithBindError(ctx, err)
 		return
 	}
 
-	account, err := server.store.GetAccountByID(ctx, request.ID)
+	account, err := server.accountByID(ctx, request.ID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"message": fmt.Sprintf("Account with id %d not found.", request.ID)})
+			abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", request.ID))
 		} else {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			server.abortWithInternalError(ctx, err)
 		}
 		return
 	}
@@ -62,41 +69,95 @@ func (server *Server) getAccountByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, account)
 }
 
-type listAccountsByOwnerRequestQuery struct {
-	PageID   int64 `form:"page_id" binding:"required,min=1"`
-	PageSize int64 `form:"page_size" binding:"required,min=1,max=100"`
+// account id, as authorize loaded it to check its owner when it did
+func (server *Server) accountByID(ctx *gin.Context, id int64) (*db.Account, error) {
+	if account, ok := ctx.Value(accountKey).(*db.Account); ok && account.ID == id {
+		return account, nil
+	}
+	return server.store.GetAccountByID(ctx, id)
 }
 
-type listAccountsByOwnerRequestJSON struct {
+type listAccountsQuery struct {
+	Owner string `form:"owner"`
+	pageQuery
+}
+
+func (server *Server) listAccounts(ctx *gin.Context) {
+	var request listAccountsQuery
+
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	listPage(server, ctx, "accounts:"+owner, request.pageQuery, func(account db.Account) db.PageKey {
+		return db.PageKey{CreatedAt: account.CreatedAt, ID: account.ID}
+	}, func(page db.Page) (*[]db.Account, error) {
+		return server.store.ListAccounts(ctx, owner, page)
+	})
+}
+
+// the owner is all an account has that can change, and only admins change it
+type patchAccountRequest struct {
 	Owner string `json:"owner" binding:"required"`
 }
 
-func (server *Server) listAccountsByOwner(ctx *gin.Context) {
-	var requestQueryParam listAccountsByOwnerRequestQuery
-	var requestJSON listAccountsByOwnerRequestJSON
+func (server *Server) patchAccount(ctx *gin.Context) {
+	var requestURI getAccountByIDRequest
+	var request patchAccountRequest
 
-	if err := ctx.ShouldBindJSON(&requestJSON); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
 		return
 	}
 
-	if err := ctx.ShouldBindQuery(&requestQueryParam); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
 		return
 	}
 
-	accounts, err := server.store.ListAccounts(ctx, requestJSON.Owner, requestQueryParam.PageSize, requestQueryParam.PageSize*(requestQueryParam.PageID-1))
+	rowsAffected, err := server.store.UpdateAccountOwner(ctx, requestURI.ID, request.Owner)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	server.respondChangedAccount(ctx, requestURI.ID, rowsAffected)
+}
+
+type listAccountsByOwnerRequestJSON struct {
+	Owner string `json:"owner"`
+}
+
+func (server *Server) listAccountsByOwner(ctx *gin.Context) {
+	var requestQueryParam pageQuery
+	var requestJSON listAccountsByOwnerRequestJSON
+
+	if err := ctx.ShouldBindJSON(&requestJSON); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&requestQueryParam); err != nil {
+		abortWithBindError(ctx, err)
 		return
 	}
 
-	if accounts == nil || len(*accounts) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": fmt.Sprintf("Accounts from entry %d not found.", requestQueryParam.PageSize*(requestQueryParam.PageID-1))})
+	owner, ok := ownerFor(ctx, requestJSON.Owner)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	listPage(server, ctx, "accounts:"+owner, requestQueryParam, func(account db.Account) db.PageKey {
+		return db.PageKey{CreatedAt: account.CreatedAt, ID: account.ID}
+	}, func(page db.Page) (*[]db.Account, error) {
+		return server.store.ListAccounts(ctx, owner, page)
+	})
 }
 
 type updateAccountOwnerRequest struct {
@@ -108,14 +169,20 @@ func (server *Server) updateAccountOwner(ctx *gin.Context) {
 	var request updateAccountOwnerRequest
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.Status(http.StatusBadRequest)
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	// the account is in the body, authorize only sees the ones in paths
+	if caller := callerOf(ctx); !caller.mayUseAccount(request.ID) {
+		abortWithProblem(ctx, http.StatusForbidden, codeForbidden, fmt.Sprintf("%s may not use account %d.", caller.Name, request.ID))
 		return
 	}
 
 	rowsAffected, err := server.store.UpdateAccountOwner(ctx, request.ID, request.NewOwner)
 
 	if err != nil {
-		ctx.Status(http.StatusInternalServerError)
+		server.abortWithInternalError(ctx, err)
 		return
 	}
 
@@ -134,19 +201,19 @@ func (server *Server) deleteAccountByID(ctx *gin.Context) {
 	var request deleteAccountByIDRequest
 
 	if err := ctx.ShouldBindUri(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithBindError(ctx, err)
 		return
 	}
 
 	rowsAffected, err := server.store.DeleteAccountByID(ctx, request.ID)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		server.abortWithInternalError(ctx, err)
 		return
 	}
 
 	if rowsAffected != 1 {
-		ctx.Status(http.StatusNotFound)
+		abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", request.ID))
 	} else {
 		ctx.Status(http.StatusNoContent)
 	}
@@ -19,14 +19,6 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
-type messageStruct struct {
-	Message string `json:"message"`
-}
-
-type errorStruct struct {
-	Error string `json:"error"`
-}
-
 func randomAccount() *db.Account {
 	return &db.Account{
 		ID:       utils.RandomInt(1, 1000),
@@ -56,7 +48,7 @@ func beforeEach(t *testing.T) (*mockdb.MockStore, *Server, *httptest.ResponseRec
 
 	store := mockdb.NewMockStore(ctrl)
 
-	server := NewServer(store)
+	server := NewServer(store, testConfig(), testLogger())
 
 	recorder := httptest.NewRecorder()
 
@@ -89,6 +81,7 @@ func TestGetAccountByIDOK(t *testing.T) {
 	url := fmt.Sprintf("/account/%d", account.ID)
 	request, err := http.NewRequest(http.MethodGet, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -98,12 +91,13 @@ func TestGetAccountByIDOK(t *testing.T) {
 
 // When invalid uri param is sent in the request, the server should respond with bad request status code.
 func TestGetAccountByIDInvalidURI(t *testing.T) {
-	_, server, recorder := beforeEach(t)
+	store, server, recorder := beforeEach(t)
 
 	// send request
 	url := fmt.Sprintf("/account/%d", 0)
 	request, err := http.NewRequest(http.MethodGet, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -124,6 +118,7 @@ func TestGetAccocuntByIDNotFound(t *testing.T) {
 	url := fmt.Sprintf("/account/%d", account.ID)
 	request, err := http.NewRequest(http.MethodGet, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -131,10 +126,11 @@ func TestGetAccocuntByIDNotFound(t *testing.T) {
 	responseBody, err := io.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 
-	var response messageStruct
+	var response problem
 	err = json.Unmarshal(responseBody, &response)
 	assert.NoError(t, err)
-	assert.Equal(t, fmt.Sprintf("Account with id %d not found.", account.ID), response.Message)
+	assert.Equal(t, codeAccountNotFound, response.Code)
+	assert.Equal(t, fmt.Sprintf("Account with id %d not found.", account.ID), response.Detail)
 }
 
 // When a random error like connection lose with the database occurs, it should return internal server error status code with apt message.
@@ -151,6 +147,7 @@ func TestGetAccountByIDInternalServerError(t *testing.T) {
 	url := fmt.Sprintf("/account/%d", account.ID)
 	request, err := http.NewRequest(http.MethodGet, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -158,10 +155,11 @@ func TestGetAccountByIDInternalServerError(t *testing.T) {
 	responseBody, err := io.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 
-	var response errorStruct
+	var response problem
 	err = json.Unmarshal(responseBody, &response)
 	assert.NoError(t, err)
-	assert.Equal(t, sql.ErrConnDone.Error(), response.Error)
+	assert.Equal(t, codeInternal, response.Code)
+	assert.NotContains(t, response.Detail, sql.ErrConnDone.Error())
 }
 
 // When required owner and the currency is supported, the server should create a new account and return status ok with the created account.
@@ -183,6 +181,7 @@ func TestCreateAccountOK(t *testing.T) {
 	url := "/account/create"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -192,7 +191,7 @@ func TestCreateAccountOK(t *testing.T) {
 
 // When the requested currency is not supported, it should respond with status bad request with apt error message.
 func TestCreateAccountUnsupportedCurrency(t *testing.T) {
-	_, server, recorder := beforeEach(t)
+	store, server, recorder := beforeEach(t)
 	account := randomAccount()
 
 	body := gin.H{"owner": account.Owner, "currency": "XYZ"}
@@ -203,21 +202,23 @@ func TestCreateAccountUnsupportedCurrency(t *testing.T) {
 	url := "/account/create"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
-	var response errorStruct
+	var response problem
 	responseBody, err := io.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 	err = json.Unmarshal(responseBody, &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "XYZ is an unsupported currency.", response.Error)
+	assert.Equal(t, codeUnsupportedCurrency, response.Code)
+	assert.Equal(t, "XYZ is an unsupported currency.", response.Detail)
 }
 
 // When the required JSON object is not present in the request, it should respond with status bad request with apt message.
 func TestCreateAccountBadRequestBody(t *testing.T) {
-	_, server, recorder := beforeEach(t)
+	store, server, recorder := beforeEach(t)
 
 	body := gin.H{}
 	data, err := json.Marshal(body)
@@ -227,6 +228,7 @@ func TestCreateAccountBadRequestBody(t *testing.T) {
 	url := "/account/create"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -251,27 +253,29 @@ func TestCreateAccountInternalServerError(t *testing.T) {
 	url := "/account/create"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
 	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
-	var response errorStruct
+	var response problem
 	responseBody, err := io.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 	err = json.Unmarshal(responseBody, &response)
 	assert.NoError(t, err)
-	assert.Equal(t, sql.ErrConnDone.Error(), response.Error)
+	assert.Equal(t, codeInternal, response.Code)
+	assert.NotContains(t, response.Detail, sql.ErrConnDone.Error())
 }
 
-// When correct query parameter and account owner is passed, it should return accounts taking into consideration pagition limit and size.
+// When correct query parameter and account owner is passed, it should return the first page of accounts in the response envelope.
 func TestListAccountsByOwnerOK(t *testing.T) {
 	store, server, recorder := beforeEach(t)
 	accounts := randomAccounts(5)
 	owner := (*accounts)[0].Owner
 
-	// build stubs
+	// build stubs, one more account than the page size is asked for to know whether there are more
 	store.EXPECT().
-		ListAccounts(gomock.Any(), gomock.Eq(owner), gomock.Eq(int64(5)), gomock.Eq(int64(0))).
+		ListAccounts(gomock.Any(), gomock.Eq(owner), gomock.Eq(db.Page{Limit: 6, Order: db.SortAsc})).
 		Times(1).
 		Return(accounts, nil)
 
@@ -282,27 +286,32 @@ func TestListAccountsByOwnerOK(t *testing.T) {
 	url := "/accounts"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, owner)
 	q := request.URL.Query()
-	q.Add("page_id", "1")
 	q.Add("page_size", "5")
 	request.URL.RawQuery = q.Encode()
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
 	assert.Equal(t, http.StatusOK, recorder.Code)
-	requireBodyMatchAccounts[[]db.Account](t, accounts, recorder.Body)
+	var response pageResponse[db.Account]
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, *accounts, response.Data)
+	assert.False(t, response.HasMore)
+	assert.Empty(t, response.NextCursor)
+	assert.Empty(t, response.PrevCursor)
 }
 
 // When owner data is not provided, it should respond with status code of bad request.
 func TestListAccountsByOwnerInvalidOwner(t *testing.T) {
-	_, server, recorder := beforeEach(t)
+	store, server, recorder := beforeEach(t)
 
 	// build & send request
 	url := "/accounts"
 	request, err := http.NewRequest(http.MethodPost, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
 	q := request.URL.Query()
-	q.Add("page_id", "1")
 	q.Add("page_size", "5")
 	request.URL.RawQuery = q.Encode()
 	server.router.ServeHTTP(recorder, request)
@@ -311,42 +320,30 @@ func TestListAccountsByOwnerInvalidOwner(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 }
 
-// When page_id or page_size is not provided or invalid in the reqest query parameter. it should respond with status code of bad request.
+// When page_size is not provided or the query parameters are invalid. it should respond with status code of bad request.
 func TestListAccountsByOwnerBadQueryParam(t *testing.T) {
-	_, server, recorder := beforeEach(t)
-
-	// build & send request
-	body := gin.H{"owner": utils.RandomOwner()}
-	data, err := json.Marshal(body)
-	assert.NoError(t, err)
-	url := "/accounts"
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
-	assert.NoError(t, err)
-	server.router.ServeHTTP(recorder, request)
-
-	// check response
-	assert.Equal(t, http.StatusBadRequest, recorder.Code)
-
-	// with only page_id
-	q := request.URL.Query()
-	q.Add("page_id", "1")
-	request.URL.RawQuery = q.Encode()
-	server.router.ServeHTTP(recorder, request)
-	assert.Equal(t, http.StatusBadRequest, recorder.Code)
-
-	// with only page_size
-	q.Del("page_id")
-	q.Add("page_size", "5")
-	request.URL.RawQuery = q.Encode()
-	server.router.ServeHTTP(recorder, request)
-	assert.Equal(t, http.StatusBadRequest, recorder.Code)
-
-	// with invalid query parameter
-	q.Add("page_id", "-1")
-	q.Set("page_size", "1000")
-	request.URL.RawQuery = q.Encode()
-	server.router.ServeHTTP(recorder, request)
-	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	store, server, _ := beforeEach(t)
+
+	for name, query := range map[string]string{
+		"no page_size":      "",
+		"page_size too big": "page_size=1000",
+		"negative":          "page_size=-1",
+		"unknown order":     "page_size=5&order=sideways",
+		"forged cursor":     "page_size=5&cursor=eyJzIjoiYSJ9.c2lnbmF0dXJl",
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			owner := utils.RandomOwner()
+			data, err := json.Marshal(gin.H{"owner": owner})
+			assert.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/accounts?"+query, bytes.NewReader(data))
+			assert.NoError(t, err)
+			expectCustomer(t, store, request, owner)
+			server.router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
 }
 
 // When any internal server occurs like connection to DB terminated, then it should respond with status internal server error.
@@ -355,64 +352,62 @@ func TestListAccountsByOwnerInternalServerError(t *testing.T) {
 
 	// build stubs
 	store.EXPECT().
-		ListAccounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		ListAccounts(gomock.Any(), gomock.Any(), gomock.Any()).
 		Times(1).
 		Return(nil, sql.ErrConnDone)
 
 	// build & send request
-	body := gin.H{"owner": utils.RandomOwner()}
+	owner := utils.RandomOwner()
+	body := gin.H{"owner": owner}
 	data, err := json.Marshal(body)
 	assert.NoError(t, err)
 	url := "/accounts"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, owner)
 	q := request.URL.Query()
-	q.Add("page_id", "1")
 	q.Add("page_size", "5")
 	request.URL.RawQuery = q.Encode()
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
 	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
-	var response errorStruct
+	var response problem
 	responseBody, err := io.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 	err = json.Unmarshal(responseBody, &response)
 	assert.NoError(t, err)
-	assert.Equal(t, sql.ErrConnDone.Error(), response.Error)
+	assert.Equal(t, codeInternal, response.Code)
+	assert.NotContains(t, response.Detail, sql.ErrConnDone.Error())
 }
 
-// If there are no account records for the request owner, then it should respond with status not found with apt message.
+// If there are no account records for the request owner, then it should respond with status OK and an empty page.
 func TestListAccountsByOwnerNoRecords(t *testing.T) {
 	store, server, recorder := beforeEach(t)
 
 	// build stubs
 	store.EXPECT().
-		ListAccounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		ListAccounts(gomock.Any(), gomock.Any(), gomock.Any()).
 		Times(1).
 		Return(&[]db.Account{}, nil)
 
 	// build & send request
-	body := gin.H{"owner": utils.RandomOwner()}
+	owner := utils.RandomOwner()
+	body := gin.H{"owner": owner}
 	data, err := json.Marshal(body)
 	assert.NoError(t, err)
 	url := "/accounts"
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, owner)
 	q := request.URL.Query()
-	q.Add("page_id", "1")
 	q.Add("page_size", "5")
 	request.URL.RawQuery = q.Encode()
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
-	assert.Equal(t, http.StatusNotFound, recorder.Code)
-	var response messageStruct
-	responseBody, err := io.ReadAll(recorder.Body)
-	assert.NoError(t, err)
-	err = json.Unmarshal(responseBody, &response)
-	assert.NoError(t, err)
-	assert.Equal(t, "Accounts from entry 0 not found.", response.Message)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data": [], "has_more": false}`, recorder.Body.String())
 }
 
 // When a correct request is sent to update the owner of an account, it should update the owner information for that account and respond with status no content indicating successfull operation.
@@ -434,6 +429,7 @@ func TestUpdateAccountOwnerOK(t *testing.T) {
 	url := "/account/update"
 	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleAdmin)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -442,12 +438,13 @@ func TestUpdateAccountOwnerOK(t *testing.T) {
 
 // When required data is not sent in the request, server should respond with status bad request.
 func TestUpdateAccountOwnerBadRequest(t *testing.T) {
-	_, server, recorder := beforeEach(t)
+	store, server, recorder := beforeEach(t)
 
 	// build & send request
 	url := "/account/update"
 	request, err := http.NewRequest(http.MethodPut, url, nil)
 	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleAdmin)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -473,12 +470,33 @@ func TestUpdateAccountOwnerInternalServerError(t *testing.T) {
 	url := "/account/update"
 	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleAdmin)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
 	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 }
 
+// Customers should not give their accounts away, changing owners is for admins.
+func TestUpdateAccountOwnerAsCustomer(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	account := randomAccount()
+
+	store.EXPECT().
+		UpdateAccountOwner(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	body := gin.H{"id": account.ID, "new_owner": utils.RandomString(8)}
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPut, "/account/update", bytes.NewReader(data))
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
 // When the server could update the owner information (because it may not exist), then server should respond with status not modified.
 func TestUpdateAccountOwnerNotModified(t *testing.T) {
 	store, server, recorder := beforeEach(t)
@@ -497,6 +515,7 @@ func TestUpdateAccountOwnerNotModified(t *testing.T) {
 	url := "/account/update"
 	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
 	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleAdmin)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -508,7 +527,11 @@ func TestDeleteAccountByIDOK(t *testing.T) {
 	store, server, recorder := beforeEach(t)
 	account := randomAccount()
 
-	// build stubs
+	// build stubs, the owner is checked before the account is deleted
+	store.EXPECT().
+		GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
 	store.EXPECT().
 		DeleteAccountByID(gomock.Any(), gomock.Eq(account.ID)).
 		Times(1).
@@ -518,6 +541,7 @@ func TestDeleteAccountByIDOK(t *testing.T) {
 	url := fmt.Sprintf("/account/delete/%d", account.ID)
 	request, err := http.NewRequest(http.MethodDelete, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -526,12 +550,13 @@ func TestDeleteAccountByIDOK(t *testing.T) {
 
 // When the uri parameter is invalid in the delete request, then the server should respond with status bad request.
 func TestDeleteAccountByIDBadRequest(t *testing.T) {
-	_, server, recorder := beforeEach(t)
+	store, server, recorder := beforeEach(t)
 
 	// build & send request
 	url := fmt.Sprintf("/account/delete/%d", 0)
 	request, err := http.NewRequest(http.MethodDelete, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -543,7 +568,11 @@ func TestDeleteAccountByIDInternalServerError(t *testing.T) {
 	store, server, recorder := beforeEach(t)
 	account := randomAccount()
 
-	// build stubs
+	// build stubs, the owner is checked before the account is deleted
+	store.EXPECT().
+		GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
 	store.EXPECT().
 		DeleteAccountByID(gomock.Any(), gomock.Eq(account.ID)).
 		Times(1).
@@ -553,6 +582,7 @@ func TestDeleteAccountByIDInternalServerError(t *testing.T) {
 	url := fmt.Sprintf("/account/delete/%d", account.ID)
 	request, err := http.NewRequest(http.MethodDelete, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -566,14 +596,18 @@ func TestDeleteAccountByIDNotFound(t *testing.T) {
 
 	// build stubs
 	store.EXPECT().
-		DeleteAccountByID(gomock.Any(), gomock.Eq(account.ID)).
+		GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).
 		Times(1).
-		Return(int64(0), nil)
+		Return(nil, sql.ErrNoRows)
+	store.EXPECT().
+		DeleteAccountByID(gomock.Any(), gomock.Any()).
+		Times(0)
 
 	// build & send request
 	url := fmt.Sprintf("/account/delete/%d", account.ID)
 	request, err := http.NewRequest(http.MethodDelete, url, nil)
 	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
 	server.router.ServeHTTP(recorder, request)
 
 	// check response
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+type adminAccountURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.setAccountFrozen(ctx, true)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.setAccountFrozen(ctx, false)
+}
+
+func (server *Server) setAccountFrozen(ctx *gin.Context, frozen bool) {
+	var requestURI adminAccountURI
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	rowsAffected, err := server.store.SetAccountFrozen(ctx, requestURI.ID, frozen)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	server.respondChangedAccount(ctx, requestURI.ID, rowsAffected)
+}
+
+type changeAccountOwnerRequest struct {
+	Owner string `json:"owner" binding:"required"`
+}
+
+func (server *Server) changeAccountOwner(ctx *gin.Context) {
+	var requestURI adminAccountURI
+	var request changeAccountOwnerRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	rowsAffected, err := server.store.UpdateAccountOwner(ctx, requestURI.ID, request.Owner)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	server.respondChangedAccount(ctx, requestURI.ID, rowsAffected)
+}
+
+// the account as changed, or not found when the change affected no row
+func (server *Server) respondChangedAccount(ctx *gin.Context, id, rowsAffected int64) {
+	if rowsAffected == 0 {
+		abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", id))
+		return
+	}
+
+	account, err := server.store.GetAccountByID(ctx, id)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+// amount is signed, credits are positive and debits negative
+type adjustAccountBalanceRequest struct {
+	Amount int64  `json:"amount" binding:"required"` // amount in cents, never 0
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// the adjustment is recorded as an entry, with the reason and the admin who made it
+func (server *Server) adjustAccountBalance(ctx *gin.Context) {
+	var requestURI adminAccountURI
+	var request adjustAccountBalanceRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	adjustment, err := server.store.AdjustAccountBalance(ctx, requestURI.ID, request.Amount, request.Reason, callerOf(ctx).Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", requestURI.ID))
+		case errors.Is(err, db.ErrAdjustmentOverdraws):
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeAdjustmentOverdraws, err.Error())
+		case errors.Is(err, db.ErrBusinessDayClosed):
+			abortWithProblem(ctx, http.StatusConflict, codeBusinessDayClosed, err.Error())
+		default:
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, adjustment)
+}
+
+func (server *Server) listUsers(ctx *gin.Context) {
+	users, err := server.store.GetUsers(ctx)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []db.User{}
+	if users != nil {
+		data = append(data, *users...)
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+type setUserRoleURI struct {
+	Username string `uri:"username" binding:"required"`
+}
+
+type setUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=customer teller admin"`
+}
+
+func (server *Server) setUserRole(ctx *gin.Context) {
+	var requestURI setUserRoleURI
+	var request setUserRoleRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	// admins demoting themselves could leave nobody able to undo it
+	if requestURI.Username == callerOf(ctx).Name && request.Role != db.RoleAdmin {
+		abortWithProblem(ctx, http.StatusConflict, codeOwnRoleChange, "Admins cannot change their own role.")
+		return
+	}
+
+	user, err := server.store.SetUserRole(ctx, requestURI.Username, request.Role)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Frozen accounts should neither send nor receive transfers until a teller unfreezes them.
+func TestFreezeAccount(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	teller := staff(t, store, db.RoleTeller)
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	recorder := sendJSONAs(t, server, teller, http.MethodPost, fmt.Sprintf("/admin/accounts/%d/freeze", to.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var account db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	assert.True(t, account.Frozen)
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD})
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, fmt.Sprintf("/admin/accounts/%d", to.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	assert.True(t, account.Frozen)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, fmt.Sprintf("/admin/accounts/%d/unfreeze", to.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, "/admin/accounts/999/freeze", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// Adjustments should need a reason and be recorded as an entry naming the admin.
+func TestAdjustAccountBalance(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+
+	account, err := store.CreateAccount(context.Background(), utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	path := fmt.Sprintf("/admin/accounts/%d/adjust", account.ID)
+
+	recorder := sendJSONAs(t, server, admin, http.MethodPost, path, gin.H{"amount": -30, "reason": "reversed fee"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var adjustment db.BalanceAdjustment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &adjustment))
+	assert.Equal(t, int64(70), adjustment.Account.Balance)
+	assert.Equal(t, "reversed fee", adjustment.Entry.Description)
+	assert.Equal(t, admin, adjustment.Entry.Metadata[db.AdjustedByKey])
+
+	for name, tc := range map[string]struct {
+		path   string
+		body   gin.H
+		status int
+	}{
+		"NoReason":  {path, gin.H{"amount": 10}, http.StatusBadRequest},
+		"NoAmount":  {path, gin.H{"amount": 0, "reason": "nothing"}, http.StatusBadRequest},
+		"Overdraws": {path, gin.H{"amount": -71, "reason": "too much"}, http.StatusUnprocessableEntity},
+		"Missing":   {"/admin/accounts/999/adjust", gin.H{"amount": 10, "reason": "missing"}, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := sendJSONAs(t, server, admin, http.MethodPost, tc.path, tc.body)
+			assert.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	found, err := store.GetAccountByID(context.Background(), account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(70), found.Balance)
+}
+
+func TestChangeAccountOwner(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+
+	account, err := store.CreateAccount(context.Background(), utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+	owner := utils.RandomOwner()
+
+	recorder := sendJSONAs(t, server, admin, http.MethodPut, fmt.Sprintf("/admin/accounts/%d/owner", account.ID), gin.H{"owner": owner})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), account))
+	assert.Equal(t, owner, account.Owner)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, fmt.Sprintf("/admin/accounts/%d/owner", account.ID), gin.H{})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/admin/accounts/999/owner", gin.H{"owner": owner})
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// Admins should grant roles, but not take their own away.
+func TestSetUserRole(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+	username := utils.RandomOwner()
+
+	recorder := sendJSONAs(t, server, username, http.MethodGet, "/admin/accounts/1", nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/admin/users/"+username, gin.H{"role": db.RoleTeller})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = sendJSONAs(t, server, username, http.MethodGet, "/admin/accounts/1", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/admin/users/"+username, gin.H{"role": "root"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/admin/users/"+admin, gin.H{"role": db.RoleCustomer})
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodGet, "/admin/users", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var users []db.User
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &users))
+	assert.Len(t, users, 2)
+}
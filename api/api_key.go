@@ -0,0 +1,191 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/apikey"
+	"github.com/joelpatel/go-bank/db"
+)
+
+// permissions a key cannot be given, so that keys cannot make more keys or staff
+var adminOnlyPermissions = []permission{permAPIKeysManage, permUsersManage}
+
+type createAPIKeyRequest struct {
+	Name             string     `json:"name" binding:"required,max=255"`
+	Permissions      []string   `json:"permissions" binding:"required,min=1"`
+	AccountIDs       []int64    `json:"account_ids" binding:"dive,min=1"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	RequireSignature bool       `json:"require_signature"`
+}
+
+// the key and its signing secret are only ever returned on creation and rotation
+type createAPIKeyResponse struct {
+	db.APIKey
+	Key           string `json:"key"`
+	SigningSecret string `json:"signing_secret"`
+}
+
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var request createAPIKeyRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	for _, name := range request.Permissions {
+		if !slices.Contains(rolePermissions[db.RoleAdmin], permission(name)) || slices.Contains(adminOnlyPermissions, permission(name)) {
+			abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, fmt.Sprintf("%s is not a permission API keys can have.", name))
+			return
+		}
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, "expires_at has to be in the future.")
+		return
+	}
+
+	key, material, ok := server.newAPIKeyMaterial(ctx)
+	if !ok {
+		return
+	}
+	material.Name = request.Name
+	material.Permissions = request.Permissions
+	material.AccountIDs = request.AccountIDs
+	material.ExpiresAt = request.ExpiresAt
+	material.RequireSignature = request.RequireSignature
+
+	created, err := server.store.CreateAPIKey(ctx, material)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createAPIKeyResponse{APIKey: *created, Key: key, SigningSecret: created.SigningSecret})
+}
+
+// a new key, and what is stored of it with the caller as its creator
+func (server *Server) newAPIKeyMaterial(ctx *gin.Context) (string, db.APIKey, bool) {
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return "", db.APIKey{}, false
+	}
+
+	secret, err := apikey.GenerateSigningSecret()
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return "", db.APIKey{}, false
+	}
+
+	return key, db.APIKey{Prefix: prefix, KeyHash: hash, SigningSecret: secret, CreatedBy: callerOf(ctx).Name}, true
+}
+
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	keys, err := server.store.GetAPIKeys(ctx)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []db.APIKey{}
+	if keys != nil {
+		data = append(data, *keys...)
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+type apiKeyURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getAPIKey(ctx *gin.Context) {
+	var requestURI apiKeyURI
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	key, err := server.store.GetAPIKeyByID(ctx, requestURI.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithProblem(ctx, http.StatusNotFound, codeAPIKeyNotFound, fmt.Sprintf("API key with id %d not found.", requestURI.ID))
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+// the old key keeps working for the grace period, so clients can switch over without failing requests
+type rotateAPIKeyRequest struct {
+	GraceSeconds int64 `json:"grace_seconds" binding:"min=0,max=604800"`
+}
+
+func (server *Server) rotateAPIKey(ctx *gin.Context) {
+	var requestURI apiKeyURI
+	var request rotateAPIKeyRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	key, material, ok := server.newAPIKeyMaterial(ctx)
+	if !ok {
+		return
+	}
+
+	rotated, err := server.store.RotateAPIKey(ctx, requestURI.ID, material, time.Now().Add(time.Duration(request.GraceSeconds)*time.Second))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			abortWithProblem(ctx, http.StatusNotFound, codeAPIKeyNotFound, fmt.Sprintf("API key with id %d not found.", requestURI.ID))
+		case errors.Is(err, db.ErrAPIKeyInactive):
+			abortWithProblem(ctx, http.StatusConflict, codeAPIKeyInactive, err.Error())
+		default:
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createAPIKeyResponse{APIKey: *rotated, Key: key, SigningSecret: rotated.SigningSecret})
+}
+
+func (server *Server) revokeAPIKey(ctx *gin.Context) {
+	var requestURI apiKeyURI
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	rowsAffected, err := server.store.RevokeAPIKey(ctx, requestURI.ID)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		abortWithProblem(ctx, http.StatusNotFound, codeAPIKeyNotFound, fmt.Sprintf("Active API key with id %d not found.", requestURI.ID))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/apikey"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// send a request with key, signed with secret unless it is empty
+func sendWithAPIKey(t *testing.T, server *Server, key, secret, nonce, method, path string, body any) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set(apikey.KeyHeader, key)
+	if secret != "" {
+		request.Header.Set(apikey.SignatureHeader, apikey.Sign(secret, time.Now(), nonce, method, path, data))
+	}
+	server.router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func createTestAPIKey(t *testing.T, server *Server, admin string, body gin.H) createAPIKeyResponse {
+	recorder := sendJSONAs(t, server, admin, http.MethodPost, "/admin/api-keys", body)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var created createAPIKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Key)
+	require.NotEmpty(t, created.SigningSecret)
+	return created
+}
+
+// Keys limited to some accounts should stay within them where the account is not in the path.
+func TestAPIKeyScopesOutsideThePath(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+	ctx := context.Background()
+
+	mine, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	other, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+
+	permissions := []string{"accounts:read", "accounts:write", "admin:accounts:write", "payees:manage", "webhooks:manage", "stream:read"}
+	key := createTestAPIKey(t, server, admin, gin.H{"name": "scoped", "permissions": permissions, "account_ids": []int64{mine.ID}}).Key
+
+	recorder := sendWithAPIKey(t, server, key, "", "", http.MethodPut, "/account/update", gin.H{"id": other.ID, "new_owner": "thief"})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodPut, "/account/update", gin.H{"id": mine.ID, "new_owner": "heir"})
+	assert.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+
+	// the owner may have accounts the key was not given
+	for _, path := range []string{"/v1/accounts?page_size=10&owner=" + other.Owner, "/payees?owner=" + other.Owner, "/webhooks?owner=" + other.Owner, "/stream?owner=" + other.Owner} {
+		recorder = sendWithAPIKey(t, server, key, "", "", http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusForbidden, recorder.Code, path)
+	}
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodPost, "/payees", gin.H{"owner": other.Owner, "account_id": mine.ID, "nickname": "me"})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// subscriptions are an owner's, even the owner's of the key's account
+	for _, owner := range []string{other.Owner, "heir"} {
+		subscription, err := store.CreateWebhookSubscription(ctx, owner, "https://example.com/hook", []string{"transfer.created"}, "0123456789abcdef")
+		require.NoError(t, err)
+		base := fmt.Sprintf("/webhooks/%d", subscription.ID)
+		for _, route := range []struct{ method, path string }{
+			{http.MethodDelete, base},
+			{http.MethodGet, base + "/deliveries?page_size=10"},
+			{http.MethodGet, base + "/deliveries/1"},
+			{http.MethodPost, base + "/deliveries/1/replay"},
+		} {
+			recorder = sendWithAPIKey(t, server, key, "", "", route.method, route.path, nil)
+			assert.Equal(t, http.StatusForbidden, recorder.Code, route.path)
+		}
+	}
+
+	// keys for every account still act for owners
+	unscoped := createTestAPIKey(t, server, admin, gin.H{"name": "unscoped", "permissions": permissions}).Key
+	recorder = sendWithAPIKey(t, server, unscoped, "", "", http.MethodGet, "/payees?owner="+other.Owner, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+}
+
+// Keys limited to some accounts should only see and decide the transfer requests from them.
+func TestAPIKeyScopesTransferRequests(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+	ctx := context.Background()
+
+	mine, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	other, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+
+	hold := func(from, to *db.Account) *db.TransferRequest {
+		request, err := store.CreateTransferRequest(ctx, db.TransferRequest{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        10,
+			Initiator:     from.Owner,
+			HoldReason:    "amount above the approval threshold",
+			ExpiresAt:     time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		return request
+	}
+	ours, theirs := hold(mine, other), hold(other, mine)
+
+	permissions := []string{"transfer_requests:read", "transfer_requests:decide"}
+	key := createTestAPIKey(t, server, admin, gin.H{"name": "scoped", "permissions": permissions, "account_ids": []int64{mine.ID}}).Key
+
+	// the queue spans every account
+	recorder := sendWithAPIKey(t, server, key, "", "", http.MethodGet, "/transfer-requests?page_size=10", nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	base := fmt.Sprintf("/transfer-requests/%d", theirs.ID)
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, base},
+		{http.MethodGet, base + "/events"},
+		{http.MethodPost, base + "/approve"},
+		{http.MethodPost, base + "/reject"},
+	} {
+		recorder = sendWithAPIKey(t, server, key, "", "", route.method, route.path, nil)
+		assert.Equal(t, http.StatusNotFound, recorder.Code, route.path)
+	}
+	stored, err := store.GetTransferRequestByID(ctx, theirs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.TransferRequestPending, stored.Status)
+
+	base = fmt.Sprintf("/transfer-requests/%d", ours.ID)
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodGet, base, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodGet, base+"/events", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodPost, base+"/reject", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+}
+
+// Keys should only do what they were given permission for, on the accounts they were given.
+func TestAPIKeyScopes(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+
+	created := createTestAPIKey(t, server, admin, gin.H{"name": "payouts", "permissions": []string{"accounts:read", "transfers:create"}, "account_ids": []int64{from.ID}})
+	assert.Equal(t, admin, created.CreatedBy)
+	key := created.Key
+
+	recorder := sendWithAPIKey(t, server, key, "", "", http.MethodGet, fmt.Sprintf("/account/%d", from.ID), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodGet, fmt.Sprintf("/account/%d/entries", to.ID), nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodGet, "/admin/eod", nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodPost, "/transfers", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodPost, "/transfers", gin.H{"from_account_id": to.ID, "to_account_id": from.ID, "amount": 10, "currency": currency.USD})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	found, err := store.GetAPIKeyByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, found.LastUsedAt)
+
+	// never shown again, nor is its hash
+	recorder = sendJSONAs(t, server, admin, http.MethodGet, "/admin/api-keys", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), key)
+	assert.NotContains(t, recorder.Body.String(), found.KeyHash)
+	assert.NotContains(t, recorder.Body.String(), found.SigningSecret)
+
+	recorder = sendWithAPIKey(t, server, key+"0", "", "", http.MethodGet, fmt.Sprintf("/account/%d", from.ID), nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	_, prefix, _, err := apikey.Generate()
+	require.NoError(t, err)
+	recorder = sendWithAPIKey(t, server, key[:4]+prefix+key[16:], "", "", http.MethodGet, fmt.Sprintf("/account/%d", from.ID), nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	assert.Equal(t, http.StatusNoContent, sendJSONAs(t, server, admin, http.MethodDelete, fmt.Sprintf("/admin/api-keys/%d", created.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSONAs(t, server, admin, http.MethodDelete, fmt.Sprintf("/admin/api-keys/%d", created.ID), nil).Code)
+	recorder = sendWithAPIKey(t, server, key, "", "", http.MethodGet, fmt.Sprintf("/account/%d", from.ID), nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestCreateAPIKeyBadRequest(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+
+	for name, body := range map[string]gin.H{
+		"NoName":            {"permissions": []string{"accounts:read"}},
+		"NoPermissions":     {"name": "svc"},
+		"UnknownPermission": {"name": "svc", "permissions": []string{"accounts:everything"}},
+		"KeysMakingKeys":    {"name": "svc", "permissions": []string{"api_keys:manage"}},
+		"Expired":           {"name": "svc", "permissions": []string{"accounts:read"}, "expires_at": time.Now().Add(-time.Hour)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := sendJSONAs(t, server, admin, http.MethodPost, "/admin/api-keys", body)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	recorder := sendJSONAs(t, server, staff(t, store, db.RoleTeller), http.MethodPost, "/admin/api-keys", gin.H{"name": "svc", "permissions": []string{"accounts:read"}})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+// The old key should keep working for the grace period only.
+func TestRotateAPIKey(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+	old := createTestAPIKey(t, server, admin, gin.H{"name": "reports", "permissions": []string{"eod:read"}})
+
+	rotate := func(id int64, body gin.H) createAPIKeyResponse {
+		recorder := sendJSONAs(t, server, admin, http.MethodPost, fmt.Sprintf("/admin/api-keys/%d/rotate", id), body)
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		var rotated createAPIKeyResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rotated))
+		return rotated
+	}
+
+	rotated := rotate(old.ID, gin.H{"grace_seconds": 60})
+	assert.Equal(t, old.Name, rotated.Name)
+	assert.Equal(t, old.Permissions, rotated.Permissions)
+	assert.NotEqual(t, old.Key, rotated.Key)
+	for _, key := range []string{old.Key, rotated.Key} {
+		assert.Equal(t, http.StatusOK, sendWithAPIKey(t, server, key, "", "", http.MethodGet, "/admin/eod", nil).Code)
+	}
+
+	// without a grace period the old key stops right away
+	replaced := rotate(rotated.ID, nil)
+	assert.Equal(t, http.StatusUnauthorized, sendWithAPIKey(t, server, rotated.Key, "", "", http.MethodGet, "/admin/eod", nil).Code)
+	assert.Equal(t, http.StatusOK, sendWithAPIKey(t, server, replaced.Key, "", "", http.MethodGet, "/admin/eod", nil).Code)
+
+	recorder := sendJSONAs(t, server, admin, http.MethodPost, fmt.Sprintf("/admin/api-keys/%d/rotate", rotated.ID), nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	recorder = sendJSONAs(t, server, admin, http.MethodPost, "/admin/api-keys/999/rotate", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// Keys that require signing should refuse unsigned, tampered and replayed requests.
+func TestAPIKeySignedRequests(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+	created := createTestAPIKey(t, server, admin, gin.H{"name": "ledger", "permissions": []string{"accounts:write"}, "require_signature": true})
+	body := gin.H{"owner": utils.RandomOwner(), "currency": currency.USD}
+
+	recorder := sendWithAPIKey(t, server, created.Key, "", "", http.MethodPost, "/account/create", body)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = sendWithAPIKey(t, server, created.Key, created.SigningSecret, "n1", http.MethodPost, "/account/create", body)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var account db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	assert.Equal(t, body["owner"], account.Owner)
+
+	recorder = sendWithAPIKey(t, server, created.Key, created.SigningSecret, "n1", http.MethodPost, "/account/create", body)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), db.ErrNonceReused.Error())
+
+	recorder = sendWithAPIKey(t, server, created.Key, "wrong", "n2", http.MethodPost, "/account/create", body)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// a stale timestamp is refused even with a fresh nonce
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/account/create", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set(apikey.KeyHeader, created.Key)
+	request.Header.Set(apikey.SignatureHeader, apikey.Sign(created.SigningSecret, time.Now().Add(-time.Hour), "n3", http.MethodPost, "/account/create", data))
+	stale := httptest.NewRecorder()
+	server.router.ServeHTTP(stale, request)
+	assert.Equal(t, http.StatusUnauthorized, stale.Code)
+}
@@ -1,13 +1,42 @@
 package api
 
 import (
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/config"
+	"github.com/joelpatel/go-bank/logging"
+	"github.com/joelpatel/go-bank/usertoken"
+	"github.com/stretchr/testify/require"
 )
 
+const testTokenSecret = "test-token-secret"
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
 }
+
+// logger of servers whose logs no test looks at
+func testLogger() *logging.Logger {
+	return logging.New(io.Discard, logging.FormatJSON, slog.LevelInfo)
+}
+
+// config of test servers, users are authenticated with tokens from setUser
+func testConfig() config.Config {
+	config := config.Default()
+	config.Auth.TokenSecret = testTokenSecret
+	return config
+}
+
+// send request as username
+func setUser(t *testing.T, request *http.Request, username string) {
+	token, err := usertoken.Issue(testTokenSecret, username, time.Hour, time.Now())
+	require.NoError(t, err)
+	request.Header.Set(usertoken.Header, "Bearer "+token)
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/apikey"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/usertoken"
+)
+
+// gin context keys of the caller making the request, and of the account it is about once authorize loaded it
+const (
+	callerKey  = "caller"
+	accountKey = "account"
+)
+
+// what a route requires of its caller, every route declares one
+type permission string
+
+const (
+	permPublic                 permission = "public" // anyone, even without credentials
+	permAccountsRead           permission = "accounts:read"
+	permAccountsWrite          permission = "accounts:write"
+	permTransfersCreate        permission = "transfers:create"
+	permPayeesManage           permission = "payees:manage"
+	permWebhooksManage         permission = "webhooks:manage"
+	permStreamRead             permission = "stream:read"
+	permTransferRequestsRead   permission = "transfer_requests:read"
+	permTransferRequestsDecide permission = "transfer_requests:decide"
+	permEndOfDayRead           permission = "eod:read"
+	permLimitsRead             permission = "limits:read"
+	permLimitsWrite            permission = "limits:write"
+	permRiskRead               permission = "risk:read"
+	permAdminAccountsRead      permission = "admin:accounts:read"
+	permAdminAccountsFreeze    permission = "admin:accounts:freeze"
+	permAdminAccountsWrite     permission = "admin:accounts:write"
+	permUsersManage            permission = "users:manage"
+	permAPIKeysManage          permission = "api_keys:manage"
+	permLoggingManage          permission = "logging:manage"
+)
+
+var customerPermissions = []permission{
+	permAccountsRead,
+	permAccountsWrite,
+	permTransfersCreate,
+	permPayeesManage,
+	permWebhooksManage,
+	permStreamRead,
+}
+
+var tellerPermissions = append(slices.Clone(customerPermissions),
+	permTransferRequestsRead,
+	permTransferRequestsDecide,
+	permEndOfDayRead,
+	permLimitsRead,
+	permRiskRead,
+	permAdminAccountsRead,
+	permAdminAccountsFreeze,
+)
+
+// permissions of each db.Role*, every role has those of the roles below it
+var rolePermissions = map[string][]permission{
+	db.RoleCustomer: customerPermissions,
+	db.RoleTeller:   tellerPermissions,
+	db.RoleAdmin: append(slices.Clone(tellerPermissions),
+		permLimitsWrite,
+		permAdminAccountsWrite,
+		permUsersManage,
+		permAPIKeysManage,
+		permLoggingManage,
+	),
+}
+
+// who makes a request and what they may do
+type caller struct {
+	Name        string // username, or api-key:<prefix> for API keys
+	KeyPrefix   string // of the API key, empty for users
+	Permissions []permission
+	AccountIDs  []int64 // accounts the caller is limited to, every account when empty
+	Owner       string  // owner whose accounts the caller is limited to, every owner's when empty; customers are themselves
+}
+
+func (caller caller) mayUseAccount(id int64) bool {
+	return len(caller.AccountIDs) == 0 || slices.Contains(caller.AccountIDs, id)
+}
+
+func (caller caller) mayUse(account *db.Account) bool {
+	return caller.mayUseAccount(account.ID) && (caller.Owner == "" || account.Owner == caller.Owner)
+}
+
+// register handler for method and path of routes, callers lacking required or over their rate limit are turned away before it runs
+func (server *Server) handle(routes *gin.RouterGroup, method, path string, required permission, handler gin.HandlerFunc) {
+	server.permissions[method+" "+strings.TrimSuffix(routes.BasePath(), "/")+path] = required
+	routes.Handle(method, path, server.rateLimitIP(required), server.authorize(required), server.rateLimit(required), handler)
+}
+
+// callers are services with an API key, or users with a token; users without a stored role are customers
+func (server *Server) authorize(required permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if required == permPublic {
+			return
+		}
+
+		caller, ok := server.authenticate(ctx)
+		if !ok {
+			return
+		}
+
+		if !slices.Contains(caller.Permissions, required) {
+			abortWithProblem(ctx, http.StatusForbidden, codeForbidden, fmt.Sprintf("%s lacks the %s permission.", caller.Name, required))
+			return
+		}
+
+		if id, ok := accountParam(ctx); ok {
+			if !caller.mayUseAccount(id) {
+				abortWithProblem(ctx, http.StatusForbidden, codeForbidden, fmt.Sprintf("%s may not use account %d.", caller.Name, id))
+				return
+			}
+			if caller.Owner != "" && !server.ownsAccount(ctx, caller, id) {
+				return
+			}
+		}
+
+		ctx.Set(callerKey, *caller)
+	}
+}
+
+// caller of the request, or false after responding when its credentials are missing or invalid
+func (server *Server) authenticate(ctx *gin.Context) (*caller, bool) {
+	if key := ctx.GetHeader(apikey.KeyHeader); key != "" {
+		return server.authenticateAPIKey(ctx, key)
+	}
+
+	token, ok := usertoken.FromHeader(ctx.GetHeader(usertoken.Header))
+	if !ok {
+		ctx.Header("WWW-Authenticate", "Bearer")
+		abortWithProblem(ctx, http.StatusUnauthorized, codeUnauthenticated, fmt.Sprintf("A bearer token or an %s header is required.", apikey.KeyHeader))
+		return nil, false
+	}
+
+	username, err := usertoken.Verify(server.config.Auth.TokenSecret, token, time.Now())
+	if err != nil {
+		ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		abortWithProblem(ctx, http.StatusUnauthorized, codeInvalidToken, "Invalid or expired token.")
+		return nil, false
+	}
+
+	user := db.User{Username: username, Role: db.RoleCustomer}
+	stored, err := server.store.GetUserByUsername(ctx, username)
+	switch {
+	case err == nil:
+		user = *stored
+	case !errors.Is(err, sql.ErrNoRows):
+		server.abortWithInternalError(ctx, err)
+		return nil, false
+	}
+
+	userCaller := &caller{Name: user.Username, Permissions: rolePermissions[user.Role]}
+	if user.Role == db.RoleCustomer {
+		userCaller.Owner = user.Username
+	}
+	return userCaller, true
+}
+
+// whether account id is caller's, otherwise responds; to a customer the accounts of others do not exist
+func (server *Server) ownsAccount(ctx *gin.Context, caller *caller, id int64) bool {
+	account, err := server.store.GetAccountByID(ctx, id)
+	switch {
+	case err == nil && account.Owner == caller.Owner:
+		ctx.Set(accountKey, account)
+		return true
+	case err == nil || errors.Is(err, sql.ErrNoRows):
+		abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", id))
+	default:
+		server.abortWithInternalError(ctx, err)
+	}
+	return false
+}
+
+// whether the caller may act beyond single accounts, otherwise responds.
+// keys limited to some accounts never act for a whole owner, who may have others
+func actsBeyondAccounts(ctx *gin.Context) bool {
+	if caller := callerOf(ctx); len(caller.AccountIDs) > 0 {
+		abortWithProblem(ctx, http.StatusForbidden, codeForbidden, fmt.Sprintf("%s is limited to some accounts and may not act for an owner.", caller.Name))
+		return false
+	}
+	return true
+}
+
+// owner a request about owner's accounts, payees, webhooks or events is for, otherwise responds.
+// customers act for themselves, whoever they name; staff and keys have to name the owner.
+func ownerFor(ctx *gin.Context, named string) (string, bool) {
+	caller := callerOf(ctx)
+	switch {
+	case !actsBeyondAccounts(ctx):
+		return "", false
+	case caller.Owner != "" && named != "" && named != caller.Owner:
+		abortWithProblem(ctx, http.StatusForbidden, codeForbidden, fmt.Sprintf("%s may not act for %s.", caller.Name, named))
+		return "", false
+	case caller.Owner != "":
+		return caller.Owner, true
+	case named == "":
+		abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, "owner is required.")
+		return "", false
+	}
+	return named, true
+}
+
+// a key has its own permissions and accounts; it signs requests when it has to, or when it chooses to
+func (server *Server) authenticateAPIKey(ctx *gin.Context, key string) (*caller, bool) {
+	invalid := func() (*caller, bool) {
+		abortWithProblem(ctx, http.StatusUnauthorized, codeInvalidAPIKey, "Invalid API key.")
+		return nil, false
+	}
+
+	prefix, err := apikey.Parse(key)
+	if err != nil {
+		return invalid()
+	}
+
+	stored, err := server.store.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invalid()
+		}
+		server.abortWithInternalError(ctx, err)
+		return nil, false
+	}
+
+	now := time.Now()
+	if !apikey.Matches(key, stored.KeyHash) || !stored.Active(now) {
+		return invalid()
+	}
+
+	if header := ctx.GetHeader(apikey.SignatureHeader); header != "" || stored.RequireSignature {
+		if !server.verifySignature(ctx, stored, header, now) {
+			return nil, false
+		}
+	}
+
+	// the request is authentic, failing to record that it happened must not refuse it
+	if err := server.store.TouchAPIKey(ctx, stored.ID, now); err != nil {
+		server.log(ctx).Warn("recording api key use", "key_prefix", stored.Prefix, "error", err.Error())
+	}
+
+	keyCaller := &caller{Name: "api-key:" + stored.Prefix, KeyPrefix: stored.Prefix, AccountIDs: stored.AccountIDs}
+	for _, name := range stored.Permissions {
+		keyCaller.Permissions = append(keyCaller.Permissions, permission(name))
+	}
+	return keyCaller, true
+}
+
+// check the signature header of the request made with key, each nonce is accepted once
+func (server *Server) verifySignature(ctx *gin.Context, key *db.APIKey, header string, now time.Time) bool {
+	invalid := func(err error) bool {
+		abortWithProblem(ctx, http.StatusUnauthorized, codeInvalidSignature, err.Error())
+		return false
+	}
+
+	signature, err := apikey.ParseSignature(header)
+	if err != nil {
+		return invalid(err)
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return invalid(err)
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	tolerance := server.config.Auth.SignatureTolerance
+	if err := signature.Verify(key.SigningSecret, ctx.Request.Method, ctx.Request.URL.RequestURI(), body, tolerance, now); err != nil {
+		return invalid(err)
+	}
+
+	// a replay has to come within tolerance of the timestamp, so the nonce is remembered as long
+	if err := server.store.UseAPIKeyNonce(ctx, key.ID, signature.Nonce, signature.Timestamp.Add(tolerance)); err != nil {
+		if errors.Is(err, db.ErrNonceReused) {
+			return invalid(err)
+		}
+		server.abortWithInternalError(ctx, err)
+		return false
+	}
+
+	return true
+}
+
+// id of the account the route is about, for the routes under an account
+func accountParam(ctx *gin.Context) (int64, bool) {
+	path := ctx.FullPath()
+	if !strings.Contains(path, "/account/:id") && !strings.Contains(path, "/accounts/:id") && path != "/account/delete/:id" {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// caller the request was authorized for, empty on public routes
+func callerOf(ctx *gin.Context) caller {
+	value, _ := ctx.Get(callerKey)
+	caller, _ := value.(caller)
+	return caller
+}
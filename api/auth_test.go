@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/joelpatel/go-bank/usertoken"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// new user with role, to send requests as with sendJSONAs
+func staff(t *testing.T, store db.Store, role string) string {
+	user, err := store.SetUserRole(context.Background(), utils.RandomOwner(), role)
+	require.NoError(t, err)
+	return user.Username
+}
+
+// send request as a user the mocked store knows to have role
+func expectStaff(t *testing.T, store *mockdb.MockStore, request *http.Request, role string) {
+	user := db.User{Username: utils.RandomOwner(), Role: role}
+	setUser(t, request, user.Username)
+
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(&user, nil)
+}
+
+// send request as username, a customer the mocked store has no role for
+func expectCustomer(t *testing.T, store *mockdb.MockStore, request *http.Request, username string) {
+	setUser(t, request, username)
+
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(username)).
+		AnyTimes().
+		Return(nil, sql.ErrNoRows)
+}
+
+// Routes registered around handle would be open to everyone.
+func TestEveryRouteDeclaresPermission(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+	routes := server.router.Routes()
+	require.NotEmpty(t, routes)
+
+	for _, route := range routes {
+		required, ok := server.permissions[route.Method+" "+route.Path]
+		if assert.True(t, ok, "%s %s declares no permission", route.Method, route.Path) && required != permPublic {
+			assert.Contains(t, rolePermissions[db.RoleAdmin], required, "%s %s requires a permission no role has", route.Method, route.Path)
+		}
+	}
+	assert.Len(t, server.permissions, len(routes))
+}
+
+func TestRolePermissions(t *testing.T) {
+	// every role can do what the roles below it can
+	for _, roles := range [][2]string{{db.RoleCustomer, db.RoleTeller}, {db.RoleTeller, db.RoleAdmin}} {
+		for _, required := range rolePermissions[roles[0]] {
+			assert.Contains(t, rolePermissions[roles[1]], required, "%s can %s but %s cannot", roles[0], required, roles[1])
+		}
+	}
+	assert.NotContains(t, rolePermissions[db.RoleCustomer], permAdminAccountsRead)
+	assert.NotContains(t, rolePermissions[db.RoleTeller], permAdminAccountsWrite)
+	assert.True(t, slices.Contains(rolePermissions[db.RoleAdmin], permUsersManage))
+}
+
+// Callers should be let through by the role stored for them, unknown users being customers.
+func TestAuthorize(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	customer, teller, admin := utils.RandomOwner(), staff(t, store, db.RoleTeller), staff(t, store, db.RoleAdmin)
+	account, err := store.CreateAccount(context.Background(), customer, 0, currency.USD)
+	require.NoError(t, err)
+	adjust := fmt.Sprintf("/admin/accounts/%d/adjust", account.ID)
+
+	testCases := []struct {
+		name   string
+		user   string
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"PublicWithoutUser", "", http.MethodGet, "/healthz", nil, http.StatusOK},
+		{"CustomerRouteWithoutUser", "", http.MethodPost, "/account/create", gin.H{"owner": customer, "currency": currency.USD}, http.StatusUnauthorized},
+		{"CustomerRouteAsCustomer", customer, http.MethodPost, "/account/create", gin.H{"owner": customer, "currency": currency.USD}, http.StatusOK},
+		{"AdminRouteWithoutUser", "", http.MethodGet, "/admin/eod", nil, http.StatusUnauthorized},
+		{"AdminRouteAsCustomer", customer, http.MethodGet, "/admin/eod", nil, http.StatusForbidden},
+		{"AdminRouteAsTeller", teller, http.MethodGet, "/admin/eod", nil, http.StatusOK},
+		{"AdminOnlyRouteAsTeller", teller, http.MethodPost, adjust, gin.H{"amount": 1, "reason": "test"}, http.StatusForbidden},
+		{"AdminOnlyRouteAsAdmin", admin, http.MethodPost, adjust, gin.H{"amount": 1, "reason": "test"}, http.StatusOK},
+		{"UsersAsTeller", teller, http.MethodGet, "/admin/users", nil, http.StatusForbidden},
+		{"UsersAsAdmin", admin, http.MethodGet, "/admin/users", nil, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := sendJSONAs(t, server, tc.user, tc.method, tc.path, tc.body)
+			assert.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+// Customers should only reach their own accounts, the others' do not exist for them; staff reach every account.
+func TestCustomersOwnAccounts(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	alice, bob, teller := utils.RandomOwner(), utils.RandomOwner(), staff(t, store, db.RoleTeller)
+	own, err := store.CreateAccount(context.Background(), alice, 100, currency.USD)
+	require.NoError(t, err)
+	others, err := store.CreateAccount(context.Background(), bob, 100, currency.USD)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		user   string
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"Get", alice, http.MethodGet, fmt.Sprintf("/v1/accounts/%d", others.ID), nil, http.StatusNotFound},
+		{"Balance", alice, http.MethodGet, fmt.Sprintf("/v1/accounts/%d/balance?as_of=2024-03-10", others.ID), nil, http.StatusNotFound},
+		{"Entries", alice, http.MethodGet, fmt.Sprintf("/v1/accounts/%d/entries?page_size=5", others.ID), nil, http.StatusNotFound},
+		{"Transfers", alice, http.MethodGet, fmt.Sprintf("/v1/accounts/%d/transfers?page_size=5", others.ID), nil, http.StatusNotFound},
+		{"Transactions", alice, http.MethodGet, fmt.Sprintf("/v1/accounts/%d/transactions?page_size=5", others.ID), nil, http.StatusNotFound},
+		{"Delete", alice, http.MethodDelete, fmt.Sprintf("/account/delete/%d", others.ID), nil, http.StatusNotFound},
+		{"TransferFrom", alice, http.MethodPost, "/v1/transfers", gin.H{"from_account_id": others.ID, "to_account_id": own.ID, "amount": 10, "currency": currency.USD}, http.StatusNotFound},
+		{"CreateForOther", alice, http.MethodPost, "/v1/accounts", gin.H{"owner": bob, "currency": currency.USD}, http.StatusForbidden},
+		{"ListOfOther", alice, http.MethodGet, "/v1/accounts?page_size=5&owner=" + bob, nil, http.StatusForbidden},
+		{"GetOwn", alice, http.MethodGet, fmt.Sprintf("/v1/accounts/%d", own.ID), nil, http.StatusOK},
+		{"TransferFromOwn", alice, http.MethodPost, "/v1/transfers", gin.H{"from_account_id": own.ID, "to_account_id": others.ID, "amount": 10, "currency": currency.USD}, http.StatusOK},
+		{"GetAsTeller", teller, http.MethodGet, fmt.Sprintf("/v1/accounts/%d", others.ID), nil, http.StatusOK},
+		{"ListAsTellerWithoutOwner", teller, http.MethodGet, "/v1/accounts?page_size=5", nil, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := sendJSONAs(t, server, tc.user, tc.method, tc.path, tc.body)
+			assert.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	_, err = store.GetAccountByID(context.Background(), others.ID)
+	assert.NoError(t, err, "deleted by another customer")
+
+	// customers open and list accounts of their own without naming themselves
+	recorder := sendJSONAs(t, server, alice, http.MethodPost, "/v1/accounts", gin.H{"currency": currency.USD})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var account db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	assert.Equal(t, alice, account.Owner)
+
+	recorder = sendJSONAs(t, server, alice, http.MethodGet, "/v1/accounts?page_size=5", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var page pageResponse[db.Account]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	assert.Equal(t, []int64{own.ID, account.ID}, idsOf(page.Data))
+}
+
+// Users should only be taken for who their token names when the server signed it and it has not expired.
+func TestAuthenticateUserToken(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+	username := utils.RandomOwner()
+
+	valid, err := usertoken.Issue(testTokenSecret, username, time.Hour, time.Now())
+	require.NoError(t, err)
+	expired, err := usertoken.Issue(testTokenSecret, username, time.Hour, time.Now().Add(-2*time.Hour))
+	require.NoError(t, err)
+	foreign, err := usertoken.Issue("another secret", username, time.Hour, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		authorization string
+		status        int
+		code          string
+	}{
+		{"Valid", "Bearer " + valid, http.StatusOK, ""},
+		{"Missing", "", http.StatusUnauthorized, codeUnauthenticated},
+		{"NotBearer", "Basic " + valid, http.StatusUnauthorized, codeUnauthenticated},
+		{"Expired", "Bearer " + expired, http.StatusUnauthorized, codeInvalidToken},
+		{"ForeignSecret", "Bearer " + foreign, http.StatusUnauthorized, codeInvalidToken},
+		{"Malformed", "Bearer " + username, http.StatusUnauthorized, codeInvalidToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/v1/payees?owner="+username, nil)
+			require.NoError(t, err)
+			if tc.authorization != "" {
+				request.Header.Set(usertoken.Header, tc.authorization)
+			}
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+			if tc.status == http.StatusUnauthorized {
+				assert.Contains(t, recorder.Header().Get("WWW-Authenticate"), "Bearer")
+				var response problem
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				assert.Equal(t, tc.code, response.Code)
+			}
+		})
+	}
+
+	// a server without a secret cannot tell a forged token from a real one, so it takes none
+	config := testConfig()
+	config.Auth.TokenSecret = ""
+	server = NewServer(memdb.NewStore(), config, testLogger())
+	recorder := sendJSONAs(t, server, username, http.MethodGet, "/v1/payees?owner="+username, nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
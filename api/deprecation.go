@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// when the routes from before /v1 were deprecated, sent in their Deprecation header
+var unversionedDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// handle under v1, and at the same path without the version where the route was before /v1
+func (server *Server) handleV1(v1 *gin.RouterGroup, method, path string, required permission, handler gin.HandlerFunc) {
+	server.handle(v1, method, path, required, handler)
+	server.handleDeprecated(method, path, v1.BasePath()+path, required, handler)
+}
+
+// register handler at a path clients should stop calling. it answers as it always has,
+// with an RFC 9745 Deprecation header and a Link to successor, the route to call instead
+func (server *Server) handleDeprecated(method, path, successor string, required permission, handler gin.HandlerFunc) {
+	server.deprecated[method+" "+path] = successor
+	server.handle(server.router.Group("", deprecation(successor)), method, path, required, handler)
+}
+
+func deprecation(successor string) gin.HandlerFunc {
+	deprecatedAt := "@" + strconv.FormatInt(unversionedDeprecatedAt.Unix(), 10)
+
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", deprecatedAt)
+		if link, ok := successorLink(ctx, successor); ok {
+			ctx.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, link))
+		}
+	}
+}
+
+// successor with the parameters of the request filled in, false when the request's path lacks one
+func successorLink(ctx *gin.Context, successor string) (string, bool) {
+	segments := strings.Split(successor, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		value := ctx.Param(name)
+		if value == "" {
+			return "", false
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	return strings.Join(segments, "/"), true
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+type listAccountEntriesRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var requestURI listAccountEntriesRequest
+	var requestQuery pageQuery
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&requestQuery); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	listPage(server, ctx, fmt.Sprintf("entries:%d", requestURI.ID), requestQuery, func(entry db.Entry) db.PageKey {
+		return db.PageKey{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	}, func(page db.Page) (*[]db.Entry, error) {
+		return server.store.GetEntriesByAccountID(ctx, requestURI.ID, page)
+	})
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+const dateLayout = "2006-01-02"
+
+func (server *Server) getEndOfDayStatus(ctx *gin.Context) {
+	lastClosed, err := server.store.GetLastClosedBusinessDay(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"last_closed": lastClosed})
+}
+
+type getBusinessDayRequest struct {
+	Date string `uri:"date" binding:"required,datetime=2006-01-02"`
+}
+
+type businessDayResponse struct {
+	db.BusinessDay
+	CurrencyTotals []db.CurrencyTotal `json:"currency_totals"`
+}
+
+func (server *Server) getBusinessDay(ctx *gin.Context) {
+	var request getBusinessDayRequest
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	businessDate, _ := time.Parse(dateLayout, request.Date)
+
+	day, err := server.store.GetBusinessDay(ctx, businessDate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithProblem(ctx, http.StatusNotFound, codeBusinessDayNotClosed, fmt.Sprintf("Business day %s is not closed.", request.Date))
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	totals, err := server.store.GetCurrencyTotals(ctx, businessDate)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, businessDayResponse{BusinessDay: *day, CurrencyTotals: *totals})
+}
+
+type getAccountBalanceAsOfURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type getAccountBalanceAsOfQuery struct {
+	AsOf string `form:"as_of" binding:"required,datetime=2006-01-02"`
+}
+
+func (server *Server) getAccountBalanceAsOf(ctx *gin.Context) {
+	var requestURI getAccountBalanceAsOfURI
+	var requestQuery getAccountBalanceAsOfQuery
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&requestQuery); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	asOf, _ := time.Parse(dateLayout, requestQuery.AsOf)
+
+	snapshot, err := server.store.GetBalanceSnapshotAsOf(ctx, requestURI.ID, asOf)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithProblem(ctx, http.StatusNotFound, codeClosingBalanceNotFound, fmt.Sprintf("No closing balance for account %d as of %s.", requestURI.ID, requestQuery.AsOf))
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, snapshot)
+}
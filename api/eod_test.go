@@ -0,0 +1,215 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func randomBusinessDay() *db.BusinessDay {
+	return &db.BusinessDay{
+		BusinessDate: time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC),
+		Timezone:     "Asia/Kolkata",
+		AccountCount: utils.RandomInt(1, 1000),
+		ClosedAt:     time.Date(2024, time.March, 10, 18, 35, 0, 0, time.UTC),
+	}
+}
+
+// The status endpoint should report the most recently closed business day.
+func TestGetEndOfDayStatusOK(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	day := randomBusinessDay()
+
+	store.EXPECT().
+		GetLastClosedBusinessDay(gomock.Any()).
+		Times(1).
+		Return(day, nil)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/eod", nil)
+	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleTeller)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		LastClosed db.BusinessDay `json:"last_closed"`
+	}
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, *day, response.LastClosed)
+}
+
+// When no business day was ever closed, the status endpoint should still respond with status OK.
+func TestGetEndOfDayStatusNeverClosed(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	store.EXPECT().
+		GetLastClosedBusinessDay(gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrNoRows)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/eod", nil)
+	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleTeller)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"last_closed": null}`, recorder.Body.String())
+}
+
+// A closed business day should be returned along with its per currency totals.
+func TestGetBusinessDayOK(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	day := randomBusinessDay()
+	totals := []db.CurrencyTotal{
+		{BusinessDate: day.BusinessDate, Currency: currency.INR, TotalBalance: utils.RandomMoney(), AccountCount: 1},
+		{BusinessDate: day.BusinessDate, Currency: currency.USD, TotalBalance: utils.RandomMoney(), AccountCount: 2},
+	}
+
+	store.EXPECT().
+		GetBusinessDay(gomock.Any(), gomock.Eq(day.BusinessDate)).
+		Times(1).
+		Return(day, nil)
+	store.EXPECT().
+		GetCurrencyTotals(gomock.Any(), gomock.Eq(day.BusinessDate)).
+		Times(1).
+		Return(&totals, nil)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/eod/2024-03-10", nil)
+	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleTeller)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response businessDayResponse
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, *day, response.BusinessDay)
+	assert.Equal(t, totals, response.CurrencyTotals)
+}
+
+// When the business day is still open, the server should respond with status not found.
+func TestGetBusinessDayNotClosed(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	store.EXPECT().
+		GetBusinessDay(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrNoRows)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/eod/2024-03-10", nil)
+	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleTeller)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	var response problem
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, codeBusinessDayNotClosed, response.Code)
+	assert.Equal(t, "Business day 2024-03-10 is not closed.", response.Detail)
+}
+
+// When the date is not formatted as YYYY-MM-DD, the server should respond with status bad request.
+func TestGetBusinessDayBadDate(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/eod/10-03-2024", nil)
+	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleTeller)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// The as-of balance should be read from the latest snapshot on or before the requested date.
+func TestGetAccountBalanceAsOfOK(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	account := randomAccount()
+	snapshot := &db.BalanceSnapshot{
+		AccountID:      account.ID,
+		BusinessDate:   time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC),
+		ClosingBalance: account.Balance,
+		Currency:       account.Currency,
+		CreatedAt:      time.Date(2024, time.March, 9, 18, 35, 0, 0, time.UTC),
+	}
+
+	store.EXPECT().
+		GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+	store.EXPECT().
+		GetBalanceSnapshotAsOf(gomock.Any(), gomock.Eq(account.ID), gomock.Eq(time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC))).
+		Times(1).
+		Return(snapshot, nil)
+
+	url := fmt.Sprintf("/account/%d/balance?as_of=2024-03-10", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response db.BalanceSnapshot
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, *snapshot, response)
+}
+
+// When the as_of query parameter is missing, the server should respond with status bad request.
+func TestGetAccountBalanceAsOfMissingDate(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	account := randomAccount()
+
+	store.EXPECT().
+		GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/account/%d/balance", account.ID), nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// When no business day covering the account was closed yet, the server should respond with status not found.
+func TestGetAccountBalanceAsOfNotFound(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	account := randomAccount()
+
+	store.EXPECT().
+		GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+	store.EXPECT().
+		GetBalanceSnapshotAsOf(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrNoRows)
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/account/%d/balance?as_of=2024-03-10", account.ID), nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, account.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// liveness only tells the process is serving requests.
+func (server *Server) liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readiness tells whether the server should receive traffic:
+// not draining, database reachable and schema migrated to the version the code expects.
+func (server *Server) readiness(ctx *gin.Context) {
+	if server.draining.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := gin.H{"database": "ok", "schema": "ok"}
+	ready := true
+
+	if err := server.store.Ping(checkCtx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	}
+
+	version, dirty, err := server.store.GetSchemaVersion(checkCtx)
+	switch {
+	case err != nil:
+		checks["schema"] = err.Error()
+		ready = false
+	case dirty:
+		checks["schema"] = fmt.Sprintf("version %d is dirty", version)
+		ready = false
+	case version < db.SchemaVersion:
+		checks["schema"] = fmt.Sprintf("version %d is behind %d", version, db.SchemaVersion)
+		ready = false
+	}
+
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
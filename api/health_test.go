@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type readinessStruct struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func getReadiness(t *testing.T, server *Server) (int, readinessStruct) {
+	request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+	assert.NoError(t, err)
+
+	_, _, recorder := beforeEach(t)
+	server.router.ServeHTTP(recorder, request)
+
+	var response readinessStruct
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+
+	return recorder.Code, response
+}
+
+// Liveness should not depend on the database.
+func TestLiveness(t *testing.T) {
+	_, server, recorder := beforeEach(t)
+
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	assert.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// With the database reachable and migrated, the server should be ready.
+func TestReadinessOK(t *testing.T) {
+	store, server, _ := beforeEach(t)
+
+	store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+	store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(int64(db.SchemaVersion), false, nil)
+
+	code, response := getReadiness(t, server)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", response.Status)
+}
+
+// When the database is unreachable, the server should not be ready.
+func TestReadinessDatabaseDown(t *testing.T) {
+	store, server, _ := beforeEach(t)
+
+	store.EXPECT().Ping(gomock.Any()).Times(1).Return(sql.ErrConnDone)
+	store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(int64(0), false, sql.ErrConnDone)
+
+	code, response := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, sql.ErrConnDone.Error(), response.Checks["database"])
+}
+
+// When migrations are behind or dirty, the server should not be ready.
+func TestReadinessSchema(t *testing.T) {
+	store, server, _ := beforeEach(t)
+
+	store.EXPECT().Ping(gomock.Any()).Times(2).Return(nil)
+	gomock.InOrder(
+		store.EXPECT().GetSchemaVersion(gomock.Any()).Return(int64(db.SchemaVersion-1), false, nil),
+		store.EXPECT().GetSchemaVersion(gomock.Any()).Return(int64(db.SchemaVersion), true, nil),
+	)
+
+	code, response := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, response.Checks["schema"], "behind")
+
+	code, response = getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, response.Checks["schema"], "dirty")
+}
+
+// Once shutdown has started, the server should report itself as draining without touching the database.
+func TestReadinessDraining(t *testing.T) {
+	_, server, _ := beforeEach(t)
+
+	assert.NoError(t, server.Shutdown(context.Background()))
+
+	code, response := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", response.Status)
+}
+
+// Shutdown should stop a running server and make StartServer return without error.
+func TestStartServerShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	serverConfig := testConfig()
+	serverConfig.Server.Address = "127.0.0.1:0"
+	server := NewServer(mockdb.NewMockStore(ctrl), serverConfig, testLogger())
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.StartServer()
+	}()
+
+	// wait for the listener to be set up
+	assert.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.httpServer != nil
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, server.Shutdown(ctx))
+	assert.NoError(t, <-serverErr)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+func (server *Server) listTransferLimits(ctx *gin.Context) {
+	limits, err := server.store.GetTransferLimits(ctx)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []db.TransferLimit{}
+	if limits != nil {
+		data = append(data, *limits...)
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+// max is in cents, or a number of transfers for daily_count
+type setTransferLimitRequest struct {
+	Scope   string `json:"scope" binding:"required,oneof=account owner currency"`
+	Subject string `json:"subject" binding:"required"`
+	Kind    string `json:"kind" binding:"required,oneof=per_transaction daily_amount monthly_amount daily_count"`
+	Max     *int64 `json:"max" binding:"required,min=0"`
+}
+
+// create or replace the limit of a scope, subject and kind
+func (server *Server) setTransferLimit(ctx *gin.Context) {
+	var request setTransferLimitRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	limit, err := server.store.SetTransferLimit(ctx, db.TransferLimit{Scope: request.Scope, Subject: request.Subject, Kind: request.Kind, Max: *request.Max})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidLimit) {
+			abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, err.Error())
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+type transferLimitURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteTransferLimit(ctx *gin.Context) {
+	var request transferLimitURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	rowsAffected, err := server.store.DeleteTransferLimitByID(ctx, request.ID)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	if rowsAffected != 1 {
+		abortWithProblem(ctx, http.StatusNotFound, codeLimitNotFound, fmt.Sprintf("Transfer limit with id %d not found.", request.ID))
+	} else {
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// problem of a transfer refused by a limit, with what is left of it
+type limitExceededProblem struct {
+	problem
+	*db.LimitExceededError
+}
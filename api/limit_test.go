@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Limits set through the admin endpoints should refuse transfers with what is left of them.
+func TestTransferLimits(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	admin := staff(t, store, db.RoleAdmin)
+
+	recorder := sendJSONAs(t, server, admin, http.MethodPut, "/admin/limits", gin.H{"scope": "account", "subject": fmt.Sprint(from.ID), "kind": "daily_amount", "max": 100})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var limit db.TransferLimit
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &limit))
+	assert.Equal(t, int64(100), limit.Max)
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 70, "currency": currency.USD})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 70, "currency": currency.USD})
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	var response struct {
+		Code      string           `json:"code"`
+		Limit     db.TransferLimit `json:"limit"`
+		Used      int64            `json:"used"`
+		Remaining int64            `json:"remaining"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, codeLimitExceeded, response.Code)
+	assert.Equal(t, limit.ID, response.Limit.ID)
+	assert.Equal(t, int64(70), response.Used)
+	assert.Equal(t, int64(30), response.Remaining)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodGet, "/admin/limits", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var limits []db.TransferLimit
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &limits))
+	require.Len(t, limits, 1)
+
+	assert.Equal(t, http.StatusNoContent, sendJSONAs(t, server, admin, http.MethodDelete, fmt.Sprintf("/admin/limits/%d", limit.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSONAs(t, server, admin, http.MethodDelete, fmt.Sprintf("/admin/limits/%d", limit.ID), nil).Code)
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 70, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestSetTransferLimitBadRequest(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+
+	for name, body := range map[string]gin.H{
+		"NoMax":          {"scope": "owner", "subject": "alice", "kind": "daily_amount"},
+		"NegativeMax":    {"scope": "owner", "subject": "alice", "kind": "daily_amount", "max": -1},
+		"UnknownScope":   {"scope": "branch", "subject": "alice", "kind": "daily_amount", "max": 1},
+		"UnknownKind":    {"scope": "owner", "subject": "alice", "kind": "weekly_amount", "max": 1},
+		"AccountSubject": {"scope": "account", "subject": "alice", "kind": "daily_amount", "max": 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := sendJSONAs(t, server, admin, http.MethodPut, "/admin/limits", body)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the id of a request; one sent by the caller is kept, so their logs and ours line up.
+const RequestIDHeader = "X-Request-ID"
+
+// gin context key of the request's id
+const requestIDKey = "request_id"
+
+// ids callers may send, anything else is replaced rather than written to the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// give the request an id, echo it in the response and hand a logger carrying it to everything the request calls
+func (server *Server) identifyRequest(ctx *gin.Context) {
+	id := ctx.GetHeader(RequestIDHeader)
+	if !validRequestID.MatchString(id) {
+		id = newRequestID()
+	}
+	ctx.Set(requestIDKey, id)
+	ctx.Header(RequestIDHeader, id)
+
+	logger := server.logger.With("request_id", id)
+	if span := trace.SpanFromContext(ctx.Request.Context()); span.SpanContext().IsValid() {
+		span.SetAttributes(attribute.String("request_id", id))
+		logger = logger.With("trace_id", span.SpanContext().TraceID().String())
+	}
+	ctx.Request = ctx.Request.WithContext(logging.WithContext(ctx.Request.Context(), logger))
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+// id of the request, see identifyRequest
+func requestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDKey)
+}
+
+// logger of the request, carrying its id
+func (server *Server) log(ctx *gin.Context) *slog.Logger {
+	return logging.FromContext(ctx.Request.Context(), server.logger.Logger)
+}
+
+// a line per request once it is answered. the query string is left out, it may carry secrets
+func (server *Server) logRequest(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", ctx.Request.Method),
+		slog.String("path", ctx.Request.URL.Path),
+		slog.String("route", ctx.FullPath()),
+		slog.Int("status", status),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("bytes", ctx.Writer.Size()),
+		slog.String("client_ip", ctx.ClientIP()),
+		slog.String("user_agent", ctx.Request.UserAgent()),
+	}
+	if caller := callerOf(ctx); caller.Name != "" {
+		attrs = append(attrs, slog.String("caller", caller.Name))
+	}
+
+	server.log(ctx).LogAttrs(ctx.Request.Context(), level, "request", attrs...)
+}
+
+// a panicking handler answers 500 and is logged with its stack, the server goes on
+func (server *Server) recoverPanic(ctx *gin.Context, recovered any) {
+	server.log(ctx).Error("handler panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+	abortWithProblem(ctx, http.StatusInternalServerError, codeInternal, "The server failed to handle the request.")
+}
+
+type logLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+func (server *Server) getLogLevel(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, logLevelResponse{Level: server.logger.Level.Level().String()})
+}
+
+// takes effect at once, for every log line of the process
+func (server *Server) setLogLevel(ctx *gin.Context) {
+	var request logLevelRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	level, err := logging.ParseLevel(request.Level)
+	if err != nil {
+		abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, fmt.Sprintf("%s is not a log level, use debug, info, warn or error.", request.Level))
+		return
+	}
+
+	previous := server.logger.Level.Level()
+	server.logger.Level.Set(level)
+	server.log(ctx).Warn("log level changed", "from", previous.String(), "to", level.String(), "by", callerOf(ctx).Name)
+
+	ctx.JSON(http.StatusOK, logLevelResponse{Level: level.String()})
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/logging"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lines logged to out, decoded
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded), line)
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+// Every request should have an id, in its response, its error body and every line logged for it.
+func TestRequestID(t *testing.T) {
+	var out bytes.Buffer
+	server := NewServer(memdb.NewStore(), testConfig(), logging.New(&out, logging.FormatJSON, slog.LevelInfo))
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/account/42?token=abc", nil)
+	require.NoError(t, err)
+	request.Header.Set(RequestIDHeader, "client-chosen.1")
+	setUser(t, request, utils.RandomOwner())
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "client-chosen.1", recorder.Header().Get(RequestIDHeader))
+
+	var body struct {
+		RequestID string `json:"request_id"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, "client-chosen.1", body.RequestID)
+
+	lines := logLines(t, &out)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "request", lines[0]["msg"])
+	assert.Equal(t, "client-chosen.1", lines[0]["request_id"])
+	assert.Equal(t, "/account/42", lines[0]["path"])
+	assert.Equal(t, "/account/:id", lines[0]["route"])
+	assert.Equal(t, 404.0, lines[0]["status"])
+	assert.NotContains(t, out.String(), "token=abc")
+
+	// ids that could forge log lines are replaced
+	request.Header.Set(RequestIDHeader, "bad\nid")
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	assert.Len(t, recorder.Header().Get(RequestIDHeader), 32)
+}
+
+// A panicking handler should answer 500 and be logged, not take the server down.
+func TestRecoverPanic(t *testing.T) {
+	var out bytes.Buffer
+	server := NewServer(memdb.NewStore(), testConfig(), logging.New(&out, logging.FormatJSON, slog.LevelInfo))
+	server.router.GET("/panic", func(ctx *gin.Context) { panic("boom") })
+
+	recorder := sendJSON(t, server, http.MethodGet, "/panic", nil)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	lines := logLines(t, &out)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "handler panicked", lines[0]["msg"])
+	assert.Equal(t, "boom", lines[0]["panic"])
+	assert.Equal(t, "ERROR", lines[1]["level"])
+	assert.Equal(t, lines[0]["request_id"], lines[1]["request_id"])
+}
+
+// Admins should be able to change the log level while the server runs.
+func TestSetLogLevel(t *testing.T) {
+	var out bytes.Buffer
+	store := memdb.NewStore()
+	logger := logging.New(&out, logging.FormatJSON, slog.LevelInfo)
+	server := NewServer(store, testConfig(), logger)
+	admin := staff(t, store, db.RoleAdmin)
+
+	recorder := sendJSONAs(t, server, staff(t, store, db.RoleTeller), http.MethodPut, "/admin/log-level", gin.H{"level": "debug"})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/admin/log-level", gin.H{"level": "loud"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/admin/log-level", gin.H{"level": "debug"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, slog.LevelDebug, logger.Level.Level())
+
+	recorder = sendJSONAs(t, server, admin, http.MethodGet, "/admin/log-level", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"level": "DEBUG"}`, recorder.Body.String())
+	assert.Contains(t, out.String(), `"msg":"log level changed"`)
+}
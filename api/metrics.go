@@ -0,0 +1,21 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// time every request by its route rather than its path, so ids do not make a series each
+func (server *Server) observeRequest(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(ctx.Writer.Status())
+	server.metrics.HTTPRequestDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Requests should be counted by route, and refused transfers by reason.
+func TestMetrics(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	recorder := postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 500, "currency": currency.USD})
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 50, "currency": currency.USD})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = sendJSON(t, server, http.MethodGet, "/metrics", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `bank_http_request_duration_seconds_count{method="POST",route="/transfers",status="422"} 1`)
+	assert.Contains(t, body, `bank_http_request_duration_seconds_count{method="POST",route="/transfers",status="200"} 1`)
+	assert.Contains(t, body, `bank_transfers_rejected_total{reason="insufficient_funds"} 1`)
+	assert.Contains(t, body, `bank_transfers_total{currency="USD"} 1`)
+	assert.Contains(t, body, `bank_transfer_amount_cents_total{currency="USD"} 50`)
+	assert.Contains(t, body, `bank_store_operation_duration_seconds_count{method="TransferMoney"} 1`)
+}
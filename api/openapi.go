@@ -0,0 +1,17 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI 3 description of every route, TestOpenAPI keeps it in step with the router
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+func serveOpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json", openAPIDocument)
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/risk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the parts of the document the tests check
+type openAPI struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas    map[string]openAPISchema    `json:"schemas"`
+		Parameters map[string]openAPIParameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string             `json:"operationId"`
+	Deprecated  bool               `json:"deprecated"`
+	Permission  string             `json:"x-permission"`
+	Parameters  []openAPIParameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema openAPISchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref      string `json:"$ref"`
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+}
+
+type openAPISchema struct {
+	Ref        string                     `json:"$ref"`
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+func fetchOpenAPI(t *testing.T, server *Server) (openAPI, []byte) {
+	recorder := sendJSON(t, server, http.MethodGet, "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var document openAPI
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	require.True(t, strings.HasPrefix(document.OpenAPI, "3."), document.OpenAPI)
+	return document, recorder.Body.Bytes()
+}
+
+// the parameters of operation in, with references to shared ones resolved
+func (document openAPI) parameters(operation openAPIOperation, in string) map[string]bool {
+	required := map[string]bool{}
+	for _, parameter := range operation.Parameters {
+		if name, ok := strings.CutPrefix(parameter.Ref, "#/components/parameters/"); ok {
+			parameter = document.Components.Parameters[name]
+		}
+		if parameter.In == in {
+			required[parameter.Name] = parameter.Required
+		}
+	}
+	return required
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// Every route is documented with the permission it declares, deprecated when it is an alias, and nothing else is.
+func TestOpenAPIRoutes(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+	document, _ := fetchOpenAPI(t, server)
+
+	operations := 0
+	for _, item := range document.Paths {
+		operations += len(item)
+	}
+	routes := server.router.Routes()
+	assert.Equal(t, len(routes), operations)
+
+	operationIDs := map[string]bool{}
+	for _, route := range routes {
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		operation, ok := document.Paths[path][strings.ToLower(route.Method)]
+		if !assert.True(t, ok, "%s %s is not documented", route.Method, path) {
+			continue
+		}
+
+		assert.False(t, operationIDs[operation.OperationID], "operationId %s is used twice", operation.OperationID)
+		operationIDs[operation.OperationID] = true
+
+		key := route.Method + " " + route.Path
+		assert.Equal(t, string(server.permissions[key]), operation.Permission, "permission of %s", key)
+		_, deprecated := server.deprecated[key]
+		assert.Equal(t, deprecated, operation.Deprecated, "deprecation of %s", key)
+
+		params := map[string]bool{}
+		for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			params[match[1]] = true
+		}
+		assert.Equal(t, params, document.parameters(operation, "path"), "path parameters of %s", key)
+	}
+}
+
+// Query parameters are documented as the handlers bind them.
+func TestOpenAPIQueries(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+	document, _ := fetchOpenAPI(t, server)
+
+	queries := map[string]any{
+		"GET /v1/accounts":                   listAccountsQuery{},
+		"POST /accounts":                     pageQuery{},
+		"GET /v1/accounts/{id}/balance":      getAccountBalanceAsOfQuery{},
+		"GET /v1/accounts/{id}/entries":      pageQuery{},
+		"GET /v1/accounts/{id}/transfers":    listAccountTransfersRequestQuery{},
+		"GET /v1/accounts/{id}/transactions": searchTransactionsRequestQuery{},
+		"GET /v1/transfer-requests":          listTransferRequestsQuery{},
+		"GET /v1/payees":                     listPayeesRequest{},
+		"DELETE /v1/payees/{id}":             deletePayeeRequest{},
+		"GET /v1/webhooks":                   listWebhookSubscriptionsRequest{},
+		"GET /v1/webhooks/{id}/deliveries":   pageQuery{},
+		"GET /v1/stream":                     streamEventsRequest{},
+		"GET /account/{id}/balance":          getAccountBalanceAsOfQuery{},
+		"GET /account/{id}/entries":          pageQuery{},
+		"GET /account/{id}/transfers":        listAccountTransfersRequestQuery{},
+		"GET /account/{id}/transactions":     searchTransactionsRequestQuery{},
+		"GET /transfer-requests":             listTransferRequestsQuery{},
+		"GET /payees":                        listPayeesRequest{},
+		"DELETE /payees/{id}":                deletePayeeRequest{},
+		"GET /webhooks":                      listWebhookSubscriptionsRequest{},
+		"GET /webhooks/{id}/deliveries":      pageQuery{},
+		"GET /stream":                        streamEventsRequest{},
+	}
+
+	for path, item := range document.Paths {
+		for method, operation := range item {
+			key := strings.ToUpper(method) + " " + path
+			documented := document.parameters(operation, "query")
+			query, ok := queries[key]
+			if !ok {
+				assert.Empty(t, documented, "%s documents query parameters no test checks", key)
+				continue
+			}
+			assert.Equal(t, boundFields(reflect.TypeOf(query), "form"), documented, "query parameters of %s", key)
+		}
+	}
+}
+
+// Schemas have the fields of the types handlers bind and respond with, and refer to nothing undefined.
+func TestOpenAPISchemas(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+	document, body := fetchOpenAPI(t, server)
+
+	types := map[string]any{
+		"Problem":                    problem{},
+		"FieldError":                 fieldError{},
+		"LimitExceededProblem":       limitExceededProblem{},
+		"RiskDeniedProblem":          riskDeniedProblem{},
+		"Account":                    db.Account{},
+		"AccountPage":                pageResponse[db.Account]{},
+		"Entry":                      db.Entry{},
+		"EntryPage":                  pageResponse[db.Entry]{},
+		"Transfer":                   db.Transfer{},
+		"TransferPage":               pageResponse[db.Transfer]{},
+		"Transaction":                db.Transaction{},
+		"TransactionPage":            pageResponse[db.Transaction]{},
+		"TransferResult":             db.TransferTxResult{},
+		"BalanceAdjustment":          db.BalanceAdjustment{},
+		"BusinessDay":                businessDayResponse{},
+		"CurrencyTotal":              db.CurrencyTotal{},
+		"BalanceSnapshot":            db.BalanceSnapshot{},
+		"Payee":                      db.Payee{},
+		"TransferLimit":              db.TransferLimit{},
+		"RiskReason":                 db.RiskReason{},
+		"RiskAssessment":             db.RiskAssessment{},
+		"Ruleset":                    risk.Ruleset{},
+		"ConfiguredRule":             risk.ConfiguredRule{},
+		"TransferRequest":            db.TransferRequest{},
+		"TransferRequestPage":        pageResponse[db.TransferRequest]{},
+		"TransferRequestEvent":       db.TransferRequestEvent{},
+		"User":                       db.User{},
+		"APIKey":                     db.APIKey{},
+		"CreatedAPIKey":              createAPIKeyResponse{},
+		"WebhookSubscription":        db.WebhookSubscription{},
+		"CreatedWebhookSubscription": createWebhookSubscriptionResponse{},
+		"WebhookDelivery":            db.WebhookDelivery{},
+		"WebhookDeliveryPage":        pageResponse[db.WebhookDelivery]{},
+		"LogLevel":                   logLevelResponse{},
+
+		"CreateAccountRequest":             createAccountRequest{},
+		"PatchAccountRequest":              patchAccountRequest{},
+		"ListAccountsByOwnerRequest":       listAccountsByOwnerRequestJSON{},
+		"UpdateAccountOwnerRequest":        updateAccountOwnerRequest{},
+		"CreateTransferRequest":            createTransferRequest{},
+		"DecideTransferRequest":            decideTransferRequestRequest{},
+		"CreatePayeeRequest":               createPayeeRequest{},
+		"UpdatePayeeRequest":               updatePayeeRequest{},
+		"SetTransferLimitRequest":          setTransferLimitRequest{},
+		"AdjustAccountBalanceRequest":      adjustAccountBalanceRequest{},
+		"ChangeAccountOwnerRequest":        changeAccountOwnerRequest{},
+		"SetUserRoleRequest":               setUserRoleRequest{},
+		"CreateAPIKeyRequest":              createAPIKeyRequest{},
+		"RotateAPIKeyRequest":              rotateAPIKeyRequest{},
+		"LogLevelRequest":                  logLevelRequest{},
+		"CreateWebhookSubscriptionRequest": createWebhookSubscriptionRequest{},
+	}
+
+	for name, schema := range document.Components.Schemas {
+		value, ok := types[name]
+		if !assert.True(t, ok, "schema %s is checked against no type", name) {
+			continue
+		}
+
+		fields := boundFields(reflect.TypeOf(value), "json")
+		properties := map[string]bool{}
+		for property := range schema.Properties {
+			properties[property] = fields[property]
+		}
+		if strings.HasSuffix(name, "Request") {
+			// what the handler requires is what the schema does
+			required := map[string]bool{}
+			for _, property := range schema.Required {
+				required[property] = true
+			}
+			for property := range properties {
+				properties[property] = required[property]
+			}
+			assert.Equal(t, fields, properties, "fields of %s", name)
+		} else {
+			assert.ElementsMatch(t, keys(fields), keys(properties), "fields of %s", name)
+		}
+	}
+	for name := range types {
+		assert.Contains(t, document.Components.Schemas, name)
+	}
+
+	for _, match := range regexp.MustCompile(`"\$ref": "#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(body), -1) {
+		var defined bool
+		switch match[1] {
+		case "schemas":
+			_, defined = document.Components.Schemas[match[2]]
+		case "parameters":
+			_, defined = document.Components.Parameters[match[2]]
+		case "responses":
+			defined = match[2] == "Problem"
+		}
+		assert.True(t, defined, "%s refers to nothing", match[0])
+	}
+}
+
+// Routes from before /v1 answer as they did, pointing to their successor.
+func TestDeprecatedRoutes(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+
+	recorder := sendJSONAs(t, server, "ann", http.MethodPost, "/account/create", map[string]string{"owner": "ann", "currency": "USD"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "@1792368000", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/accounts>; rel="successor-version"`, recorder.Header().Get("Link"))
+
+	var account db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+
+	recorder = sendJSONAs(t, server, "ann", http.MethodGet, fmt.Sprintf("/account/%d/entries?page_size=5", account.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, fmt.Sprintf(`</v1/accounts/%d/entries>; rel="successor-version"`, account.ID), recorder.Header().Get("Link"))
+
+	// the id of the successor is in the body, there is no link to give
+	recorder = sendJSONAs(t, server, admin, http.MethodPut, "/account/update", map[string]any{"id": account.ID, "new_owner": "bob"})
+	require.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+	assert.NotEmpty(t, recorder.Header().Get("Deprecation"))
+	assert.Empty(t, recorder.Header().Get("Link"))
+
+	// refused requests are marked too
+	recorder = sendJSON(t, server, http.MethodGet, "/admin/limits", nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Deprecation"))
+
+	recorder = sendJSONAs(t, server, "bob", http.MethodGet, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get("Deprecation"))
+}
+
+func TestV1Accounts(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	admin := staff(t, store, db.RoleAdmin)
+
+	recorder := sendJSONAs(t, server, "ann", http.MethodPost, "/v1/accounts", map[string]string{"owner": "ann", "currency": "USD"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var account db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+
+	recorder = sendJSONAs(t, server, "ann", http.MethodGet, "/v1/accounts?owner=ann&page_size=10", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var page pageResponse[db.Account]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, account.ID, page.Data[0].ID)
+
+	// owners give accounts away only through an admin
+	recorder = sendJSONAs(t, server, "ann", http.MethodPatch, fmt.Sprintf("/v1/accounts/%d", account.ID), map[string]string{"owner": "bob"})
+	require.Equal(t, http.StatusForbidden, recorder.Code, recorder.Body.String())
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPatch, fmt.Sprintf("/v1/accounts/%d", account.ID), map[string]string{"owner": "bob"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	assert.Equal(t, "bob", account.Owner)
+
+	recorder = sendJSONAs(t, server, "bob", http.MethodDelete, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+	require.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+
+	recorder = sendJSONAs(t, server, admin, http.MethodPatch, fmt.Sprintf("/v1/accounts/%d", account.ID), map[string]string{"owner": "ann"})
+	require.Equal(t, http.StatusNotFound, recorder.Code, recorder.Body.String())
+	assert.Equal(t, codeAccountNotFound, decodeProblem(t, recorder).Code)
+}
+
+// json or form names of the fields of a struct type, and whether binding requires them
+func boundFields(typ reflect.Type, tag string) map[string]bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	fields := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if field.Anonymous && name == "" {
+			for embedded, required := range boundFields(field.Type, tag) {
+				fields[embedded] = required
+			}
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		rules, _, _ := strings.Cut(field.Tag.Get("binding"), "dive")
+		fields[name] = strings.Contains(","+rules, ",required,") || strings.HasSuffix(","+rules, ",required")
+	}
+	return fields
+}
+
+func keys(set map[string]bool) []string {
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// position a page continues from, handed to clients signed and opaque
+type cursor struct {
+	Scope     string `json:"s"`           // list the cursor was issued for
+	Order     string `json:"o"`           // order of the list
+	Backward  bool   `json:"b,omitempty"` // continue towards the start of the list
+	CreatedAt int64  `json:"t"`           // unix microseconds, the precision of timestamptz
+	ID        int64  `json:"i"`
+}
+
+// signs cursors so clients cannot forge positions or carry them over to another list
+type cursorCodec struct {
+	secret []byte
+}
+
+// with a random key when secret is empty, cursors then only work on this process
+func newCursorCodec(secret string) *cursorCodec {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+	return &cursorCodec{secret: key}
+}
+
+func (codec *cursorCodec) sign(payload string) string {
+	mac := hmac.New(sha256.New, codec.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// base64url JSON payload and its signature, separated by a dot
+func (codec *cursorCodec) encode(c cursor) string {
+	data, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + codec.sign(payload)
+}
+
+func (codec *cursorCodec) decode(token string) (cursor, error) {
+	var c cursor
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(codec.sign(payload))) {
+		return c, errInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+type pageQuery struct {
+	PageSize int64  `form:"page_size" binding:"required,min=1,max=100"`
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor   string `form:"cursor"`
+}
+
+// has_more tells whether there is more in the direction the page was fetched,
+// next_cursor and prev_cursor are left out at either end of the list
+type pageResponse[T any] struct {
+	Data       []T    `json:"data"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+func reverseOrder(order string) string {
+	if order == db.SortDesc {
+		return db.SortAsc
+	}
+	return db.SortDesc
+}
+
+// respond with the page of list query asks for, ordered by (created_at, id).
+// scope ties the cursors to this one list, key gives a row's position in it
+func listPage[T any](server *Server, ctx *gin.Context, scope string, query pageQuery, key func(row T) db.PageKey, list func(page db.Page) (*[]T, error)) {
+	order := query.Order
+	if order == "" {
+		order = db.SortAsc
+	}
+
+	// one row more than asked for tells whether there are more
+	page := db.Page{Limit: query.PageSize + 1, Order: order}
+	backward := false
+
+	if query.Cursor != "" {
+		position, err := server.cursors.decode(query.Cursor)
+		if err != nil || position.Scope != scope {
+			abortWithProblem(ctx, http.StatusBadRequest, codeInvalidCursor, "The cursor is invalid.")
+			return
+		}
+		if query.Order != "" && query.Order != position.Order {
+			abortWithProblem(ctx, http.StatusBadRequest, codeInvalidCursor, fmt.Sprintf("The cursor was issued for order %s.", position.Order))
+			return
+		}
+
+		order, backward = position.Order, position.Backward
+		page.Order = order
+		if backward {
+			page.Order = reverseOrder(order)
+		}
+		page.After = &db.PageKey{CreatedAt: time.UnixMicro(position.CreatedAt), ID: position.ID}
+	}
+
+	rows, err := list(page)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []T{}
+	if rows != nil {
+		data = append(data, *rows...)
+	}
+
+	hasMore := int64(len(data)) > query.PageSize
+	if hasMore {
+		data = data[:query.PageSize]
+	}
+	if backward {
+		slices.Reverse(data)
+	}
+
+	response := pageResponse[T]{Data: data, HasMore: hasMore}
+
+	cursorAt := func(row T, backward bool) string {
+		position := key(row)
+		return server.cursors.encode(cursor{Scope: scope, Order: order, Backward: backward, CreatedAt: position.CreatedAt.UnixMicro(), ID: position.ID})
+	}
+
+	// whichever side a followed cursor came from has rows
+	if len(data) > 0 {
+		if hasMore || backward {
+			response.NextCursor = cursorAt(data[len(data)-1], false)
+		}
+		if backward && hasMore || !backward && query.Cursor != "" {
+			response.PrevCursor = cursorAt(data[0], true)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
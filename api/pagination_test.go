@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// server on an in-memory store with owner's n accounts, oldest first
+func serverWithAccounts(t *testing.T, owner string, n int) (*Server, []int64) {
+	store := memdb.NewStore()
+
+	var ids []int64
+	for i := 0; i < n; i++ {
+		account, err := store.CreateAccount(context.Background(), owner, utils.RandomMoney(), currency.USD)
+		require.NoError(t, err)
+		ids = append(ids, account.ID)
+	}
+
+	return NewServer(store, testConfig(), testLogger()), ids
+}
+
+func listAccountsPage(t *testing.T, server *Server, owner string, query url.Values) (int, pageResponse[db.Account]) {
+	data, err := json.Marshal(gin.H{"owner": owner})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/accounts?"+query.Encode(), bytes.NewReader(data))
+	require.NoError(t, err)
+	setUser(t, request, owner)
+	server.router.ServeHTTP(recorder, request)
+
+	var response pageResponse[db.Account]
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	}
+	return recorder.Code, response
+}
+
+func idsOf(accounts []db.Account) []int64 {
+	var ids []int64
+	for _, account := range accounts {
+		ids = append(ids, account.ID)
+	}
+	return ids
+}
+
+// Following next_cursor to the end and prev_cursor back should visit every page once, in order.
+func TestListAccountsCursorsWalk(t *testing.T) {
+	owner := utils.RandomOwner()
+	server, ids := serverWithAccounts(t, owner, 5)
+
+	code, first := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ids[0:2], idsOf(first.Data))
+	assert.True(t, first.HasMore)
+	assert.Empty(t, first.PrevCursor)
+
+	_, second := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, ids[2:4], idsOf(second.Data))
+	assert.True(t, second.HasMore)
+	assert.NotEmpty(t, second.PrevCursor)
+
+	_, last := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {second.NextCursor}})
+	assert.Equal(t, ids[4:], idsOf(last.Data))
+	assert.False(t, last.HasMore)
+	assert.Empty(t, last.NextCursor)
+
+	// and back again
+	_, back := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {last.PrevCursor}})
+	assert.Equal(t, ids[2:4], idsOf(back.Data))
+	assert.True(t, back.HasMore)
+	assert.NotEmpty(t, back.NextCursor)
+
+	_, start := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {back.PrevCursor}})
+	assert.Equal(t, ids[0:2], idsOf(start.Data))
+	assert.False(t, start.HasMore)
+	assert.Empty(t, start.PrevCursor)
+	assert.NotEmpty(t, start.NextCursor)
+}
+
+// Descending pages should start at the newest account and keep their order when following cursors.
+func TestListAccountsCursorsDescending(t *testing.T) {
+	owner := utils.RandomOwner()
+	server, ids := serverWithAccounts(t, owner, 3)
+
+	_, first := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "order": {"desc"}})
+	assert.Equal(t, []int64{ids[2], ids[1]}, idsOf(first.Data))
+	assert.True(t, first.HasMore)
+
+	// the cursor carries the order
+	_, second := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, []int64{ids[0]}, idsOf(second.Data))
+	assert.False(t, second.HasMore)
+
+	code, _ := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "order": {"asc"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// Rows inserted while paging should neither be skipped nor repeated.
+func TestListAccountsCursorsStableUnderInserts(t *testing.T) {
+	owner := utils.RandomOwner()
+	server, ids := serverWithAccounts(t, owner, 4)
+
+	_, first := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}})
+
+	account, err := server.store.CreateAccount(context.Background(), owner, utils.RandomMoney(), currency.USD)
+	require.NoError(t, err)
+
+	_, second := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, ids[2:4], idsOf(second.Data))
+
+	_, third := listAccountsPage(t, server, owner, url.Values{"page_size": {"2"}, "cursor": {second.NextCursor}})
+	assert.Equal(t, []int64{account.ID}, idsOf(third.Data))
+}
+
+// Cursors should only be accepted by the list and the server key they were issued for.
+func TestListAccountsCursorsRejected(t *testing.T) {
+	owner := utils.RandomOwner()
+	server, _ := serverWithAccounts(t, owner, 3)
+
+	_, first := listAccountsPage(t, server, owner, url.Values{"page_size": {"1"}})
+	require.NotEmpty(t, first.NextCursor)
+
+	code, _ := listAccountsPage(t, server, utils.RandomOwner(), url.Values{"page_size": {"1"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = listAccountsPage(t, server, owner, url.Values{"page_size": {"1"}, "cursor": {first.NextCursor + "x"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	other := NewServer(server.store, testConfig(), testLogger())
+	code, _ = listAccountsPage(t, other, owner, url.Values{"page_size": {"1"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// Replicas sharing the cursor secret should accept each other's cursors.
+func TestCursorCodecSharedSecret(t *testing.T) {
+	issued := newCursorCodec("secret").encode(cursor{Scope: "accounts:owner", Order: db.SortAsc, CreatedAt: 1, ID: 2})
+
+	decoded, err := newCursorCodec("secret").decode(issued)
+	require.NoError(t, err)
+	assert.Equal(t, cursor{Scope: "accounts:owner", Order: db.SortAsc, CreatedAt: 1, ID: 2}, decoded)
+
+	_, err = newCursorCodec("other").decode(issued)
+	assert.ErrorIs(t, err, errInvalidCursor)
+}
+
+// Entries and transfers of an account should be paged the same way, transfers optionally by direction.
+func TestListEntriesAndTransfers(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	account, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	other, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, account.ID, other.ID, 10, db.Details{})
+	require.NoError(t, err)
+	_, err = store.TransferMoney(ctx, other.ID, account.ID, 20, db.Details{})
+	require.NoError(t, err)
+	_, err = store.TransferMoney(ctx, account.ID, other.ID, 30, db.Details{})
+	require.NoError(t, err)
+
+	get := func(path string, response any) int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		setUser(t, request, account.Owner)
+		server.router.ServeHTTP(recorder, request)
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+		}
+		return recorder.Code
+	}
+
+	var entries pageResponse[db.Entry]
+	assert.Equal(t, http.StatusOK, get(fmt.Sprintf("/account/%d/entries?page_size=2&order=desc", account.ID), &entries))
+	require.Len(t, entries.Data, 2)
+	assert.Equal(t, int64(-30), entries.Data[0].Amount)
+	assert.Equal(t, int64(20), entries.Data[1].Amount)
+	assert.True(t, entries.HasMore)
+
+	var transfers pageResponse[db.Transfer]
+	assert.Equal(t, http.StatusOK, get(fmt.Sprintf("/account/%d/transfers?page_size=10", account.ID), &transfers))
+	assert.Len(t, transfers.Data, 3)
+
+	transfers = pageResponse[db.Transfer]{}
+	assert.Equal(t, http.StatusOK, get(fmt.Sprintf("/account/%d/transfers?page_size=10&direction=incoming", account.ID), &transfers))
+	require.Len(t, transfers.Data, 1)
+	assert.Equal(t, int64(20), transfers.Data[0].Amount)
+
+	// a cursor of one direction is not valid for the other
+	transfers = pageResponse[db.Transfer]{}
+	assert.Equal(t, http.StatusOK, get(fmt.Sprintf("/account/%d/transfers?page_size=1&direction=outgoing", account.ID), &transfers))
+	require.NotEmpty(t, transfers.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, get(fmt.Sprintf("/account/%d/transfers?page_size=1&cursor=%s", account.ID, transfers.NextCursor), &transfers))
+
+	assert.Equal(t, http.StatusBadRequest, get(fmt.Sprintf("/account/%d/transfers?page_size=1&direction=sideways", account.ID), &transfers))
+
+	// an account without postings has an empty page
+	empty, err := store.CreateAccount(ctx, account.Owner, 0, currency.USD)
+	require.NoError(t, err)
+	entries = pageResponse[db.Entry]{}
+	assert.Equal(t, http.StatusOK, get(fmt.Sprintf("/account/%d/entries?page_size=5", empty.ID), &entries))
+	assert.Empty(t, entries.Data)
+	assert.NotNil(t, entries.Data)
+
+	// the postings of other customers' accounts are not theirs to see
+	assert.Equal(t, http.StatusNotFound, get(fmt.Sprintf("/account/%d/entries?page_size=5", other.ID), &entries))
+	assert.Equal(t, http.StatusNotFound, get(fmt.Sprintf("/account/%d/transfers?page_size=5", other.ID), &transfers))
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+type createPayeeRequest struct {
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id" binding:"required,min=1"`
+	Nickname  string `json:"nickname" binding:"required,max=64"`
+}
+
+func (server *Server) createPayee(ctx *gin.Context) {
+	var request createPayeeRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	payee, err := server.store.CreatePayee(ctx, owner, request.AccountID, request.Nickname)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", request.AccountID))
+		case errors.Is(err, db.ErrDuplicatePayee):
+			abortWithProblem(ctx, http.StatusConflict, codeDuplicatePayee, err.Error())
+		default:
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payee)
+}
+
+type listPayeesRequest struct {
+	Owner string `form:"owner"`
+}
+
+func (server *Server) listPayees(ctx *gin.Context) {
+	var request listPayeesRequest
+
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	payees, err := server.store.GetPayeesByOwner(ctx, owner)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []db.Payee{}
+	if payees != nil {
+		data = append(data, *payees...)
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+type payeeURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the payee if it belongs to owner, otherwise responds and returns false.
+// other owners' payees are reported as not found
+func (server *Server) ownPayee(ctx *gin.Context, id int64, owner string) (*db.Payee, bool) {
+	payee, err := server.store.GetPayeeByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		server.abortWithInternalError(ctx, err)
+		return nil, false
+	}
+
+	if err != nil || payee.Owner != owner {
+		abortWithProblem(ctx, http.StatusNotFound, codePayeeNotFound, fmt.Sprintf("Payee with id %d not found.", id))
+		return nil, false
+	}
+
+	return payee, true
+}
+
+type updatePayeeRequest struct {
+	Owner    string `json:"owner"`
+	Nickname string `json:"nickname" binding:"required,max=64"`
+}
+
+func (server *Server) updatePayee(ctx *gin.Context) {
+	var requestURI payeeURI
+	var request updatePayeeRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	payee, ok := server.ownPayee(ctx, requestURI.ID, owner)
+	if !ok {
+		return
+	}
+
+	_, err := server.store.UpdatePayeeNickname(ctx, payee.ID, request.Nickname)
+	if err != nil {
+		if errors.Is(err, db.ErrDuplicatePayee) {
+			abortWithProblem(ctx, http.StatusConflict, codeDuplicatePayee, err.Error())
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	payee.Nickname = request.Nickname
+	ctx.JSON(http.StatusOK, payee)
+}
+
+type deletePayeeRequest struct {
+	Owner string `form:"owner"`
+}
+
+func (server *Server) deletePayee(ctx *gin.Context) {
+	var requestURI payeeURI
+	var request deletePayeeRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	if _, ok := server.ownPayee(ctx, requestURI.ID, owner); !ok {
+		return
+	}
+
+	if _, err := server.store.DeletePayeeByID(ctx, requestURI.ID); err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// the account a transfer of amount from owner to payee id goes to,
+// otherwise responds and returns false. large amounts wait out the cooling-off period
+func (server *Server) payeeAccount(ctx *gin.Context, id int64, owner string, amount int64) (int64, bool) {
+	payee, ok := server.ownPayee(ctx, id, owner)
+	if !ok || !server.cooledOff(ctx, payee, amount) {
+		return 0, false
+	}
+
+	return payee.AccountID, true
+}
+
+// whether owner may send amount to account to when the transfer names it rather than a payee, otherwise responds.
+// large amounts to other owners go to payees only, so their cooling-off period holds however the recipient is named
+func (server *Server) mayPayAccount(ctx *gin.Context, owner string, to *db.Account, amount int64) bool {
+	large := server.config.Transfers.PayeeLargeAmount
+	if amount < large || to.Owner == owner {
+		return true
+	}
+
+	payees, err := server.store.GetPayeesByOwner(ctx, owner)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return false
+	}
+	if payees != nil {
+		for _, payee := range *payees {
+			if payee.AccountID == to.ID {
+				return server.cooledOff(ctx, &payee, amount)
+			}
+		}
+	}
+
+	abortWithProblem(ctx, http.StatusForbidden, codePayeeRequired, fmt.Sprintf("Amounts of %d or more are sent to payees only, add account %d as one first.", large, to.ID))
+	return false
+}
+
+// whether amount may go to payee yet, otherwise responds
+func (server *Server) cooledOff(ctx *gin.Context, payee *db.Payee, amount int64) bool {
+	transfers := server.config.Transfers
+	if coolsOffAt := payee.CreatedAt.Add(transfers.PayeeCoolingOff); amount >= transfers.PayeeLargeAmount && time.Now().Before(coolsOffAt) {
+		abortWithProblem(ctx, http.StatusForbidden, codePayeeCoolingOff, fmt.Sprintf("Payee %d is new, amounts of %d or more can be sent from %s.", payee.ID, transfers.PayeeLargeAmount, coolsOffAt.UTC().Format(time.RFC3339)))
+		return false
+	}
+	return true
+}
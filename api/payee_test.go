@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendJSON(t *testing.T, server *Server, method, path string, body any) *httptest.ResponseRecorder {
+	return sendJSONAs(t, server, "", method, path, body)
+}
+
+// sendJSON on behalf of username, see staff
+func sendJSONAs(t *testing.T, server *Server, username, method, path string, body any) *httptest.ResponseRecorder {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, reader)
+	require.NoError(t, err)
+	if username != "" {
+		setUser(t, request, username)
+	}
+	server.router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// Payees should be created, listed, renamed and deleted by their owner only.
+func TestPayeesCRUD(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	owner, stranger := utils.RandomOwner(), utils.RandomOwner()
+
+	account, err := store.CreateAccount(context.Background(), utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	recorder := sendJSONAs(t, server, owner, http.MethodPost, "/payees", gin.H{"owner": owner, "account_id": account.ID, "nickname": "Landlord"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var payee db.Payee
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &payee))
+	assert.Equal(t, "Landlord", payee.Nickname)
+	assert.Equal(t, account.ID, payee.AccountID)
+
+	assert.Equal(t, http.StatusConflict, sendJSONAs(t, server, owner, http.MethodPost, "/payees", gin.H{"owner": owner, "account_id": account.ID, "nickname": "Rent"}).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSONAs(t, server, owner, http.MethodPost, "/payees", gin.H{"owner": owner, "account_id": account.ID + 1000, "nickname": "Ghost"}).Code)
+	assert.Equal(t, http.StatusBadRequest, sendJSONAs(t, server, owner, http.MethodPost, "/payees", gin.H{"owner": owner, "account_id": account.ID}).Code)
+
+	recorder = sendJSONAs(t, server, owner, http.MethodPut, fmt.Sprintf("/payees/%d", payee.ID), gin.H{"owner": owner, "nickname": "Old landlord"})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	// someone else's payee does not exist for them
+	assert.Equal(t, http.StatusNotFound, sendJSONAs(t, server, stranger, http.MethodPut, fmt.Sprintf("/payees/%d", payee.ID), gin.H{"owner": stranger, "nickname": "Mine"}).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSONAs(t, server, stranger, http.MethodDelete, fmt.Sprintf("/payees/%d?owner=%s", payee.ID, stranger), nil).Code)
+
+	// nor can they name its owner to reach it
+	assert.Equal(t, http.StatusForbidden, sendJSONAs(t, server, stranger, http.MethodGet, "/payees?owner="+owner, nil).Code)
+	assert.Equal(t, http.StatusForbidden, sendJSONAs(t, server, stranger, http.MethodDelete, fmt.Sprintf("/payees/%d?owner=%s", payee.ID, owner), nil).Code)
+
+	// customers need not name themselves
+	recorder = sendJSONAs(t, server, owner, http.MethodGet, "/payees", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var payees []db.Payee
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &payees))
+	require.Len(t, payees, 1)
+	assert.Equal(t, "Old landlord", payees[0].Nickname)
+
+	assert.Equal(t, http.StatusNoContent, sendJSONAs(t, server, owner, http.MethodDelete, fmt.Sprintf("/payees/%d?owner=%s", payee.ID, owner), nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSONAs(t, server, owner, http.MethodDelete, fmt.Sprintf("/payees/%d?owner=%s", payee.ID, owner), nil).Code)
+
+	recorder = sendJSONAs(t, server, owner, http.MethodGet, "/payees?owner="+owner, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, "[]", recorder.Body.String())
+}
+
+// Transfers to a new payee should be limited to small amounts until its cooling-off period is over.
+func TestTransferToPayee(t *testing.T) {
+	store := memdb.NewStore()
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 2000, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	payee, err := store.CreatePayee(ctx, from.Owner, to.ID, "Savings")
+	require.NoError(t, err)
+	stranger, err := store.CreatePayee(ctx, utils.RandomOwner(), to.ID, "Savings")
+	require.NoError(t, err)
+
+	cooling := testConfig()
+	cooling.Transfers.PayeeCoolingOff = time.Hour
+	cooling.Transfers.PayeeLargeAmount = 500
+	server := NewServer(store, cooling, testLogger())
+
+	recorder := postTransfer(t, server, gin.H{"from_account_id": from.ID, "payee_id": payee.ID, "amount": 499, "currency": currency.USD})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var result db.TransferTxResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Equal(t, to.ID, result.TransferRecord.ToAccountID)
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "payee_id": payee.ID, "amount": 500, "currency": currency.USD})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// naming the payee's account instead does not get around the cooling-off period
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 500, "currency": currency.USD})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, codePayeeCoolingOff, decodeProblem(t, recorder).Code)
+
+	// large amounts to accounts that are no payee of the sender are refused, small ones and those between own accounts are not
+	unknown, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": unknown.ID, "amount": 500, "currency": currency.USD})
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, codePayeeRequired, decodeProblem(t, recorder).Code)
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": unknown.ID, "amount": 1, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	own, err := store.CreateAccount(ctx, from.Owner, 0, currency.USD)
+	require.NoError(t, err)
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": own.ID, "amount": 500, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	// only the sender's own payees
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "payee_id": stranger.ID, "amount": 1, "currency": currency.USD})
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "payee_id": payee.ID, "amount": 1, "currency": currency.USD})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "amount": 1, "currency": currency.USD})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// once cooled off, whichever way the recipient is named
+	cooled := testConfig()
+	cooled.Transfers.PayeeCoolingOff = 0
+	cooled.Transfers.PayeeLargeAmount = 1
+	recorder = postTransfer(t, NewServer(store, cooled, testLogger()), gin.H{"from_account_id": from.ID, "payee_id": payee.ID, "amount": 1, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = postTransfer(t, NewServer(store, cooled, testLogger()), gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 1, "currency": currency.USD})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
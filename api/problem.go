@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const problemContentType = "application/problem+json"
+
+// codes of the problems the API answers with. clients branch on these, never on the wording of detail
+const (
+	codeInvalidRequest            = "INVALID_REQUEST"   // malformed body, query, path or header
+	codeValidationFailed          = "VALIDATION_FAILED" // well formed, but breaking the rules of the API; see problem.Errors when fields do
+	codeUnauthenticated           = "UNAUTHENTICATED"
+	codeInvalidAPIKey             = "INVALID_API_KEY"
+	codeInvalidToken              = "INVALID_TOKEN"
+	codeInvalidSignature          = "INVALID_SIGNATURE"
+	codeForbidden                 = "FORBIDDEN"
+	codeRateLimited               = "RATE_LIMITED"
+	codeNotFound                  = "NOT_FOUND" // no such route
+	codeInternal                  = "INTERNAL_ERROR"
+	codeInvalidCursor             = "INVALID_CURSOR"
+	codeAccountNotFound           = "ACCOUNT_NOT_FOUND"
+	codeAccountFrozen             = "ACCOUNT_FROZEN"
+	codeUnsupportedCurrency       = "UNSUPPORTED_CURRENCY"
+	codeCurrencyMismatch          = "CURRENCY_MISMATCH"
+	codeSameAccount               = "SAME_ACCOUNT"
+	codeInsufficientFunds         = "INSUFFICIENT_FUNDS"
+	codeLimitExceeded             = "LIMIT_EXCEEDED"
+	codeRiskDenied                = "RISK_DENIED"
+	codeDuplicateReference        = "DUPLICATE_REFERENCE"
+	codeBusinessDayClosed         = "BUSINESS_DAY_CLOSED"
+	codeBusinessDayNotClosed      = "BUSINESS_DAY_NOT_CLOSED"
+	codeClosingBalanceNotFound    = "CLOSING_BALANCE_NOT_FOUND"
+	codeAdjustmentOverdraws       = "ADJUSTMENT_OVERDRAWS"
+	codeOwnRoleChange             = "OWN_ROLE_CHANGE"
+	codePayeeNotFound             = "PAYEE_NOT_FOUND"
+	codeDuplicatePayee            = "DUPLICATE_PAYEE"
+	codePayeeCoolingOff           = "PAYEE_COOLING_OFF"
+	codePayeeRequired             = "PAYEE_REQUIRED"
+	codeTransferRequestNotFound   = "TRANSFER_REQUEST_NOT_FOUND"
+	codeTransferRequestNotPending = "TRANSFER_REQUEST_NOT_PENDING"
+	codeTransferRequestExpired    = "TRANSFER_REQUEST_EXPIRED"
+	codeSelfReview                = "SELF_REVIEW"
+	codeLimitNotFound             = "LIMIT_NOT_FOUND"
+	codeRiskAssessmentNotFound    = "RISK_ASSESSMENT_NOT_FOUND"
+	codeAPIKeyNotFound            = "API_KEY_NOT_FOUND"
+	codeAPIKeyInactive            = "API_KEY_INACTIVE"
+	codeWebhookNotFound           = "WEBHOOK_NOT_FOUND"
+	codeDeliveryNotFound          = "DELIVERY_NOT_FOUND"
+	codeUnsupportedEventType      = "UNSUPPORTED_EVENT_TYPE"
+)
+
+// body of every failed request, an RFC 7807 problem with the code and request id as extensions
+type problem struct {
+	Type      string       `json:"type"`  // always about:blank, code tells problems apart
+	Title     string       `json:"title"` // text of the status
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"` // path of the request
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id"`
+	Errors    []fieldError `json:"errors,omitempty"`
+}
+
+// a field of the request breaking one of its rules
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func newProblem(ctx *gin.Context, status int, code, detail string) problem {
+	return problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  ctx.Request.URL.Path,
+		Code:      code,
+		RequestID: requestID(ctx),
+	}
+}
+
+// answer with a problem and run nothing after, body is a problem or a struct embedding one
+func writeProblem(ctx *gin.Context, status int, body any) {
+	ctx.Header("Content-Type", problemContentType)
+	ctx.AbortWithStatusJSON(status, body)
+}
+
+func abortWithProblem(ctx *gin.Context, status int, code, detail string) {
+	writeProblem(ctx, status, newProblem(ctx, status, code, detail))
+}
+
+// answer 400 to a request gin failed to bind, naming each invalid field
+func abortWithBindError(ctx *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	var numError *strconv.NumError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		body := newProblem(ctx, http.StatusBadRequest, codeValidationFailed, "The request has invalid fields.")
+		for _, invalid := range validationErrors {
+			body.Errors = append(body.Errors, fieldError{Field: invalid.Field(), Rule: invalid.Tag(), Message: ruleMessage(invalid)})
+		}
+		writeProblem(ctx, http.StatusBadRequest, body)
+	case errors.As(err, &typeError):
+		body := newProblem(ctx, http.StatusBadRequest, codeValidationFailed, "The request has invalid fields.")
+		body.Errors = []fieldError{{Field: typeError.Field, Rule: "type", Message: fmt.Sprintf("must be a %s", typeError.Type)}}
+		writeProblem(ctx, http.StatusBadRequest, body)
+	case errors.Is(err, io.EOF):
+		abortWithProblem(ctx, http.StatusBadRequest, codeInvalidRequest, "The request body is empty.")
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		abortWithProblem(ctx, http.StatusBadRequest, codeInvalidRequest, "The request body is not valid JSON.")
+	case errors.As(err, &numError):
+		abortWithProblem(ctx, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("%q is not a number.", numError.Num))
+	default:
+		abortWithProblem(ctx, http.StatusBadRequest, codeInvalidRequest, err.Error())
+	}
+}
+
+// answer 500 without the error, which may tell more than callers should know; the log has it under the request id
+func (server *Server) abortWithInternalError(ctx *gin.Context, err error) {
+	server.log(ctx).Error("handling request", "error", err.Error())
+	abortWithProblem(ctx, http.StatusInternalServerError, codeInternal, "The server failed to handle the request.")
+}
+
+func ruleMessage(invalid validator.FieldError) string {
+	switch invalid.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + invalid.Param()
+	case "max":
+		return "must be at most " + invalid.Param()
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(invalid.Param()), ", ")
+	case "datetime":
+		return "must be a time formatted as " + invalid.Param()
+	case "url":
+		return "must be a URL"
+	default:
+		return "fails the " + invalid.Tag() + " rule"
+	}
+}
+
+// validation errors name fields as clients send them: by their json, uri or form tag
+func init() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, key := range []string{"json", "uri", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) problem {
+	assert.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+
+	var response problem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, recorder.Code, response.Status)
+	assert.Equal(t, http.StatusText(recorder.Code), response.Title)
+	assert.Equal(t, recorder.Header().Get(RequestIDHeader), response.RequestID)
+	return response
+}
+
+// Binding errors name each invalid field the way the client sent it.
+func TestProblemValidationFailed(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+
+	recorder := sendJSONAs(t, server, staff(t, store, db.RoleAdmin), http.MethodPut, "/account/update", gin.H{"id": 0})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	response := decodeProblem(t, recorder)
+	assert.Equal(t, codeValidationFailed, response.Code)
+	assert.Equal(t, "/account/update", response.Instance)
+	assert.ElementsMatch(t, []fieldError{
+		{Field: "id", Rule: "required", Message: "is required"},
+		{Field: "new_owner", Rule: "required", Message: "is required"},
+	}, response.Errors)
+
+	recorder = sendJSONAs(t, server, utils.RandomOwner(), http.MethodPost, "/account/create", gin.H{"owner": 5, "currency": "USD"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	response = decodeProblem(t, recorder)
+	assert.Equal(t, codeValidationFailed, response.Code)
+	assert.Equal(t, []fieldError{{Field: "owner", Rule: "type", Message: "must be a string"}}, response.Errors)
+}
+
+func TestProblemInvalidRequest(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/account/create", strings.NewReader("{"))
+	require.NoError(t, err)
+	setUser(t, request, utils.RandomOwner())
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	response := decodeProblem(t, recorder)
+	assert.Equal(t, codeInvalidRequest, response.Code)
+	assert.Empty(t, response.Errors)
+}
+
+// Middleware and unknown routes answer with problems too.
+func TestProblemOutsideHandlers(t *testing.T) {
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+
+	recorder := sendJSON(t, server, http.MethodGet, "/admin/limits", nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, codeUnauthenticated, decodeProblem(t, recorder).Code)
+
+	recorder = sendJSON(t, server, http.MethodGet, "/nowhere", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, codeNotFound, decodeProblem(t, recorder).Code)
+}
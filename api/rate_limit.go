@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/config"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/ratelimit"
+)
+
+// route groups with a budget of their own, see config.RateLimitConfig; client IPs have one across groups
+const (
+	rateLimitIP            = "ip"
+	rateLimitDefault       = "default"
+	rateLimitAccountsRead  = "accounts_read"
+	rateLimitAccountsWrite = "accounts_write"
+	rateLimitTransfers     = "transfers"
+)
+
+// rate limit group of the routes requiring each permission, the others share the default budget
+var rateLimitGroups = map[permission]string{
+	permAccountsRead:    rateLimitAccountsRead,
+	permAccountsWrite:   rateLimitAccountsWrite,
+	permTransfersCreate: rateLimitTransfers,
+}
+
+// limiter for rateLimit, nil when rate limiting is off
+func newRateLimiter(store db.Store, rateLimit config.RateLimitConfig, logger *slog.Logger) *ratelimit.Limiter {
+	var backend ratelimit.Backend
+	switch rateLimit.Backend {
+	case config.RateLimitMemory:
+		backend = ratelimit.NewMemoryBackend()
+	case config.RateLimitPostgres:
+		backend = ratelimit.NewStoreBackend(store)
+	default:
+		return nil
+	}
+
+	return ratelimit.NewLimiter(backend, map[string]ratelimit.Limit{
+		rateLimitIP:            ratelimit.PerMinute(rateLimit.IPPerMinute, rateLimit.IPBurst),
+		rateLimitDefault:       ratelimit.PerMinute(rateLimit.DefaultPerMinute, rateLimit.DefaultBurst),
+		rateLimitAccountsRead:  ratelimit.PerMinute(rateLimit.AccountsReadPerMinute, rateLimit.AccountsReadBurst),
+		rateLimitAccountsWrite: ratelimit.PerMinute(rateLimit.AccountsWritePerMinute, rateLimit.AccountsWriteBurst),
+		rateLimitTransfers:     ratelimit.PerMinute(rateLimit.TransfersPerMinute, rateLimit.TransfersBurst),
+	}, logger)
+}
+
+// take a token from the client IP's budget, answer 429 when there is none.
+// runs before authorize, so callers sending invalid credentials are limited as well
+func (server *Server) rateLimitIP(required permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if server.limiter == nil || required == permPublic {
+			return
+		}
+
+		server.takeRateLimitToken(ctx, rateLimitIP, "ip:"+ctx.ClientIP())
+	}
+}
+
+// take a token for the caller from the budget of the route's group, answer 429 when there is none.
+// runs after authorize, so the key or user it is spent for has been verified
+func (server *Server) rateLimit(required permission) gin.HandlerFunc {
+	group, ok := rateLimitGroups[required]
+	if !ok {
+		group = rateLimitDefault
+	}
+
+	return func(ctx *gin.Context) {
+		if server.limiter == nil || required == permPublic {
+			return
+		}
+
+		caller := callerOf(ctx)
+		identity := "user:" + caller.Name
+		if caller.KeyPrefix != "" {
+			identity = "key:" + caller.KeyPrefix
+		}
+
+		server.takeRateLimitToken(ctx, group, identity)
+	}
+}
+
+// headers tell about the last budget a token was taken from
+func (server *Server) takeRateLimitToken(ctx *gin.Context, group, identity string) {
+	result, err := server.limiter.Allow(ctx, group, identity)
+	if err != nil {
+		// an unavailable backend must not take the API down with it
+		server.log(ctx).Error("taking rate limit token", "group", group, "error", err.Error())
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	header := ctx.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.FormatInt(result.Limit.Burst, 10))
+	header.Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	header.Set("RateLimit-Reset", seconds(result.Reset))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", result.Limit.Burst, seconds(result.Limit.Window())))
+
+	if !result.Allowed {
+		header.Set("Retry-After", seconds(result.RetryAfter))
+		abortWithProblem(ctx, http.StatusTooManyRequests, codeRateLimited, fmt.Sprintf("Rate limit of %s exceeded, retry in %s seconds.", group, seconds(result.RetryAfter)))
+	}
+}
+
+// whole seconds, rounded up so clients waiting that long are not too early
+func seconds(duration time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(duration.Seconds())), 10)
+}
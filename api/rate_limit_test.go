@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/apikey"
+	"github.com/joelpatel/go-bank/config"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Callers should get their own budget per route group, and be told when to come back once it is spent.
+func TestRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.AccountsWritePerMinute, cfg.RateLimit.AccountsWriteBurst = 6, 2
+	server := NewServer(memdb.NewStore(), cfg, testLogger())
+
+	create := func(username string) *http.Response {
+		recorder := sendJSONAs(t, server, username, http.MethodPost, "/account/create", gin.H{"currency": currency.USD})
+		return recorder.Result()
+	}
+
+	username := utils.RandomOwner()
+	response := create(username)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "2", response.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", response.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "10", response.Header.Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=20", response.Header.Get("RateLimit-Policy"))
+	assert.Empty(t, response.Header.Get("Retry-After"))
+
+	response = create(username)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "0", response.Header.Get("RateLimit-Remaining"))
+
+	response = create(username)
+	require.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "0", response.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "10", response.Header.Get("Retry-After"))
+
+	// every user has a budget of their own
+	response = create(utils.RandomOwner())
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// reading is another group
+	recorder := sendJSONAs(t, server, username, http.MethodGet, "/account/1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "30", recorder.Header().Get("RateLimit-Limit"))
+
+	// public routes are not limited
+	recorder = sendJSON(t, server, http.MethodGet, "/healthz", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+}
+
+// Invalid credentials should spend the client IP's budget, they have no caller's to spend.
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.IPPerMinute, cfg.RateLimit.IPBurst = 6, 2
+	server := NewServer(memdb.NewStore(), cfg, testLogger())
+
+	guess := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/account/1", nil)
+		require.NoError(t, err)
+		request.Header.Set(apikey.KeyHeader, "gbk_guessed")
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, guess().Code)
+	assert.Equal(t, http.StatusUnauthorized, guess().Code)
+	recorder := guess()
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "10", recorder.Header().Get("Retry-After"))
+
+	// the IP's budget is spent for everyone behind it, valid users included
+	recorder = sendJSONAs(t, server, utils.RandomOwner(), http.MethodGet, "/account/1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+// A forged X-Forwarded-For should not buy a fresh budget, only trusted proxies name the client.
+func TestRateLimitForwardedFor(t *testing.T) {
+	guess := func(server *Server, forwardedFor string) int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/account/1", nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "10.0.0.1:40000"
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+		request.Header.Set(apikey.KeyHeader, "gbk_guessed")
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	cfg := testConfig()
+	cfg.RateLimit.IPPerMinute, cfg.RateLimit.IPBurst = 6, 2
+	server := NewServer(memdb.NewStore(), cfg, testLogger())
+	assert.Equal(t, http.StatusUnauthorized, guess(server, "203.0.113.1"))
+	assert.Equal(t, http.StatusUnauthorized, guess(server, "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, guess(server, "203.0.113.3"))
+
+	// behind a trusted proxy every client has a budget of its own
+	cfg.Server.TrustedProxies = "10.0.0.0/8"
+	server = NewServer(memdb.NewStore(), cfg, testLogger())
+	assert.Equal(t, http.StatusUnauthorized, guess(server, "203.0.113.1"))
+	assert.Equal(t, http.StatusUnauthorized, guess(server, "203.0.113.2"))
+	assert.Equal(t, http.StatusUnauthorized, guess(server, "203.0.113.3"))
+}
+
+// Without a backend nothing should be limited.
+func TestRateLimitOff(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.Backend = config.RateLimitOff
+	cfg.RateLimit.AccountsWriteBurst = 1
+	server := NewServer(memdb.NewStore(), cfg, testLogger())
+	require.Nil(t, server.RateLimiter())
+
+	for i := 0; i < 3; i++ {
+		recorder := sendJSONAs(t, server, utils.RandomOwner(), http.MethodPost, "/account/create", gin.H{"currency": currency.USD})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+	}
+}
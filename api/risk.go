@@ -0,0 +1,69 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+// problem of a transfer refused by risk screening, with the assessment that refused it
+type riskDeniedProblem struct {
+	problem
+	Assessment *db.RiskAssessment `json:"assessment"`
+}
+
+// rules in use, with their settings as written in the rules file
+func (server *Server) getRiskRules(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.risk.Rules())
+}
+
+type riskAssessmentURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getRiskAssessment(ctx *gin.Context) {
+	var request riskAssessmentURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	assessment, err := server.store.GetRiskAssessmentByID(ctx, request.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithProblem(ctx, http.StatusNotFound, codeRiskAssessmentNotFound, fmt.Sprintf("Risk assessment with id %d not found.", request.ID))
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, assessment)
+}
+
+// only transfers some rule matched have an assessment
+func (server *Server) getTransferRiskAssessment(ctx *gin.Context) {
+	var request riskAssessmentURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	assessment, err := server.store.GetRiskAssessmentByTransferID(ctx, request.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithProblem(ctx, http.StatusNotFound, codeRiskAssessmentNotFound, fmt.Sprintf("No risk assessment for transfer %d.", request.ID))
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, assessment)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/risk"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Denied transfers should not be made, flagged ones should wait for approval and keep why they were flagged.
+func TestTransferRiskScreening(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	rules, err := risk.ParseRules([]byte(`
+rules:
+  - type: first_counterparty
+  - type: first_counterparty
+    name: large_first
+    decision: deny
+    min_amount: 500
+`))
+	require.NoError(t, err)
+	server.Risk().SetRules(rules)
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	recorder := postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 500, "currency": currency.USD})
+	require.Equal(t, http.StatusForbidden, recorder.Code, recorder.Body.String())
+	var denied struct {
+		Assessment db.RiskAssessment `json:"assessment"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &denied))
+	assert.Equal(t, db.RiskDeny, denied.Assessment.Decision)
+	assert.Nil(t, denied.Assessment.TransferID)
+	assert.Len(t, denied.Assessment.Reasons, 2)
+
+	account, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), account.Balance)
+
+	teller := staff(t, store, db.RoleTeller)
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, fmt.Sprintf("/admin/risk/assessments/%d", denied.Assessment.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// flagged for review, held until someone else approves it
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 100, "currency": currency.USD})
+	require.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+	var held db.TransferRequest
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &held))
+	require.NotNil(t, held.RiskAssessmentID)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, fmt.Sprintf("/transfer-requests/%d/approve", held.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &held))
+	require.Equal(t, db.TransferRequestExecuted, held.Status)
+	result := db.TransferTxResult{TransferRecord: db.Transfer{ID: *held.TransferID}}
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, fmt.Sprintf("/admin/risk/transfers/%d", result.TransferRecord.ID), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var flagged db.RiskAssessment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &flagged))
+	assert.Equal(t, db.RiskReview, flagged.Decision)
+	require.Len(t, flagged.Reasons, 1)
+	assert.Equal(t, "first_counterparty", flagged.Reasons[0].Rule)
+
+	// a known counterparty matches nothing and leaves no assessment
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 600, "currency": currency.USD})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, fmt.Sprintf("/admin/risk/transfers/%d", result.TransferRecord.ID), nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, "/admin/risk/rules", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var ruleset risk.Ruleset
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &ruleset))
+	require.Len(t, ruleset.Rules, 2)
+	assert.Equal(t, "large_first", ruleset.Rules[1].Name)
+	assert.Equal(t, map[string]any{"min_amount": 500.0}, ruleset.Rules[1].Settings)
+}
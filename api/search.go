@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+)
+
+type searchTransactionsRequestURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// dates are whole UTC days, both ends included; amounts are absolute, in cents.
+// reference matches exactly, memo anywhere in the description
+type searchTransactionsRequestQuery struct {
+	pageQuery
+	Type         string `form:"type" binding:"omitempty,oneof=transfer entry"`
+	Direction    string `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	Counterparty *int64 `form:"counterparty" binding:"omitempty,min=1"`
+	From         string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To           string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	MinAmount    *int64 `form:"min_amount" binding:"omitempty,min=0"`
+	MaxAmount    *int64 `form:"max_amount" binding:"omitempty,min=0"`
+	Reference    string `form:"reference"`
+	Memo         string `form:"memo"`
+}
+
+func (query searchTransactionsRequestQuery) filter(accountID int64) db.TransactionFilter {
+	filter := db.TransactionFilter{
+		AccountID:      accountID,
+		Type:           query.Type,
+		Direction:      query.Direction,
+		CounterpartyID: query.Counterparty,
+		MinAmount:      query.MinAmount,
+		MaxAmount:      query.MaxAmount,
+		Reference:      query.Reference,
+		Memo:           query.Memo,
+	}
+	if filter.Type == "" {
+		filter.Type = db.TransactionTransfer
+	}
+
+	if query.From != "" {
+		from, _ := time.Parse(dateLayout, query.From)
+		filter.CreatedFrom = &from
+	}
+	if query.To != "" {
+		// up to the end of the day
+		to, _ := time.Parse(dateLayout, query.To)
+		to = to.AddDate(0, 0, 1)
+		filter.CreatedTo = &to
+	}
+
+	return filter
+}
+
+// the list a cursor belongs to, every filter changes it
+func searchScope(filter db.TransactionFilter) string {
+	optional := func(value *int64) string {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(*value)
+	}
+	date := func(value *time.Time) string {
+		if value == nil {
+			return ""
+		}
+		return value.Format(dateLayout)
+	}
+
+	return fmt.Sprintf("transactions:%d:%s:%s:%s:%s:%s:%s:%s:%q:%q", filter.AccountID, filter.Type, filter.Direction,
+		optional(filter.CounterpartyID), date(filter.CreatedFrom), date(filter.CreatedTo), optional(filter.MinAmount), optional(filter.MaxAmount),
+		filter.Reference, filter.Memo)
+}
+
+func (server *Server) searchTransactions(ctx *gin.Context) {
+	var requestURI searchTransactionsRequestURI
+	var requestQuery searchTransactionsRequestQuery
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&requestQuery); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	filter := requestQuery.filter(requestURI.ID)
+	if err := filter.Validate(); err != nil {
+		abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+
+	listPage(server, ctx, searchScope(filter), requestQuery.pageQuery, func(transaction db.Transaction) db.PageKey {
+		return db.PageKey{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+	}, func(page db.Page) (*[]db.Transaction, error) {
+		return server.store.SearchTransactions(ctx, filter, page)
+	})
+}
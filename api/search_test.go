@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// search the transactions of accountID as its owner
+func searchTransactionsPage(t *testing.T, server *Server, accountID int64, query url.Values) (int, pageResponse[db.Transaction]) {
+	username := utils.RandomOwner()
+	if account, err := server.store.GetAccountByID(context.Background(), accountID); err == nil {
+		username = account.Owner
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/account/%d/transactions?%s", accountID, query.Encode()), nil)
+	require.NoError(t, err)
+	setUser(t, request, username)
+	server.router.ServeHTTP(recorder, request)
+
+	var response pageResponse[db.Transaction]
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	}
+	return recorder.Code, response
+}
+
+func amountsOf(transactions []db.Transaction) []int64 {
+	var amounts []int64
+	for _, transaction := range transactions {
+		amounts = append(amounts, transaction.Amount)
+	}
+	return amounts
+}
+
+// Every filter should narrow the account's transfers, and entries should be searchable the same way.
+func TestSearchTransactions(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	account, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, currency.USD)
+	require.NoError(t, err)
+	payee, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, currency.USD)
+	require.NoError(t, err)
+	payer, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, currency.USD)
+	require.NoError(t, err)
+
+	for _, transfer := range []struct{ from, to, amount int64 }{
+		{account.ID, payee.ID, 10},
+		{payee.ID, account.ID, 20},
+		{account.ID, payer.ID, 30},
+		{payer.ID, account.ID, 40},
+	} {
+		_, err := store.TransferMoney(ctx, transfer.from, transfer.to, transfer.amount, db.Details{})
+		require.NoError(t, err)
+	}
+
+	today := time.Now().UTC().Format(dateLayout)
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(dateLayout)
+
+	testCases := []struct {
+		name     string
+		query    url.Values
+		expected []int64
+	}{
+		{"All", url.Values{}, []int64{-10, 20, -30, 40}},
+		{"Outgoing", url.Values{"direction": {"outgoing"}}, []int64{-10, -30}},
+		{"Counterparty", url.Values{"counterparty": {fmt.Sprint(payer.ID)}}, []int64{-30, 40}},
+		{"AmountRange", url.Values{"min_amount": {"20"}, "max_amount": {"30"}}, []int64{20, -30}},
+		{"Today", url.Values{"from": {today}, "to": {today}}, []int64{-10, 20, -30, 40}},
+		{"Yesterday", url.Values{"to": {yesterday}}, nil},
+		{"Entries", url.Values{"type": {"entry"}, "direction": {"incoming"}}, []int64{20, 40}},
+		{"Combined", url.Values{"direction": {"incoming"}, "counterparty": {fmt.Sprint(payee.ID)}, "min_amount": {"20"}}, []int64{20}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := url.Values{"page_size": {"10"}}
+			for key, values := range tc.query {
+				query[key] = values
+			}
+
+			code, response := searchTransactionsPage(t, server, account.ID, query)
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tc.expected, amountsOf(response.Data))
+			assert.NotNil(t, response.Data)
+		})
+	}
+
+	// a cursor only continues the search it came from
+	code, first := searchTransactionsPage(t, server, account.ID, url.Values{"page_size": {"1"}, "direction": {"outgoing"}})
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, first.NextCursor)
+
+	code, second := searchTransactionsPage(t, server, account.ID, url.Values{"page_size": {"1"}, "direction": {"outgoing"}, "cursor": {first.NextCursor}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []int64{-30}, amountsOf(second.Data))
+
+	code, _ = searchTransactionsPage(t, server, account.ID, url.Values{"page_size": {"1"}, "min_amount": {"1"}, "direction": {"outgoing"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestSearchTransactionsBadRequest(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	account, err := store.CreateAccount(context.Background(), utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	for name, query := range map[string]url.Values{
+		"NoPageSize":           {},
+		"UnknownType":          {"type": {"payment"}},
+		"UnknownDirection":     {"direction": {"sideways"}},
+		"BadDate":              {"from": {"01/03/2024"}},
+		"EmptyDateRange":       {"from": {"2024-03-02"}, "to": {"2024-03-01"}},
+		"NegativeAmount":       {"min_amount": {"-1"}},
+		"EmptyAmountRange":     {"min_amount": {"20"}, "max_amount": {"10"}},
+		"EntryCounterparty":    {"type": {"entry"}, "counterparty": {"2"}},
+		"InjectedCounterparty": {"counterparty": {"1 OR 1=1"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if name != "NoPageSize" {
+				query.Set("page_size", "10")
+			}
+
+			code, _ := searchTransactionsPage(t, server, account.ID, query)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
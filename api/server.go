@@ -1,36 +1,217 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
 	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/config"
 	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/logging"
+	"github.com/joelpatel/go-bank/metrics"
+	"github.com/joelpatel/go-bank/ratelimit"
+	"github.com/joelpatel/go-bank/risk"
+	"github.com/joelpatel/go-bank/stream"
 )
 
 // Server serves HTTP requests for the banking service.
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config      config.Config
+	store       db.Store
+	cursors     *cursorCodec
+	broker      *stream.Broker
+	risk        *risk.Engine
+	limiter     *ratelimit.Limiter // nil when rate limiting is off
+	metrics     *metrics.Metrics
+	logger      *logging.Logger
+	router      *gin.Engine
+	permissions map[string]permission // declared by each route, see handle
+	deprecated  map[string]string     // successors of the deprecated routes, see handleDeprecated
+	mu          sync.Mutex
+	httpServer  *http.Server
+	draining    atomic.Bool
 }
 
 // NewServer creates a new HTTP server instance and sets up routing.
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
-	router := gin.Default()
+// Everything it logs goes to logger, whose level admins can change while it runs.
+func NewServer(store db.Store, config config.Config, logger *logging.Logger) *Server {
+	collected := metrics.New()
+	store = metrics.NewStore(store, collected)
+
+	server := &Server{
+		config:  config,
+		store:   store,
+		cursors: newCursorCodec(config.Server.CursorSecret),
+		broker:  stream.NewBroker(store, logger.Logger),
+		risk:    risk.NewEngine(store),
+		limiter: newRateLimiter(store, config.RateLimit, logger.Logger),
+		metrics: collected,
+		logger:  logger,
+
+		permissions: map[string]permission{},
+		deprecated:  map[string]string{},
+	}
+	server.router = gin.New()
+	server.router.ContextWithFallback = true
+	// client IPs are taken from X-Forwarded-For only when a trusted proxy sent it, anyone could forge it
+	if err := server.router.SetTrustedProxies(config.Server.Proxies()); err != nil {
+		logger.Error("invalid trusted proxies, trusting none", slog.Any("error", err))
+		_ = server.router.SetTrustedProxies(nil)
+	}
+	server.router.Use(
+		server.traceRequest,
+		server.identifyRequest,
+		server.logRequest,
+		gin.CustomRecoveryWithWriter(io.Discard, server.recoverPanic),
+		server.observeRequest,
+	)
+
+	v1 := server.router.Group("/v1")
+
+	server.handle(v1, http.MethodPost, "/accounts", permAccountsWrite, server.createAccount)
+	server.handle(v1, http.MethodGet, "/accounts", permAccountsRead, server.listAccounts)
+	server.handle(v1, http.MethodGet, "/accounts/:id", permAccountsRead, server.getAccountByID)
+	server.handle(v1, http.MethodPatch, "/accounts/:id", permAdminAccountsWrite, server.patchAccount)
+	server.handle(v1, http.MethodDelete, "/accounts/:id", permAccountsWrite, server.deleteAccountByID)
+	server.handle(v1, http.MethodGet, "/accounts/:id/balance", permAccountsRead, server.getAccountBalanceAsOf)
+	server.handle(v1, http.MethodGet, "/accounts/:id/entries", permAccountsRead, server.listAccountEntries)
+	server.handle(v1, http.MethodGet, "/accounts/:id/transfers", permAccountsRead, server.listAccountTransfers)
+	server.handle(v1, http.MethodGet, "/accounts/:id/transactions", permAccountsRead, server.searchTransactions)
+
+	server.handleV1(v1, http.MethodPost, "/transfers", permTransfersCreate, server.createTransfer)
+
+	server.handleV1(v1, http.MethodGet, "/transfer-requests", permTransferRequestsRead, server.listTransferRequests)
+	server.handleV1(v1, http.MethodGet, "/transfer-requests/:id", permTransferRequestsRead, server.getTransferRequest)
+	server.handleV1(v1, http.MethodGet, "/transfer-requests/:id/events", permTransferRequestsRead, server.listTransferRequestEvents)
+	server.handleV1(v1, http.MethodPost, "/transfer-requests/:id/approve", permTransferRequestsDecide, server.approveTransferRequest)
+	server.handleV1(v1, http.MethodPost, "/transfer-requests/:id/reject", permTransferRequestsDecide, server.rejectTransferRequest)
+
+	server.handleV1(v1, http.MethodPost, "/payees", permPayeesManage, server.createPayee)
+	server.handleV1(v1, http.MethodGet, "/payees", permPayeesManage, server.listPayees)
+	server.handle(v1, http.MethodPatch, "/payees/:id", permPayeesManage, server.updatePayee)
+	server.handleV1(v1, http.MethodDelete, "/payees/:id", permPayeesManage, server.deletePayee)
+
+	server.handleV1(v1, http.MethodGet, "/admin/eod", permEndOfDayRead, server.getEndOfDayStatus)
+	server.handleV1(v1, http.MethodGet, "/admin/eod/:date", permEndOfDayRead, server.getBusinessDay)
+	server.handleV1(v1, http.MethodGet, "/admin/limits", permLimitsRead, server.listTransferLimits)
+	server.handleV1(v1, http.MethodPut, "/admin/limits", permLimitsWrite, server.setTransferLimit)
+	server.handleV1(v1, http.MethodDelete, "/admin/limits/:id", permLimitsWrite, server.deleteTransferLimit)
+	server.handleV1(v1, http.MethodGet, "/admin/risk/rules", permRiskRead, server.getRiskRules)
+	server.handleV1(v1, http.MethodGet, "/admin/risk/assessments/:id", permRiskRead, server.getRiskAssessment)
+	server.handleV1(v1, http.MethodGet, "/admin/risk/transfers/:id", permRiskRead, server.getTransferRiskAssessment)
+	server.handleV1(v1, http.MethodGet, "/admin/accounts/:id", permAdminAccountsRead, server.getAccountByID)
+	server.handleV1(v1, http.MethodPost, "/admin/accounts/:id/freeze", permAdminAccountsFreeze, server.freezeAccount)
+	server.handleV1(v1, http.MethodPost, "/admin/accounts/:id/unfreeze", permAdminAccountsFreeze, server.unfreezeAccount)
+	server.handleV1(v1, http.MethodPost, "/admin/accounts/:id/adjust", permAdminAccountsWrite, server.adjustAccountBalance)
+	server.handleV1(v1, http.MethodPut, "/admin/accounts/:id/owner", permAdminAccountsWrite, server.changeAccountOwner)
+	server.handleV1(v1, http.MethodGet, "/admin/users", permUsersManage, server.listUsers)
+	server.handleV1(v1, http.MethodPut, "/admin/users/:username", permUsersManage, server.setUserRole)
+	server.handleV1(v1, http.MethodPost, "/admin/api-keys", permAPIKeysManage, server.createAPIKey)
+	server.handleV1(v1, http.MethodGet, "/admin/api-keys", permAPIKeysManage, server.listAPIKeys)
+	server.handleV1(v1, http.MethodGet, "/admin/api-keys/:id", permAPIKeysManage, server.getAPIKey)
+	server.handleV1(v1, http.MethodPost, "/admin/api-keys/:id/rotate", permAPIKeysManage, server.rotateAPIKey)
+	server.handleV1(v1, http.MethodDelete, "/admin/api-keys/:id", permAPIKeysManage, server.revokeAPIKey)
+	server.handleV1(v1, http.MethodGet, "/admin/log-level", permLoggingManage, server.getLogLevel)
+	server.handleV1(v1, http.MethodPut, "/admin/log-level", permLoggingManage, server.setLogLevel)
+
+	server.handleV1(v1, http.MethodPost, "/webhooks", permWebhooksManage, server.createWebhookSubscription)
+	server.handleV1(v1, http.MethodGet, "/webhooks", permWebhooksManage, server.listWebhookSubscriptions)
+	server.handleV1(v1, http.MethodDelete, "/webhooks/:id", permWebhooksManage, server.deleteWebhookSubscription)
+	server.handleV1(v1, http.MethodGet, "/webhooks/:id/deliveries", permWebhooksManage, server.listWebhookDeliveries)
+	server.handleV1(v1, http.MethodGet, "/webhooks/:id/deliveries/:delivery_id", permWebhooksManage, server.getWebhookDelivery)
+	server.handleV1(v1, http.MethodPost, "/webhooks/:id/deliveries/:delivery_id/replay", permWebhooksManage, server.replayWebhookDelivery)
+
+	server.handleV1(v1, http.MethodGet, "/stream", permStreamRead, server.streamEvents)
+
+	// where accounts were before /v1, and payees' nickname was put
+	server.handleDeprecated(http.MethodPost, "/account/create", "/v1/accounts", permAccountsWrite, server.createAccount)
+	server.handleDeprecated(http.MethodGet, "/account/:id", "/v1/accounts/:id", permAccountsRead, server.getAccountByID)
+	server.handleDeprecated(http.MethodPost, "/accounts", "/v1/accounts", permAccountsRead, server.listAccountsByOwner)
+	server.handleDeprecated(http.MethodPut, "/account/update", "/v1/accounts/:id", permAdminAccountsWrite, server.updateAccountOwner)
+	server.handleDeprecated(http.MethodDelete, "/account/delete/:id", "/v1/accounts/:id", permAccountsWrite, server.deleteAccountByID)
+	server.handleDeprecated(http.MethodGet, "/account/:id/balance", "/v1/accounts/:id/balance", permAccountsRead, server.getAccountBalanceAsOf)
+	server.handleDeprecated(http.MethodGet, "/account/:id/entries", "/v1/accounts/:id/entries", permAccountsRead, server.listAccountEntries)
+	server.handleDeprecated(http.MethodGet, "/account/:id/transfers", "/v1/accounts/:id/transfers", permAccountsRead, server.listAccountTransfers)
+	server.handleDeprecated(http.MethodGet, "/account/:id/transactions", "/v1/accounts/:id/transactions", permAccountsRead, server.searchTransactions)
+	server.handleDeprecated(http.MethodPut, "/payees/:id", "/v1/payees/:id", permPayeesManage, server.updatePayee)
+
+	// operations, not part of the versioned API
+	root := &server.router.RouterGroup
+	server.handle(root, http.MethodGet, "/healthz", permPublic, server.liveness)
+	server.handle(root, http.MethodGet, "/readyz", permPublic, server.readiness)
+	server.handle(root, http.MethodGet, "/metrics", permPublic, gin.WrapH(server.metrics.Handler()))
+	server.handle(root, http.MethodGet, "/openapi.json", permPublic, serveOpenAPI)
 
-	router.POST("/account/create", server.createAccount)
-	router.GET("/account/:id", server.getAccountByID)
-	router.POST("/accounts", server.listAccountsByOwner)
-	router.PUT("/account/update", server.updateAccountOwner)
-	router.DELETE("/account/delete/:id", server.deleteAccountByID)
+	server.router.NoRoute(func(ctx *gin.Context) {
+		abortWithProblem(ctx, http.StatusNotFound, codeNotFound, fmt.Sprintf("No route for %s %s.", ctx.Request.Method, ctx.Request.URL.Path))
+	})
 
-	server.router = router
 	return server
 }
 
-// StartServer runs the HTTP server on a provided address.
-func (server *Server) StartServer(address string) error {
-	return server.router.Run(address)
+// Broker returns the streaming broker; it has to be Run to receive events.
+func (server *Server) Broker() *stream.Broker {
+	return server.broker
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Risk returns the engine screening transfers, it allows everything until given rules.
+func (server *Server) Risk() *risk.Engine {
+	return server.risk
+}
+
+// RateLimiter returns the limiter of callers' requests, nil when rate limiting is off.
+// Its idle buckets are only deleted while it Runs.
+func (server *Server) RateLimiter() *ratelimit.Limiter {
+	return server.limiter
+}
+
+// Metrics returns what the server collects, the store it was given included.
+// Wrap other users of the store with metrics.NewStore to have them counted too.
+func (server *Server) Metrics() *metrics.Metrics {
+	return server.metrics
+}
+
+// StartServer runs the HTTP server until Shutdown is called.
+func (server *Server) StartServer() error {
+	httpServer := &http.Server{
+		Addr:              server.config.Server.Address,
+		Handler:           server.router,
+		ReadTimeout:       server.config.Server.ReadTimeout,
+		ReadHeaderTimeout: server.config.Server.ReadHeaderTimeout,
+		WriteTimeout:      server.config.Server.WriteTimeout,
+		IdleTimeout:       server.config.Server.IdleTimeout,
+	}
+	// streams never finish on their own, end them so draining can complete
+	httpServer.RegisterOnShutdown(server.broker.CloseAll)
+
+	server.mu.Lock()
+	server.httpServer = httpServer
+	server.mu.Unlock()
+
+	err := httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown marks the server as not ready, stops accepting connections
+// and waits for in-flight requests to finish or ctx to be done.
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.draining.Store(true)
+
+	server.mu.Lock()
+	httpServer := server.httpServer
+	server.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
 }
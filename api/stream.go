@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/stream"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type streamEventsRequest struct {
+	Owner       string `form:"owner"`
+	LastEventID int64  `form:"last_event_id" binding:"min=0"`
+}
+
+// streamEvents pushes balance changes and new entries of the owner's accounts as Server-Sent Events.
+// A reconnecting client resumes with the Last-Event-ID header (or last_event_id query parameter);
+// events are sent in the order they committed, which ids do not follow.
+func (server *Server) streamEvents(ctx *gin.Context) {
+	var request streamEventsRequest
+
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	lastEventID := request.LastEventID
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			abortWithProblem(ctx, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("%s is an invalid Last-Event-ID.", header))
+			return
+		}
+		lastEventID = id
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	// subscribe before replaying so nothing committed in between is missed
+	subscription := server.broker.Subscribe(owner)
+	defer subscription.Close()
+
+	// the server's write timeout is meant for regular requests, not for streams
+	http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	send := func(message stream.Message) error {
+		data, err := json.Marshal(message.Data)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", message.ID, message.Event, data)
+		ctx.Writer.Flush()
+		return err
+	}
+
+	// live messages up to the last replayed event were sent by the replay
+	var replayed stream.Position
+	if lastEventID > 0 {
+		var err error
+		replayed, err = server.broker.Replay(ctx, owner, lastEventID, send)
+		if err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case message, ok := <-subscription.Messages():
+			if !ok {
+				return
+			}
+			if !message.Position.After(replayed) {
+				continue
+			}
+			if err := send(message); err != nil {
+				return
+			}
+		}
+	}
+}
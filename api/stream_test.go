@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func balanceChangedEvent(t *testing.T, id int64, account *db.Account) db.OutboxEvent {
+	payload, err := json.Marshal(account)
+	assert.NoError(t, err)
+
+	return db.OutboxEvent{ID: id, AggregateType: db.AggregateAccount, AggregateID: account.ID, EventType: db.EventAccountBalanceChanged, Payload: payload, TxID: id}
+}
+
+// read one server-sent event, skipping heartbeats
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, ": ")
+		fields[key] = value
+	}
+}
+
+// A resuming client should first get the missed events, then live ones, without duplicates.
+func TestStreamEventsResume(t *testing.T) {
+	store, server, _ := beforeEach(t)
+	account := randomAccount()
+	resumed := balanceChangedEvent(t, 10, account)
+	missed := balanceChangedEvent(t, 11, account)
+	replayed := make(chan struct{})
+
+	store.EXPECT().
+		GetOutboxEventByID(gomock.Any(), gomock.Eq(int64(10))).
+		Times(1).
+		Return(&resumed, nil)
+	store.EXPECT().
+		GetOutboxEventsAfter(gomock.Any(), gomock.Eq(int64(10)), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, afterID, limit int64) (*[]db.OutboxEvent, error) {
+			close(replayed)
+			return &[]db.OutboxEvent{missed}, nil
+		})
+
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/stream", nil)
+	assert.NoError(t, err)
+	request.Header.Set("Last-Event-ID", "10")
+	expectCustomer(t, store, request, account.Owner)
+
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	event := readServerSentEvent(t, reader)
+	assert.Equal(t, "11", event["id"])
+	assert.Equal(t, "balance", event["event"])
+
+	// the replayed event arriving live again is skipped
+	<-replayed
+	assert.NoError(t, server.broker.Publish(context.Background(), missed))
+	assert.NoError(t, server.broker.Publish(context.Background(), balanceChangedEvent(t, 12, account)))
+
+	event = readServerSentEvent(t, reader)
+	assert.Equal(t, "12", event["id"])
+
+	var data db.Account
+	assert.NoError(t, json.Unmarshal([]byte(event["data"]), &data))
+	assert.Equal(t, account.ID, data.ID)
+}
+
+// When staff leave the owner out, the server should respond with status bad request.
+func TestStreamEventsMissingOwner(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	request, err := http.NewRequest(http.MethodGet, "/stream", nil)
+	assert.NoError(t, err)
+	expectStaff(t, store, request, db.RoleTeller)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// A customer should not be able to stream the balance changes of another owner.
+func TestStreamEventsOfAnotherOwner(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	request, err := http.NewRequest(http.MethodGet, "/stream?owner=victim", nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, "intruder")
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+// When the Last-Event-ID header is not a number, the server should respond with status bad request.
+func TestStreamEventsBadLastEventID(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	request, err := http.NewRequest(http.MethodGet, "/stream?owner=abcdef", nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, "abcdef")
+	request.Header.Set("Last-Event-ID", "abc")
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
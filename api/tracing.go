@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/joelpatel/go-bank/api"
+
+// span of every request, continuing the trace of the caller's traceparent header.
+// handlers pass the gin context on, which falls back to the request's, so store spans become children
+func (server *Server) traceRequest(ctx *gin.Context) {
+	parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+	route := ctx.FullPath()
+	name := ctx.Request.Method
+	if route != "" {
+		name += " " + route
+	}
+
+	spanCtx, span := otel.Tracer(tracerName).Start(parent, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(ctx.Request.Method), semconv.HTTPRoute(route)),
+	)
+	defer span.End()
+
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("%d %s", status, http.StatusText(status)))
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/tracing"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Requests should be spans named after their route, continuing the trace of the traceparent header.
+func TestTraceRequest(t *testing.T) {
+	exporter := tracing.InMemory()
+	server := NewServer(memdb.NewStore(), testConfig(), testLogger())
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/account/42", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	setUser(t, request, utils.RandomOwner())
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /account/:id", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Contains(t, span.Attributes, semconv.HTTPResponseStatusCode(http.StatusNotFound))
+}
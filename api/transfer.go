@@ -0,0 +1,223 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/metrics"
+)
+
+// the recipient is either to_account_id or one of the sender's payees
+type createTransferRequest struct {
+	FromAccountID     int64       `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID       int64       `json:"to_account_id" binding:"omitempty,min=1"`
+	PayeeID           int64       `json:"payee_id" binding:"omitempty,min=1"`
+	Amount            int64       `json:"amount" binding:"required,min=1"` // amount in cents
+	Currency          string      `json:"currency" binding:"required"`
+	Description       string      `json:"description"`
+	ExternalReference string      `json:"external_reference"`
+	Metadata          db.Metadata `json:"metadata"`
+}
+
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var request createTransferRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	details := db.Details{Description: request.Description, ExternalReference: request.ExternalReference, Metadata: request.Metadata}
+	if err := details.Validate(); err != nil {
+		abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+
+	if !callerOf(ctx).mayUseAccount(request.FromAccountID) {
+		abortWithProblem(ctx, http.StatusForbidden, codeForbidden, fmt.Sprintf("%s may not use account %d.", callerOf(ctx).Name, request.FromAccountID))
+		return
+	}
+
+	if (request.ToAccountID == 0) == (request.PayeeID == 0) {
+		abortWithProblem(ctx, http.StatusBadRequest, codeValidationFailed, "Exactly one of to_account_id and payee_id is required.")
+		return
+	}
+
+	fromAccount, ok := server.validAccount(ctx, request.FromAccountID, request.Currency)
+	if !ok {
+		return
+	}
+	// customers send from their own accounts only, to them the others do not exist
+	if !callerOf(ctx).mayUse(fromAccount) {
+		abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", request.FromAccountID))
+		return
+	}
+
+	toAccountID := request.ToAccountID
+	if request.PayeeID != 0 {
+		if toAccountID, ok = server.payeeAccount(ctx, request.PayeeID, fromAccount.Owner, request.Amount); !ok {
+			return
+		}
+	}
+
+	if toAccountID == request.FromAccountID {
+		abortWithProblem(ctx, http.StatusBadRequest, codeSameAccount, "Cannot transfer to the sending account.")
+		return
+	}
+	toAccount, ok := server.validAccount(ctx, toAccountID, request.Currency)
+	if !ok {
+		return
+	}
+	if request.PayeeID == 0 && !server.mayPayAccount(ctx, fromAccount.Owner, toAccount, request.Amount) {
+		return
+	}
+
+	for _, account := range []*db.Account{fromAccount, toAccount} {
+		if account.Frozen {
+			server.metrics.TransfersRejected.WithLabelValues(metrics.RejectedAccountFrozen).Inc()
+			abortWithProblem(ctx, http.StatusConflict, codeAccountFrozen, fmt.Sprintf("Account %d is frozen.", account.ID))
+			return
+		}
+	}
+
+	if fromAccount.Balance < request.Amount {
+		server.metrics.TransfersRejected.WithLabelValues(metrics.RejectedInsufficientFunds).Inc()
+		abortWithProblem(ctx, http.StatusUnprocessableEntity, codeInsufficientFunds, fmt.Sprintf("Account %d has insufficient funds.", request.FromAccountID))
+		return
+	}
+
+	assessment, err := server.risk.Assess(ctx, *fromAccount, *toAccount, request.Amount)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+	if assessment.Decision == db.RiskDeny {
+		server.metrics.TransfersRejected.WithLabelValues(metrics.RejectedRiskDenied).Inc()
+		denied, err := server.store.CreateRiskAssessment(ctx, *assessment)
+		if err != nil {
+			server.abortWithInternalError(ctx, err)
+			return
+		}
+		writeProblem(ctx, http.StatusForbidden, riskDeniedProblem{
+			problem:    newProblem(ctx, http.StatusForbidden, codeRiskDenied, "Transfer refused by risk screening."),
+			Assessment: denied,
+		})
+		return
+	}
+
+	var holdReasons []string
+	if threshold := server.config.Transfers.ApprovalThreshold; threshold > 0 && request.Amount > threshold {
+		holdReasons = append(holdReasons, fmt.Sprintf("amount above the approval threshold of %d", threshold))
+	}
+	if assessment.Decision == db.RiskReview {
+		holdReasons = append(holdReasons, "flagged by risk screening")
+	}
+	if len(holdReasons) > 0 {
+		server.holdTransfer(ctx, db.TransferRequest{
+			FromAccountID: request.FromAccountID,
+			ToAccountID:   toAccountID,
+			Amount:        request.Amount,
+			Details:       details,
+			Initiator:     callerOf(ctx).Name,
+			HoldReason:    strings.Join(holdReasons, "; "),
+		}, assessment)
+		return
+	}
+
+	result, err := server.store.TransferMoney(ctx, request.FromAccountID, toAccountID, request.Amount, details)
+	if err != nil {
+		var exceeded *db.LimitExceededError
+		switch {
+		case errors.As(err, &exceeded):
+			server.metrics.TransfersRejected.WithLabelValues(metrics.RejectedLimitExceeded).Inc()
+			writeProblem(ctx, http.StatusUnprocessableEntity, limitExceededProblem{
+				problem:            newProblem(ctx, http.StatusUnprocessableEntity, codeLimitExceeded, err.Error()),
+				LimitExceededError: exceeded,
+			})
+		case errors.Is(err, db.ErrDuplicateReference):
+			abortWithProblem(ctx, http.StatusConflict, codeDuplicateReference, err.Error())
+		case errors.Is(err, db.ErrBusinessDayClosed):
+			abortWithProblem(ctx, http.StatusConflict, codeBusinessDayClosed, err.Error())
+		case errors.Is(err, db.ErrAccountFrozen):
+			abortWithProblem(ctx, http.StatusConflict, codeAccountFrozen, err.Error())
+		default:
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	// the transfer is made, failing to keep why it was flagged must not make the client retry it
+	if len(assessment.Reasons) > 0 {
+		assessment.TransferID = &result.TransferRecord.ID
+		if _, err := server.store.CreateRiskAssessment(ctx, *assessment); err != nil {
+			server.log(ctx).Error("recording risk assessment", "transfer_id", result.TransferRecord.ID, "error", err.Error())
+		}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// the account if it exists and is in currency, otherwise responds and returns false
+func (server *Server) validAccount(ctx *gin.Context, id int64, currency string) (*db.Account, bool) {
+	account, err := server.store.GetAccountByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithProblem(ctx, http.StatusNotFound, codeAccountNotFound, fmt.Sprintf("Account with id %d not found.", id))
+		} else {
+			server.abortWithInternalError(ctx, err)
+		}
+		return nil, false
+	}
+
+	if account.Currency != currency {
+		abortWithProblem(ctx, http.StatusBadRequest, codeCurrencyMismatch, fmt.Sprintf("Account %d is in %s, not %s.", id, account.Currency, currency))
+		return nil, false
+	}
+
+	return account, true
+}
+
+type listAccountTransfersRequestURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listAccountTransfersRequestQuery struct {
+	pageQuery
+	// both when empty
+	Direction string `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+}
+
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	var requestURI listAccountTransfersRequestURI
+	var requestQuery listAccountTransfersRequestQuery
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&requestQuery); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	// -1 matches no account
+	from, to := requestURI.ID, requestURI.ID
+	switch requestQuery.Direction {
+	case "incoming":
+		from = -1
+	case "outgoing":
+		to = -1
+	}
+
+	listPage(server, ctx, fmt.Sprintf("transfers:%d:%s", requestURI.ID, requestQuery.Direction), requestQuery.pageQuery, func(transfer db.Transfer) db.PageKey {
+		return db.PageKey{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
+	}, func(page db.Page) (*[]db.Transfer, error) {
+		return server.store.GetTransfersFromTo(ctx, from, to, page)
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/approval"
+	"github.com/joelpatel/go-bank/db"
+)
+
+// submit request for approval instead of making the transfer, with the assessment that flagged it
+func (server *Server) holdTransfer(ctx *gin.Context, request db.TransferRequest, assessment *db.RiskAssessment) {
+	if len(assessment.Reasons) > 0 {
+		recorded, err := server.store.CreateRiskAssessment(ctx, *assessment)
+		if err != nil {
+			server.abortWithInternalError(ctx, err)
+			return
+		}
+		request.RiskAssessmentID = &recorded.ID
+	}
+
+	request.ExpiresAt = time.Now().Add(server.config.Transfers.ApprovalTTL)
+	held, err := server.store.CreateTransferRequest(ctx, request)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, held)
+}
+
+type listTransferRequestsQuery struct {
+	pageQuery
+	// every status when empty
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected expired executed failed"`
+}
+
+// the review queue is status=pending, it spans every account so keys limited to some accounts may not read it
+func (server *Server) listTransferRequests(ctx *gin.Context) {
+	var request listTransferRequestsQuery
+
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if !actsBeyondAccounts(ctx) {
+		return
+	}
+
+	listPage(server, ctx, "transfer_requests:"+request.Status, request.pageQuery, func(transferRequest db.TransferRequest) db.PageKey {
+		return db.PageKey{CreatedAt: transferRequest.CreatedAt, ID: transferRequest.ID}
+	}, func(page db.Page) (*[]db.TransferRequest, error) {
+		return server.store.ListTransferRequests(ctx, request.Status, page)
+	})
+}
+
+type transferRequestURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the request if the caller may see it, otherwise responds and returns false.
+// to keys limited to some accounts the requests from other accounts do not exist
+func (server *Server) visibleTransferRequest(ctx *gin.Context, id int64) (*db.TransferRequest, bool) {
+	transferRequest, err := server.store.GetTransferRequestByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		server.abortWithInternalError(ctx, err)
+		return nil, false
+	}
+
+	if err != nil || !callerOf(ctx).mayUseAccount(transferRequest.FromAccountID) {
+		abortWithProblem(ctx, http.StatusNotFound, codeTransferRequestNotFound, fmt.Sprintf("Transfer request with id %d not found.", id))
+		return nil, false
+	}
+
+	return transferRequest, true
+}
+
+func (server *Server) getTransferRequest(ctx *gin.Context) {
+	var request transferRequestURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	transferRequest, ok := server.visibleTransferRequest(ctx, request.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferRequest)
+}
+
+// audit trail, oldest first
+func (server *Server) listTransferRequestEvents(ctx *gin.Context) {
+	var request transferRequestURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if _, ok := server.visibleTransferRequest(ctx, request.ID); !ok {
+		return
+	}
+
+	events, err := server.store.GetTransferRequestEvents(ctx, request.ID)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []db.TransferRequestEvent{}
+	if events != nil {
+		data = append(data, *events...)
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+// reviewer is never the initiator of the request
+// the reviewer is the caller, the body may be left out
+type decideTransferRequestRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+// approve and make the transfer; a transfer that cannot be made leaves the request failed
+func (server *Server) approveTransferRequest(ctx *gin.Context) {
+	server.decideTransferRequest(ctx, approval.Approve)
+}
+
+func (server *Server) rejectTransferRequest(ctx *gin.Context) {
+	server.decideTransferRequest(ctx, approval.Reject)
+}
+
+func (server *Server) decideTransferRequest(ctx *gin.Context, decide func(ctx context.Context, store db.Store, id int64, reviewer, note string) (*db.TransferRequest, error)) {
+	var requestURI transferRequestURI
+	var request decideTransferRequestRequest
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if _, ok := server.visibleTransferRequest(ctx, requestURI.ID); !ok {
+		return
+	}
+
+	transferRequest, err := decide(ctx, server.store, requestURI.ID, callerOf(ctx).Name, request.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			abortWithProblem(ctx, http.StatusNotFound, codeTransferRequestNotFound, fmt.Sprintf("Transfer request with id %d not found.", requestURI.ID))
+		case errors.Is(err, db.ErrSelfReview):
+			abortWithProblem(ctx, http.StatusForbidden, codeSelfReview, err.Error())
+		case errors.Is(err, db.ErrRequestNotPending):
+			abortWithProblem(ctx, http.StatusConflict, codeTransferRequestNotPending, err.Error())
+		case errors.Is(err, db.ErrRequestExpired):
+			abortWithProblem(ctx, http.StatusConflict, codeTransferRequestExpired, err.Error())
+		default:
+			server.abortWithInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferRequest)
+}
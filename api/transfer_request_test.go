@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Transfers above the approval threshold should wait for a second user and be made once approved.
+func TestTransferApproval(t *testing.T) {
+	store := memdb.NewStore()
+	cfg := testConfig()
+	cfg.Transfers.ApprovalThreshold = 500
+	server := NewServer(store, cfg, testLogger())
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 2000, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	// at the threshold is made right away
+	recorder := postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 500, "currency": currency.USD})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	hold := func() db.TransferRequest {
+		recorder := postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 501, "currency": currency.USD, "description": "car"})
+		require.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+
+		var request db.TransferRequest
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &request))
+		assert.Equal(t, db.TransferRequestPending, request.Status)
+		assert.Equal(t, from.Owner, request.Initiator)
+		assert.Equal(t, "car", request.Description)
+		assert.Contains(t, request.HoldReason, "approval threshold")
+		return request
+	}
+	approved, rejected := hold(), hold()
+
+	account, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), account.Balance)
+
+	// the queue
+	teller := staff(t, store, db.RoleTeller)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, "/transfer-requests?"+url.Values{"status": {"pending"}, "page_size": {"10"}}.Encode(), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var queue pageResponse[db.TransferRequest]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &queue))
+	require.Len(t, queue.Data, 2)
+	assert.Equal(t, approved.ID, queue.Data[0].ID)
+
+	path := func(request db.TransferRequest, action string) string {
+		return fmt.Sprintf("/transfer-requests/%d/%s", request.ID, action)
+	}
+
+	// only by staff, and never by the initiator
+	recorder = sendJSON(t, server, http.MethodPost, path(approved, "approve"), nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	recorder = sendJSONAs(t, server, to.Owner, http.MethodPost, path(approved, "approve"), nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	_, err = store.SetUserRole(ctx, from.Owner, db.RoleTeller)
+	require.NoError(t, err)
+	recorder = sendJSONAs(t, server, from.Owner, http.MethodPost, path(approved, "approve"), nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), db.ErrSelfReview.Error())
+
+	// the reviewer is whoever calls, never whoever the body names
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, path(approved, "approve"), gin.H{"note": "called the customer", "reviewer": to.Owner})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &approved))
+	assert.Equal(t, db.TransferRequestExecuted, approved.Status)
+	require.NotNil(t, approved.TransferID)
+	require.NotNil(t, approved.Reviewer)
+	assert.Equal(t, teller, *approved.Reviewer)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, path(approved, "reject"), nil)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, path(rejected, "reject"), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rejected))
+	assert.Equal(t, db.TransferRequestRejected, rejected.Status)
+
+	account, err = store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(999), account.Balance)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, path(approved, "events"), nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var events []db.TransferRequestEvent
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &events))
+	require.Len(t, events, 3)
+	assert.Equal(t, db.TransferRequestSubmitted, events[0].Action)
+	assert.Equal(t, from.Owner, events[0].Actor)
+	assert.Equal(t, db.TransferRequestApproved, events[1].Action)
+	assert.Equal(t, teller, events[1].Actor)
+	assert.Equal(t, "called the customer", events[1].Note)
+	assert.Equal(t, db.TransferRequestExecuted, events[2].Action)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, fmt.Sprintf("/transfer-requests/%d", rejected.ID), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, "/transfer-requests/999", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = sendJSONAs(t, server, teller, http.MethodGet, "/transfer-requests/999/events", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, "/transfer-requests/999/approve", nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// Staff who make a held transfer for a customer are its initiator and may not approve it themselves.
+func TestTransferApprovalStaffInitiator(t *testing.T) {
+	store := memdb.NewStore()
+	cfg := testConfig()
+	cfg.Transfers.ApprovalThreshold = 500
+	server := NewServer(store, cfg, testLogger())
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 2000, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+	teller := staff(t, store, db.RoleTeller)
+
+	recorder := sendJSONAs(t, server, teller, http.MethodPost, "/transfers", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 501, "currency": currency.USD})
+	require.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+	var request db.TransferRequest
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &request))
+	assert.Equal(t, teller, request.Initiator)
+
+	recorder = sendJSONAs(t, server, teller, http.MethodPost, fmt.Sprintf("/transfer-requests/%d/approve", request.ID), nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), db.ErrSelfReview.Error())
+
+	account, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000), account.Balance)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postTransfer(t *testing.T, server *Server, body gin.H) *httptest.ResponseRecorder {
+	username := utils.RandomOwner()
+	if id, ok := body["from_account_id"].(int64); ok {
+		if account, err := server.store.GetAccountByID(context.Background(), id); err == nil {
+			username = account.Owner
+		}
+	}
+
+	return sendJSONAs(t, server, username, http.MethodPost, "/transfers", body)
+}
+
+// A transfer should keep its description, reference and metadata on the transfer and both entries.
+func TestCreateTransferDetails(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	recorder := postTransfer(t, server, gin.H{
+		"from_account_id":    from.ID,
+		"to_account_id":      to.ID,
+		"amount":             40,
+		"currency":           currency.USD,
+		"description":        "Dinner at Luigi's",
+		"external_reference": "order-42",
+		"metadata":           gin.H{"split": "3"},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var result db.TransferTxResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	expected := db.Details{Description: "Dinner at Luigi's", ExternalReference: "order-42", Metadata: db.Metadata{"split": "3"}}
+	assert.Equal(t, expected, result.TransferRecord.Details)
+	assert.Equal(t, expected, result.FromEntryRecord.Details)
+	assert.Equal(t, expected, result.ToEntryRecord.Details)
+	assert.Equal(t, int64(60), result.FromAccount.Balance)
+	assert.Equal(t, int64(40), result.ToAccount.Balance)
+
+	// the reference is taken for this sender
+	recorder = postTransfer(t, server, gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD, "external_reference": "order-42"})
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// and finds the transfer, as does its memo
+	for _, query := range []url.Values{
+		{"page_size": {"10"}, "reference": {"order-42"}},
+		{"page_size": {"10"}, "memo": {"luigi"}},
+	} {
+		code, response := searchTransactionsPage(t, server, to.ID, query)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, result.TransferRecord.ID, response.Data[0].ID)
+		assert.Equal(t, "Dinner at Luigi's", response.Data[0].Description)
+	}
+
+	code, response := searchTransactionsPage(t, server, to.ID, url.Values{"page_size": {"10"}, "memo": {"pizza"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, response.Data)
+}
+
+func TestCreateTransferRejected(t *testing.T) {
+	store := memdb.NewStore()
+	server := NewServer(store, testConfig(), testLogger())
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+	rupees, err := store.CreateAccount(ctx, utils.RandomOwner(), 100, currency.INR)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		body     gin.H
+		expected int
+	}{
+		{"NoAmount", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "currency": currency.USD}, http.StatusBadRequest},
+		{"SameAccount", gin.H{"from_account_id": from.ID, "to_account_id": from.ID, "amount": 10, "currency": currency.USD}, http.StatusBadRequest},
+		{"LongDescription", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD, "description": strings.Repeat("x", db.MaxDescriptionLength+1)}, http.StatusBadRequest},
+		{"BadReference", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD, "external_reference": "order #1"}, http.StatusBadRequest},
+		{"NestedMetadata", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 10, "currency": currency.USD, "metadata": gin.H{"a": gin.H{"b": "c"}}}, http.StatusBadRequest},
+		{"MissingAccount", gin.H{"from_account_id": from.ID, "to_account_id": to.ID + 1000, "amount": 10, "currency": currency.USD}, http.StatusNotFound},
+		{"OtherCurrency", gin.H{"from_account_id": from.ID, "to_account_id": rupees.ID, "amount": 10, "currency": currency.USD}, http.StatusBadRequest},
+		{"InsufficientFunds", gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": 101, "currency": currency.USD}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := postTransfer(t, server, tc.body)
+			assert.Equal(t, tc.expected, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	account, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), account.Balance)
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/webhook"
+)
+
+type createWebhookSubscriptionRequest struct {
+	Owner      string   `json:"owner"`
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+}
+
+// the secret is only ever returned on creation
+type createWebhookSubscriptionResponse struct {
+	db.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (server *Server) createWebhookSubscription(ctx *gin.Context) {
+	var request createWebhookSubscriptionRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	for _, eventType := range request.EventTypes {
+		if !webhook.IsSupportedEventType(eventType) {
+			abortWithProblem(ctx, http.StatusBadRequest, codeUnsupportedEventType, fmt.Sprintf("%s is an unsupported event type.", eventType))
+			return
+		}
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		secret, err = webhook.GenerateSecret()
+		if err != nil {
+			server.abortWithInternalError(ctx, err)
+			return
+		}
+	}
+
+	subscription, err := server.store.CreateWebhookSubscription(ctx, owner, request.URL, request.EventTypes, secret)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookSubscriptionResponse{WebhookSubscription: *subscription, Secret: subscription.Secret})
+}
+
+type listWebhookSubscriptionsRequest struct {
+	Owner string `form:"owner"`
+}
+
+func (server *Server) listWebhookSubscriptions(ctx *gin.Context) {
+	var request listWebhookSubscriptionsRequest
+
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	owner, ok := ownerFor(ctx, request.Owner)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := server.store.GetWebhookSubscriptionsByOwner(ctx, owner)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	data := []db.WebhookSubscription{}
+	if subscriptions != nil {
+		data = append(data, *subscriptions...)
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+type webhookSubscriptionURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the subscription if the caller may manage it, otherwise responds and returns false.
+// customers manage their own, to them the others' do not exist; subscriptions are an owner's, keys limited to some accounts manage none
+func (server *Server) managedSubscription(ctx *gin.Context, id int64) (*db.WebhookSubscription, bool) {
+	if !actsBeyondAccounts(ctx) {
+		return nil, false
+	}
+
+	subscription, err := server.store.GetWebhookSubscriptionByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		server.abortWithInternalError(ctx, err)
+		return nil, false
+	}
+
+	if caller := callerOf(ctx); err != nil || caller.Owner != "" && subscription.Owner != caller.Owner {
+		abortWithProblem(ctx, http.StatusNotFound, codeWebhookNotFound, fmt.Sprintf("Webhook subscription with id %d not found.", id))
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+func (server *Server) deleteWebhookSubscription(ctx *gin.Context) {
+	var request webhookSubscriptionURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if _, ok := server.managedSubscription(ctx, request.ID); !ok {
+		return
+	}
+
+	rowsAffected, err := server.store.DeleteWebhookSubscriptionByID(ctx, request.ID)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	if rowsAffected != 1 {
+		abortWithProblem(ctx, http.StatusNotFound, codeWebhookNotFound, fmt.Sprintf("Webhook subscription with id %d not found.", request.ID))
+	} else {
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var requestURI webhookSubscriptionURI
+	var requestQuery pageQuery
+
+	if err := ctx.ShouldBindUri(&requestURI); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&requestQuery); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if _, ok := server.managedSubscription(ctx, requestURI.ID); !ok {
+		return
+	}
+
+	listPage(server, ctx, fmt.Sprintf("webhook_deliveries:%d", requestURI.ID), requestQuery, func(delivery db.WebhookDelivery) db.PageKey {
+		return db.PageKey{CreatedAt: delivery.CreatedAt, ID: delivery.ID}
+	}, func(page db.Page) (*[]db.WebhookDelivery, error) {
+		return server.store.ListWebhookDeliveries(ctx, requestURI.ID, page)
+	})
+}
+
+type webhookDeliveryURI struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+func (server *Server) getWebhookDelivery(ctx *gin.Context) {
+	var request webhookDeliveryURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if _, ok := server.managedSubscription(ctx, request.ID); !ok {
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDeliveryByID(ctx, request.DeliveryID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	if err != nil || delivery.SubscriptionID != request.ID {
+		abortWithProblem(ctx, http.StatusNotFound, codeDeliveryNotFound, fmt.Sprintf("Delivery with id %d not found.", request.DeliveryID))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+func (server *Server) replayWebhookDelivery(ctx *gin.Context) {
+	var request webhookDeliveryURI
+
+	if err := ctx.ShouldBindUri(&request); err != nil {
+		abortWithBindError(ctx, err)
+		return
+	}
+
+	if _, ok := server.managedSubscription(ctx, request.ID); !ok {
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDeliveryByID(ctx, request.DeliveryID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	if err != nil || delivery.SubscriptionID != request.ID {
+		abortWithProblem(ctx, http.StatusNotFound, codeDeliveryNotFound, fmt.Sprintf("Delivery with id %d not found.", request.DeliveryID))
+		return
+	}
+
+	delivery, err = server.store.ReplayWebhookDelivery(ctx, request.DeliveryID)
+	if err != nil {
+		server.abortWithInternalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
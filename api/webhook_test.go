@@ -0,0 +1,336 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/joelpatel/go-bank/webhook"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func randomWebhookSubscription() *db.WebhookSubscription {
+	return &db.WebhookSubscription{
+		ID:         utils.RandomInt(1, 1000),
+		Owner:      utils.RandomOwner(),
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: db.StringArray{webhook.EventAccountCredited},
+		Secret:     utils.RandomString(32),
+	}
+}
+
+func randomWebhookDelivery(subscriptionID int64) *db.WebhookDelivery {
+	return &db.WebhookDelivery{
+		ID:             utils.RandomInt(1, 1000),
+		SubscriptionID: subscriptionID,
+		EventID:        utils.RandomInt(1, 1000),
+		EventType:      webhook.EventAccountCredited,
+		Payload:        json.RawMessage(`{"amount":10}`),
+		Status:         db.WebhookDeliveryDead,
+		Attempts:       8,
+	}
+}
+
+func expectWebhookSubscription(store *mockdb.MockStore, subscription *db.WebhookSubscription) {
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+}
+
+// When a valid subscription is requested without a secret, the server should generate one and return it once.
+func TestCreateWebhookSubscriptionOK(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+
+	store.EXPECT().
+		CreateWebhookSubscription(gomock.Any(), gomock.Eq(subscription.Owner), gomock.Eq(subscription.URL), gomock.Eq([]string{webhook.EventAccountCredited}), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, owner, url string, eventTypes []string, secret string) (*db.WebhookSubscription, error) {
+			assert.True(t, strings.HasPrefix(secret, "whsec_"))
+			subscription.Secret = secret
+			return subscription, nil
+		})
+
+	body := gin.H{"url": subscription.URL, "event_types": []string{webhook.EventAccountCredited}}
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		ID     int64  `json:"id"`
+		Secret string `json:"secret"`
+	}
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, subscription.ID, response.ID)
+	assert.Equal(t, subscription.Secret, response.Secret)
+}
+
+// A customer should not be able to subscribe to the events of another owner.
+func TestCreateWebhookSubscriptionForAnotherOwner(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	store.EXPECT().
+		CreateWebhookSubscription(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	body := gin.H{"owner": "victim", "url": "https://partner.example.com/hooks", "event_types": []string{webhook.EventAccountCredited}}
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, "intruder")
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+// When an event type can't be subscribed to, the server should respond with status bad request.
+func TestCreateWebhookSubscriptionUnsupportedEvent(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	body := gin.H{"owner": utils.RandomOwner(), "url": "https://partner.example.com/hooks", "event_types": []string{"AccountDeleted"}}
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var response problem
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, codeUnsupportedEventType, response.Code)
+	assert.Equal(t, "AccountDeleted is an unsupported event type.", response.Detail)
+}
+
+// When the url is not valid, the server should respond with status bad request.
+func TestCreateWebhookSubscriptionBadURL(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	body := gin.H{"owner": utils.RandomOwner(), "url": "not a url", "event_types": []string{webhook.EventAccountCredited}}
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// Listing subscriptions should never expose their secrets.
+func TestListWebhookSubscriptionsHidesSecret(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+
+	store.EXPECT().
+		GetWebhookSubscriptionsByOwner(gomock.Any(), gomock.Eq(subscription.Owner)).
+		Times(1).
+		Return(&[]db.WebhookSubscription{*subscription}, nil)
+
+	request, err := http.NewRequest(http.MethodGet, "/webhooks", nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), subscription.Secret)
+}
+
+// Deleting an unknown subscription should respond with status not found.
+func TestDeleteWebhookSubscriptionNotFound(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(int64(7))).
+		Times(1).
+		Return(nil, sql.ErrNoRows)
+	store.EXPECT().
+		DeleteWebhookSubscriptionByID(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request, err := http.NewRequest(http.MethodDelete, "/webhooks/7", nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, utils.RandomOwner())
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// The subscriptions of other owners should not exist to a customer.
+func TestDeleteWebhookSubscriptionOfAnotherOwner(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+
+	expectWebhookSubscription(store, subscription)
+	store.EXPECT().
+		DeleteWebhookSubscriptionByID(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/webhooks/%d", subscription.ID), nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner+"x")
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// The delivery log should be paginated like every other listing.
+func TestListWebhookDeliveriesOK(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+	deliveries := []db.WebhookDelivery{*randomWebhookDelivery(subscription.ID)}
+
+	expectWebhookSubscription(store, subscription)
+	store.EXPECT().
+		ListWebhookDeliveries(gomock.Any(), gomock.Eq(subscription.ID), gomock.Eq(db.Page{Limit: 11, Order: db.SortDesc})).
+		Times(1).
+		Return(&deliveries, nil)
+
+	url := fmt.Sprintf("/webhooks/%d/deliveries?order=desc&page_size=10", subscription.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response pageResponse[db.WebhookDelivery]
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, deliveries, response.Data)
+	assert.False(t, response.HasMore)
+}
+
+// Empty lists should be empty arrays, not null.
+func TestListWebhooksEmpty(t *testing.T) {
+	store, server, _ := beforeEach(t)
+	subscription := randomWebhookSubscription()
+
+	store.EXPECT().
+		GetWebhookSubscriptionsByOwner(gomock.Any(), gomock.Eq(subscription.Owner)).
+		Times(1).
+		Return(new([]db.WebhookSubscription), nil)
+	expectWebhookSubscription(store, subscription)
+	store.EXPECT().
+		ListWebhookDeliveries(gomock.Any(), gomock.Eq(subscription.ID), gomock.Any()).
+		Times(1).
+		Return(new([]db.WebhookDelivery), nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/webhooks", nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[]`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, fmt.Sprintf("/webhooks/%d/deliveries?page_size=10", subscription.ID), nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data": [], "has_more": false}`, recorder.Body.String())
+}
+
+// Replaying a dead lettered delivery should reset it and respond with status accepted.
+func TestReplayWebhookDeliveryOK(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+	delivery := randomWebhookDelivery(subscription.ID)
+	replayed := *delivery
+	replayed.Status = db.WebhookDeliveryPending
+	replayed.Attempts = 0
+
+	expectWebhookSubscription(store, subscription)
+	store.EXPECT().
+		GetWebhookDeliveryByID(gomock.Any(), gomock.Eq(delivery.ID)).
+		Times(1).
+		Return(delivery, nil)
+	store.EXPECT().
+		ReplayWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+		Times(1).
+		Return(&replayed, nil)
+
+	url := fmt.Sprintf("/webhooks/%d/deliveries/%d/replay", delivery.SubscriptionID, delivery.ID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var response db.WebhookDelivery
+	responseBody, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	err = json.Unmarshal(responseBody, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, db.WebhookDeliveryPending, response.Status)
+}
+
+// A delivery of another subscription should not be replayable through this one.
+func TestReplayWebhookDeliveryOtherSubscription(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+	delivery := randomWebhookDelivery(subscription.ID + 1)
+
+	expectWebhookSubscription(store, subscription)
+	store.EXPECT().
+		GetWebhookDeliveryByID(gomock.Any(), gomock.Eq(delivery.ID)).
+		Times(1).
+		Return(delivery, nil)
+
+	url := fmt.Sprintf("/webhooks/%d/deliveries/%d/replay", subscription.ID, delivery.ID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// Replaying an unknown delivery should respond with status not found.
+func TestReplayWebhookDeliveryNotFound(t *testing.T) {
+	store, server, recorder := beforeEach(t)
+	subscription := randomWebhookSubscription()
+
+	expectWebhookSubscription(store, subscription)
+	store.EXPECT().
+		GetWebhookDeliveryByID(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrNoRows)
+
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%d/deliveries/1/replay", subscription.ID), nil)
+	assert.NoError(t, err)
+	expectCustomer(t, store, request, subscription.Owner)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
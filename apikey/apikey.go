@@ -0,0 +1,147 @@
+// Package apikey makes and checks the credentials services use to call the API without a user.
+//
+// A key looks like gbk_<prefix>_<secret>. The prefix identifies the key and may be shown and
+// logged, only the SHA-256 hash of the whole key is stored. Requests may additionally be signed
+// with the key's signing secret, see Sign.
+package apikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers of requests made with a key
+const (
+	KeyHeader       = "X-API-Key"
+	SignatureHeader = "X-Bank-Signature"
+)
+
+var (
+	ErrMalformedKey     = errors.New("malformed API key")
+	ErrInvalidSignature = errors.New("invalid request signature")
+)
+
+const keyPrefix = "gbk_"
+
+// Generate returns a new key, its prefix and the hash to store in place of it.
+func Generate() (key, prefix, hash string, err error) {
+	prefix, err = randomHex(6)
+	if err != nil {
+		return "", "", "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = keyPrefix + prefix + "_" + secret
+	return key, prefix, Hash(key), nil
+}
+
+// Parse returns the prefix of key, to look up the stored hash by.
+func Parse(key string) (prefix string, err error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, keyPrefix), "_")
+	if !strings.HasPrefix(key, keyPrefix) || !ok || len(prefix) != 12 || len(secret) != 64 {
+		return "", ErrMalformedKey
+	}
+	return prefix, nil
+}
+
+// Hash returns the hex SHA-256 of key; keys are random enough that they need no salt.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether key hashes to hash, in constant time.
+func Matches(key, hash string) bool {
+	return hmac.Equal([]byte(Hash(key)), []byte(hash))
+}
+
+// GenerateSigningSecret returns a random secret for signing requests.
+func GenerateSigningSecret() (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	return "gbs_" + secret, nil
+}
+
+// Sign returns the signature header value of a request sent at timestamp with a nonce never used before:
+// "t=<unix seconds>,n=<nonce>,v1=<hex HMAC-SHA256 of "<unix seconds>.<nonce>.<method>.<path and query>.<body>">".
+func Sign(secret string, timestamp time.Time, nonce, method, path string, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,n=%s,v1=%s", unix, nonce, computeMAC(secret, unix, nonce, method, path, body))
+}
+
+// Signature is a parsed signature header.
+type Signature struct {
+	Timestamp time.Time
+	Nonce     string
+	mac       string
+}
+
+// ParseSignature splits a signature header produced by Sign.
+func ParseSignature(header string) (*Signature, error) {
+	var signature Signature
+	var seconds string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			seconds = value
+		case "n":
+			signature.Nonce = value
+		case "v1":
+			signature.mac = value
+		}
+	}
+
+	parsed, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || signature.Nonce == "" || len(signature.Nonce) > 64 || signature.mac == "" {
+		return nil, ErrInvalidSignature
+	}
+	signature.Timestamp = time.Unix(parsed, 0)
+
+	return &signature, nil
+}
+
+// Verify checks the signature of a request and rejects it when its timestamp is further than tolerance from now.
+// A nonce has to be refused once seen, until its timestamp is out of tolerance.
+func (signature *Signature) Verify(secret, method, path string, body []byte, tolerance time.Duration, now time.Time) error {
+	if age := now.Sub(signature.Timestamp); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+
+	unix := strconv.FormatInt(signature.Timestamp.Unix(), 10)
+	if !hmac.Equal([]byte(signature.mac), []byte(computeMAC(secret, unix, signature.Nonce, method, path, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func computeMAC(secret, unix, nonce, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range []string{unix, nonce, method, path} {
+		mac.Write([]byte(part))
+		mac.Write([]byte("."))
+	}
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(size int) (string, error) {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buffer), nil
+}
@@ -0,0 +1,56 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateParse(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "gbk_"+prefix+"_"))
+	assert.NotContains(t, hash, prefix)
+
+	parsed, err := Parse(key)
+	require.NoError(t, err)
+	assert.Equal(t, prefix, parsed)
+	assert.True(t, Matches(key, hash))
+	assert.False(t, Matches(key+"0", hash))
+
+	other, _, _, err := Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	for _, malformed := range []string{"", "gbk_", "gbk_abc_def", strings.TrimPrefix(key, "gbk_"), key + "0"} {
+		_, err := Parse(malformed)
+		assert.ErrorIs(t, err, ErrMalformedKey, malformed)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"amount":10}`)
+	header := Sign("secret", now, "n1", "POST", "/transfers", body)
+
+	signature, err := ParseSignature(header)
+	require.NoError(t, err)
+	assert.Equal(t, "n1", signature.Nonce)
+	assert.Equal(t, now, signature.Timestamp)
+
+	assert.NoError(t, signature.Verify("secret", "POST", "/transfers", body, time.Minute, now))
+	assert.ErrorIs(t, signature.Verify("other", "POST", "/transfers", body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signature.Verify("secret", "PUT", "/transfers", body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signature.Verify("secret", "POST", "/transfers?x=1", body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signature.Verify("secret", "POST", "/transfers", []byte(`{}`), time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signature.Verify("secret", "POST", "/transfers", body, time.Minute, now.Add(2*time.Minute)), ErrInvalidSignature)
+	assert.ErrorIs(t, signature.Verify("secret", "POST", "/transfers", body, time.Minute, now.Add(-2*time.Minute)), ErrInvalidSignature)
+
+	for _, malformed := range []string{"garbage", "t=1,v1=ab", "t=x,n=1,v1=ab", "t=1,n=1"} {
+		_, err := ParseSignature(malformed)
+		assert.ErrorIs(t, err, ErrInvalidSignature, malformed)
+	}
+}
@@ -0,0 +1,65 @@
+// Package approval carries out maker-checker decisions on held transfers.
+//
+// A transfer request is submitted by whoever made the transfer, the account owner or
+// staff acting for them, and decided by someone else. Approved requests are made with TransferMoney like any other transfer,
+// so limits, balances and closed business days still apply when the transfer happens.
+// Every step is kept in the request's audit trail.
+package approval
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// Approve approves a pending request as reviewer and makes its transfer.
+// A transfer that cannot be made leaves the request failed, with the reason,
+// rather than returning an error; errors are about the decision itself.
+// The decision, the transfer and its outcome are stored together, a crash never leaves
+// a request approved without one.
+func Approve(ctx context.Context, store db.Store, id int64, reviewer, note string) (*db.TransferRequest, error) {
+	return store.ApproveTransferRequest(ctx, id, reviewer, note)
+}
+
+// Reject rejects a pending request as reviewer.
+func Reject(ctx context.Context, store db.Store, id int64, reviewer, note string) (*db.TransferRequest, error) {
+	return store.DecideTransferRequest(ctx, id, reviewer, db.TransferRequestRejected, note)
+}
+
+const defaultInterval = time.Minute
+
+// Expirer expires pending requests nobody decided in time.
+// Deciding a stale request expires it as well, the expirer keeps the queue and its trail current.
+type Expirer struct {
+	store    db.Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewExpirer creates an expirer checking every minute, logging to logger.
+func NewExpirer(store db.Store, logger *slog.Logger) *Expirer {
+	return &Expirer{store: store, interval: defaultInterval, logger: logger}
+}
+
+// Run expires stale requests every interval until ctx is done.
+func (expirer *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(expirer.interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := expirer.store.ExpireTransferRequests(ctx)
+		if err != nil {
+			expirer.logger.Error("expiring transfer requests", "error", err.Error())
+		} else if expired > 0 {
+			expirer.logger.Info("expired transfer requests", "count", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
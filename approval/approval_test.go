@@ -0,0 +1,104 @@
+package approval
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func submit(t *testing.T, store db.Store, balance, amount int64, expiresIn time.Duration) (*db.Account, *db.Account, *db.TransferRequest) {
+	ctx := context.Background()
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), balance, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+
+	request, err := store.CreateTransferRequest(ctx, db.TransferRequest{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Initiator:     from.Owner,
+		HoldReason:    "amount above the approval threshold",
+		ExpiresAt:     time.Now().Add(expiresIn),
+	})
+	require.NoError(t, err)
+	return from, to, request
+}
+
+// An approved request should be transferred and point to its transfer.
+func TestApprove(t *testing.T) {
+	store := memdb.NewStore()
+	ctx := context.Background()
+	from, to, request := submit(t, store, 1000, 600, time.Hour)
+
+	_, err := Approve(ctx, store, request.ID, from.Owner, "")
+	require.ErrorIs(t, err, db.ErrSelfReview)
+
+	approved, err := Approve(ctx, store, request.ID, utils.RandomOwner(), "ok")
+	require.NoError(t, err)
+	assert.Equal(t, db.TransferRequestExecuted, approved.Status)
+	require.NotNil(t, approved.TransferID)
+
+	transfer, err := store.GetTransferByID(ctx, *approved.TransferID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), transfer.Amount)
+
+	account, err := store.GetAccountByID(ctx, to.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), account.Balance)
+
+	_, err = Approve(ctx, store, request.ID, utils.RandomOwner(), "")
+	require.ErrorIs(t, err, db.ErrRequestNotPending)
+}
+
+// A transfer that cannot be made at approval time should fail the request, not the approval.
+func TestApproveFailedTransfer(t *testing.T) {
+	store := memdb.NewStore()
+	ctx := context.Background()
+	from, _, request := submit(t, store, 1000, 600, time.Hour)
+
+	// the money left in the meantime
+	_, err := store.AddAccountBalance(ctx, from.ID, -900)
+	require.NoError(t, err)
+
+	failed, err := Approve(ctx, store, request.ID, utils.RandomOwner(), "")
+	require.NoError(t, err)
+	assert.Equal(t, db.TransferRequestFailed, failed.Status)
+	assert.NotEmpty(t, failed.Failure)
+	assert.Nil(t, failed.TransferID)
+}
+
+func TestReject(t *testing.T) {
+	store := memdb.NewStore()
+	_, _, request := submit(t, store, 1000, 600, time.Hour)
+
+	rejected, err := Reject(context.Background(), store, request.ID, utils.RandomOwner(), "no")
+	require.NoError(t, err)
+	assert.Equal(t, db.TransferRequestRejected, rejected.Status)
+}
+
+func TestExpirerRun(t *testing.T) {
+	store := memdb.NewStore()
+	_, _, stale := submit(t, store, 1000, 600, -time.Second)
+	_, _, fresh := submit(t, store, 1000, 600, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewExpirer(store, slog.Default()).Run(ctx)
+
+	request, err := store.GetTransferRequestByID(context.Background(), stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.TransferRequestExpired, request.Status)
+
+	request, err = store.GetTransferRequestByID(context.Background(), fresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.TransferRequestPending, request.Status)
+}
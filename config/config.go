@@ -0,0 +1,346 @@
+// Package config loads the service configuration from defaults, a YAML or TOML file,
+// environment variables and command line flags, in increasing order of precedence.
+//
+// Every setting is a field of a section struct tagged with:
+//
+//	config:"key"     name in the file (section.key), in the env (SECTION_KEY) and as flag (-section.key)
+//	env:"NAME"       overrides the derived env variable name
+//	default:"value"  value used when no source sets it
+//	validate:"required"
+//	usage:"text"     flag help
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config is the configuration shared by the server, the CLI and the tests.
+type Config struct {
+	Server    ServerConfig    `config:"server"`
+	Database  DatabaseConfig  `config:"database"`
+	Business  BusinessConfig  `config:"business"`
+	Outbox    OutboxConfig    `config:"outbox"`
+	Transfers TransferConfig  `config:"transfers"`
+	Risk      RiskConfig      `config:"risk"`
+	Auth      AuthConfig      `config:"auth"`
+	RateLimit RateLimitConfig `config:"rate_limit"`
+	Tracing   TracingConfig   `config:"tracing"`
+	Log       LogConfig       `config:"log"`
+}
+
+// ServerConfig configures the listening HTTP server.
+type ServerConfig struct {
+	Address           string        `config:"address" default:"0.0.0.0:8080" validate:"required" usage:"address to listen on"`
+	ReadTimeout       time.Duration `config:"read_timeout" default:"10s" usage:"maximum duration for reading a request"`
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" default:"5s" usage:"maximum duration for reading request headers"`
+	WriteTimeout      time.Duration `config:"write_timeout" default:"15s" usage:"maximum duration for writing a response, not applied to /stream"`
+	IdleTimeout       time.Duration `config:"idle_timeout" default:"60s" usage:"maximum duration a keep-alive connection stays idle"`
+	ShutdownTimeout   time.Duration `config:"shutdown_timeout" default:"30s" usage:"maximum duration for draining connections on shutdown"`
+	CursorSecret      string        `config:"cursor_secret" usage:"key signing pagination cursors, share it between replicas; random per process when empty"`
+	TrustedProxies    string        `config:"trusted_proxies" usage:"comma-separated IPs or CIDRs of proxies whose X-Forwarded-For names the client; none when empty"`
+}
+
+// DatabaseConfig configures the Postgres connection and its pool.
+type DatabaseConfig struct {
+	Host                   string        `config:"host" validate:"required" usage:"database host"`
+	Port                   int           `config:"port" default:"5432" usage:"database port"`
+	User                   string        `config:"user" validate:"required" usage:"database user"`
+	Password               string        `config:"password" env:"DATABASE_PASS" usage:"database password"`
+	Name                   string        `config:"name" validate:"required" usage:"database name"`
+	SSLMode                string        `config:"sslmode" default:"disable" usage:"disable, allow, prefer, require, verify-ca or verify-full"`
+	ApplicationName        string        `config:"application_name" default:"go-bank" usage:"application_name reported to Postgres"`
+	ConnectTimeout         time.Duration `config:"connect_timeout" default:"5s" usage:"timeout for establishing a connection"`
+	StatementTimeout       time.Duration `config:"statement_timeout" default:"30s" usage:"maximum duration of a single query, enforced by Postgres; 0 for none"`
+	StatementCacheCapacity int           `config:"statement_cache_capacity" default:"512" usage:"prepared statements cached per connection"`
+	AutoMigrate            bool          `config:"auto_migrate" usage:"apply pending migrations on startup"`
+
+	Backend         string        `config:"backend" default:"sql" usage:"connection pool: sql (database/sql) or pgxpool"`
+	MaxConns        int           `config:"max_conns" default:"10" usage:"maximum number of open connections"`
+	MaxIdleConns    int           `config:"max_idle_conns" default:"10" usage:"maximum number of idle connections kept by the sql backend"`
+	MinConns        int           `config:"min_conns" default:"0" usage:"minimum number of connections kept open by the pgxpool backend"`
+	MaxConnLifetime time.Duration `config:"max_conn_lifetime" default:"1h" usage:"maximum duration a connection is reused"`
+	MaxConnIdleTime time.Duration `config:"max_conn_idle_time" default:"30m" usage:"maximum duration a connection stays idle"`
+}
+
+// connection pool backends
+const (
+	BackendSQL     = "sql"
+	BackendPgxPool = "pgxpool"
+)
+
+// BusinessConfig configures business day handling.
+type BusinessConfig struct {
+	Timezone string `config:"timezone" default:"UTC" usage:"IANA time zone business days are closed in"`
+}
+
+// OutboxConfig configures where outbox events are published besides webhooks.
+type OutboxConfig struct {
+	Publisher string `config:"publisher" usage:"additional publisher: stdout or file"`
+	File      string `config:"file" usage:"file the file publisher appends to"`
+}
+
+// TransferConfig configures what transfers are allowed.
+type TransferConfig struct {
+	PayeeCoolingOff   time.Duration `config:"payee_cooling_off" default:"24h" usage:"how long a new payee only receives amounts below payee_large_amount"`
+	PayeeLargeAmount  int64         `config:"payee_large_amount" default:"100000" usage:"amount in cents from which a transfer to a payee in its cooling-off period is refused"`
+	ApprovalThreshold int64         `config:"approval_threshold" default:"1000000" usage:"amount in cents above which a transfer waits for a second user's approval; 0 to hold only transfers flagged by risk screening"`
+	ApprovalTTL       time.Duration `config:"approval_ttl" default:"48h" usage:"how long a transfer waits for approval before it expires"`
+}
+
+// RiskConfig configures the screening of transfers, see package risk.
+type RiskConfig struct {
+	RulesFile      string        `config:"rules_file" usage:"YAML or JSON file of risk rules; every transfer is allowed when empty"`
+	ReloadInterval time.Duration `config:"reload_interval" default:"10s" usage:"how often the rules file is checked for changes; 0 to load it only on startup"`
+}
+
+// AuthConfig configures how API keys and user tokens are checked.
+type AuthConfig struct {
+	TokenSecret        string        `config:"token_secret" usage:"key user tokens are signed with, shared with whoever signs users in; users cannot authenticate when empty"`
+	TokenTTL           time.Duration `config:"token_ttl" default:"1h" usage:"how long tokens made with go-bank token are valid"`
+	SignatureTolerance time.Duration `config:"signature_tolerance" default:"5m" usage:"how far the timestamp of a signed request may be from the server's clock"`
+}
+
+// RateLimitConfig configures the request budget of each caller, see package ratelimit.
+// Every client IP has a budget, spent before credentials are checked so invalid ones are limited too;
+// API keys and users have one per route group, spent once they are verified.
+type RateLimitConfig struct {
+	Backend string `config:"backend" default:"memory" usage:"where buckets are kept: memory (per replica), postgres (shared by replicas) or off"`
+
+	IPPerMinute int `config:"ip_per_minute" default:"1200" usage:"requests a minute from one client IP, whoever makes them"`
+	IPBurst     int `config:"ip_burst" default:"200" usage:"requests at once from one client IP, whoever makes them"`
+
+	DefaultPerMinute       int `config:"default_per_minute" default:"600" usage:"requests a minute to routes outside the groups below"`
+	DefaultBurst           int `config:"default_burst" default:"100" usage:"requests at once to routes outside the groups below"`
+	AccountsReadPerMinute  int `config:"accounts_read_per_minute" default:"120" usage:"requests a minute reading accounts"`
+	AccountsReadBurst      int `config:"accounts_read_burst" default:"30" usage:"requests at once reading accounts"`
+	AccountsWritePerMinute int `config:"accounts_write_per_minute" default:"30" usage:"requests a minute creating, changing or deleting accounts"`
+	AccountsWriteBurst     int `config:"accounts_write_burst" default:"10" usage:"requests at once creating, changing or deleting accounts"`
+	TransfersPerMinute     int `config:"transfers_per_minute" default:"60" usage:"requests a minute creating transfers"`
+	TransfersBurst         int `config:"transfers_burst" default:"20" usage:"requests at once creating transfers"`
+}
+
+// rate limit backends
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+	RateLimitOff      = "off"
+)
+
+// TracingConfig configures where OpenTelemetry spans go, see package tracing.
+type TracingConfig struct {
+	Exporter    string `config:"exporter" default:"none" usage:"where spans are exported: otlp, stdout or none"`
+	Endpoint    string `config:"endpoint" usage:"host:port of the OTLP/HTTP collector; OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318 when empty"`
+	Insecure    bool   `config:"insecure" usage:"send spans to the OTLP collector over plain HTTP"`
+	ServiceName string `config:"service_name" default:"go-bank" usage:"service.name spans are reported under"`
+}
+
+// tracing exporters
+const (
+	TracingOTLP   = "otlp"
+	TracingStdout = "stdout"
+	TracingNone   = "none"
+)
+
+// LogConfig configures the structured logger, see package logging.
+type LogConfig struct {
+	Level  string `config:"level" default:"info" usage:"lowest level logged: debug, info, warn or error; admins can change it at runtime"`
+	Format string `config:"format" default:"json" usage:"json or text"`
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Location returns the time zone business days are closed in.
+func (business BusinessConfig) Location() (*time.Location, error) {
+	return time.LoadLocation(business.Timezone)
+}
+
+// Proxies returns the trusted proxies, none when unset.
+func (server ServerConfig) Proxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(server.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// DSN returns the keyword/value connection string, pool settings included as pool_* parameters.
+func (database DatabaseConfig) DSN() string {
+	parameters := []struct {
+		key   string
+		value string
+	}{
+		{"host", database.Host},
+		{"port", fmt.Sprint(database.Port)},
+		{"user", database.User},
+		{"password", database.Password},
+		{"dbname", database.Name},
+		{"sslmode", database.SSLMode},
+		{"application_name", database.ApplicationName},
+		{"connect_timeout", fmt.Sprint(int(database.ConnectTimeout.Seconds()))},
+		{"statement_timeout", fmt.Sprint(database.StatementTimeout.Milliseconds())},
+		{"statement_cache_capacity", fmt.Sprint(database.StatementCacheCapacity)},
+		{"pool_max_conns", fmt.Sprint(database.MaxConns)},
+		{"pool_min_conns", fmt.Sprint(database.MinConns)},
+		{"pool_max_conn_lifetime", database.MaxConnLifetime.String()},
+		{"pool_max_conn_idle_time", database.MaxConnIdleTime.String()},
+	}
+
+	pairs := make([]string, 0, len(parameters))
+	for _, parameter := range parameters {
+		if parameter.value == "" {
+			continue
+		}
+		pairs = append(pairs, parameter.key+"="+quoteDSNValue(parameter.value))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// values with spaces, quotes or backslashes have to be single quoted and escaped
+func quoteDSNValue(value string) string {
+	if !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// Validate checks the semantic constraints the struct tags cannot express.
+// All problems are reported at once.
+func (config Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	errs = append(errs, validateRequired(&config)...)
+
+	for _, timeout := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"server.read_timeout", config.Server.ReadTimeout},
+		{"server.read_header_timeout", config.Server.ReadHeaderTimeout},
+		{"server.write_timeout", config.Server.WriteTimeout},
+		{"server.idle_timeout", config.Server.IdleTimeout},
+		{"server.shutdown_timeout", config.Server.ShutdownTimeout},
+		{"database.connect_timeout", config.Database.ConnectTimeout},
+		{"database.statement_timeout", config.Database.StatementTimeout},
+		{"database.max_conn_lifetime", config.Database.MaxConnLifetime},
+		{"database.max_conn_idle_time", config.Database.MaxConnIdleTime},
+		{"transfers.payee_cooling_off", config.Transfers.PayeeCoolingOff},
+		{"risk.reload_interval", config.Risk.ReloadInterval},
+	} {
+		if timeout.value < 0 {
+			fail("%s must not be negative", timeout.key)
+		}
+	}
+
+	for _, proxy := range config.Server.Proxies() {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			fail("server.trusted_proxies %q is neither an IP nor a CIDR", proxy)
+		}
+	}
+
+	if config.Database.Port < 1 || config.Database.Port > 65535 {
+		fail("database.port %d is out of range", config.Database.Port)
+	}
+	if !slices.Contains(sslModes, config.Database.SSLMode) {
+		fail("database.sslmode %q must be one of %s", config.Database.SSLMode, strings.Join(sslModes, ", "))
+	}
+	if config.Database.StatementCacheCapacity < 0 {
+		fail("database.statement_cache_capacity must not be negative")
+	}
+	if config.Database.Backend != BackendSQL && config.Database.Backend != BackendPgxPool {
+		fail("database.backend %q must be %s or %s", config.Database.Backend, BackendSQL, BackendPgxPool)
+	}
+	if config.Database.MaxConns < 1 {
+		fail("database.max_conns must be at least 1")
+	}
+	if config.Database.MaxIdleConns < 0 || config.Database.MaxIdleConns > config.Database.MaxConns {
+		fail("database.max_idle_conns must be between 0 and database.max_conns")
+	}
+	if config.Database.MinConns < 0 || config.Database.MinConns > config.Database.MaxConns {
+		fail("database.min_conns must be between 0 and database.max_conns")
+	}
+
+	if config.Transfers.PayeeLargeAmount < 1 {
+		fail("transfers.payee_large_amount must be at least 1")
+	}
+	if config.Transfers.ApprovalThreshold < 0 {
+		fail("transfers.approval_threshold must not be negative")
+	}
+	if config.Transfers.ApprovalTTL <= 0 {
+		fail("transfers.approval_ttl must be positive")
+	}
+	if config.Auth.TokenTTL <= 0 {
+		fail("auth.token_ttl must be positive")
+	}
+	if config.Auth.SignatureTolerance <= 0 {
+		fail("auth.signature_tolerance must be positive")
+	}
+
+	switch config.RateLimit.Backend {
+	case RateLimitMemory, RateLimitPostgres, RateLimitOff:
+	default:
+		fail("rate_limit.backend %q must be %s, %s or %s", config.RateLimit.Backend, RateLimitMemory, RateLimitPostgres, RateLimitOff)
+	}
+	for _, budget := range []struct {
+		key   string
+		value int
+	}{
+		{"rate_limit.ip_per_minute", config.RateLimit.IPPerMinute},
+		{"rate_limit.ip_burst", config.RateLimit.IPBurst},
+		{"rate_limit.default_per_minute", config.RateLimit.DefaultPerMinute},
+		{"rate_limit.default_burst", config.RateLimit.DefaultBurst},
+		{"rate_limit.accounts_read_per_minute", config.RateLimit.AccountsReadPerMinute},
+		{"rate_limit.accounts_read_burst", config.RateLimit.AccountsReadBurst},
+		{"rate_limit.accounts_write_per_minute", config.RateLimit.AccountsWritePerMinute},
+		{"rate_limit.accounts_write_burst", config.RateLimit.AccountsWriteBurst},
+		{"rate_limit.transfers_per_minute", config.RateLimit.TransfersPerMinute},
+		{"rate_limit.transfers_burst", config.RateLimit.TransfersBurst},
+	} {
+		if budget.value < 1 {
+			fail("%s must be at least 1", budget.key)
+		}
+	}
+
+	switch config.Tracing.Exporter {
+	case TracingOTLP, TracingStdout, TracingNone:
+	default:
+		fail("tracing.exporter %q must be %s, %s or %s", config.Tracing.Exporter, TracingOTLP, TracingStdout, TracingNone)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Log.Level)); err != nil {
+		fail("log.level %q must be debug, info, warn or error", config.Log.Level)
+	}
+	if config.Log.Format != "json" && config.Log.Format != "text" {
+		fail("log.format %q must be json or text", config.Log.Format)
+	}
+
+	if _, err := config.Business.Location(); err != nil {
+		fail("business.timezone: %s", err.Error())
+	}
+
+	switch config.Outbox.Publisher {
+	case "", "stdout":
+	case "file":
+		if config.Outbox.File == "" {
+			fail("outbox.file is required when outbox.publisher is file")
+		}
+	default:
+		fail("outbox.publisher %q must be stdout or file", config.Outbox.Publisher)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	assert.NoError(t, err)
+	return path
+}
+
+// minimal env so validation passes
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("DATABASE_HOST", "localhost")
+	t.Setenv("DATABASE_USER", "bank")
+	t.Setenv("DATABASE_NAME", "bank")
+}
+
+func TestDefault(t *testing.T) {
+	config := Default()
+
+	assert.Equal(t, "0.0.0.0:8080", config.Server.Address)
+	assert.Equal(t, 15*time.Second, config.Server.WriteTimeout)
+	assert.Equal(t, 30*time.Second, config.Server.ShutdownTimeout)
+	assert.Equal(t, 5432, config.Database.Port)
+	assert.Equal(t, "disable", config.Database.SSLMode)
+	assert.Equal(t, 10, config.Database.MaxConns)
+	assert.Equal(t, "UTC", config.Business.Timezone)
+}
+
+// Flags should win over env, env over the file and the file over defaults.
+func TestLoadPrecedence(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "server:\n  address: file:1\n  write_timeout: 20s\n  idle_timeout: 90s\ndatabase:\n  port: 6543\n",
+		"config.toml": "[server]\naddress = \"file:1\"\nwrite_timeout = \"20s\"\nidle_timeout = \"90s\"\n\n[database]\nport = 6543\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("CONFIG_FILE", writeFile(t, name, content))
+			t.Setenv("SERVER_ADDRESS", "env:2")
+			t.Setenv("SERVER_WRITE_TIMEOUT", "25s")
+
+			config, err := Load(Options{Args: []string{"-server.address", "flag:3"}})
+			assert.NoError(t, err)
+
+			assert.Equal(t, "flag:3", config.Server.Address)
+			assert.Equal(t, 25*time.Second, config.Server.WriteTimeout)
+			assert.Equal(t, 90*time.Second, config.Server.IdleTimeout)
+			assert.Equal(t, 6543, config.Database.Port)
+			assert.Equal(t, 10*time.Second, config.Server.ReadTimeout)
+		})
+	}
+}
+
+// The -config flag should take precedence over CONFIG_FILE.
+func TestLoadConfigFlag(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "env.yaml", "business:\n  timezone: Europe/Berlin\n"))
+	flagFile := writeFile(t, "flag.yaml", "business:\n  timezone: Asia/Kolkata\n")
+
+	config, err := Load(Options{Args: []string{"-config", flagFile}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Kolkata", config.Business.Timezone)
+}
+
+// Tests read their own database settings from TEST_ prefixed variables.
+func TestLoadEnvPrefix(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("TEST_DATABASE_HOST", "test-host")
+	t.Setenv("TEST_DATABASE_USER", "test-user")
+	t.Setenv("TEST_DATABASE_NAME", "test-name")
+	t.Setenv("TEST_DATABASE_PASS", "test-pass")
+
+	config, err := Load(Options{EnvPrefix: "TEST_"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test-host", config.Database.Host)
+	assert.Equal(t, "test-user", config.Database.User)
+	assert.Equal(t, "test-name", config.Database.Name)
+	assert.Equal(t, "test-pass", config.Database.Password)
+}
+
+// A .env file should fill in the environment without overriding it, and may be absent.
+func TestLoadDotEnv(t *testing.T) {
+	t.Setenv("DATABASE_HOST", "from-env")
+	// registered with t.Setenv so whatever the file sets is cleaned up afterwards
+	for _, name := range []string{"DATABASE_USER", "DATABASE_NAME"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	dotEnv := writeFile(t, ".env", "DATABASE_HOST=from-dotenv\nDATABASE_USER=bank\nDATABASE_NAME=bank\n")
+
+	config, err := Load(Options{DotEnv: dotEnv})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", config.Database.Host)
+	assert.Equal(t, "bank", config.Database.User)
+
+	_, err = Load(Options{DotEnv: filepath.Join(t.TempDir(), ".env")})
+	assert.NoError(t, err)
+}
+
+// Every problem should be reported at once.
+func TestLoadAggregatesErrors(t *testing.T) {
+	t.Setenv("DATABASE_PORT", "not-a-port")
+	t.Setenv("DATABASE_SSLMODE", "sometimes")
+	t.Setenv("BUSINESS_TIMEZONE", "Mars/Olympus_Mons")
+	t.Setenv("OUTBOX_PUBLISHER", "file")
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8, proxy.internal")
+
+	_, err := Load(Options{})
+	assert.Error(t, err)
+
+	for _, problem := range []string{
+		"env DATABASE_PORT",
+		"database.host is required",
+		"database.user is required",
+		"database.name is required",
+		"database.sslmode",
+		"business.timezone",
+		"outbox.file is required",
+		`server.trusted_proxies "proxy.internal"`,
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+// Typos in the file should not go unnoticed.
+func TestLoadUnknownSetting(t *testing.T) {
+	setRequiredEnv(t)
+	path := writeFile(t, "config.yaml", "server:\n  adress: localhost:1\n")
+
+	_, err := Load(Options{File: path})
+	assert.ErrorContains(t, err, "unknown setting server.adress")
+
+	_, err = Load(Options{File: writeFile(t, "config.json", "{}")})
+	assert.ErrorContains(t, err, "unsupported format")
+}
+
+// The DSN should carry the pool and session settings and survive special characters in the password.
+func TestDSN(t *testing.T) {
+	database := Default().Database
+	database.Host = "localhost"
+	database.User = "bank"
+	database.Password = `it's a \secret`
+	database.Name = "bank"
+	database.MaxConns = 25
+
+	poolConfig, err := pgxpool.ParseConfig(database.DSN())
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(25), poolConfig.MaxConns)
+	assert.Equal(t, time.Hour, poolConfig.MaxConnLifetime)
+	assert.Equal(t, 30*time.Minute, poolConfig.MaxConnIdleTime)
+	assert.Equal(t, database.Password, poolConfig.ConnConfig.Password)
+	assert.Equal(t, uint16(5432), poolConfig.ConnConfig.Port)
+	assert.Equal(t, 5*time.Second, poolConfig.ConnConfig.ConnectTimeout)
+	assert.Equal(t, "30000", poolConfig.ConnConfig.RuntimeParams["statement_timeout"])
+	assert.Equal(t, "go-bank", poolConfig.ConnConfig.RuntimeParams["application_name"])
+	assert.Equal(t, 512, poolConfig.ConnConfig.StatementCacheCapacity)
+}
+
+// Pool sizes should be consistent with each other.
+func TestValidatePool(t *testing.T) {
+	config := Default()
+	config.Database.Host, config.Database.User, config.Database.Name = "localhost", "bank", "bank"
+	assert.NoError(t, config.Validate())
+
+	config.Database.Backend = "bouncer"
+	config.Database.MaxIdleConns = config.Database.MaxConns + 1
+	config.Database.MinConns = -1
+	err := config.Validate()
+	assert.ErrorContains(t, err, "database.backend")
+	assert.ErrorContains(t, err, "database.max_idle_conns")
+	assert.ErrorContains(t, err, "database.min_conns")
+}
+
+// Rate limits need a known backend and a budget for every group.
+func TestValidateRateLimit(t *testing.T) {
+	config := Default()
+	config.Database.Host, config.Database.User, config.Database.Name = "localhost", "bank", "bank"
+	assert.Equal(t, RateLimitMemory, config.RateLimit.Backend)
+
+	config.RateLimit.Backend = "redis"
+	config.RateLimit.TransfersBurst = 0
+	err := config.Validate()
+	assert.ErrorContains(t, err, "rate_limit.backend")
+	assert.ErrorContains(t, err, "rate_limit.transfers_burst")
+}
+
+// Spans go to a known exporter, or nowhere by default.
+func TestValidateTracing(t *testing.T) {
+	config := Default()
+	config.Database.Host, config.Database.User, config.Database.Name = "localhost", "bank", "bank"
+	assert.Equal(t, TracingNone, config.Tracing.Exporter)
+
+	config.Tracing.Exporter = "jaeger"
+	assert.ErrorContains(t, config.Validate(), "tracing.exporter")
+}
+
+func TestValidateLog(t *testing.T) {
+	config := Default()
+	config.Database.Host, config.Database.User, config.Database.Name = "localhost", "bank", "bank"
+	config.Log.Level = "warn"
+	assert.NoError(t, config.Validate())
+
+	config.Log.Level, config.Log.Format = "loud", "xml"
+	err := config.Validate()
+	assert.ErrorContains(t, err, "log.level")
+	assert.ErrorContains(t, err, "log.format")
+}
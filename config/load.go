@@ -0,0 +1,284 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Options tells Load where to look for settings.
+type Options struct {
+	// Args are the command line arguments without the program name.
+	Args []string
+	// File is read when neither the -config flag nor the CONFIG_FILE env variable name one.
+	File string
+	// DotEnv is loaded into the environment first, without overriding it; a missing file is ignored.
+	DotEnv string
+	// EnvPrefix is prepended to every env variable name, e.g. "TEST_" for the test database.
+	EnvPrefix string
+}
+
+// setting is one configurable field and the names it is known by.
+type setting struct {
+	key      string
+	env      string
+	usage    string
+	fallback string
+	required bool
+	value    reflect.Value
+}
+
+// Load builds the configuration from defaults, file, env and flags (later ones win) and validates it.
+// Every problem found is reported in the returned error, not just the first one.
+func Load(options Options) (Config, error) {
+	var config Config
+	var errs []error
+
+	if options.DotEnv != "" {
+		if err := godotenv.Load(options.DotEnv); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return config, fmt.Errorf("%s: %w", options.DotEnv, err)
+		}
+	}
+
+	settings := settingsOf(&config)
+	if err := applyDefaults(settings); err != nil {
+		return config, err
+	}
+
+	// flags are parsed first to find the file, but applied last
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	file := flags.String("config", "", "YAML or TOML configuration file")
+	for _, s := range settings {
+		flags.String(s.key, s.fallback, s.usage)
+	}
+	if err := flags.Parse(options.Args); err != nil {
+		return config, err
+	}
+	if flags.NArg() > 0 {
+		errs = append(errs, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " ")))
+	}
+
+	path := options.File
+	if env, ok := os.LookupEnv(options.EnvPrefix + "CONFIG_FILE"); ok {
+		path = env
+	}
+	if *file != "" {
+		path = *file
+	}
+	if path != "" {
+		errs = append(errs, applyFile(settings, path)...)
+	}
+
+	for _, s := range settings {
+		name := options.EnvPrefix + s.env
+		if raw, ok := os.LookupEnv(name); ok {
+			if err := set(s.value, raw); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+			}
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.key == f.Name {
+				if err := set(s.value, f.Value.String()); err != nil {
+					errs = append(errs, fmt.Errorf("flag -%s: %w", f.Name, err))
+				}
+			}
+		}
+	})
+
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return config, errors.Join(errs...)
+}
+
+// Default returns the configuration with only the defaults applied.
+func Default() Config {
+	var config Config
+	if err := applyDefaults(settingsOf(&config)); err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// settingsOf lists the fields of every section of config.
+func settingsOf(config *Config) []setting {
+	var settings []setting
+
+	sections := reflect.ValueOf(config).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Type().Field(i).Tag.Get("config")
+		fields := sections.Field(i)
+
+		for j := 0; j < fields.NumField(); j++ {
+			field := fields.Type().Field(j)
+			key := section + "." + field.Tag.Get("config")
+
+			env := field.Tag.Get("env")
+			if env == "" {
+				env = strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+			}
+
+			settings = append(settings, setting{
+				key:      key,
+				env:      env,
+				usage:    field.Tag.Get("usage"),
+				fallback: field.Tag.Get("default"),
+				required: field.Tag.Get("validate") == "required",
+				value:    fields.Field(j),
+			})
+		}
+	}
+
+	return settings
+}
+
+func applyDefaults(settings []setting) error {
+	for _, s := range settings {
+		if s.fallback == "" {
+			continue
+		}
+		if err := set(s.value, s.fallback); err != nil {
+			return fmt.Errorf("default of %s: %w", s.key, err)
+		}
+	}
+	return nil
+}
+
+// applyFile sets what the file at path configures; the format follows the extension.
+func applyFile(settings []setting, path string) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		err = errors.New("unsupported format, use .yaml, .yml or .toml")
+	}
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	bySection := map[string]map[string]any{}
+	var errs []error
+	for _, section := range sortedKeys(values) {
+		fields, ok := values[section].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s is not a section", path, section))
+			continue
+		}
+		bySection[section] = fields
+	}
+
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[s.key] = true
+
+		section, field, _ := strings.Cut(s.key, ".")
+		raw, ok := bySection[section][field]
+		if !ok {
+			continue
+		}
+
+		switch raw.(type) {
+		case map[string]any, []any:
+			errs = append(errs, fmt.Errorf("%s: %s must be a single value", path, s.key))
+			continue
+		}
+
+		if err := set(s.value, fmt.Sprint(raw)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, s.key, err))
+		}
+	}
+
+	// typos would otherwise silently leave the default in place
+	for _, section := range sortedKeys(bySection) {
+		for _, field := range sortedKeys(bySection[section]) {
+			if key := section + "." + field; !known[key] {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %s", path, key))
+			}
+		}
+	}
+
+	return errs
+}
+
+// set parses raw into the field according to its type.
+func set(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		value.SetInt(int64(number))
+	case reflect.Int64:
+		number, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		value.SetInt(number)
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		value.SetBool(boolean)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+// validateRequired reports the settings tagged validate:"required" that are left empty.
+func validateRequired(config *Config) []error {
+	var errs []error
+	for _, s := range settingsOf(config) {
+		if s.required && s.value.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required", s.key))
+		}
+	}
+	return errs
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,17 +2,28 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
 )
 
+var ErrAccountFrozen = errors.New("account is frozen")
+
 // create
 func (s *Queries) CreateAccount(ctx context.Context, owner string, balance int64, currency string) (*Account, error) {
-	row := s.db.QueryRowContext(ctx, "INSERT INTO accounts (owner, balance, currency) VALUES ($1, $2, $3) RETURNING id, owner, balance, currency, created_at;", owner, balance, currency)
+	row := s.db.QueryRowContext(ctx, `WITH account AS (
+			INSERT INTO accounts (owner, balance, currency) VALUES ($1, $2, $3) RETURNING id, owner, balance, currency, frozen, created_at
+		), event AS (
+			INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $4, id, $5, `+accountEventPayload+` FROM account
+		)
+		SELECT id, owner, balance, currency, frozen, created_at FROM account;`, owner, balance, currency, AggregateAccount, EventAccountCreated)
 
 	var account Account
 
-	row.Scan(&account.ID, &account.Owner, &account.Balance, &account.Currency, &account.CreatedAt)
+	err := row.Scan(&account.ID, &account.Owner, &account.Balance, &account.Currency, &account.Frozen, &account.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
 
 	return &account, nil
 }
@@ -21,7 +32,7 @@ func (s *Queries) CreateAccount(ctx context.Context, owner string, balance int64
 func (s *Queries) GetAccountByID(ctx context.Context, id int64) (*Account, error) {
 	var account Account
 
-	err := s.db.GetContext(ctx, &account, "SELECT id, owner, balance, currency, created_at FROM accounts WHERE id = $1;", id)
+	err := s.db.GetContext(ctx, &account, "SELECT id, owner, balance, currency, frozen, created_at FROM accounts WHERE id = $1;", id)
 	if err != nil {
 		return nil, err
 	}
@@ -32,7 +43,7 @@ func (s *Queries) GetAccountByID(ctx context.Context, id int64) (*Account, error
 func (s *Queries) GetAccountByIDForUpdate(ctx context.Context, id int64) (*Account, error) {
 	var account Account
 
-	err := s.db.GetContext(ctx, &account, "SELECT id, owner, balance, currency, created_at FROM accounts WHERE id = $1 FOR NO KEY UPDATE;", id)
+	err := s.db.GetContext(ctx, &account, "SELECT id, owner, balance, currency, frozen, created_at FROM accounts WHERE id = $1 FOR NO KEY UPDATE;", id)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +55,7 @@ func (s *Queries) GetAccountByIDForUpdate(ctx context.Context, id int64) (*Accou
 func (s *Queries) GetAccountsByOwner(ctx context.Context, owner string) (*[]Account, error) {
 	var accounts []Account
 
-	err := s.db.SelectContext(ctx, &accounts, "SELECT id, owner, balance, currency, created_at FROM accounts WHERE owner = $1;", owner)
+	err := s.db.SelectContext(ctx, &accounts, "SELECT id, owner, balance, currency, frozen, created_at FROM accounts WHERE owner = $1;", owner)
 	if err != nil {
 		return nil, err
 	}
@@ -52,11 +63,12 @@ func (s *Queries) GetAccountsByOwner(ctx context.Context, owner string) (*[]Acco
 	return &accounts, nil
 }
 
-// read (owner) (pagination)
-func (s *Queries) ListAccounts(ctx context.Context, owner string, limit, offset int64) (*[]Account, error) {
+// read (owner) (keyset pagination)
+func (s *Queries) ListAccounts(ctx context.Context, owner string, page Page) (*[]Account, error) {
 	var accounts []Account
 
-	err := s.db.SelectContext(ctx, &accounts, "SELECT id, owner, balance, currency, created_at FROM accounts WHERE owner = $1 ORDER BY id LIMIT $2 OFFSET $3;", owner, limit, offset)
+	clause, args := page.clause(2)
+	err := s.db.SelectContext(ctx, &accounts, "SELECT id, owner, balance, currency, frozen, created_at FROM accounts WHERE owner = $1"+clause+";", append([]any{owner}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -66,28 +78,55 @@ func (s *Queries) ListAccounts(ctx context.Context, owner string, limit, offset
 
 // update (for adming use ONLY)
 func (s *Queries) UpdateAccount(ctx context.Context, account *Account) (int64, error) {
-	return s.db.MustExecContext(ctx, "UPDATE accounts SET owner = $1, balance = $2, currency = $3 WHERE id = $4;", account.Owner, account.Balance, account.Currency, account.ID).RowsAffected()
+	return rowsAffected(s.db.ExecContext(ctx, `WITH account AS (
+			UPDATE accounts SET owner = $1, balance = $2, currency = $3 WHERE id = $4 RETURNING id, owner, balance, currency, frozen, created_at
+		)
+		INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $5, id, $6, `+accountEventPayload+` FROM account;`, account.Owner, account.Balance, account.Currency, account.ID, AggregateAccount, EventAccountUpdated))
 }
 
 // update owner for accountID
 func (s *Queries) UpdateAccountOwner(ctx context.Context, accountID int64, newOwner string) (int64, error) {
-	return s.db.MustExecContext(ctx, "UPDATE accounts SET owner = $1 WHERE id = $2;", newOwner, accountID).RowsAffected()
+	return rowsAffected(s.db.ExecContext(ctx, `WITH account AS (
+			UPDATE accounts SET owner = $1 WHERE id = $2 RETURNING id, owner, balance, currency, frozen, created_at
+		)
+		INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $3, id, $4, `+accountEventPayload+` FROM account;`, newOwner, accountID, AggregateAccount, EventAccountOwnerChanged))
 }
 
 // update account balance
 func (s *Queries) UpdateAccountBalance(ctx context.Context, id int64, balance int64) (int64, error) {
-	return s.db.MustExecContext(ctx, "UPDATE accounts SET balance = $1 WHERE id = $2;", balance, id).RowsAffected()
+	return rowsAffected(s.db.ExecContext(ctx, `WITH account AS (
+			UPDATE accounts SET balance = $1 WHERE id = $2 RETURNING id, owner, balance, currency, frozen, created_at
+		)
+		INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $3, id, $4, `+accountEventPayload+` FROM account;`, balance, id, AggregateAccount, EventAccountBalanceChanged))
+}
+
+// freeze or unfreeze, frozen accounts neither send nor receive transfers
+func (s *Queries) SetAccountFrozen(ctx context.Context, id int64, frozen bool) (int64, error) {
+	eventType := EventAccountUnfrozen
+	if frozen {
+		eventType = EventAccountFrozen
+	}
+
+	return rowsAffected(s.db.ExecContext(ctx, `WITH account AS (
+			UPDATE accounts SET frozen = $1 WHERE id = $2 RETURNING id, owner, balance, currency, frozen, created_at
+		)
+		INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $3, id, $4, `+accountEventPayload+` FROM account;`, frozen, id, AggregateAccount, eventType))
 }
 
 // add to account's balance
 func (s *Queries) AddAccountBalance(ctx context.Context, id int64, amount int64) (*Account, error) {
-	row := s.db.QueryRowContext(ctx, "UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING id, owner, balance, currency, created_at;", amount, id)
+	row := s.db.QueryRowContext(ctx, `WITH account AS (
+			UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING id, owner, balance, currency, frozen, created_at
+		), event AS (
+			INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $3, id, $4, `+accountEventPayload+` FROM account
+		)
+		SELECT id, owner, balance, currency, frozen, created_at FROM account;`, amount, id, AggregateAccount, EventAccountBalanceChanged)
 
 	var account Account
 
-	err := row.Scan(&account.ID, &account.Owner, &account.Balance, &account.Currency, &account.CreatedAt)
+	err := row.Scan(&account.ID, &account.Owner, &account.Balance, &account.Currency, &account.Frozen, &account.CreatedAt)
 	if err != nil {
-		if strings.Contains(err.Error(), "balance_nonnegative") {
+		if violates(err, "balance_nonnegative") {
 			// NOTE: may want to get the account to return better formatted string (with actual balance)
 			return nil, fmt.Errorf("%d's balance is less than requested amount", id)
 		}
@@ -97,7 +136,19 @@ func (s *Queries) AddAccountBalance(ctx context.Context, id int64, amount int64)
 	return &account, nil
 }
 
+// rows affected by a statement that may fail on a constraint
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // delete
+// fails while entries or transfers still reference the account
 func (s *Queries) DeleteAccountByID(ctx context.Context, id int64) (int64, error) {
-	return s.db.MustExecContext(ctx, "DELETE FROM accounts WHERE id = $1;", id).RowsAffected()
+	return rowsAffected(s.db.ExecContext(ctx, `WITH account AS (
+			DELETE FROM accounts WHERE id = $1 RETURNING id
+		)
+		INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT $2, id, $3, jsonb_build_object('id', id) FROM account;`, id, AggregateAccount, EventAccountDeleted))
 }
@@ -17,7 +17,7 @@ func createRandomAccount(t *testing.T) *Account {
 	owner := utils.RandomOwner()
 	balance := utils.RandomMoney()
 
-	account, err := testStore.CreateAccount(context.Background(), owner, balance, currency.USD)
+	account, err := testStore(t).CreateAccount(context.Background(), owner, balance, currency.USD)
 
 	require.NoError(t, err)
 	require.NotEmpty(t, account)
@@ -38,7 +38,7 @@ func TestCreateAccount(t *testing.T) {
 
 func TestGetAccount(t *testing.T) {
 	expectedAccount := createRandomAccount(t)
-	account, err := testStore.GetAccountByID(context.Background(), expectedAccount.ID)
+	account, err := testStore(t).GetAccountByID(context.Background(), expectedAccount.ID)
 
 	require.NoError(t, err)
 	require.Equal(t, expectedAccount.ID, account.ID)
@@ -50,7 +50,7 @@ func TestGetAccount(t *testing.T) {
 
 func TestGetAccountForUpdate(t *testing.T) {
 	expectedAccount := createRandomAccount(t)
-	account, err := testStore.GetAccountByIDForUpdate(context.Background(), expectedAccount.ID)
+	account, err := testStore(t).GetAccountByIDForUpdate(context.Background(), expectedAccount.ID)
 
 	require.NoError(t, err)
 	require.Equal(t, expectedAccount.ID, account.ID)
@@ -62,10 +62,10 @@ func TestGetAccountForUpdate(t *testing.T) {
 
 func TestGetAccountsByOwner(t *testing.T) {
 	account1 := createRandomAccount(t)
-	account2, err := testStore.CreateAccount(context.Background(), account1.Owner, utils.RandomMoney(), currency.USD)
+	account2, err := testStore(t).CreateAccount(context.Background(), account1.Owner, utils.RandomMoney(), currency.USD)
 	require.NoError(t, err)
 
-	accounts, err := testStore.GetAccountsByOwner(context.Background(), account1.Owner)
+	accounts, err := testStore(t).GetAccountsByOwner(context.Background(), account1.Owner)
 	require.NoError(t, err)
 
 	require.Equal(t, 2, len(*accounts))
@@ -87,12 +87,12 @@ func TestGetAllAccounts(t *testing.T) {
 	expectedAccounts[0] = *createRandomAccount(t)
 
 	for i := 1; i < 10; i++ {
-		account, err := testStore.CreateAccount(context.Background(), expectedAccounts[0].Owner, utils.RandomMoney(), currency.USD)
+		account, err := testStore(t).CreateAccount(context.Background(), expectedAccounts[0].Owner, utils.RandomMoney(), currency.USD)
 		expectedAccounts[i] = *account
 		require.NoError(t, err)
 	}
 
-	accounts, err := testStore.ListAccounts(context.Background(), expectedAccounts[0].Owner, 5, 0)
+	accounts, err := testStore(t).ListAccounts(context.Background(), expectedAccounts[0].Owner, Page{Limit: 5})
 
 	require.NoError(t, err)
 	for i := 0; i < 5; i++ {
@@ -103,7 +103,9 @@ func TestGetAllAccounts(t *testing.T) {
 		require.WithinDuration(t, expectedAccounts[i].CreatedAt, (*accounts)[i].CreatedAt, time.Second)
 	}
 
-	accounts, err = testStore.ListAccounts(context.Background(), expectedAccounts[0].Owner, 5, 5)
+	// the next page starts right after the last account of this one
+	last := (*accounts)[4]
+	accounts, err = testStore(t).ListAccounts(context.Background(), expectedAccounts[0].Owner, Page{Limit: 5, After: &PageKey{CreatedAt: last.CreatedAt, ID: last.ID}})
 	require.NoError(t, err)
 	for i, j := 0, 5; i < 5 && j < 10; i, j = i+1, j+1 {
 		require.Equal(t, expectedAccounts[j].ID, (*accounts)[i].ID)
@@ -132,11 +134,11 @@ func TestUpdateAccount(t *testing.T) {
 		CreatedAt: originalAccount.CreatedAt,
 	}
 
-	rowsAffected, err := testStore.UpdateAccount(context.Background(), &expectedAccount)
+	rowsAffected, err := testStore(t).UpdateAccount(context.Background(), &expectedAccount)
 	require.NoError(t, err)
 	require.Equal(t, int64(1), rowsAffected)
 
-	updatedAccount, err := testStore.GetAccountByID(context.Background(), originalAccount.ID)
+	updatedAccount, err := testStore(t).GetAccountByID(context.Background(), originalAccount.ID)
 	require.NoError(t, err)
 	require.Equal(t, expectedAccount.ID, updatedAccount.ID)
 	require.Equal(t, expectedAccount.Owner, updatedAccount.Owner)
@@ -148,11 +150,11 @@ func TestUpdateAccount(t *testing.T) {
 func TestUpdateAccountOwner(t *testing.T) {
 	account := createRandomAccount(t)
 
-	rowsAffected, err := testStore.UpdateAccountOwner(context.Background(), account.ID, "new_owner")
+	rowsAffected, err := testStore(t).UpdateAccountOwner(context.Background(), account.ID, "new_owner")
 	require.NoError(t, err)
 	require.Equal(t, int64(1), rowsAffected)
 
-	updatedAccout, err := testStore.GetAccountByID(context.Background(), account.ID)
+	updatedAccout, err := testStore(t).GetAccountByID(context.Background(), account.ID)
 	require.NoError(t, err)
 	require.Equal(t, account.ID, updatedAccout.ID)
 	require.Equal(t, "new_owner", updatedAccout.Owner)
@@ -164,11 +166,11 @@ func TestUpdateAccountOwner(t *testing.T) {
 func TestUpdateAccountBalance(t *testing.T) {
 	originalAccount := createRandomAccount(t)
 
-	rowsAffected, err := testStore.UpdateAccountBalance(context.Background(), originalAccount.ID, originalAccount.Balance+2000)
+	rowsAffected, err := testStore(t).UpdateAccountBalance(context.Background(), originalAccount.ID, originalAccount.Balance+2000)
 	require.NoError(t, err)
 	require.Equal(t, int64(1), rowsAffected)
 
-	updatedAccount, err := testStore.GetAccountByID(context.Background(), originalAccount.ID)
+	updatedAccount, err := testStore(t).GetAccountByID(context.Background(), originalAccount.ID)
 	require.NoError(t, err)
 	require.Equal(t, originalAccount.ID, updatedAccount.ID)
 	require.Equal(t, originalAccount.Owner, updatedAccount.Owner)
@@ -180,7 +182,7 @@ func TestUpdateAccountBalance(t *testing.T) {
 func TestAddAccountBalance(t *testing.T) {
 	originalAccount := createRandomAccount(t)
 
-	updatedAccount, err := testStore.AddAccountBalance(context.Background(), originalAccount.ID, 2000)
+	updatedAccount, err := testStore(t).AddAccountBalance(context.Background(), originalAccount.ID, 2000)
 
 	require.NoError(t, err)
 	require.Equal(t, originalAccount.ID, updatedAccount.ID)
@@ -189,7 +191,7 @@ func TestAddAccountBalance(t *testing.T) {
 	require.Equal(t, originalAccount.Currency, updatedAccount.Currency)
 	require.Equal(t, originalAccount.CreatedAt, updatedAccount.CreatedAt)
 
-	_, err = testStore.AddAccountBalance(context.Background(), originalAccount.ID, -10000) // random generate max 1000 + 2000 leads to max 3000 ==> this should lead to negative amount
+	_, err = testStore(t).AddAccountBalance(context.Background(), originalAccount.ID, -10000) // random generate max 1000 + 2000 leads to max 3000 ==> this should lead to negative amount
 	expectedError := fmt.Errorf("%d's balance is less than requested amount", originalAccount.ID)
 	require.Error(t, expectedError, err)
 }
@@ -197,11 +199,11 @@ func TestAddAccountBalance(t *testing.T) {
 func TestDeleteAccountByID(t *testing.T) {
 	originalAccount := createRandomAccount(t)
 
-	rowsAffected, err := testStore.DeleteAccountByID(context.Background(), originalAccount.ID)
+	rowsAffected, err := testStore(t).DeleteAccountByID(context.Background(), originalAccount.ID)
 	require.NoError(t, err)
 	require.Equal(t, int64(1), rowsAffected)
 
-	account, err := testStore.GetAccountByID(context.Background(), originalAccount.ID)
+	account, err := testStore(t).GetAccountByID(context.Background(), originalAccount.ID)
 	require.Error(t, err)
 	require.True(t, strings.Contains(err.Error(), pgx.ErrNoRows.Error()))
 	require.Empty(t, account)
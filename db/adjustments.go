@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrAdjustmentOverdraws = errors.New("adjustment would make the balance negative")
+
+// metadata key of an adjustment's entry naming who made it
+const AdjustedByKey = "adjusted_by"
+
+// correct an account's balance by amount outside of any transfer;
+// the entry keeps reason as its description and actor in its metadata
+func (s *SQLStore) AdjustAccountBalance(ctx context.Context, accountID, amount int64, reason, actor string) (*BalanceAdjustment, error) {
+	details := Details{Description: reason, Metadata: Metadata{AdjustedByKey: actor}}
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	q := s.inTx(tx)
+
+	account, err := q.GetAccountByIDForUpdate(ctx, accountID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if account.Balance+amount < 0 {
+		tx.Rollback()
+		return nil, fmt.Errorf("%w: account %d holds %d", ErrAdjustmentOverdraws, accountID, account.Balance)
+	}
+
+	entry, err := q.CreateEntry(ctx, accountID, amount, details)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	account, err = q.AddAccountBalance(ctx, accountID, amount)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return &BalanceAdjustment{Account: *account, Entry: *entry}, nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrAPIKeyInactive = errors.New("API key is revoked or expired")
+	ErrNonceReused    = errors.New("nonce was already used")
+)
+
+const apiKeyColumns = "id, name, prefix, key_hash, signing_secret, require_signature, permissions, account_ids, created_by, expires_at, last_used_at, revoked_at, rotated_to, created_at"
+
+// whether the key may be used at now
+func (key APIKey) Active(now time.Time) bool {
+	return key.RevokedAt == nil && (key.ExpiresAt == nil || now.Before(*key.ExpiresAt))
+}
+
+// create
+func (s *Queries) CreateAPIKey(ctx context.Context, key APIKey) (*APIKey, error) {
+	var created APIKey
+
+	err := s.db.GetContext(ctx, &created, "INSERT INTO api_keys (name, prefix, key_hash, signing_secret, require_signature, permissions, account_ids, created_by, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING "+apiKeyColumns+";",
+		key.Name, key.Prefix, key.KeyHash, key.SigningSecret, key.RequireSignature, key.Permissions, key.AccountIDs, key.CreatedBy, key.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// read (id)
+func (s *Queries) GetAPIKeyByID(ctx context.Context, id int64) (*APIKey, error) {
+	var key APIKey
+
+	err := s.db.GetContext(ctx, &key, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// read (prefix), the part of a key that may be shown
+func (s *Queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	var key APIKey
+
+	err := s.db.GetContext(ctx, &key, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1;", prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// read all, oldest first
+func (s *Queries) GetAPIKeys(ctx context.Context) (*[]APIKey, error) {
+	var keys []APIKey
+
+	err := s.db.SelectContext(ctx, &keys, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+
+	return &keys, nil
+}
+
+// revoke, 0 rows when missing or already revoked
+func (s *Queries) RevokeAPIKey(ctx context.Context, id int64) (int64, error) {
+	return rowsAffected(s.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL;", id))
+}
+
+// record a use at usedAt, at most once a minute to spare writes on busy keys
+func (s *Queries) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2 - interval '1 minute');", id, usedAt)
+	return err
+}
+
+// replace an active key by a new one with the same name, permissions, accounts and expiry.
+// replacement holds the new key material; the old key keeps working until oldExpiresAt
+func (s *SQLStore) RotateAPIKey(ctx context.Context, id int64, replacement APIKey, oldExpiresAt time.Time) (*APIKey, error) {
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	q := s.inTx(tx)
+
+	var old APIKey
+	err := q.db.GetContext(ctx, &old, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1 FOR UPDATE;", id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !old.Active(time.Now()) {
+		tx.Rollback()
+		return nil, fmt.Errorf("%w: key %d", ErrAPIKeyInactive, id)
+	}
+
+	replacement.Name = old.Name
+	replacement.RequireSignature = old.RequireSignature
+	replacement.Permissions = old.Permissions
+	replacement.AccountIDs = old.AccountIDs
+	replacement.ExpiresAt = old.ExpiresAt
+	rotated, err := q.CreateAPIKey(ctx, replacement)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = q.db.ExecContext(ctx, "UPDATE api_keys SET rotated_to = $2, expires_at = LEAST(expires_at, $3) WHERE id = $1;", id, rotated.ID, oldExpiresAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return rotated, nil
+}
+
+// remember nonce of a signed request by keyID until expiresAt, ErrNonceReused when it is remembered already.
+// nonces of keyID that expired are forgotten on the way
+func (s *Queries) UseAPIKeyNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM api_key_nonces WHERE key_id = $1 AND expires_at < now();", keyID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := rowsAffected(s.db.ExecContext(ctx, "INSERT INTO api_key_nonces (key_id, nonce, expires_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING;", keyID, nonce, expiresAt))
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNonceReused
+	}
+
+	return nil
+}
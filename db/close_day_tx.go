@@ -0,0 +1,72 @@
+// end-of-day closing of a business day
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// advisory lock key shared by every closer (replicas included)
+const closeBusinessDayLockKey = 26_0001
+
+// lock the closing process
+// if already closed: return the existing record (safe to re-run)
+// create the business day marker (postings into it are rejected from now on)
+// create a closing balance snapshot for every account
+// create per currency totals
+func (s *SQLStore) CloseBusinessDay(ctx context.Context, businessDate time.Time, location *time.Location) (*BusinessDay, error) {
+	if !businessDate.Before(BusinessDate(time.Now(), location)) {
+		return nil, fmt.Errorf("business day %s has not ended yet in %s", businessDate.Format(businessDateLayout), location)
+	}
+
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	q := s.inTx(tx)
+
+	_, err := q.db.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1);", closeBusinessDayLockKey)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	day, err := q.GetBusinessDay(ctx, businessDate)
+	if err == nil {
+		tx.Rollback()
+		return day, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return nil, err
+	}
+
+	cutoff := businessDayCutoff(businessDate, location)
+
+	day, err = q.CreateBusinessDay(ctx, businessDate, location.String(), cutoff)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = q.CreateBalanceSnapshots(ctx, businessDate, cutoff)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = q.CreateCurrencyTotals(ctx, businessDate)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return day, nil
+}
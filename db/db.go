@@ -1,35 +1,80 @@
 package db
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
 	"os"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/joelpatel/go-bank/config"
 )
 
-func InitializeDBStore() Store {
-	host := os.Getenv("DATABASE_HOST")
-	port := os.Getenv("DATABASE_PORT")
-	user := os.Getenv("DATABASE_USER")
-	pass := os.Getenv("DATABASE_PASS")
-	dbName := os.Getenv("DATABASE_NAME")
-	sslMode := os.Getenv("DATABASE_SSLMODE")
+// OpenDB connects to the configured database through the configured pool backend.
+// Either way queries go through the same Ops, only the connection management differs:
+//   - sql: database/sql pools pgx connections itself.
+//   - pgxpool: pgxpool owns the connections and database/sql borrows one per query or transaction,
+//     so connections and their prepared statement caches are kept warm by pgxpool.
+func OpenDB(database config.DatabaseConfig) (*sqlx.DB, error) {
+	// pgxpool understands the pool_* parameters and strips them off the connection settings
+	poolConfig, err := pgxpool.ParseConfig(database.DSN())
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
 
-	databaseUrl := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, pass, dbName, sslMode)
+	ctx, cancel := context.WithTimeout(context.Background(), database.ConnectTimeout)
+	defer cancel()
 
-	db, err := sqlx.Open("pgx", databaseUrl)
+	var sqlDB *sql.DB
+	switch database.Backend {
+	case config.BackendPgxPool:
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			return nil, err
+		}
+		sqlDB = sql.OpenDB(poolConnector{Connector: stdlib.GetPoolConnector(pool), pool: pool})
+		// idle connections belong to pgxpool
+		sqlDB.SetMaxIdleConns(0)
+	default:
+		sqlDB = stdlib.OpenDB(*poolConfig.ConnConfig)
+		sqlDB.SetMaxOpenConns(int(poolConfig.MaxConns))
+		sqlDB.SetMaxIdleConns(database.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(poolConfig.MaxConnLifetime)
+		sqlDB.SetConnMaxIdleTime(poolConfig.MaxConnIdleTime)
+	}
 
-	if err != nil {
-		log.Fatal(err.Error())
+	db := sqlx.NewDb(sqlDB, "pgx")
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	err = db.Ping()
+	return db, nil
+}
+
+// poolConnector closes the pgxpool along with the sql.DB using it.
+type poolConnector struct {
+	driver.Connector
+	pool *pgxpool.Pool
+}
+
+func (c poolConnector) Close() error {
+	c.pool.Close()
+	return nil
+}
+
+func InitializeDBStore(database config.DatabaseConfig, logger *slog.Logger) Store {
+	db, err := OpenDB(database)
 
 	if err != nil {
-		log.Fatal(err.Error())
+		logger.Error("opening database", "error", err.Error())
+		os.Exit(1)
 	}
 
-	return NewStore(db)
+	return NewStore(db, logger)
 }
@@ -1,45 +1,39 @@
 package db
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"sync"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/joho/godotenv"
+	"github.com/joelpatel/go-bank/config"
+	"github.com/stretchr/testify/require"
 )
 
-var testStore Store
-
-func TestMain(m *testing.M) {
-	err := godotenv.Load("../.env")
-	if err != nil {
-		log.Fatal("error loading .env file")
-	}
-
-	host := os.Getenv("TEST_DATABASE_HOST")
-	port := os.Getenv("TEST_DATABASE_PORT")
-	user := os.Getenv("TEST_DATABASE_USER")
-	pass := os.Getenv("TEST_DATABASE_PASS")
-	dbName := os.Getenv("TEST_DATABASE_NAME")
-	sslMode := os.Getenv("TEST_DATABASE_SSLMODE")
-
-	databaseUrl := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, pass, dbName, sslMode)
-
-	db, err := sqlx.Open("pgx", databaseUrl)
-
-	if err != nil {
-		log.Fatal(err.Error())
-	}
-
-	err = db.Ping()
-
-	if err != nil {
-		log.Fatal(err.Error())
-	}
+var (
+	testOnce   sync.Once
+	testConfig config.Config
+	testConn   *sqlx.DB
+	testErr    error
+)
 
-	testStore = NewStore(db)
+// the test database is configured like the server's, with TEST_ prefixed env variables,
+// opened on first use and shared by every test of the package
+func testDatabase(tb testing.TB) (config.Config, *sqlx.DB) {
+	testOnce.Do(func() {
+		testConfig, testErr = config.Load(config.Options{DotEnv: "../.env", EnvPrefix: "TEST_"})
+		if testErr != nil {
+			return
+		}
+		testConn, testErr = OpenDB(testConfig.Database)
+	})
+	require.NoError(tb, testErr)
+
+	return testConfig, testConn
+}
 
-	os.Exit(m.Run())
+// store on the shared test database
+func testStore(tb testing.TB) Store {
+	_, conn := testDatabase(tb)
+	return NewStore(conn, slog.Default())
 }
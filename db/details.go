@@ -0,0 +1,91 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// size limits of Details, the columns check the first two as well
+const (
+	MaxDescriptionLength       = 255
+	MaxExternalReferenceLength = 64
+	MaxMetadataKeys            = 20
+	MaxMetadataKeyLength       = 40
+	MaxMetadataValueLength     = 500
+)
+
+var (
+	ErrInvalidDetails     = errors.New("invalid transfer details")
+	ErrDuplicateReference = errors.New("external reference is already used by another transfer from this account")
+)
+
+// letters, digits and . _ : - so references survive URLs and CSV exports unchanged
+var externalReferencePattern = regexp.MustCompile(`^[A-Za-z0-9._:-]*$`)
+
+// client supplied key/value pairs, stored as a jsonb object
+type Metadata map[string]string
+
+func (m *Metadata) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(src, m)
+	case string:
+		return json.Unmarshal([]byte(src), m)
+	}
+	return fmt.Errorf("cannot scan %T into Metadata", src)
+}
+
+// an empty object rather than null
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(m))
+	return string(data), err
+}
+
+// what a client says about a transfer, copied onto both of its entries.
+// empty fields are not set; external references of a sending account are unique
+type Details struct {
+	Description       string   `json:"description,omitempty" db:"description"`
+	ExternalReference string   `json:"external_reference,omitempty" db:"external_reference"`
+	Metadata          Metadata `json:"metadata,omitempty" db:"metadata"`
+}
+
+// check the size limits, wrapping ErrInvalidDetails
+func (details Details) Validate() error {
+	switch {
+	case !utf8.ValidString(details.Description):
+		return fmt.Errorf("%w: description is not valid UTF-8", ErrInvalidDetails)
+	case utf8.RuneCountInString(details.Description) > MaxDescriptionLength:
+		return fmt.Errorf("%w: description is longer than %d characters", ErrInvalidDetails, MaxDescriptionLength)
+	case len(details.ExternalReference) > MaxExternalReferenceLength:
+		return fmt.Errorf("%w: external reference is longer than %d characters", ErrInvalidDetails, MaxExternalReferenceLength)
+	case !externalReferencePattern.MatchString(details.ExternalReference):
+		return fmt.Errorf("%w: external reference may only contain letters, digits and . _ : -", ErrInvalidDetails)
+	case len(details.Metadata) > MaxMetadataKeys:
+		return fmt.Errorf("%w: metadata has more than %d keys", ErrInvalidDetails, MaxMetadataKeys)
+	}
+
+	for key, value := range details.Metadata {
+		switch {
+		case key == "":
+			return fmt.Errorf("%w: metadata keys must not be empty", ErrInvalidDetails)
+		case !utf8.ValidString(key) || !utf8.ValidString(value):
+			return fmt.Errorf("%w: metadata %q is not valid UTF-8", ErrInvalidDetails, key)
+		case utf8.RuneCountInString(key) > MaxMetadataKeyLength:
+			return fmt.Errorf("%w: metadata key %q is longer than %d characters", ErrInvalidDetails, key, MaxMetadataKeyLength)
+		case utf8.RuneCountInString(value) > MaxMetadataValueLength:
+			return fmt.Errorf("%w: metadata value of %q is longer than %d characters", ErrInvalidDetails, key, MaxMetadataValueLength)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataValueAndScan(t *testing.T) {
+	value, err := Metadata(nil).Value()
+	require.NoError(t, err)
+	require.Equal(t, "{}", value)
+
+	value, err = Metadata{"order": "42"}.Value()
+	require.NoError(t, err)
+	require.Equal(t, `{"order":"42"}`, value)
+
+	var metadata Metadata
+	require.NoError(t, metadata.Scan([]byte(`{"order":"42"}`)))
+	require.Equal(t, Metadata{"order": "42"}, metadata)
+
+	require.NoError(t, metadata.Scan(nil))
+	require.Nil(t, metadata)
+
+	require.Error(t, metadata.Scan(42))
+	require.Error(t, metadata.Scan(`["not", "an", "object"]`))
+}
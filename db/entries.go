@@ -5,13 +5,20 @@ import (
 )
 
 // create
-func (s *Queries) CreateEntry(ctx context.Context, accountID, amount int64) (*Entry, error) {
-	row := s.db.QueryRowContext(ctx, "INSERT INTO entries (account_id, amount) VALUES ($1, $2) RETURNING id, account_id, amount, created_at;", accountID, amount)
+func (s *Queries) CreateEntry(ctx context.Context, accountID, amount int64, details Details) (*Entry, error) {
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, "INSERT INTO entries (account_id, amount, description, external_reference, metadata) VALUES ($1, $2, $3, $4, $5::jsonb) RETURNING id, account_id, amount, description, external_reference, metadata, created_at;", accountID, amount, details.Description, details.ExternalReference, details.Metadata)
 
 	var entry Entry
 
-	err := row.Scan(&entry.ID, &entry.AccountID, &entry.Amount, &entry.CreatedAt)
+	err := row.Scan(&entry.ID, &entry.AccountID, &entry.Amount, &entry.Description, &entry.ExternalReference, &entry.Metadata, &entry.CreatedAt)
 	if err != nil {
+		if violates(err, "business_day_closed") {
+			return nil, ErrBusinessDayClosed
+		}
 		return nil, err
 	}
 
@@ -22,7 +29,7 @@ func (s *Queries) CreateEntry(ctx context.Context, accountID, amount int64) (*En
 func (s *Queries) GetEntryByID(ctx context.Context, id int64) (*Entry, error) {
 	var entry Entry
 
-	err := s.db.GetContext(ctx, &entry, "SELECT id, account_id, amount, created_at FROM entries WHERE id = $1;", id)
+	err := s.db.GetContext(ctx, &entry, "SELECT id, account_id, amount, description, external_reference, metadata, created_at FROM entries WHERE id = $1;", id)
 	if err != nil {
 		return nil, err
 	}
@@ -30,11 +37,12 @@ func (s *Queries) GetEntryByID(ctx context.Context, id int64) (*Entry, error) {
 	return &entry, nil
 }
 
-// read all for account_id (pagination)
-func (s *Queries) GetEntriesByAccountID(ctx context.Context, account_id, limit, offset int64) (*[]Entry, error) {
+// read all for account_id (keyset pagination)
+func (s *Queries) GetEntriesByAccountID(ctx context.Context, account_id int64, page Page) (*[]Entry, error) {
 	var entries []Entry
 
-	err := s.db.SelectContext(ctx, &entries, "SELECT id, account_id, amount, created_at FROM entries WHERE account_id = $1 ORDER BY id LIMIT $2 OFFSET $3;", account_id, limit, offset)
+	clause, args := page.clause(2)
+	err := s.db.SelectContext(ctx, &entries, "SELECT id, account_id, amount, description, external_reference, metadata, created_at FROM entries WHERE account_id = $1"+clause+";", append([]any{account_id}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -11,7 +11,7 @@ import (
 
 func createRandomEntry(t *testing.T, account *Account) *Entry {
 	entryAmount := utils.RandomMoney()
-	entry, err := testStore.CreateEntry(context.Background(), account.ID, entryAmount)
+	entry, err := testStore(t).CreateEntry(context.Background(), account.ID, entryAmount, Details{})
 
 	require.NoError(t, err)
 	require.NotEmpty(t, entry)
@@ -31,7 +31,7 @@ func TestCreateEntry(t *testing.T) {
 func TestGetEntryById(t *testing.T) {
 	expectedEntry := createRandomEntry(t, createRandomAccount(t))
 
-	entry, err := testStore.GetEntryByID(context.Background(), expectedEntry.ID)
+	entry, err := testStore(t).GetEntryByID(context.Background(), expectedEntry.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry)
 
@@ -49,7 +49,7 @@ func TestGetEntriesByAccountID(t *testing.T) {
 		expectedEntries[i] = *createRandomEntry(t, account)
 	}
 
-	entries, err := testStore.GetEntriesByAccountID(context.Background(), account.ID, 5, 0)
+	entries, err := testStore(t).GetEntriesByAccountID(context.Background(), account.ID, Page{Limit: 5})
 	require.NoError(t, err)
 
 	for i, entry := range *entries {
@@ -59,7 +59,8 @@ func TestGetEntriesByAccountID(t *testing.T) {
 		require.Equal(t, expectedEntries[i].CreatedAt, entry.CreatedAt)
 	}
 
-	entries, err = testStore.GetEntriesByAccountID(context.Background(), account.ID, 5, 5)
+	last := (*entries)[len(*entries)-1]
+	entries, err = testStore(t).GetEntriesByAccountID(context.Background(), account.ID, Page{Limit: 5, After: &PageKey{CreatedAt: last.CreatedAt, ID: last.ID}})
 	require.NoError(t, err)
 
 	for i, entry := range *entries {
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// aggregates events are ordered by
+const (
+	AggregateAccount  = "account"
+	AggregateTransfer = "transfer"
+)
+
+// event types and their payloads
+const (
+	EventAccountCreated        = "AccountCreated"        // Account
+	EventAccountUpdated        = "AccountUpdated"        // Account
+	EventAccountOwnerChanged   = "AccountOwnerChanged"   // Account
+	EventAccountBalanceChanged = "AccountBalanceChanged" // Account
+	EventAccountFrozen         = "AccountFrozen"         // Account
+	EventAccountUnfrozen       = "AccountUnfrozen"       // Account
+	EventAccountDeleted        = "AccountDeleted"        // AccountDeletedEvent
+	EventTransferCompleted     = "TransferCompleted"     // TransferTxResult
+)
+
+type AccountDeletedEvent struct {
+	ID int64 `json:"id"`
+}
+
+// jsonb payload of an account row, same shape as Account's JSON
+const accountEventPayload = "jsonb_build_object('id', id, 'owner', owner, 'balance', balance, 'currency', currency, 'frozen', frozen, 'created_at', created_at)"
+
+// unmarshal the event's payload into v
+func (event *OutboxEvent) Decode(v any) error {
+	return json.Unmarshal(event.Payload, v)
+}
+
+// create
+func (s *Queries) CreateOutboxEvent(ctx context.Context, aggregateType string, aggregateID int64, eventType string, payload any) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var event OutboxEvent
+
+	err = s.db.GetContext(ctx, &event, "INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) VALUES ($1, $2, $3, $4::jsonb) RETURNING id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, txid;", aggregateType, aggregateID, eventType, string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// read unpublished (oldest first)
+func (s *Queries) GetUnpublishedOutboxEvents(ctx context.Context, limit int64) (*[]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	err := s.db.SelectContext(ctx, &events, "SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, txid FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT $1;", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
+
+// read all for aggregate
+func (s *Queries) GetOutboxEventsByAggregate(ctx context.Context, aggregateType string, aggregateID int64) (*[]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	err := s.db.SelectContext(ctx, &events, "SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, txid FROM outbox WHERE aggregate_type = $1 AND aggregate_id = $2 ORDER BY id;", aggregateType, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
+
+// update published_at for ids
+func (s *Queries) MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE outbox SET published_at = now() WHERE id = ANY($1) AND published_at IS NULL;", ids)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// read (id)
+func (s *Queries) GetOutboxEventByID(ctx context.Context, id int64) (*OutboxEvent, error) {
+	var event OutboxEvent
+
+	err := s.db.GetContext(ctx, &event, "SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, txid FROM outbox WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// transactions below it have all ended, so no event written under a smaller txid can still commit
+const committedHorizon = "pg_snapshot_xmin(pg_current_snapshot())::text::bigint"
+
+// read committed events after the event afterID, in commit order (txid, id), 0 for the first (pagination)
+// an id that was never written resumes after the event written before it
+// events of transactions at or past the horizon wait, an older one could still commit before them
+func (s *Queries) GetOutboxEventsAfter(ctx context.Context, afterID, limit int64) (*[]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	err := s.db.SelectContext(ctx, &events, "SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, txid FROM outbox WHERE (txid, id) > (COALESCE((SELECT txid FROM outbox WHERE id = $1), (SELECT txid FROM outbox WHERE id < $1 ORDER BY id DESC LIMIT 1), 0), $1) AND txid < "+committedHorizon+" ORDER BY txid, id LIMIT $2;", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
+
+// read the id of the last committed event in commit order, 0 when there is none
+func (s *Queries) GetLastOutboxEventID(ctx context.Context) (int64, error) {
+	var id int64
+
+	err := s.db.GetContext(ctx, &id, "SELECT COALESCE((SELECT id FROM outbox WHERE txid < "+committedHorizon+" ORDER BY txid DESC, id DESC LIMIT 1), 0);")
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,4 @@
+package db
+
+// lets the external conformance test use the shared test database
+var TestStore = testStore
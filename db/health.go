@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+)
+
+// latest migration in sql/ the code is written against
+const SchemaVersion = 17
+
+// read migration version recorded by golang-migrate
+func (s *Queries) GetSchemaVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	err = s.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1;").Scan(&version, &dirty)
+	return
+}
+
+// check database connectivity
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.conn.PingContext(ctx)
+}
+
+// close the connection pool
+func (s *SQLStore) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// what a transfer limit applies to
+const (
+	LimitScopeAccount  = "account"  // one account
+	LimitScopeOwner    = "owner"    // all accounts of an owner together
+	LimitScopeCurrency = "currency" // every account in a currency, each on its own
+)
+
+// what a transfer limit counts
+const (
+	LimitPerTransaction = "per_transaction" // amount of a single transfer
+	LimitDailyAmount    = "daily_amount"    // amount sent in the last 24 hours
+	LimitMonthlyAmount  = "monthly_amount"  // amount sent in the calendar month, UTC
+	LimitDailyCount     = "daily_count"     // transfers sent in the last 24 hours
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid transfer limit")
+	ErrLimitExceeded = errors.New("transfer limit exceeded")
+)
+
+// LimitExceededError is returned by TransferMoney for the first limit a transfer would break.
+// It matches ErrLimitExceeded with errors.Is.
+type LimitExceededError struct {
+	Limit     TransferLimit `json:"limit"`
+	Used      int64         `json:"used"`      // of the limit's window before the transfer
+	Remaining int64         `json:"remaining"` // what may still be sent within the window
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit of %s %s exceeded, %d remaining", e.Limit.Kind, e.Limit.Scope, e.Limit.Subject, e.Remaining)
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// check the scope, kind and subject, wrapping ErrInvalidLimit
+func (limit TransferLimit) Validate() error {
+	switch limit.Scope {
+	case LimitScopeAccount:
+		if id, err := strconv.ParseInt(limit.Subject, 10, 64); err != nil || id < 1 || strconv.FormatInt(id, 10) != limit.Subject {
+			return fmt.Errorf("%w: subject of an account limit is its id", ErrInvalidLimit)
+		}
+	case LimitScopeOwner, LimitScopeCurrency:
+		if limit.Subject == "" {
+			return fmt.Errorf("%w: subject is required", ErrInvalidLimit)
+		}
+	default:
+		return fmt.Errorf("%w: unknown scope %q", ErrInvalidLimit, limit.Scope)
+	}
+
+	if !slices.Contains([]string{LimitPerTransaction, LimitDailyAmount, LimitMonthlyAmount, LimitDailyCount}, limit.Kind) {
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidLimit, limit.Kind)
+	}
+	if limit.Max < 0 {
+		return fmt.Errorf("%w: max must not be negative", ErrInvalidLimit)
+	}
+
+	return nil
+}
+
+// outgoing entries of one account or owner, in the windows limits count
+type LimitUsage struct {
+	DailyAmount   int64 `db:"daily_amount"`
+	DailyCount    int64 `db:"daily_count"`
+	MonthlyAmount int64 `db:"monthly_amount"`
+}
+
+// LimitWindows returns the start of the daily and the monthly window at now.
+func LimitWindows(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	return now.Add(-24 * time.Hour), time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// CheckTransferLimits returns a *LimitExceededError for the first of limits that sending amount breaks,
+// given what the account and its owner already sent. Account and currency limits count the account's usage,
+// owner limits the owner's. Stores call it with the usage they aggregated.
+func CheckTransferLimits(limits []TransferLimit, amount int64, account, owner LimitUsage) error {
+	limits = slices.Clone(limits)
+	slices.SortFunc(limits, func(a, b TransferLimit) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for _, limit := range limits {
+		usage := account
+		if limit.Scope == LimitScopeOwner {
+			usage = owner
+		}
+
+		var used, requested int64
+		switch limit.Kind {
+		case LimitPerTransaction:
+			used, requested = 0, amount
+		case LimitDailyAmount:
+			used, requested = usage.DailyAmount, amount
+		case LimitMonthlyAmount:
+			used, requested = usage.MonthlyAmount, amount
+		case LimitDailyCount:
+			used, requested = usage.DailyCount, 1
+		}
+
+		if used+requested > limit.Max {
+			return &LimitExceededError{Limit: limit, Used: used, Remaining: max(limit.Max-used, 0)}
+		}
+	}
+
+	return nil
+}
+
+// create or replace the limit of its scope, subject and kind
+func (s *Queries) SetTransferLimit(ctx context.Context, limit TransferLimit) (*TransferLimit, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	var set TransferLimit
+
+	err := s.db.GetContext(ctx, &set, `INSERT INTO transfer_limits (scope, subject, kind, max_value) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, subject, kind) DO UPDATE SET max_value = excluded.max_value
+		RETURNING id, scope, subject, kind, max_value, created_at;`, limit.Scope, limit.Subject, limit.Kind, limit.Max)
+	if err != nil {
+		return nil, err
+	}
+
+	return &set, nil
+}
+
+// read (id)
+func (s *Queries) GetTransferLimitByID(ctx context.Context, id int64) (*TransferLimit, error) {
+	var limit TransferLimit
+
+	err := s.db.GetContext(ctx, &limit, "SELECT id, scope, subject, kind, max_value, created_at FROM transfer_limits WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limit, nil
+}
+
+// read all
+func (s *Queries) GetTransferLimits(ctx context.Context) (*[]TransferLimit, error) {
+	var limits []TransferLimit
+
+	err := s.db.SelectContext(ctx, &limits, "SELECT id, scope, subject, kind, max_value, created_at FROM transfer_limits ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
+// read (limits applying to account)
+func (s *Queries) transferLimitsFor(ctx context.Context, account *Account) (*[]TransferLimit, error) {
+	var limits []TransferLimit
+
+	err := s.db.SelectContext(ctx, &limits, `SELECT id, scope, subject, kind, max_value, created_at FROM transfer_limits
+		WHERE (scope = $1 AND subject = $2) OR (scope = $3 AND subject = $4) OR (scope = $5 AND subject = $6) ORDER BY id;`,
+		LimitScopeAccount, strconv.FormatInt(account.ID, 10), LimitScopeOwner, account.Owner, LimitScopeCurrency, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
+// delete
+func (s *Queries) DeleteTransferLimitByID(ctx context.Context, id int64) (int64, error) {
+	return rowsAffected(s.db.ExecContext(ctx, "DELETE FROM transfer_limits WHERE id = $1;", id))
+}
+
+// outgoing entries of the accounts matching condition ($1 being its argument) in the limit windows at now
+func (s *Queries) limitUsage(ctx context.Context, condition string, arg any, now time.Time) (LimitUsage, error) {
+	day, month := LimitWindows(now)
+
+	var usage LimitUsage
+
+	err := s.db.GetContext(ctx, &usage, `SELECT
+			coalesce(sum(-amount) FILTER (WHERE created_at > $2), 0) AS daily_amount,
+			count(*) FILTER (WHERE created_at > $2) AS daily_count,
+			coalesce(sum(-amount) FILTER (WHERE created_at >= $3), 0) AS monthly_amount
+		FROM entries WHERE amount < 0 AND created_at >= least($2, $3) AND `+condition+";", arg, day, month)
+
+	return usage, err
+}
+
+// check the limits applying to a transfer of amount from account id.
+// serializes with other transfers of the account and its owner until tx ends, so their usage cannot change meanwhile
+func (s *Queries) checkTransferLimits(ctx context.Context, id, amount int64) error {
+	account, err := s.GetAccountByID(ctx, id)
+	if err != nil {
+		// a missing account fails the transfer on its own
+		return nil
+	}
+
+	limits, err := s.transferLimitsFor(ctx, account)
+	if err != nil || len(*limits) == 0 {
+		return err
+	}
+
+	// always owner first, then account; row locks are only taken afterwards
+	for _, key := range []string{"transfer_limits:owner:" + account.Owner, "transfer_limits:account:" + strconv.FormatInt(id, 10)} {
+		if _, err := s.db.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0));", key); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	accountUsage, err := s.limitUsage(ctx, "account_id = $1", id, now)
+	if err != nil {
+		return err
+	}
+	ownerUsage, err := s.limitUsage(ctx, "account_id IN (SELECT id FROM accounts WHERE owner = $1)", account.Owner, now)
+	if err != nil {
+		return err
+	}
+
+	return CheckTransferLimits(*limits, amount, accountUsage, ownerUsage)
+}
@@ -0,0 +1,54 @@
+// listening for committed outbox events
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+const outboxEventsChannel = "outbox_events"
+
+// hold a dedicated connection LISTENing on outbox_events
+// read every notified event and hand it to handle, in commit order
+// blocks until ctx is done, the connection breaks or handle fails
+func (s *SQLStore) ListenOutboxEvents(ctx context.Context, handle func(ctx context.Context, event OutboxEvent) error) error {
+	conn, err := s.conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		// a LISTENing connection must never go back to the pool
+		defer pgxConn.Close(context.Background())
+
+		_, err := pgxConn.Exec(ctx, "LISTEN "+outboxEventsChannel+";")
+		if err != nil {
+			return err
+		}
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			id, err := strconv.ParseInt(notification.Payload, 10, 64)
+			if err != nil {
+				return err
+			}
+
+			event, err := s.GetOutboxEventByID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := handle(ctx, *event); err != nil {
+				return err
+			}
+		}
+	})
+}
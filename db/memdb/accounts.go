@@ -0,0 +1,285 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+var errBalanceNegative = errors.New(`new row for relation "accounts" violates check constraint "balance_nonnegative"`)
+
+// create
+func (s *Store) CreateAccount(ctx context.Context, owner string, balance int64, currency string) (*db.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if balance < 0 {
+		return nil, errBalanceNegative
+	}
+
+	account := db.Account{ID: s.nextID("accounts"), Owner: owner, Balance: balance, Currency: currency, CreatedAt: now()}
+	s.accounts[account.ID] = account
+
+	if err := s.addOutboxEvent(db.AggregateAccount, account.ID, db.EventAccountCreated, account); err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// read (id)
+func (s *Store) GetAccountByID(ctx context.Context, id int64) (*db.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &account, nil
+}
+
+// every method holds the store's lock, so reading is enough
+func (s *Store) GetAccountByIDForUpdate(ctx context.Context, id int64) (*db.Account, error) {
+	return s.GetAccountByID(ctx, id)
+}
+
+// read (owner)
+func (s *Store) GetAccountsByOwner(ctx context.Context, owner string) (*[]db.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts := s.accountsOf(owner)
+	return &accounts, nil
+}
+
+// read (owner) (keyset pagination)
+func (s *Store) ListAccounts(ctx context.Context, owner string, page db.Page) (*[]db.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts := keysetPage(s.accountsOf(owner), func(account db.Account) db.PageKey {
+		return db.PageKey{CreatedAt: account.CreatedAt, ID: account.ID}
+	}, page)
+	return &accounts, nil
+}
+
+// accounts of owner ordered by id, must hold mu
+func (s *Store) accountsOf(owner string) []db.Account {
+	var accounts []db.Account
+	for _, account := range s.accounts {
+		if account.Owner == owner {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts
+}
+
+// update (for adming use ONLY)
+func (s *Store) UpdateAccount(ctx context.Context, account *db.Account) (int64, error) {
+	return s.updateAccount(account.ID, db.EventAccountUpdated, func(stored *db.Account) {
+		stored.Owner = account.Owner
+		stored.Balance = account.Balance
+		stored.Currency = account.Currency
+	})
+}
+
+// update owner for accountID
+func (s *Store) UpdateAccountOwner(ctx context.Context, accountID int64, newOwner string) (int64, error) {
+	return s.updateAccount(accountID, db.EventAccountOwnerChanged, func(stored *db.Account) {
+		stored.Owner = newOwner
+	})
+}
+
+// update account balance
+func (s *Store) UpdateAccountBalance(ctx context.Context, id int64, balance int64) (int64, error) {
+	return s.updateAccount(id, db.EventAccountBalanceChanged, func(stored *db.Account) {
+		stored.Balance = balance
+	})
+}
+
+// apply update to the account and record eventType; 0 rows when it does not exist
+func (s *Store) updateAccount(id int64, eventType string, update func(stored *db.Account)) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return 0, nil
+	}
+
+	update(&account)
+	if account.Balance < 0 {
+		return 0, errBalanceNegative
+	}
+	s.accounts[id] = account
+
+	if err := s.addOutboxEvent(db.AggregateAccount, id, eventType, account); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// freeze or unfreeze, frozen accounts neither send nor receive transfers
+func (s *Store) SetAccountFrozen(ctx context.Context, id int64, frozen bool) (int64, error) {
+	eventType := db.EventAccountUnfrozen
+	if frozen {
+		eventType = db.EventAccountFrozen
+	}
+
+	return s.updateAccount(id, eventType, func(stored *db.Account) {
+		stored.Frozen = frozen
+	})
+}
+
+// same steps as the Postgres store: the entry, then the balance
+func (s *Store) AdjustAccountBalance(ctx context.Context, accountID, amount int64, reason, actor string) (*db.BalanceAdjustment, error) {
+	details := db.Details{Description: reason, Metadata: db.Metadata{db.AdjustedByKey: actor}}
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if account.Balance+amount < 0 {
+		return nil, fmt.Errorf("%w: account %d holds %d", db.ErrAdjustmentOverdraws, accountID, account.Balance)
+	}
+
+	entry, err := s.createEntry(accountID, amount, details)
+	if err != nil {
+		return nil, err
+	}
+
+	adjusted, err := s.addAccountBalance(accountID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.BalanceAdjustment{Account: *adjusted, Entry: *entry}, nil
+}
+
+// add to account's balance
+func (s *Store) AddAccountBalance(ctx context.Context, id int64, amount int64) (*db.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addAccountBalance(id, amount)
+}
+
+// must hold mu
+func (s *Store) addAccountBalance(id int64, amount int64) (*db.Account, error) {
+	account, ok := s.accounts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	account.Balance += amount
+	if account.Balance < 0 {
+		return nil, fmt.Errorf("%d's balance is less than requested amount", id)
+	}
+	s.accounts[id] = account
+
+	if err := s.addOutboxEvent(db.AggregateAccount, id, db.EventAccountBalanceChanged, account); err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// delete (balance snapshots cascade, entries and transfers have to go first)
+func (s *Store) DeleteAccountByID(ctx context.Context, id int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[id]; !ok {
+		return 0, nil
+	}
+
+	for _, entry := range s.entries {
+		if entry.AccountID == id {
+			return 0, foreignKeyError("entries", "entries_account_id_fkey")
+		}
+	}
+	for _, transfer := range s.transfers {
+		if transfer.FromAccountID == id {
+			return 0, foreignKeyError("transfers", "transfers_from_account_id_fkey")
+		}
+		if transfer.ToAccountID == id {
+			return 0, foreignKeyError("transfers", "transfers_to_account_id_fkey")
+		}
+	}
+	for _, request := range s.requests {
+		if request.FromAccountID == id {
+			return 0, foreignKeyError("transfer_requests", "transfer_requests_from_account_id_fkey")
+		}
+		if request.ToAccountID == id {
+			return 0, foreignKeyError("transfer_requests", "transfer_requests_to_account_id_fkey")
+		}
+	}
+
+	delete(s.accounts, id)
+	for _, snapshots := range s.snapshots {
+		delete(snapshots, id)
+	}
+	for payeeID, payee := range s.payees {
+		if payee.AccountID == id {
+			delete(s.payees, payeeID)
+		}
+	}
+
+	if err := s.addOutboxEvent(db.AggregateAccount, id, db.EventAccountDeleted, db.AccountDeletedEvent{ID: id}); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// keyset page of rows, same ordering and bounds as the Postgres store
+func keysetPage[T any](rows []T, key func(row T) db.PageKey, page db.Page) []T {
+	// whether a comes before b in the page's order
+	before := func(a, b db.PageKey) bool {
+		if page.Order == db.SortDesc {
+			return b.Before(a)
+		}
+		return a.Before(b)
+	}
+
+	sorted := slices.Clone(rows)
+	sort.Slice(sorted, func(i, j int) bool { return before(key(sorted[i]), key(sorted[j])) })
+
+	var result []T
+	for _, row := range sorted {
+		if int64(len(result)) >= page.Limit {
+			break
+		}
+		if page.After == nil || before(*page.After, key(row)) {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+// LIMIT/OFFSET of rows already in order
+func page[T any](rows []T, limit, offset int64) []T {
+	if offset >= int64(len(rows)) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit < int64(len(rows)) {
+		rows = rows[:limit]
+	}
+	return rows
+}
@@ -0,0 +1,207 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+var errDuplicatePrefix = errors.New(`duplicate key value violates unique constraint "api_keys_prefix_idx"`)
+
+// create
+func (s *Store) CreateAPIKey(ctx context.Context, key db.APIKey) (*db.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createAPIKey(key)
+}
+
+// must hold mu
+func (s *Store) createAPIKey(key db.APIKey) (*db.APIKey, error) {
+	for _, stored := range s.apiKeys {
+		if stored.Prefix == key.Prefix {
+			return nil, errDuplicatePrefix
+		}
+	}
+
+	created := db.APIKey{
+		ID:               s.nextID("api_keys"),
+		Name:             key.Name,
+		Prefix:           key.Prefix,
+		KeyHash:          key.KeyHash,
+		SigningSecret:    key.SigningSecret,
+		RequireSignature: key.RequireSignature,
+		Permissions:      key.Permissions,
+		AccountIDs:       key.AccountIDs,
+		CreatedBy:        key.CreatedBy,
+		ExpiresAt:        cloneTime(key.ExpiresAt),
+		CreatedAt:        now(),
+	}
+	if created.ExpiresAt != nil {
+		*created.ExpiresAt = created.ExpiresAt.Truncate(time.Microsecond)
+	}
+	s.apiKeys[created.ID] = *cloneAPIKey(created)
+
+	return cloneAPIKey(created), nil
+}
+
+// read (id)
+func (s *Store) GetAPIKeyByID(ctx context.Context, id int64) (*db.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return cloneAPIKey(key), nil
+}
+
+// read (prefix), the part of a key that may be shown
+func (s *Store) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*db.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.Prefix == prefix {
+			return cloneAPIKey(key), nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// read all, oldest first
+func (s *Store) GetAPIKeys(ctx context.Context) (*[]db.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []db.APIKey
+	for _, key := range s.apiKeys {
+		keys = append(keys, *cloneAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return &keys, nil
+}
+
+// revoke, 0 rows when missing or already revoked
+func (s *Store) RevokeAPIKey(ctx context.Context, id int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return 0, nil
+	}
+
+	revokedAt := now()
+	key.RevokedAt = &revokedAt
+	s.apiKeys[id] = key
+
+	return 1, nil
+}
+
+// record a use at usedAt, at most once a minute like the Postgres store
+func (s *Store) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok || (key.LastUsedAt != nil && !key.LastUsedAt.Before(usedAt.Add(-time.Minute))) {
+		return nil
+	}
+
+	usedAt = usedAt.Truncate(time.Microsecond)
+	key.LastUsedAt = &usedAt
+	s.apiKeys[id] = key
+
+	return nil
+}
+
+// same steps as the Postgres store, all or nothing
+func (s *Store) RotateAPIKey(ctx context.Context, id int64, replacement db.APIKey, oldExpiresAt time.Time) (*db.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.apiKeys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if !old.Active(time.Now()) {
+		return nil, fmt.Errorf("%w: key %d", db.ErrAPIKeyInactive, id)
+	}
+
+	replacement.Name = old.Name
+	replacement.RequireSignature = old.RequireSignature
+	replacement.Permissions = old.Permissions
+	replacement.AccountIDs = old.AccountIDs
+	replacement.ExpiresAt = old.ExpiresAt
+	rotated, err := s.createAPIKey(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	oldExpiresAt = oldExpiresAt.Truncate(time.Microsecond)
+	if old.ExpiresAt == nil || oldExpiresAt.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &oldExpiresAt
+	}
+	old.RotatedTo = &rotated.ID
+	s.apiKeys[id] = old
+
+	return rotated, nil
+}
+
+// remember nonce until expiresAt, db.ErrNonceReused when it is remembered already
+func (s *Store) UseAPIKeyNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.apiKeys[keyID]; !ok {
+		return foreignKeyError("api_key_nonces", "api_key_nonces_key_id_fkey")
+	}
+
+	nonces := s.nonces[keyID]
+	if nonces == nil {
+		nonces = map[string]time.Time{}
+		s.nonces[keyID] = nonces
+	}
+	for used, expires := range nonces {
+		if expires.Before(time.Now()) {
+			delete(nonces, used)
+		}
+	}
+
+	if _, ok := nonces[nonce]; ok {
+		return db.ErrNonceReused
+	}
+	nonces[nonce] = expiresAt
+
+	return nil
+}
+
+// stored keys keep their own slices and times
+func cloneAPIKey(key db.APIKey) *db.APIKey {
+	key.Permissions = slices.Clone(key.Permissions)
+	key.AccountIDs = append(db.Int64Array{}, key.AccountIDs...)
+	key.ExpiresAt = cloneTime(key.ExpiresAt)
+	key.LastUsedAt = cloneTime(key.LastUsedAt)
+	key.RevokedAt = cloneTime(key.RevokedAt)
+	key.RotatedTo = cloneID(key.RotatedTo)
+	return &key
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
@@ -0,0 +1,67 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// create
+func (s *Store) CreateEntry(ctx context.Context, accountID, amount int64, details db.Details) (*db.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createEntry(accountID, amount, details)
+}
+
+// must hold mu
+func (s *Store) createEntry(accountID, amount int64, details db.Details) (*db.Entry, error) {
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := s.accounts[accountID]; !ok {
+		return nil, foreignKeyError("entries", "entries_account_id_fkey")
+	}
+
+	entry := db.Entry{AccountID: accountID, Amount: amount, Details: cloneDetails(details), CreatedAt: now()}
+	if s.inClosedBusinessDay(entry.CreatedAt) {
+		return nil, db.ErrBusinessDayClosed
+	}
+
+	entry.ID = s.nextID("entries")
+	s.entries[entry.ID] = entry
+
+	return &entry, nil
+}
+
+// read
+func (s *Store) GetEntryByID(ctx context.Context, id int64) (*db.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &entry, nil
+}
+
+// read all for account_id (keyset pagination)
+func (s *Store) GetEntriesByAccountID(ctx context.Context, account_id int64, page db.Page) (*[]db.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []db.Entry
+	for _, entry := range s.entries {
+		if entry.AccountID == account_id {
+			entries = append(entries, entry)
+		}
+	}
+
+	entries = keysetPage(entries, func(entry db.Entry) db.PageKey {
+		return db.PageKey{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	}, page)
+	return &entries, nil
+}
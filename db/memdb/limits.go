@@ -0,0 +1,127 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// create or replace the limit of its scope, subject and kind
+func (s *Store) SetTransferLimit(ctx context.Context, limit db.TransferLimit) (*db.TransferLimit, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.limits {
+		if existing.Scope == limit.Scope && existing.Subject == limit.Subject && existing.Kind == limit.Kind {
+			existing.Max = limit.Max
+			s.limits[existing.ID] = existing
+			return &existing, nil
+		}
+	}
+
+	limit.ID = s.nextID("transfer_limits")
+	limit.CreatedAt = now()
+	s.limits[limit.ID] = limit
+
+	return &limit, nil
+}
+
+// read (id)
+func (s *Store) GetTransferLimitByID(ctx context.Context, id int64) (*db.TransferLimit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limit, ok := s.limits[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &limit, nil
+}
+
+// read all
+func (s *Store) GetTransferLimits(ctx context.Context) (*[]db.TransferLimit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limits := []db.TransferLimit{}
+	for _, limit := range s.limits {
+		limits = append(limits, limit)
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].ID < limits[j].ID })
+
+	return &limits, nil
+}
+
+// delete
+func (s *Store) DeleteTransferLimitByID(ctx context.Context, id int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.limits[id]; !ok {
+		return 0, nil
+	}
+	delete(s.limits, id)
+
+	return 1, nil
+}
+
+// outgoing entries of the accounts matching in the limit windows at now, must hold mu
+func (s *Store) limitUsage(matches func(accountID int64) bool, now time.Time) db.LimitUsage {
+	day, month := db.LimitWindows(now)
+
+	var usage db.LimitUsage
+	for _, entry := range s.entries {
+		if entry.Amount >= 0 || !matches(entry.AccountID) {
+			continue
+		}
+		if entry.CreatedAt.After(day) {
+			usage.DailyAmount -= entry.Amount
+			usage.DailyCount++
+		}
+		if !entry.CreatedAt.Before(month) {
+			usage.MonthlyAmount -= entry.Amount
+		}
+	}
+	return usage
+}
+
+// check the limits applying to a transfer of amount from account id, must hold mu
+func (s *Store) checkTransferLimits(id, amount int64) error {
+	account, ok := s.accounts[id]
+	if !ok {
+		// a missing account fails the transfer on its own
+		return nil
+	}
+
+	var limits []db.TransferLimit
+	for _, limit := range s.limits {
+		switch {
+		case limit.Scope == db.LimitScopeAccount && limit.Subject == strconv.FormatInt(id, 10),
+			limit.Scope == db.LimitScopeOwner && limit.Subject == account.Owner,
+			limit.Scope == db.LimitScopeCurrency && limit.Subject == account.Currency:
+			limits = append(limits, limit)
+		}
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	accountUsage := s.limitUsage(func(accountID int64) bool {
+		return accountID == id
+	}, now)
+	ownerUsage := s.limitUsage(func(accountID int64) bool {
+		return s.accounts[accountID].Owner == account.Owner
+	}, now)
+
+	return db.CheckTransferLimits(limits, amount, accountUsage, ownerUsage)
+}
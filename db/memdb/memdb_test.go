@@ -0,0 +1,14 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.RunConformance(t, func(t *testing.T) db.Store {
+		return NewStore()
+	})
+}
@@ -0,0 +1,153 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// read all for aggregate
+func (s *Store) GetOutboxEventsByAggregate(ctx context.Context, aggregateType string, aggregateID int64) (*[]db.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []db.OutboxEvent
+	for _, event := range s.outbox {
+		if event.AggregateType == aggregateType && event.AggregateID == aggregateID {
+			events = append(events, event)
+		}
+	}
+
+	return &events, nil
+}
+
+// read (id)
+func (s *Store) GetOutboxEventByID(ctx context.Context, id int64) (*db.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range s.outbox {
+		if event.ID == id {
+			return &event, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// read all after id, in commit order (pagination)
+func (s *Store) GetOutboxEventsAfter(ctx context.Context, afterID, limit int64) (*[]db.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.outboxEventsAfter(afterID, limit)
+	return &events, nil
+}
+
+// read the id of the last event, 0 when there is none
+func (s *Store) GetLastOutboxEventID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.outbox) == 0 {
+		return 0, nil
+	}
+	return s.outbox[len(s.outbox)-1].ID, nil
+}
+
+// must hold mu
+func (s *Store) outboxEventsAfter(afterID, limit int64) []db.OutboxEvent {
+	var events []db.OutboxEvent
+	for _, event := range s.outbox {
+		if int64(len(events)) == limit {
+			break
+		}
+		if event.ID > afterID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// same contract as the Postgres store: one relay at a time, per aggregate order,
+// returns the number of published events and the joined publish errors.
+// publish runs without the store's lock so it may use the store.
+func (s *Store) PublishOutboxEvents(ctx context.Context, limit int64, publish func(ctx context.Context, event db.OutboxEvent) error) (int64, error) {
+	if !s.relay.TryLock() {
+		return 0, nil
+	}
+	defer s.relay.Unlock()
+
+	s.mu.Lock()
+	var events []db.OutboxEvent
+	for _, event := range s.outbox {
+		if int64(len(events)) == limit {
+			break
+		}
+		if event.PublishedAt == nil {
+			events = append(events, event)
+		}
+	}
+	s.mu.Unlock()
+
+	published := map[int64]bool{}
+	var publishErr error
+	blocked := make(map[string]bool)
+
+	for _, event := range events {
+		aggregate := fmt.Sprintf("%s/%d", event.AggregateType, event.AggregateID)
+		if blocked[aggregate] {
+			continue
+		}
+
+		if err := publish(ctx, event); err != nil {
+			blocked[aggregate] = true
+			publishErr = errors.Join(publishErr, fmt.Errorf("event %d: %w", event.ID, err))
+			continue
+		}
+
+		published[event.ID] = true
+	}
+
+	s.mu.Lock()
+	publishedAt := now()
+	for i := range s.outbox {
+		if published[s.outbox[i].ID] && s.outbox[i].PublishedAt == nil {
+			s.outbox[i].PublishedAt = &publishedAt
+		}
+	}
+	s.mu.Unlock()
+
+	return int64(len(published)), publishErr
+}
+
+// hand every event added from now on to handle, in order
+// blocks until ctx is done or handle fails
+func (s *Store) ListenOutboxEvents(ctx context.Context, handle func(ctx context.Context, event db.OutboxEvent) error) error {
+	s.mu.Lock()
+	lastID := s.sequences["outbox"]
+	s.mu.Unlock()
+
+	for {
+		s.mu.Lock()
+		events := s.outboxEventsAfter(lastID, -1)
+		changed := s.outboxChanged
+		s.mu.Unlock()
+
+		for _, event := range events {
+			if err := handle(ctx, event); err != nil {
+				return err
+			}
+			lastID = event.ID
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
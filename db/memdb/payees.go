@@ -0,0 +1,108 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+var errNicknameLength = errors.New(`new row for relation "payees" violates check constraint "payee_nickname_length"`)
+
+// must hold mu
+func (s *Store) checkPayee(payee db.Payee) error {
+	if length := utf8.RuneCountInString(payee.Nickname); length < 1 || length > db.MaxNicknameLength {
+		return errNicknameLength
+	}
+
+	for _, other := range s.payees {
+		if other.ID != payee.ID && other.Owner == payee.Owner && (other.Nickname == payee.Nickname || other.AccountID == payee.AccountID) {
+			return db.ErrDuplicatePayee
+		}
+	}
+	return nil
+}
+
+// create, sql.ErrNoRows when the account does not exist
+func (s *Store) CreatePayee(ctx context.Context, owner string, accountID int64, nickname string) (*db.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[accountID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	payee := db.Payee{Owner: owner, AccountID: accountID, Nickname: nickname, CreatedAt: now()}
+	if err := s.checkPayee(payee); err != nil {
+		return nil, err
+	}
+
+	payee.ID = s.nextID("payees")
+	s.payees[payee.ID] = payee
+
+	return &payee, nil
+}
+
+// read (id)
+func (s *Store) GetPayeeByID(ctx context.Context, id int64) (*db.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payee, ok := s.payees[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &payee, nil
+}
+
+// read (owner)
+func (s *Store) GetPayeesByOwner(ctx context.Context, owner string) (*[]db.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payees := []db.Payee{}
+	for _, payee := range s.payees {
+		if payee.Owner == owner {
+			payees = append(payees, payee)
+		}
+	}
+	sort.Slice(payees, func(i, j int) bool { return payees[i].ID < payees[j].ID })
+
+	return &payees, nil
+}
+
+// update nickname
+func (s *Store) UpdatePayeeNickname(ctx context.Context, id int64, nickname string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payee, ok := s.payees[id]
+	if !ok {
+		return 0, nil
+	}
+
+	payee.Nickname = nickname
+	if err := s.checkPayee(payee); err != nil {
+		return 0, err
+	}
+	s.payees[id] = payee
+
+	return 1, nil
+}
+
+// delete
+func (s *Store) DeletePayeeByID(ctx context.Context, id int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.payees[id]; !ok {
+		return 0, nil
+	}
+	delete(s.payees, id)
+
+	return 1, nil
+}
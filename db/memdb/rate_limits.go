@@ -0,0 +1,47 @@
+package memdb
+
+import (
+	"context"
+	"time"
+)
+
+type rateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// same refill as the Postgres store
+func (s *Store) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int64) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	tokens := float64(burst)
+	if bucket, ok := s.rateLimitBuckets[key]; ok {
+		tokens = min(float64(burst), bucket.tokens+max(0, now.Sub(bucket.updatedAt).Seconds())*rate)
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	s.rateLimitBuckets[key] = rateLimitBucket{tokens: tokens, updatedAt: now}
+
+	return tokens, allowed, nil
+}
+
+// delete buckets nothing was taken from for idle
+func (s *Store) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range s.rateLimitBuckets {
+		if time.Since(bucket.updatedAt) > idle {
+			delete(s.rateLimitBuckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
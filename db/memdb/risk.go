@@ -0,0 +1,78 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+var (
+	errRiskDecision        = errors.New(`new row for relation "risk_assessments" violates check constraint "risk_assessment_decision"`)
+	errDuplicateAssessment = errors.New(`duplicate key value violates unique constraint "risk_assessments_transfer_id_idx"`)
+)
+
+// create
+func (s *Store) CreateRiskAssessment(ctx context.Context, assessment db.RiskAssessment) (*db.RiskAssessment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch assessment.Decision {
+	case db.RiskAllow, db.RiskReview, db.RiskDeny:
+	default:
+		return nil, errRiskDecision
+	}
+
+	if assessment.TransferID != nil {
+		if _, ok := s.transfers[*assessment.TransferID]; !ok {
+			return nil, foreignKeyError("risk_assessments", "risk_assessments_transfer_id_fkey")
+		}
+		for _, existing := range s.assessments {
+			if existing.TransferID != nil && *existing.TransferID == *assessment.TransferID {
+				return nil, errDuplicateAssessment
+			}
+		}
+	}
+
+	assessment = *cloneAssessment(assessment)
+	assessment.ID = s.nextID("risk_assessments")
+	assessment.CreatedAt = now()
+	s.assessments[assessment.ID] = assessment
+
+	return cloneAssessment(assessment), nil
+}
+
+// read (id)
+func (s *Store) GetRiskAssessmentByID(ctx context.Context, id int64) (*db.RiskAssessment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assessment, ok := s.assessments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return cloneAssessment(assessment), nil
+}
+
+// read (transfer id)
+func (s *Store) GetRiskAssessmentByTransferID(ctx context.Context, transferID int64) (*db.RiskAssessment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, assessment := range s.assessments {
+		if assessment.TransferID != nil && *assessment.TransferID == transferID {
+			return cloneAssessment(assessment), nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// copy that shares no memory with the stored assessment
+func cloneAssessment(assessment db.RiskAssessment) *db.RiskAssessment {
+	assessment.TransferID = cloneID(assessment.TransferID)
+	assessment.Reasons = append(db.RiskReasons{}, assessment.Reasons...)
+	return &assessment
+}
@@ -0,0 +1,99 @@
+package memdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// whether a transaction passes filter's conditions other than account and counterparty
+func matches(filter db.TransactionFilter, transaction db.Transaction) bool {
+	amount := transaction.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+
+	switch {
+	case filter.CreatedFrom != nil && transaction.CreatedAt.Before(*filter.CreatedFrom),
+		filter.CreatedTo != nil && !transaction.CreatedAt.Before(*filter.CreatedTo),
+		filter.MinAmount != nil && amount < *filter.MinAmount,
+		filter.MaxAmount != nil && amount > *filter.MaxAmount,
+		filter.Direction != "" && transaction.Direction != filter.Direction,
+		filter.Reference != "" && transaction.ExternalReference != filter.Reference,
+		filter.Memo != "" && !strings.Contains(strings.ToLower(transaction.Description), strings.ToLower(filter.Memo)):
+		return false
+	}
+	return true
+}
+
+// must hold mu
+func (s *Store) transferTransactions(filter db.TransactionFilter) []db.Transaction {
+	var transactions []db.Transaction
+	for _, transfer := range s.transfers {
+		transaction := db.Transaction{Type: db.TransactionTransfer, ID: transfer.ID, AccountID: filter.AccountID, Details: transfer.Details, CreatedAt: transfer.CreatedAt}
+
+		var counterparty int64
+		switch filter.AccountID {
+		case transfer.FromAccountID:
+			counterparty = transfer.ToAccountID
+			transaction.Direction, transaction.Amount = db.DirectionOutgoing, -transfer.Amount
+		case transfer.ToAccountID:
+			counterparty = transfer.FromAccountID
+			transaction.Direction, transaction.Amount = db.DirectionIncoming, transfer.Amount
+		default:
+			continue
+		}
+		transaction.CounterpartyID = &counterparty
+
+		if filter.CounterpartyID != nil && counterparty != *filter.CounterpartyID {
+			continue
+		}
+		if matches(filter, transaction) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions
+}
+
+// must hold mu
+func (s *Store) entryTransactions(filter db.TransactionFilter) []db.Transaction {
+	var transactions []db.Transaction
+	for _, entry := range s.entries {
+		if entry.AccountID != filter.AccountID {
+			continue
+		}
+
+		transaction := db.Transaction{Type: db.TransactionEntry, ID: entry.ID, AccountID: entry.AccountID, Direction: db.DirectionIncoming, Amount: entry.Amount, Details: entry.Details, CreatedAt: entry.CreatedAt}
+		if entry.Amount < 0 {
+			transaction.Direction = db.DirectionOutgoing
+		}
+
+		if matches(filter, transaction) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions
+}
+
+// read filter's transactions (keyset pagination)
+func (s *Store) SearchTransactions(ctx context.Context, filter db.TransactionFilter, page db.Page) (*[]db.Transaction, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transactions []db.Transaction
+	if filter.Type == db.TransactionTransfer {
+		transactions = s.transferTransactions(filter)
+	} else {
+		transactions = s.entryTransactions(filter)
+	}
+
+	transactions = keysetPage(transactions, func(transaction db.Transaction) db.PageKey {
+		return db.PageKey{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+	}, page)
+	return &transactions, nil
+}
@@ -0,0 +1,178 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+const businessDateLayout = "2006-01-02"
+
+// date part of t as midnight UTC, the way date columns are scanned
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// whether a posting at t falls into a closed business day, must hold mu
+func (s *Store) inClosedBusinessDay(t time.Time) bool {
+	for _, day := range s.businessDays {
+		location, err := time.LoadLocation(day.Timezone)
+		if err != nil {
+			continue
+		}
+		if !day.BusinessDate.Before(db.BusinessDate(t, location)) {
+			return true
+		}
+	}
+	return false
+}
+
+// same steps as the Postgres store: if already closed return it,
+// else record the day, snapshot every account that existed before the cutoff and total per currency
+func (s *Store) CloseBusinessDay(ctx context.Context, businessDate time.Time, location *time.Location) (*db.BusinessDay, error) {
+	if !businessDate.Before(db.BusinessDate(time.Now(), location)) {
+		return nil, fmt.Errorf("business day %s has not ended yet in %s", businessDate.Format(businessDateLayout), location)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	businessDate = dateOf(businessDate)
+	date := businessDate.Format(businessDateLayout)
+	if day, ok := s.businessDays[date]; ok {
+		return &day, nil
+	}
+
+	year, month, dayOfMonth := businessDate.Date()
+	cutoff := time.Date(year, month, dayOfMonth+1, 0, 0, 0, 0, location)
+
+	// everything posted at or after the cutoff is taken back out of the current balance
+	postedSince := map[int64]int64{}
+	for _, entry := range s.entries {
+		if !entry.CreatedAt.Before(cutoff) {
+			postedSince[entry.AccountID] += entry.Amount
+		}
+	}
+
+	closedAt := now()
+	snapshots := map[int64]db.BalanceSnapshot{}
+	totals := map[string]*db.CurrencyTotal{}
+	for _, account := range s.accounts {
+		if !account.CreatedAt.Before(cutoff) {
+			continue
+		}
+
+		snapshot := db.BalanceSnapshot{
+			AccountID:      account.ID,
+			BusinessDate:   businessDate,
+			ClosingBalance: account.Balance - postedSince[account.ID],
+			Currency:       account.Currency,
+			CreatedAt:      closedAt,
+		}
+		snapshots[account.ID] = snapshot
+
+		total, ok := totals[account.Currency]
+		if !ok {
+			total = &db.CurrencyTotal{BusinessDate: businessDate, Currency: account.Currency}
+			totals[account.Currency] = total
+		}
+		total.TotalBalance += snapshot.ClosingBalance
+		total.AccountCount++
+	}
+
+	var currencyTotals []db.CurrencyTotal
+	for _, total := range totals {
+		currencyTotals = append(currencyTotals, *total)
+	}
+	sort.Slice(currencyTotals, func(i, j int) bool { return currencyTotals[i].Currency < currencyTotals[j].Currency })
+
+	day := db.BusinessDay{BusinessDate: businessDate, Timezone: location.String(), AccountCount: int64(len(snapshots)), ClosedAt: closedAt}
+	s.businessDays[date] = day
+	s.snapshots[date] = snapshots
+	s.totals[date] = currencyTotals
+
+	return &day, nil
+}
+
+// read (business date)
+func (s *Store) GetBusinessDay(ctx context.Context, businessDate time.Time) (*db.BusinessDay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day, ok := s.businessDays[businessDate.Format(businessDateLayout)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &day, nil
+}
+
+// read (most recently closed)
+func (s *Store) GetLastClosedBusinessDay(ctx context.Context) (*db.BusinessDay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last *db.BusinessDay
+	for _, day := range s.businessDays {
+		day := day
+		if last == nil || day.BusinessDate.After(last.BusinessDate) {
+			last = &day
+		}
+	}
+	if last == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return last, nil
+}
+
+// read (account_id, business date)
+func (s *Store) GetBalanceSnapshot(ctx context.Context, accountID int64, businessDate time.Time) (*db.BalanceSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, ok := s.snapshots[businessDate.Format(businessDateLayout)][accountID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &snapshot, nil
+}
+
+// read latest snapshot on or before business date (as-of balance)
+func (s *Store) GetBalanceSnapshotAsOf(ctx context.Context, accountID int64, businessDate time.Time) (*db.BalanceSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	businessDate = dateOf(businessDate)
+
+	var latest *db.BalanceSnapshot
+	for _, snapshots := range s.snapshots {
+		snapshot, ok := snapshots[accountID]
+		if !ok || snapshot.BusinessDate.After(businessDate) {
+			continue
+		}
+		if latest == nil || snapshot.BusinessDate.After(latest.BusinessDate) {
+			latest = &snapshot
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return latest, nil
+}
+
+// read all currency totals for business date
+func (s *Store) GetCurrencyTotals(ctx context.Context, businessDate time.Time) (*[]db.CurrencyTotal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totals := append([]db.CurrencyTotal(nil), s.totals[businessDate.Format(businessDateLayout)]...)
+	return &totals, nil
+}
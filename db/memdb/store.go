@@ -0,0 +1,126 @@
+// Package memdb is an in-memory db.Store for fast tests and local demos.
+// It keeps the semantics of the Postgres store: nonnegative balances, foreign keys,
+// id ordering, closed business days, outbox events and atomic transfers.
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+var _ db.Store = (*Store)(nil)
+
+// Store keeps every table in maps guarded by one mutex, so each method is atomic.
+type Store struct {
+	mu sync.Mutex
+
+	accounts      map[int64]db.Account
+	entries       map[int64]db.Entry
+	transfers     map[int64]db.Transfer
+	businessDays  map[string]db.BusinessDay
+	snapshots     map[string]map[int64]db.BalanceSnapshot // business date -> account id
+	totals        map[string][]db.CurrencyTotal
+	outbox        []db.OutboxEvent
+	subscriptions map[int64]db.WebhookSubscription
+	deliveries    map[int64]db.WebhookDelivery
+	payees        map[int64]db.Payee
+	limits        map[int64]db.TransferLimit
+	assessments   map[int64]db.RiskAssessment
+	requests      map[int64]db.TransferRequest
+	requestEvents []db.TransferRequestEvent
+	users         map[string]db.User
+	apiKeys       map[int64]db.APIKey
+	nonces        map[int64]map[string]time.Time // key id -> nonce -> expiry
+
+	rateLimitBuckets map[string]rateLimitBucket
+
+	// last id handed out per table, like bigserial
+	sequences map[string]int64
+
+	// closed and replaced whenever an outbox event is added
+	outboxChanged chan struct{}
+	// held by the single active relay
+	relay sync.Mutex
+}
+
+func NewStore() *Store {
+	return &Store{
+		accounts:      map[int64]db.Account{},
+		entries:       map[int64]db.Entry{},
+		transfers:     map[int64]db.Transfer{},
+		businessDays:  map[string]db.BusinessDay{},
+		snapshots:     map[string]map[int64]db.BalanceSnapshot{},
+		totals:        map[string][]db.CurrencyTotal{},
+		subscriptions: map[int64]db.WebhookSubscription{},
+		deliveries:    map[int64]db.WebhookDelivery{},
+		payees:        map[int64]db.Payee{},
+		limits:        map[int64]db.TransferLimit{},
+		assessments:   map[int64]db.RiskAssessment{},
+		requests:      map[int64]db.TransferRequest{},
+		users:         map[string]db.User{},
+		apiKeys:       map[int64]db.APIKey{},
+		nonces:        map[int64]map[string]time.Time{},
+
+		rateLimitBuckets: map[string]rateLimitBucket{},
+		sequences:        map[string]int64{},
+		outboxChanged:    make(chan struct{}),
+	}
+}
+
+// next id of table, must hold mu
+func (s *Store) nextID(table string) int64 {
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+// timestamps are stored with the precision of timestamptz
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// add an outbox event, must hold mu
+func (s *Store) addOutboxEvent(aggregateType string, aggregateID int64, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	id := s.nextID("outbox")
+	// writes hold mu until they are done, so ids already follow commit order
+	s.outbox = append(s.outbox, db.OutboxEvent{
+		ID:            id,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+		CreatedAt:     now(),
+		TxID:          id,
+	})
+
+	close(s.outboxChanged)
+	s.outboxChanged = make(chan struct{})
+	return nil
+}
+
+// the in-memory schema always matches the code
+func (s *Store) GetSchemaVersion(ctx context.Context) (int64, bool, error) {
+	return db.SchemaVersion, false, nil
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (s *Store) Close() error {
+	return nil
+}
+
+// error of a violated foreign key, worded like Postgres'
+func foreignKeyError(table, constraint string) error {
+	return fmt.Errorf("insert, update or delete on table %q violates foreign key constraint %q", table, constraint)
+}
@@ -0,0 +1,263 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+var errRequestAmount = errors.New(`new row for relation "transfer_requests" violates check constraint "transfer_request_amount_positive"`)
+
+// create a pending request and its submitted event
+func (s *Store) CreateTransferRequest(ctx context.Context, request db.TransferRequest) (*db.TransferRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[request.FromAccountID]; !ok {
+		return nil, foreignKeyError("transfer_requests", "transfer_requests_from_account_id_fkey")
+	}
+	if _, ok := s.accounts[request.ToAccountID]; !ok {
+		return nil, foreignKeyError("transfer_requests", "transfer_requests_to_account_id_fkey")
+	}
+	if request.RiskAssessmentID != nil {
+		if _, ok := s.assessments[*request.RiskAssessmentID]; !ok {
+			return nil, foreignKeyError("transfer_requests", "transfer_requests_risk_assessment_id_fkey")
+		}
+	}
+	if request.Amount <= 0 {
+		return nil, errRequestAmount
+	}
+
+	created := db.TransferRequest{
+		ID:               s.nextID("transfer_requests"),
+		FromAccountID:    request.FromAccountID,
+		ToAccountID:      request.ToAccountID,
+		Amount:           request.Amount,
+		Details:          cloneDetails(request.Details),
+		Initiator:        request.Initiator,
+		HoldReason:       request.HoldReason,
+		RiskAssessmentID: cloneID(request.RiskAssessmentID),
+		Status:           db.TransferRequestPending,
+		ExpiresAt:        request.ExpiresAt.Truncate(time.Microsecond),
+		CreatedAt:        now(),
+	}
+	s.requests[created.ID] = created
+	s.addTransferRequestEvent(created.ID, db.TransferRequestSubmitted, created.Initiator, created.HoldReason)
+
+	return cloneRequest(created), nil
+}
+
+// read (id)
+func (s *Store) GetTransferRequestByID(ctx context.Context, id int64) (*db.TransferRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request, ok := s.requests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return cloneRequest(request), nil
+}
+
+// read (status, keyset page), every status when empty
+func (s *Store) ListTransferRequests(ctx context.Context, status string, page db.Page) (*[]db.TransferRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []db.TransferRequest
+	for _, request := range s.requests {
+		if status == "" || request.Status == status {
+			requests = append(requests, *cloneRequest(request))
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+
+	requests = keysetPage(requests, func(request db.TransferRequest) db.PageKey {
+		return db.PageKey{CreatedAt: request.CreatedAt, ID: request.ID}
+	}, page)
+	return &requests, nil
+}
+
+// read audit trail, oldest first
+func (s *Store) GetTransferRequestEvents(ctx context.Context, requestID int64) (*[]db.TransferRequestEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []db.TransferRequestEvent{}
+	for _, event := range s.requestEvents {
+		if event.RequestID == requestID {
+			events = append(events, event)
+		}
+	}
+
+	return &events, nil
+}
+
+// approve or reject a pending request, expiring it instead when it is past its expiry
+func (s *Store) DecideTransferRequest(ctx context.Context, id int64, reviewer, status, note string) (*db.TransferRequest, error) {
+	if status != db.TransferRequestApproved && status != db.TransferRequestRejected {
+		return nil, fmt.Errorf("transfer requests are approved or rejected, not %s", status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.decideTransferRequest(id, reviewer, status, note)
+}
+
+// record the outcome of an approved request's transfer
+func (s *Store) FinishTransferRequest(ctx context.Context, id int64, transferID *int64, failure string) (*db.TransferRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishTransferRequest(id, transferID, failure)
+}
+
+// approve a pending request and make its transfer at once; a transfer that cannot be made
+// is undone alone and fails the request with the reason
+func (s *Store) ApproveTransferRequest(ctx context.Context, id int64, reviewer, note string) (*db.TransferRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request, err := s.decideTransferRequest(id, reviewer, db.TransferRequestApproved, note)
+	if err != nil {
+		return nil, err
+	}
+
+	rollback := s.savepoint(request.FromAccountID, request.ToAccountID)
+
+	result, err := s.transferMoney(request.FromAccountID, request.ToAccountID, request.Amount, request.Details)
+	if err != nil {
+		rollback()
+		return s.finishTransferRequest(id, nil, err.Error())
+	}
+
+	return s.finishTransferRequest(id, &result.TransferRecord.ID, "")
+}
+
+// must hold mu
+func (s *Store) decideTransferRequest(id int64, reviewer, status, note string) (*db.TransferRequest, error) {
+	request, ok := s.requests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	if request.Status != db.TransferRequestPending {
+		return nil, fmt.Errorf("%w: it is %s", db.ErrRequestNotPending, request.Status)
+	}
+	if reviewer == request.Initiator {
+		return nil, db.ErrSelfReview
+	}
+	if s.expireTransferRequests(id) > 0 {
+		return nil, db.ErrRequestExpired
+	}
+
+	decidedAt := now()
+	request.Status = status
+	request.Reviewer = &reviewer
+	request.DecidedAt = &decidedAt
+	s.requests[id] = request
+	s.addTransferRequestEvent(id, status, reviewer, note)
+
+	return cloneRequest(request), nil
+}
+
+// must hold mu
+func (s *Store) finishTransferRequest(id int64, transferID *int64, failure string) (*db.TransferRequest, error) {
+	request, ok := s.requests[id]
+	if !ok || request.Status != db.TransferRequestApproved {
+		return nil, db.ErrRequestNotApproved
+	}
+
+	status, note := db.TransferRequestFailed, failure
+	if transferID != nil {
+		if _, ok := s.transfers[*transferID]; !ok {
+			return nil, foreignKeyError("transfer_requests", "transfer_requests_transfer_id_fkey")
+		}
+		status, note = db.TransferRequestExecuted, fmt.Sprintf("transfer %d", *transferID)
+
+		if request.RiskAssessmentID != nil {
+			assessment := s.assessments[*request.RiskAssessmentID]
+			assessment.TransferID = cloneID(transferID)
+			s.assessments[assessment.ID] = assessment
+		}
+	}
+
+	request.Status = status
+	request.TransferID = cloneID(transferID)
+	request.Failure = failure
+	s.requests[id] = request
+	s.addTransferRequestEvent(id, status, *request.Reviewer, note)
+
+	return cloneRequest(request), nil
+}
+
+// expire every pending request past its expiry
+func (s *Store) ExpireTransferRequests(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.expireTransferRequests(0), nil
+}
+
+// expire pending requests past their expiry, only the one with id unless it is 0, must hold mu
+func (s *Store) expireTransferRequests(id int64) int64 {
+	var ids []int64
+	for _, request := range s.requests {
+		if request.Status == db.TransferRequestPending && !request.ExpiresAt.After(now()) && (id == 0 || request.ID == id) {
+			ids = append(ids, request.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, expired := range ids {
+		request := s.requests[expired]
+		request.Status = db.TransferRequestExpired
+		s.requests[expired] = request
+		s.addTransferRequestEvent(expired, db.TransferRequestExpired, db.SystemActor, "")
+	}
+
+	return int64(len(ids))
+}
+
+// append to the audit trail, must hold mu
+func (s *Store) addTransferRequestEvent(requestID int64, action, actor, note string) {
+	s.requestEvents = append(s.requestEvents, db.TransferRequestEvent{
+		ID:        s.nextID("transfer_request_events"),
+		RequestID: requestID,
+		Action:    action,
+		Actor:     actor,
+		Note:      note,
+		CreatedAt: now(),
+	})
+}
+
+func cloneID(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	clone := *id
+	return &clone
+}
+
+// copy that shares no memory with the stored request
+func cloneRequest(request db.TransferRequest) *db.TransferRequest {
+	request.Details = cloneDetails(request.Details)
+	request.RiskAssessmentID = cloneID(request.RiskAssessmentID)
+	request.TransferID = cloneID(request.TransferID)
+	if request.Reviewer != nil {
+		reviewer := *request.Reviewer
+		request.Reviewer = &reviewer
+	}
+	if request.DecidedAt != nil {
+		decidedAt := *request.DecidedAt
+		request.DecidedAt = &decidedAt
+	}
+	return &request
+}
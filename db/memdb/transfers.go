@@ -0,0 +1,197 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// create
+func (s *Store) CreateTransfer(ctx context.Context, from_account_id, to_account_id, amount int64, details db.Details) (*db.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createTransfer(from_account_id, to_account_id, amount, details)
+}
+
+// must hold mu
+func (s *Store) createTransfer(from_account_id, to_account_id, amount int64, details db.Details) (*db.Transfer, error) {
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+	from, ok := s.accounts[from_account_id]
+	if !ok {
+		return nil, foreignKeyError("transfers", "transfers_from_account_id_fkey")
+	}
+	to, ok := s.accounts[to_account_id]
+	if !ok {
+		return nil, foreignKeyError("transfers", "transfers_to_account_id_fkey")
+	}
+	if from.Frozen || to.Frozen {
+		return nil, db.ErrAccountFrozen
+	}
+
+	transfer := db.Transfer{FromAccountID: from_account_id, ToAccountID: to_account_id, Amount: amount, Details: cloneDetails(details), CreatedAt: now()}
+	if s.inClosedBusinessDay(transfer.CreatedAt) {
+		return nil, db.ErrBusinessDayClosed
+	}
+	if _, err := s.transferByExternalReference(from_account_id, details.ExternalReference); err == nil {
+		return nil, db.ErrDuplicateReference
+	}
+
+	transfer.ID = s.nextID("transfers")
+	s.transfers[transfer.ID] = transfer
+
+	return &transfer, nil
+}
+
+// read (id)
+func (s *Store) GetTransferByID(ctx context.Context, id int64) (*db.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, ok := s.transfers[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &transfer, nil
+}
+
+// read (from_account_id, external_reference)
+func (s *Store) GetTransferByExternalReference(ctx context.Context, from_account_id int64, external_reference string) (*db.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.transferByExternalReference(from_account_id, external_reference)
+}
+
+// must hold mu
+func (s *Store) transferByExternalReference(from_account_id int64, external_reference string) (*db.Transfer, error) {
+	if external_reference == "" {
+		return nil, sql.ErrNoRows
+	}
+
+	for _, transfer := range s.transfers {
+		if transfer.FromAccountID == from_account_id && transfer.ExternalReference == external_reference {
+			return &transfer, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// read (from_account_id OR to_account_id)
+// (-1 if don't want to search for from exor to)
+// keyset paginated
+func (s *Store) GetTransfersFromTo(ctx context.Context, from_account_id, to_account_id int64, page db.Page) (*[]db.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transfers []db.Transfer
+	for _, transfer := range s.transfers {
+		if transfer.FromAccountID == from_account_id || transfer.ToAccountID == to_account_id {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	transfers = keysetPage(transfers, func(transfer db.Transfer) db.PageKey {
+		return db.PageKey{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
+	}, page)
+	return &transfers, nil
+}
+
+// same steps as the Postgres store, all or nothing:
+// transfer record, both entries, both balances and the TransferCompleted event
+func (s *Store) TransferMoney(ctx context.Context, from_account_id, to_account_id, amount int64, details db.Details) (*db.TransferTxResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rollback := s.savepoint(from_account_id, to_account_id)
+
+	result, err := s.transferMoney(from_account_id, to_account_id, amount, details)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// must hold mu
+func (s *Store) transferMoney(from_account_id, to_account_id, amount int64, details db.Details) (*db.TransferTxResult, error) {
+	if err := s.checkTransferLimits(from_account_id, amount); err != nil {
+		return nil, err
+	}
+
+	transferRecord, err := s.createTransfer(from_account_id, to_account_id, amount, details)
+	if err != nil {
+		return nil, err
+	}
+
+	fromEntry, err := s.createEntry(from_account_id, -amount, details)
+	if err != nil {
+		return nil, err
+	}
+
+	toEntry, err := s.createEntry(to_account_id, amount, details)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := s.addAccountBalance(from_account_id, -amount)
+	if err != nil {
+		return nil, err
+	}
+
+	toAccount, err := s.addAccountBalance(to_account_id, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &db.TransferTxResult{
+		TransferRecord:  *transferRecord,
+		FromEntryRecord: *fromEntry,
+		ToEntryRecord:   *toEntry,
+		FromAccount:     *fromAccount,
+		ToAccount:       *toAccount,
+	}
+
+	if err := s.addOutboxEvent(db.AggregateTransfer, transferRecord.ID, db.EventTransferCompleted, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// stored rows keep their own metadata map
+func cloneDetails(details db.Details) db.Details {
+	details.Metadata = maps.Clone(details.Metadata)
+	return details
+}
+
+// remember what transferMoney between accountIDs writes; the returned func undoes it.
+// that is exactly the accounts' rows plus the entries, transfers and outbox events added after
+// this call, nothing else: a step that comes to write another table has to be undone here too.
+// ids handed out meanwhile stay used, like a rolled back sequence. must hold mu
+func (s *Store) savepoint(accountIDs ...int64) (rollback func()) {
+	accounts := map[int64]db.Account{}
+	for _, id := range accountIDs {
+		if account, ok := s.accounts[id]; ok {
+			accounts[id] = account
+		}
+	}
+	lastEntryID, lastTransferID, outbox := s.sequences["entries"], s.sequences["transfers"], len(s.outbox)
+
+	return func() {
+		maps.Copy(s.accounts, accounts)
+		for id := lastEntryID + 1; id <= s.sequences["entries"]; id++ {
+			delete(s.entries, id)
+		}
+		for id := lastTransferID + 1; id <= s.sequences["transfers"]; id++ {
+			delete(s.transfers, id)
+		}
+		s.outbox = s.outbox[:outbox]
+	}
+}
@@ -0,0 +1,60 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// create or change the role of username
+func (s *Store) SetUserRole(ctx context.Context, username, role string) (*db.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("%w: username is required", db.ErrInvalidRole)
+	}
+	if !slices.Contains(db.Roles, role) {
+		return nil, fmt.Errorf("%w: unknown role %q", db.ErrInvalidRole, role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		user = db.User{Username: username, CreatedAt: now()}
+	}
+	user.Role = role
+	s.users[username] = user
+
+	return &user, nil
+}
+
+// read (username)
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &user, nil
+}
+
+// read all, by username
+func (s *Store) GetUsers(ctx context.Context) (*[]db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []db.User
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
+	return &users, nil
+}
@@ -0,0 +1,243 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// create
+func (s *Store) CreateWebhookSubscription(ctx context.Context, owner, url string, eventTypes []string, secret string) (*db.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription := db.WebhookSubscription{
+		ID:         s.nextID("webhook_subscriptions"),
+		Owner:      owner,
+		URL:        url,
+		EventTypes: slices.Clone(eventTypes),
+		Secret:     secret,
+		CreatedAt:  now(),
+	}
+	s.subscriptions[subscription.ID] = subscription
+
+	return &subscription, nil
+}
+
+// read (id)
+func (s *Store) GetWebhookSubscriptionByID(ctx context.Context, id int64) (*db.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, ok := s.subscriptions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &subscription, nil
+}
+
+// read (owner)
+func (s *Store) GetWebhookSubscriptionsByOwner(ctx context.Context, owner string) (*[]db.WebhookSubscription, error) {
+	return s.webhookSubscriptions(func(subscription db.WebhookSubscription) bool {
+		return subscription.Owner == owner
+	}), nil
+}
+
+// read (owner subscribed to event type)
+func (s *Store) GetWebhookSubscriptionsForEvent(ctx context.Context, owner, eventType string) (*[]db.WebhookSubscription, error) {
+	return s.webhookSubscriptions(func(subscription db.WebhookSubscription) bool {
+		return subscription.Owner == owner && slices.Contains(subscription.EventTypes, eventType)
+	}), nil
+}
+
+// subscriptions matching filter ordered by id
+func (s *Store) webhookSubscriptions(filter func(subscription db.WebhookSubscription) bool) *[]db.WebhookSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subscriptions []db.WebhookSubscription
+	for _, subscription := range s.subscriptions {
+		if filter(subscription) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+
+	return &subscriptions
+}
+
+// delete (deliveries cascade)
+func (s *Store) DeleteWebhookSubscriptionByID(ctx context.Context, id int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[id]; !ok {
+		return 0, nil
+	}
+
+	delete(s.subscriptions, id)
+	for deliveryID, delivery := range s.deliveries {
+		if delivery.SubscriptionID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+
+	return 1, nil
+}
+
+// create (idempotent per subscription, event and event type)
+func (s *Store) CreateWebhookDelivery(ctx context.Context, subscriptionID, eventID int64, eventType string, payload json.RawMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[subscriptionID]; !ok {
+		return 0, foreignKeyError("webhook_deliveries", "webhook_deliveries_subscription_id_fkey")
+	}
+
+	for _, delivery := range s.deliveries {
+		if delivery.SubscriptionID == subscriptionID && delivery.EventID == eventID && delivery.EventType == eventType {
+			return 0, nil
+		}
+	}
+
+	createdAt := now()
+	delivery := db.WebhookDelivery{
+		ID:             s.nextID("webhook_deliveries"),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        slices.Clone(payload),
+		Status:         db.WebhookDeliveryPending,
+		NextAttemptAt:  createdAt,
+		CreatedAt:      createdAt,
+	}
+	s.deliveries[delivery.ID] = delivery
+
+	return 1, nil
+}
+
+// read (id)
+func (s *Store) GetWebhookDeliveryByID(ctx context.Context, id int64) (*db.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &delivery, nil
+}
+
+// read a keyset page of subscription_id's deliveries
+func (s *Store) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, page db.Page) (*[]db.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []db.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	deliveries = keysetPage(deliveries, func(delivery db.WebhookDelivery) db.PageKey {
+		return db.PageKey{CreatedAt: delivery.CreatedAt, ID: delivery.ID}
+	}, page)
+	return &deliveries, nil
+}
+
+// claim up to limit due deliveries; claimed ones are not due again until lease has passed
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, limit int64, lease time.Duration) (*[]db.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimedAt := now()
+
+	var due []db.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == db.WebhookDeliveryPending && !delivery.NextAttemptAt.After(claimedAt) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+
+	due = page(due, limit, 0)
+	for i := range due {
+		due[i].NextAttemptAt = claimedAt.Add(lease.Truncate(time.Millisecond))
+		s.deliveries[due[i].ID] = due[i]
+	}
+
+	return &due, nil
+}
+
+// update after a successful attempt
+func (s *Store) MarkWebhookDeliverySucceeded(ctx context.Context, id, statusCode int64) (int64, error) {
+	return s.updateWebhookDelivery(id, func(delivery *db.WebhookDelivery) {
+		deliveredAt := now()
+		delivery.Status = db.WebhookDeliverySucceeded
+		delivery.Attempts++
+		delivery.LastStatusCode = &statusCode
+		delivery.LastError = nil
+		delivery.DeliveredAt = &deliveredAt
+	})
+}
+
+// update after a failed attempt; dead lettered once attempts reaches maxAttempts
+// statusCode is 0 when no response was received
+func (s *Store) MarkWebhookDeliveryFailed(ctx context.Context, id, statusCode int64, lastError string, nextAttemptAt time.Time, maxAttempts int64) (int64, error) {
+	return s.updateWebhookDelivery(id, func(delivery *db.WebhookDelivery) {
+		delivery.Attempts++
+		delivery.Status = db.WebhookDeliveryPending
+		if delivery.Attempts >= maxAttempts {
+			delivery.Status = db.WebhookDeliveryDead
+		}
+		delivery.LastStatusCode = nil
+		if statusCode != 0 {
+			delivery.LastStatusCode = &statusCode
+		}
+		delivery.LastError = &lastError
+		delivery.NextAttemptAt = nextAttemptAt
+	})
+}
+
+// apply update to the delivery; 0 rows when it does not exist
+func (s *Store) updateWebhookDelivery(id int64, update func(delivery *db.WebhookDelivery)) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return 0, nil
+	}
+
+	update(&delivery)
+	s.deliveries[id] = delivery
+
+	return 1, nil
+}
+
+// update to deliver again from scratch
+func (s *Store) ReplayWebhookDelivery(ctx context.Context, id int64) (*db.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	delivery.Status = db.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now()
+	delivery.DeliveredAt = nil
+	s.deliveries[id] = delivery
+
+	return &delivery, nil
+}
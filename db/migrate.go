@@ -0,0 +1,273 @@
+// applying schema migrations from the binary
+// versions are recorded in schema_migrations the same way golang-migrate does
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// advisory lock key held while migrating (replicas included)
+const migrateLockKey = 33_0001
+
+var (
+	ErrSchemaDirty  = errors.New("schema is dirty")
+	ErrSchemaTooNew = errors.New("schema is newer than this binary")
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// one numbered schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// a migration and whether the database has it
+type MigrationStatus struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// applies embedded migrations to the database
+type Migrator struct {
+	conn       *sqlx.DB
+	migrations []Migration
+}
+
+// read NNNNNN_name.up.sql / NNNNNN_name.down.sql pairs from fsys
+// versions have to start at 1 and have no gaps
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		match := migrationFileName.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, file.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", migration.Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+func NewMigrator(conn *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{conn: conn, migrations: migrations}, nil
+}
+
+// version of the last migration known to the binary
+func (m *Migrator) Latest() int64 {
+	return int64(len(m.migrations))
+}
+
+// version recorded in the database, 0 when nothing is applied yet
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+	var exists bool
+	err = m.conn.GetContext(ctx, &exists, "SELECT to_regclass('schema_migrations') IS NOT NULL;")
+	if err != nil || !exists {
+		return 0, false, err
+	}
+
+	version, dirty, err = NewQueries(m.conn).GetSchemaVersion(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// every known migration and whether it is applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = MigrationStatus{Version: migration.Version, Name: migration.Name, Applied: migration.Version <= version}
+	}
+	return status, nil
+}
+
+// refuse to run against a dirty schema or one migrated by a newer binary
+// a schema that is behind is left to readiness to report
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	return m.check(version, dirty)
+}
+
+func (m *Migrator) check(version int64, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("%w: version %d failed halfway and has to be fixed by hand", ErrSchemaDirty, version)
+	}
+	if version > m.Latest() {
+		return fmt.Errorf("%w: version %d, latest known is %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	return nil
+}
+
+// apply every pending migration, returns the ones applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sqlx.Conn, version int64) error {
+		for _, migration := range m.migrations[version:] {
+			if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// revert the last steps migrations, returns the ones reverted
+func (m *Migrator) Down(ctx context.Context, steps int64) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withLock(ctx, func(conn *sqlx.Conn, version int64) error {
+		for ; steps > 0 && version > 0; steps-- {
+			migration := m.migrations[version-1]
+			if err := m.apply(ctx, conn, migration.Down, version-1); err != nil {
+				return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+			version--
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// hold the migrate lock on a dedicated connection while fn runs
+// fn gets the version found once the lock is held, so a replica waiting on another one sees its result
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn, version int64) error) error {
+	conn, err := m.conn.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// waiting for another replica and long migrations must not hit the statement timeout
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0;"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "RESET statement_timeout;")
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1);", migrateLockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", migrateLockKey)
+
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL);")
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRowxContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1;").Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if err := m.check(version, dirty); err != nil {
+		return err
+	}
+
+	return fn(conn, version)
+}
+
+// run one migration file and record the resulting version in the same transaction,
+// a failing file leaves both the schema and the version untouched
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, statements string, version int64) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "TRUNCATE schema_migrations;"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// like golang-migrate, no row means nothing is applied
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false);", version); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	schema "github.com/joelpatel/go-bank/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMigrator(t *testing.T) *Migrator {
+	migrator, err := NewMigrator(testStore(t).(*SQLStore).conn, schema.Migrations)
+	require.NoError(t, err)
+	return migrator
+}
+
+// The test database is migrated to the latest version before tests run.
+func TestMigratorStatus(t *testing.T) {
+	migrator := newTestMigrator(t)
+
+	version, dirty, err := migrator.Version(context.Background())
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, int64(SchemaVersion), version)
+	require.NoError(t, migrator.Check(context.Background()))
+
+	status, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, status, SchemaVersion)
+	for _, migration := range status {
+		require.True(t, migration.Applied)
+	}
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, applied)
+}
+
+// Reverting and re-applying the last migration should end at the same version.
+func TestMigratorDownUp(t *testing.T) {
+	migrator := newTestMigrator(t)
+
+	reverted, err := migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+
+	version, _, err := migrator.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(SchemaVersion-1), version)
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	require.Equal(t, int64(SchemaVersion), applied[0].Version)
+}
+
+// Concurrent replicas should apply pending migrations exactly once.
+func TestMigratorConcurrentUp(t *testing.T) {
+	migrator := newTestMigrator(t)
+	_, err := migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+
+	n := 3
+	results := make(chan []Migration, n)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			applied, err := migrator.Up(context.Background())
+			errs <- err
+			results <- applied
+		}()
+	}
+
+	total := 0
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		total += len(<-results)
+	}
+	require.Equal(t, 1, total)
+}
+
+// A schema migrated by a newer binary must be refused.
+func TestMigratorRefusesNewerSchema(t *testing.T) {
+	conn := testStore(t).(*SQLStore).conn
+	migrator := newTestMigrator(t)
+
+	setVersion := func(version int64) {
+		_, err := conn.Exec("UPDATE schema_migrations SET version = $1;", version)
+		require.NoError(t, err)
+	}
+	setVersion(SchemaVersion + 1)
+	defer setVersion(SchemaVersion)
+
+	require.ErrorIs(t, migrator.Check(context.Background()), ErrSchemaTooNew)
+	_, err := migrator.Up(context.Background())
+	require.ErrorIs(t, err, ErrSchemaTooNew)
+}
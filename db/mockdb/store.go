@@ -11,7 +11,9 @@ package mockdb
 
 import (
 	context "context"
+	json "encoding/json"
 	reflect "reflect"
+	time "time"
 
 	db "github.com/joelpatel/go-bank/db"
 	gomock "go.uber.org/mock/gomock"
@@ -55,6 +57,95 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1, arg2 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1, arg2)
 }
 
+// AdjustAccountBalance mocks base method.
+func (m *MockStore) AdjustAccountBalance(arg0 context.Context, arg1, arg2 int64, arg3, arg4 string) (*db.BalanceAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustAccountBalance", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*db.BalanceAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustAccountBalance indicates an expected call of AdjustAccountBalance.
+func (mr *MockStoreMockRecorder) AdjustAccountBalance(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustAccountBalance", reflect.TypeOf((*MockStore)(nil).AdjustAccountBalance), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ApproveTransferRequest mocks base method.
+func (m *MockStore) ApproveTransferRequest(arg0 context.Context, arg1 int64, arg2, arg3 string) (*db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveTransferRequest", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveTransferRequest indicates an expected call of ApproveTransferRequest.
+func (mr *MockStoreMockRecorder) ApproveTransferRequest(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveTransferRequest", reflect.TypeOf((*MockStore)(nil).ApproveTransferRequest), arg0, arg1, arg2, arg3)
+}
+
+// ClaimDueWebhookDeliveries mocks base method.
+func (m *MockStore) ClaimDueWebhookDeliveries(arg0 context.Context, arg1 int64, arg2 time.Duration) (*[]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueWebhookDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueWebhookDeliveries indicates an expected call of ClaimDueWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ClaimDueWebhookDeliveries(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ClaimDueWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// Close mocks base method.
+func (m *MockStore) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockStoreMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStore)(nil).Close))
+}
+
+// CloseBusinessDay mocks base method.
+func (m *MockStore) CloseBusinessDay(arg0 context.Context, arg1 time.Time, arg2 *time.Location) (*db.BusinessDay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseBusinessDay", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*db.BusinessDay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseBusinessDay indicates an expected call of CloseBusinessDay.
+func (mr *MockStoreMockRecorder) CloseBusinessDay(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseBusinessDay", reflect.TypeOf((*MockStore)(nil).CloseBusinessDay), arg0, arg1, arg2)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockStore) CreateAPIKey(arg0 context.Context, arg1 db.APIKey) (*db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(*db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStoreMockRecorder) CreateAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 string, arg2 int64, arg3 string) (*db.Account, error) {
 	m.ctrl.T.Helper()
@@ -71,33 +162,123 @@ func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1, arg2, arg3 any) *gomo
 }
 
 // CreateEntry mocks base method.
-func (m *MockStore) CreateEntry(arg0 context.Context, arg1, arg2 int64) (*db.Entry, error) {
+func (m *MockStore) CreateEntry(arg0 context.Context, arg1, arg2 int64, arg3 db.Details) (*db.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(*db.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateEntry indicates an expected call of CreateEntry.
-func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1, arg2, arg3)
+}
+
+// CreatePayee mocks base method.
+func (m *MockStore) CreatePayee(arg0 context.Context, arg1 string, arg2 int64, arg3 string) (*db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayee", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayee indicates an expected call of CreatePayee.
+func (mr *MockStoreMockRecorder) CreatePayee(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayee", reflect.TypeOf((*MockStore)(nil).CreatePayee), arg0, arg1, arg2, arg3)
+}
+
+// CreateRiskAssessment mocks base method.
+func (m *MockStore) CreateRiskAssessment(arg0 context.Context, arg1 db.RiskAssessment) (*db.RiskAssessment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRiskAssessment", arg0, arg1)
+	ret0, _ := ret[0].(*db.RiskAssessment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRiskAssessment indicates an expected call of CreateRiskAssessment.
+func (mr *MockStoreMockRecorder) CreateRiskAssessment(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRiskAssessment", reflect.TypeOf((*MockStore)(nil).CreateRiskAssessment), arg0, arg1)
 }
 
 // CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(arg0 context.Context, arg1, arg2, arg3 int64) (*db.Transfer, error) {
+func (m *MockStore) CreateTransfer(arg0 context.Context, arg1, arg2, arg3 int64, arg4 db.Details) (*db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(*db.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CreateTransferRequest mocks base method.
+func (m *MockStore) CreateTransferRequest(arg0 context.Context, arg1 db.TransferRequest) (*db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferRequest", arg0, arg1)
+	ret0, _ := ret[0].(*db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferRequest indicates an expected call of CreateTransferRequest.
+func (mr *MockStoreMockRecorder) CreateTransferRequest(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferRequest", reflect.TypeOf((*MockStore)(nil).CreateTransferRequest), arg0, arg1)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockStore) CreateWebhookDelivery(arg0 context.Context, arg1, arg2 int64, arg3 string, arg4 json.RawMessage) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockStoreMockRecorder) CreateWebhookDelivery(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).CreateWebhookDelivery), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockStore) CreateWebhookSubscription(arg0 context.Context, arg1, arg2 string, arg3 []string, arg4 string) (*db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockStoreMockRecorder) CreateWebhookSubscription(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockStore)(nil).CreateWebhookSubscription), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DecideTransferRequest mocks base method.
+func (m *MockStore) DecideTransferRequest(arg0 context.Context, arg1 int64, arg2, arg3, arg4 string) (*db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideTransferRequest", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecideTransferRequest indicates an expected call of DecideTransferRequest.
+func (mr *MockStoreMockRecorder) DecideTransferRequest(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideTransferRequest", reflect.TypeOf((*MockStore)(nil).DecideTransferRequest), arg0, arg1, arg2, arg3, arg4)
 }
 
 // DeleteAccountByID mocks base method.
@@ -115,6 +296,141 @@ func (mr *MockStoreMockRecorder) DeleteAccountByID(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountByID", reflect.TypeOf((*MockStore)(nil).DeleteAccountByID), arg0, arg1)
 }
 
+// DeleteIdleRateLimitBuckets mocks base method.
+func (m *MockStore) DeleteIdleRateLimitBuckets(arg0 context.Context, arg1 time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdleRateLimitBuckets", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdleRateLimitBuckets indicates an expected call of DeleteIdleRateLimitBuckets.
+func (mr *MockStoreMockRecorder) DeleteIdleRateLimitBuckets(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdleRateLimitBuckets", reflect.TypeOf((*MockStore)(nil).DeleteIdleRateLimitBuckets), arg0, arg1)
+}
+
+// DeletePayeeByID mocks base method.
+func (m *MockStore) DeletePayeeByID(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePayeeByID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePayeeByID indicates an expected call of DeletePayeeByID.
+func (mr *MockStoreMockRecorder) DeletePayeeByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePayeeByID", reflect.TypeOf((*MockStore)(nil).DeletePayeeByID), arg0, arg1)
+}
+
+// DeleteTransferLimitByID mocks base method.
+func (m *MockStore) DeleteTransferLimitByID(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTransferLimitByID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTransferLimitByID indicates an expected call of DeleteTransferLimitByID.
+func (mr *MockStoreMockRecorder) DeleteTransferLimitByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransferLimitByID", reflect.TypeOf((*MockStore)(nil).DeleteTransferLimitByID), arg0, arg1)
+}
+
+// DeleteWebhookSubscriptionByID mocks base method.
+func (m *MockStore) DeleteWebhookSubscriptionByID(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscriptionByID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhookSubscriptionByID indicates an expected call of DeleteWebhookSubscriptionByID.
+func (mr *MockStoreMockRecorder) DeleteWebhookSubscriptionByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscriptionByID", reflect.TypeOf((*MockStore)(nil).DeleteWebhookSubscriptionByID), arg0, arg1)
+}
+
+// ExpireTransferRequests mocks base method.
+func (m *MockStore) ExpireTransferRequests(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireTransferRequests", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireTransferRequests indicates an expected call of ExpireTransferRequests.
+func (mr *MockStoreMockRecorder) ExpireTransferRequests(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireTransferRequests", reflect.TypeOf((*MockStore)(nil).ExpireTransferRequests), arg0)
+}
+
+// FinishTransferRequest mocks base method.
+func (m *MockStore) FinishTransferRequest(arg0 context.Context, arg1 int64, arg2 *int64, arg3 string) (*db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishTransferRequest", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishTransferRequest indicates an expected call of FinishTransferRequest.
+func (mr *MockStoreMockRecorder) FinishTransferRequest(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishTransferRequest", reflect.TypeOf((*MockStore)(nil).FinishTransferRequest), arg0, arg1, arg2, arg3)
+}
+
+// GetAPIKeyByID mocks base method.
+func (m *MockStore) GetAPIKeyByID(arg0 context.Context, arg1 int64) (*db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByID indicates an expected call of GetAPIKeyByID.
+func (mr *MockStoreMockRecorder) GetAPIKeyByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByID", reflect.TypeOf((*MockStore)(nil).GetAPIKeyByID), arg0, arg1)
+}
+
+// GetAPIKeyByPrefix mocks base method.
+func (m *MockStore) GetAPIKeyByPrefix(arg0 context.Context, arg1 string) (*db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByPrefix", arg0, arg1)
+	ret0, _ := ret[0].(*db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByPrefix indicates an expected call of GetAPIKeyByPrefix.
+func (mr *MockStoreMockRecorder) GetAPIKeyByPrefix(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByPrefix", reflect.TypeOf((*MockStore)(nil).GetAPIKeyByPrefix), arg0, arg1)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockStore) GetAPIKeys(arg0 context.Context) (*[]db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", arg0)
+	ret0, _ := ret[0].(*[]db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockStoreMockRecorder) GetAPIKeys(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockStore)(nil).GetAPIKeys), arg0)
+}
+
 // GetAccountByID mocks base method.
 func (m *MockStore) GetAccountByID(arg0 context.Context, arg1 int64) (*db.Account, error) {
 	m.ctrl.T.Helper()
@@ -160,19 +476,79 @@ func (mr *MockStoreMockRecorder) GetAccountsByOwner(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsByOwner", reflect.TypeOf((*MockStore)(nil).GetAccountsByOwner), arg0, arg1)
 }
 
+// GetBalanceSnapshot mocks base method.
+func (m *MockStore) GetBalanceSnapshot(arg0 context.Context, arg1 int64, arg2 time.Time) (*db.BalanceSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceSnapshot", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*db.BalanceSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceSnapshot indicates an expected call of GetBalanceSnapshot.
+func (mr *MockStoreMockRecorder) GetBalanceSnapshot(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceSnapshot", reflect.TypeOf((*MockStore)(nil).GetBalanceSnapshot), arg0, arg1, arg2)
+}
+
+// GetBalanceSnapshotAsOf mocks base method.
+func (m *MockStore) GetBalanceSnapshotAsOf(arg0 context.Context, arg1 int64, arg2 time.Time) (*db.BalanceSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceSnapshotAsOf", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*db.BalanceSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceSnapshotAsOf indicates an expected call of GetBalanceSnapshotAsOf.
+func (mr *MockStoreMockRecorder) GetBalanceSnapshotAsOf(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceSnapshotAsOf", reflect.TypeOf((*MockStore)(nil).GetBalanceSnapshotAsOf), arg0, arg1, arg2)
+}
+
+// GetBusinessDay mocks base method.
+func (m *MockStore) GetBusinessDay(arg0 context.Context, arg1 time.Time) (*db.BusinessDay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBusinessDay", arg0, arg1)
+	ret0, _ := ret[0].(*db.BusinessDay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBusinessDay indicates an expected call of GetBusinessDay.
+func (mr *MockStoreMockRecorder) GetBusinessDay(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBusinessDay", reflect.TypeOf((*MockStore)(nil).GetBusinessDay), arg0, arg1)
+}
+
+// GetCurrencyTotals mocks base method.
+func (m *MockStore) GetCurrencyTotals(arg0 context.Context, arg1 time.Time) (*[]db.CurrencyTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrencyTotals", arg0, arg1)
+	ret0, _ := ret[0].(*[]db.CurrencyTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrencyTotals indicates an expected call of GetCurrencyTotals.
+func (mr *MockStoreMockRecorder) GetCurrencyTotals(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrencyTotals", reflect.TypeOf((*MockStore)(nil).GetCurrencyTotals), arg0, arg1)
+}
+
 // GetEntriesByAccountID mocks base method.
-func (m *MockStore) GetEntriesByAccountID(arg0 context.Context, arg1, arg2, arg3 int64) (*[]db.Entry, error) {
+func (m *MockStore) GetEntriesByAccountID(arg0 context.Context, arg1 int64, arg2 db.Page) (*[]db.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntriesByAccountID", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "GetEntriesByAccountID", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*[]db.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetEntriesByAccountID indicates an expected call of GetEntriesByAccountID.
-func (mr *MockStoreMockRecorder) GetEntriesByAccountID(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetEntriesByAccountID(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntriesByAccountID", reflect.TypeOf((*MockStore)(nil).GetEntriesByAccountID), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntriesByAccountID", reflect.TypeOf((*MockStore)(nil).GetEntriesByAccountID), arg0, arg1, arg2)
 }
 
 // GetEntryByID mocks base method.
@@ -190,107 +566,690 @@ func (mr *MockStoreMockRecorder) GetEntryByID(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryByID", reflect.TypeOf((*MockStore)(nil).GetEntryByID), arg0, arg1)
 }
 
-// GetTransferByID mocks base method.
-func (m *MockStore) GetTransferByID(arg0 context.Context, arg1 int64) (*db.Transfer, error) {
+// GetLastClosedBusinessDay mocks base method.
+func (m *MockStore) GetLastClosedBusinessDay(arg0 context.Context) (*db.BusinessDay, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransferByID", arg0, arg1)
-	ret0, _ := ret[0].(*db.Transfer)
+	ret := m.ctrl.Call(m, "GetLastClosedBusinessDay", arg0)
+	ret0, _ := ret[0].(*db.BusinessDay)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransferByID indicates an expected call of GetTransferByID.
-func (mr *MockStoreMockRecorder) GetTransferByID(arg0, arg1 any) *gomock.Call {
+// GetLastClosedBusinessDay indicates an expected call of GetLastClosedBusinessDay.
+func (mr *MockStoreMockRecorder) GetLastClosedBusinessDay(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferByID", reflect.TypeOf((*MockStore)(nil).GetTransferByID), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastClosedBusinessDay", reflect.TypeOf((*MockStore)(nil).GetLastClosedBusinessDay), arg0)
 }
 
-// GetTransfersFromTo mocks base method.
-func (m *MockStore) GetTransfersFromTo(arg0 context.Context, arg1, arg2, arg3, arg4 int64) (*[]db.Transfer, error) {
+// GetLastOutboxEventID mocks base method.
+func (m *MockStore) GetLastOutboxEventID(arg0 context.Context) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfersFromTo", arg0, arg1, arg2, arg3, arg4)
-	ret0, _ := ret[0].(*[]db.Transfer)
+	ret := m.ctrl.Call(m, "GetLastOutboxEventID", arg0)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfersFromTo indicates an expected call of GetTransfersFromTo.
-func (mr *MockStoreMockRecorder) GetTransfersFromTo(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+// GetLastOutboxEventID indicates an expected call of GetLastOutboxEventID.
+func (mr *MockStoreMockRecorder) GetLastOutboxEventID(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfersFromTo", reflect.TypeOf((*MockStore)(nil).GetTransfersFromTo), arg0, arg1, arg2, arg3, arg4)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastOutboxEventID", reflect.TypeOf((*MockStore)(nil).GetLastOutboxEventID), arg0)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(arg0 context.Context, arg1 string, arg2, arg3 int64) (*[]db.Account, error) {
+// GetOutboxEventByID mocks base method.
+func (m *MockStore) GetOutboxEventByID(arg0 context.Context, arg1 int64) (*db.OutboxEvent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1, arg2, arg3)
-	ret0, _ := ret[0].(*[]db.Account)
+	ret := m.ctrl.Call(m, "GetOutboxEventByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.OutboxEvent)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1, arg2, arg3 any) *gomock.Call {
+// GetOutboxEventByID indicates an expected call of GetOutboxEventByID.
+func (mr *MockStoreMockRecorder) GetOutboxEventByID(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxEventByID", reflect.TypeOf((*MockStore)(nil).GetOutboxEventByID), arg0, arg1)
 }
 
-// TransferMoney mocks base method.
-func (m *MockStore) TransferMoney(arg0 context.Context, arg1, arg2, arg3 int64) (*db.TransferTxResult, error) {
+// GetOutboxEventsAfter mocks base method.
+func (m *MockStore) GetOutboxEventsAfter(arg0 context.Context, arg1, arg2 int64) (*[]db.OutboxEvent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferMoney", arg0, arg1, arg2, arg3)
-	ret0, _ := ret[0].(*db.TransferTxResult)
+	ret := m.ctrl.Call(m, "GetOutboxEventsAfter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.OutboxEvent)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// TransferMoney indicates an expected call of TransferMoney.
-func (mr *MockStoreMockRecorder) TransferMoney(arg0, arg1, arg2, arg3 any) *gomock.Call {
+// GetOutboxEventsAfter indicates an expected call of GetOutboxEventsAfter.
+func (mr *MockStoreMockRecorder) GetOutboxEventsAfter(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferMoney", reflect.TypeOf((*MockStore)(nil).TransferMoney), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxEventsAfter", reflect.TypeOf((*MockStore)(nil).GetOutboxEventsAfter), arg0, arg1, arg2)
 }
 
-// UpdateAccount mocks base method.
-func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 *db.Account) (int64, error) {
+// GetOutboxEventsByAggregate mocks base method.
+func (m *MockStore) GetOutboxEventsByAggregate(arg0 context.Context, arg1 string, arg2 int64) (*[]db.OutboxEvent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccount", arg0, arg1)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "GetOutboxEventsByAggregate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.OutboxEvent)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateAccount indicates an expected call of UpdateAccount.
-func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 any) *gomock.Call {
+// GetOutboxEventsByAggregate indicates an expected call of GetOutboxEventsByAggregate.
+func (mr *MockStoreMockRecorder) GetOutboxEventsByAggregate(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxEventsByAggregate", reflect.TypeOf((*MockStore)(nil).GetOutboxEventsByAggregate), arg0, arg1, arg2)
 }
 
-// UpdateAccountBalance mocks base method.
-func (m *MockStore) UpdateAccountBalance(arg0 context.Context, arg1, arg2 int64) (int64, error) {
+// GetPayeeByID mocks base method.
+func (m *MockStore) GetPayeeByID(arg0 context.Context, arg1 int64) (*db.Payee, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccountBalance", arg0, arg1, arg2)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "GetPayeeByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.Payee)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateAccountBalance indicates an expected call of UpdateAccountBalance.
-func (mr *MockStoreMockRecorder) UpdateAccountBalance(arg0, arg1, arg2 any) *gomock.Call {
+// GetPayeeByID indicates an expected call of GetPayeeByID.
+func (mr *MockStoreMockRecorder) GetPayeeByID(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountBalance", reflect.TypeOf((*MockStore)(nil).UpdateAccountBalance), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeeByID", reflect.TypeOf((*MockStore)(nil).GetPayeeByID), arg0, arg1)
 }
 
-// UpdateAccountOwner mocks base method.
-func (m *MockStore) UpdateAccountOwner(arg0 context.Context, arg1 int64, arg2 string) (int64, error) {
+// GetPayeesByOwner mocks base method.
+func (m *MockStore) GetPayeesByOwner(arg0 context.Context, arg1 string) (*[]db.Payee, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccountOwner", arg0, arg1, arg2)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "GetPayeesByOwner", arg0, arg1)
+	ret0, _ := ret[0].(*[]db.Payee)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateAccountOwner indicates an expected call of UpdateAccountOwner.
-func (mr *MockStoreMockRecorder) UpdateAccountOwner(arg0, arg1, arg2 any) *gomock.Call {
+// GetPayeesByOwner indicates an expected call of GetPayeesByOwner.
+func (mr *MockStoreMockRecorder) GetPayeesByOwner(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountOwner", reflect.TypeOf((*MockStore)(nil).UpdateAccountOwner), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeesByOwner", reflect.TypeOf((*MockStore)(nil).GetPayeesByOwner), arg0, arg1)
+}
+
+// GetRiskAssessmentByID mocks base method.
+func (m *MockStore) GetRiskAssessmentByID(arg0 context.Context, arg1 int64) (*db.RiskAssessment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskAssessmentByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.RiskAssessment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskAssessmentByID indicates an expected call of GetRiskAssessmentByID.
+func (mr *MockStoreMockRecorder) GetRiskAssessmentByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskAssessmentByID", reflect.TypeOf((*MockStore)(nil).GetRiskAssessmentByID), arg0, arg1)
+}
+
+// GetRiskAssessmentByTransferID mocks base method.
+func (m *MockStore) GetRiskAssessmentByTransferID(arg0 context.Context, arg1 int64) (*db.RiskAssessment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskAssessmentByTransferID", arg0, arg1)
+	ret0, _ := ret[0].(*db.RiskAssessment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskAssessmentByTransferID indicates an expected call of GetRiskAssessmentByTransferID.
+func (mr *MockStoreMockRecorder) GetRiskAssessmentByTransferID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskAssessmentByTransferID", reflect.TypeOf((*MockStore)(nil).GetRiskAssessmentByTransferID), arg0, arg1)
+}
+
+// GetSchemaVersion mocks base method.
+func (m *MockStore) GetSchemaVersion(arg0 context.Context) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchemaVersion", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetSchemaVersion indicates an expected call of GetSchemaVersion.
+func (mr *MockStoreMockRecorder) GetSchemaVersion(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaVersion", reflect.TypeOf((*MockStore)(nil).GetSchemaVersion), arg0)
+}
+
+// GetTransferByExternalReference mocks base method.
+func (m *MockStore) GetTransferByExternalReference(arg0 context.Context, arg1 int64, arg2 string) (*db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferByExternalReference", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferByExternalReference indicates an expected call of GetTransferByExternalReference.
+func (mr *MockStoreMockRecorder) GetTransferByExternalReference(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferByExternalReference", reflect.TypeOf((*MockStore)(nil).GetTransferByExternalReference), arg0, arg1, arg2)
+}
+
+// GetTransferByID mocks base method.
+func (m *MockStore) GetTransferByID(arg0 context.Context, arg1 int64) (*db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferByID indicates an expected call of GetTransferByID.
+func (mr *MockStoreMockRecorder) GetTransferByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferByID", reflect.TypeOf((*MockStore)(nil).GetTransferByID), arg0, arg1)
+}
+
+// GetTransferLimitByID mocks base method.
+func (m *MockStore) GetTransferLimitByID(arg0 context.Context, arg1 int64) (*db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferLimitByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferLimitByID indicates an expected call of GetTransferLimitByID.
+func (mr *MockStoreMockRecorder) GetTransferLimitByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferLimitByID", reflect.TypeOf((*MockStore)(nil).GetTransferLimitByID), arg0, arg1)
+}
+
+// GetTransferLimits mocks base method.
+func (m *MockStore) GetTransferLimits(arg0 context.Context) (*[]db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferLimits", arg0)
+	ret0, _ := ret[0].(*[]db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferLimits indicates an expected call of GetTransferLimits.
+func (mr *MockStoreMockRecorder) GetTransferLimits(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferLimits", reflect.TypeOf((*MockStore)(nil).GetTransferLimits), arg0)
+}
+
+// GetTransferRequestByID mocks base method.
+func (m *MockStore) GetTransferRequestByID(arg0 context.Context, arg1 int64) (*db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferRequestByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferRequestByID indicates an expected call of GetTransferRequestByID.
+func (mr *MockStoreMockRecorder) GetTransferRequestByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferRequestByID", reflect.TypeOf((*MockStore)(nil).GetTransferRequestByID), arg0, arg1)
+}
+
+// GetTransferRequestEvents mocks base method.
+func (m *MockStore) GetTransferRequestEvents(arg0 context.Context, arg1 int64) (*[]db.TransferRequestEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferRequestEvents", arg0, arg1)
+	ret0, _ := ret[0].(*[]db.TransferRequestEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferRequestEvents indicates an expected call of GetTransferRequestEvents.
+func (mr *MockStoreMockRecorder) GetTransferRequestEvents(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferRequestEvents", reflect.TypeOf((*MockStore)(nil).GetTransferRequestEvents), arg0, arg1)
+}
+
+// GetTransfersFromTo mocks base method.
+func (m *MockStore) GetTransfersFromTo(arg0 context.Context, arg1, arg2 int64, arg3 db.Page) (*[]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfersFromTo", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*[]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfersFromTo indicates an expected call of GetTransfersFromTo.
+func (mr *MockStoreMockRecorder) GetTransfersFromTo(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfersFromTo", reflect.TypeOf((*MockStore)(nil).GetTransfersFromTo), arg0, arg1, arg2, arg3)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (*db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(*db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+}
+
+// GetUsers mocks base method.
+func (m *MockStore) GetUsers(arg0 context.Context) (*[]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", arg0)
+	ret0, _ := ret[0].(*[]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockStoreMockRecorder) GetUsers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockStore)(nil).GetUsers), arg0)
+}
+
+// GetWebhookDeliveryByID mocks base method.
+func (m *MockStore) GetWebhookDeliveryByID(arg0 context.Context, arg1 int64) (*db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveryByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveryByID indicates an expected call of GetWebhookDeliveryByID.
+func (mr *MockStoreMockRecorder) GetWebhookDeliveryByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveryByID", reflect.TypeOf((*MockStore)(nil).GetWebhookDeliveryByID), arg0, arg1)
+}
+
+// GetWebhookSubscriptionByID mocks base method.
+func (m *MockStore) GetWebhookSubscriptionByID(arg0 context.Context, arg1 int64) (*db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptionByID", arg0, arg1)
+	ret0, _ := ret[0].(*db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptionByID indicates an expected call of GetWebhookSubscriptionByID.
+func (mr *MockStoreMockRecorder) GetWebhookSubscriptionByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptionByID", reflect.TypeOf((*MockStore)(nil).GetWebhookSubscriptionByID), arg0, arg1)
+}
+
+// GetWebhookSubscriptionsByOwner mocks base method.
+func (m *MockStore) GetWebhookSubscriptionsByOwner(arg0 context.Context, arg1 string) (*[]db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptionsByOwner", arg0, arg1)
+	ret0, _ := ret[0].(*[]db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptionsByOwner indicates an expected call of GetWebhookSubscriptionsByOwner.
+func (mr *MockStoreMockRecorder) GetWebhookSubscriptionsByOwner(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptionsByOwner", reflect.TypeOf((*MockStore)(nil).GetWebhookSubscriptionsByOwner), arg0, arg1)
+}
+
+// GetWebhookSubscriptionsForEvent mocks base method.
+func (m *MockStore) GetWebhookSubscriptionsForEvent(arg0 context.Context, arg1, arg2 string) (*[]db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptionsForEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptionsForEvent indicates an expected call of GetWebhookSubscriptionsForEvent.
+func (mr *MockStoreMockRecorder) GetWebhookSubscriptionsForEvent(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptionsForEvent", reflect.TypeOf((*MockStore)(nil).GetWebhookSubscriptionsForEvent), arg0, arg1, arg2)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 string, arg2 db.Page) (*[]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1, arg2)
+}
+
+// ListTransferRequests mocks base method.
+func (m *MockStore) ListTransferRequests(arg0 context.Context, arg1 string, arg2 db.Page) (*[]db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferRequests", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferRequests indicates an expected call of ListTransferRequests.
+func (mr *MockStoreMockRecorder) ListTransferRequests(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferRequests", reflect.TypeOf((*MockStore)(nil).ListTransferRequests), arg0, arg1, arg2)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(arg0 context.Context, arg1 int64, arg2 db.Page) (*[]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// ListenOutboxEvents mocks base method.
+func (m *MockStore) ListenOutboxEvents(arg0 context.Context, arg1 func(context.Context, db.OutboxEvent) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListenOutboxEvents", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListenOutboxEvents indicates an expected call of ListenOutboxEvents.
+func (mr *MockStoreMockRecorder) ListenOutboxEvents(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenOutboxEvents", reflect.TypeOf((*MockStore)(nil).ListenOutboxEvents), arg0, arg1)
+}
+
+// MarkWebhookDeliveryFailed mocks base method.
+func (m *MockStore) MarkWebhookDeliveryFailed(arg0 context.Context, arg1, arg2 int64, arg3 string, arg4 time.Time, arg5 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliveryFailed", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkWebhookDeliveryFailed indicates an expected call of MarkWebhookDeliveryFailed.
+func (mr *MockStoreMockRecorder) MarkWebhookDeliveryFailed(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliveryFailed", reflect.TypeOf((*MockStore)(nil).MarkWebhookDeliveryFailed), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// MarkWebhookDeliverySucceeded mocks base method.
+func (m *MockStore) MarkWebhookDeliverySucceeded(arg0 context.Context, arg1, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliverySucceeded", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkWebhookDeliverySucceeded indicates an expected call of MarkWebhookDeliverySucceeded.
+func (mr *MockStoreMockRecorder) MarkWebhookDeliverySucceeded(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliverySucceeded", reflect.TypeOf((*MockStore)(nil).MarkWebhookDeliverySucceeded), arg0, arg1, arg2)
+}
+
+// Ping mocks base method.
+func (m *MockStore) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), arg0)
+}
+
+// PublishOutboxEvents mocks base method.
+func (m *MockStore) PublishOutboxEvents(arg0 context.Context, arg1 int64, arg2 func(context.Context, db.OutboxEvent) error) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOutboxEvents", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishOutboxEvents indicates an expected call of PublishOutboxEvents.
+func (mr *MockStoreMockRecorder) PublishOutboxEvents(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOutboxEvents", reflect.TypeOf((*MockStore)(nil).PublishOutboxEvents), arg0, arg1, arg2)
+}
+
+// ReplayWebhookDelivery mocks base method.
+func (m *MockStore) ReplayWebhookDelivery(arg0 context.Context, arg1 int64) (*db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(*db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery.
+func (mr *MockStoreMockRecorder) ReplayWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockStore)(nil).ReplayWebhookDelivery), arg0, arg1)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStore) RevokeAPIKey(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStoreMockRecorder) RevokeAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStore)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// RotateAPIKey mocks base method.
+func (m *MockStore) RotateAPIKey(arg0 context.Context, arg1 int64, arg2 db.APIKey, arg3 time.Time) (*db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAPIKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAPIKey indicates an expected call of RotateAPIKey.
+func (mr *MockStoreMockRecorder) RotateAPIKey(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKey", reflect.TypeOf((*MockStore)(nil).RotateAPIKey), arg0, arg1, arg2, arg3)
+}
+
+// SearchTransactions mocks base method.
+func (m *MockStore) SearchTransactions(arg0 context.Context, arg1 db.TransactionFilter, arg2 db.Page) (*[]db.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTransactions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]db.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTransactions indicates an expected call of SearchTransactions.
+func (mr *MockStoreMockRecorder) SearchTransactions(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTransactions", reflect.TypeOf((*MockStore)(nil).SearchTransactions), arg0, arg1, arg2)
+}
+
+// SetAccountFrozen mocks base method.
+func (m *MockStore) SetAccountFrozen(arg0 context.Context, arg1 int64, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountFrozen", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAccountFrozen indicates an expected call of SetAccountFrozen.
+func (mr *MockStoreMockRecorder) SetAccountFrozen(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountFrozen", reflect.TypeOf((*MockStore)(nil).SetAccountFrozen), arg0, arg1, arg2)
+}
+
+// SetTransferLimit mocks base method.
+func (m *MockStore) SetTransferLimit(arg0 context.Context, arg1 db.TransferLimit) (*db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(*db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetTransferLimit indicates an expected call of SetTransferLimit.
+func (mr *MockStoreMockRecorder) SetTransferLimit(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTransferLimit", reflect.TypeOf((*MockStore)(nil).SetTransferLimit), arg0, arg1)
+}
+
+// SetUserRole mocks base method.
+func (m *MockStore) SetUserRole(arg0 context.Context, arg1, arg2 string) (*db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockStoreMockRecorder) SetUserRole(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockStore)(nil).SetUserRole), arg0, arg1, arg2)
+}
+
+// TakeRateLimitToken mocks base method.
+func (m *MockStore) TakeRateLimitToken(arg0 context.Context, arg1 string, arg2 float64, arg3 int64) (float64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeRateLimitToken", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TakeRateLimitToken indicates an expected call of TakeRateLimitToken.
+func (mr *MockStoreMockRecorder) TakeRateLimitToken(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeRateLimitToken", reflect.TypeOf((*MockStore)(nil).TakeRateLimitToken), arg0, arg1, arg2, arg3)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockStore) TouchAPIKey(arg0 context.Context, arg1 int64, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStoreMockRecorder) TouchAPIKey(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStore)(nil).TouchAPIKey), arg0, arg1, arg2)
+}
+
+// TransferMoney mocks base method.
+func (m *MockStore) TransferMoney(arg0 context.Context, arg1, arg2, arg3 int64, arg4 db.Details) (*db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferMoney", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferMoney indicates an expected call of TransferMoney.
+func (mr *MockStoreMockRecorder) TransferMoney(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferMoney", reflect.TypeOf((*MockStore)(nil).TransferMoney), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UpdateAccount mocks base method.
+func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 *db.Account) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccount indicates an expected call of UpdateAccount.
+func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
+}
+
+// UpdateAccountBalance mocks base method.
+func (m *MockStore) UpdateAccountBalance(arg0 context.Context, arg1, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountBalance", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountBalance indicates an expected call of UpdateAccountBalance.
+func (mr *MockStoreMockRecorder) UpdateAccountBalance(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountBalance", reflect.TypeOf((*MockStore)(nil).UpdateAccountBalance), arg0, arg1, arg2)
+}
+
+// UpdateAccountOwner mocks base method.
+func (m *MockStore) UpdateAccountOwner(arg0 context.Context, arg1 int64, arg2 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountOwner", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountOwner indicates an expected call of UpdateAccountOwner.
+func (mr *MockStoreMockRecorder) UpdateAccountOwner(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountOwner", reflect.TypeOf((*MockStore)(nil).UpdateAccountOwner), arg0, arg1, arg2)
+}
+
+// UpdatePayeeNickname mocks base method.
+func (m *MockStore) UpdatePayeeNickname(arg0 context.Context, arg1 int64, arg2 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayeeNickname", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePayeeNickname indicates an expected call of UpdatePayeeNickname.
+func (mr *MockStoreMockRecorder) UpdatePayeeNickname(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayeeNickname", reflect.TypeOf((*MockStore)(nil).UpdatePayeeNickname), arg0, arg1, arg2)
+}
+
+// UseAPIKeyNonce mocks base method.
+func (m *MockStore) UseAPIKeyNonce(arg0 context.Context, arg1 int64, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseAPIKeyNonce", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseAPIKeyNonce indicates an expected call of UseAPIKeyNonce.
+func (mr *MockStoreMockRecorder) UseAPIKeyNonce(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseAPIKeyNonce", reflect.TypeOf((*MockStore)(nil).UseAPIKeyNonce), arg0, arg1, arg2, arg3)
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -9,22 +10,25 @@ type Account struct {
 	Owner     string    `json:"owner" db:"owner"`
 	Balance   int64     `json:"balance" db:"balance"` // balance in cents
 	Currency  string    `json:"currency" db:"currency"`
+	Frozen    bool      `json:"frozen" db:"frozen"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 type Entry struct {
-	ID        int64     `json:"id" db:"id"`
-	AccountID int64     `json:"account_id" db:"account_id"`
-	Amount    int64     `json:"amount" db:"amount"` // amount in cents
+	ID        int64 `json:"id" db:"id"`
+	AccountID int64 `json:"account_id" db:"account_id"`
+	Amount    int64 `json:"amount" db:"amount"` // amount in cents
+	Details
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 type Transfer struct {
-	ID            int64     `json:"id" db:"id"`
-	FromAccountID int64     `json:"from_account_id" db:"from_account_id"`
-	ToAccountID   int64     `json:"to_account_id" db:"to_account_id"`
-	Amount        int64     `json:"amount" db:"amount"` // amount in cents
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	ID            int64 `json:"id" db:"id"`
+	FromAccountID int64 `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id" db:"to_account_id"`
+	Amount        int64 `json:"amount" db:"amount"` // amount in cents
+	Details
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 type TransferTxResult struct {
@@ -34,3 +38,163 @@ type TransferTxResult struct {
 	FromEntryRecord Entry    `json:"from_entry"`
 	ToEntryRecord   Entry    `json:"to_entry"`
 }
+
+// manual correction of a balance, see AdjustAccountBalance
+type BalanceAdjustment struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+type BusinessDay struct {
+	BusinessDate time.Time `json:"business_date" db:"business_date"`
+	Timezone     string    `json:"timezone" db:"timezone"`
+	AccountCount int64     `json:"account_count" db:"account_count"`
+	ClosedAt     time.Time `json:"closed_at" db:"closed_at"`
+}
+
+type BalanceSnapshot struct {
+	AccountID      int64     `json:"account_id" db:"account_id"`
+	BusinessDate   time.Time `json:"business_date" db:"business_date"`
+	ClosingBalance int64     `json:"closing_balance" db:"closing_balance"` // balance in cents
+	Currency       string    `json:"currency" db:"currency"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type CurrencyTotal struct {
+	BusinessDate time.Time `json:"business_date" db:"business_date"`
+	Currency     string    `json:"currency" db:"currency"`
+	TotalBalance int64     `json:"total_balance" db:"total_balance"` // balance in cents
+	AccountCount int64     `json:"account_count" db:"account_count"`
+}
+
+type OutboxEvent struct {
+	ID            int64           `json:"id" db:"id"`
+	AggregateType string          `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id" db:"aggregate_id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty" db:"published_at"`
+	TxID          int64           `json:"-" db:"txid"`
+}
+
+type WebhookSubscription struct {
+	ID         int64       `json:"id" db:"id"`
+	Owner      string      `json:"owner" db:"owner"`
+	URL        string      `json:"url" db:"url"`
+	EventTypes StringArray `json:"event_types" db:"event_types"`
+	Secret     string      `json:"-" db:"secret"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	SubscriptionID int64           `json:"subscription_id" db:"subscription_id"`
+	EventID        int64           `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int64           `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode *int64          `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// transfer or entry as seen from the searched account
+type Transaction struct {
+	Type           string `json:"type" db:"type"`
+	ID             int64  `json:"id" db:"id"`
+	AccountID      int64  `json:"account_id" db:"account_id"`
+	CounterpartyID *int64 `json:"counterparty_id,omitempty" db:"counterparty_id"` // transfers only
+	Direction      string `json:"direction" db:"direction"`
+	Amount         int64  `json:"amount" db:"amount"` // amount in cents, negative when outgoing
+	Details
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// saved recipient of an owner's transfers
+type Payee struct {
+	ID        int64     `json:"id" db:"id"`
+	Owner     string    `json:"owner" db:"owner"`
+	AccountID int64     `json:"account_id" db:"account_id"`
+	Nickname  string    `json:"nickname" db:"nickname"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// most that may leave an account within a window, see LimitScope* and Limit*
+type TransferLimit struct {
+	ID        int64     `json:"id" db:"id"`
+	Scope     string    `json:"scope" db:"scope"`
+	Subject   string    `json:"subject" db:"subject"` // account id, owner or currency code, depending on Scope
+	Kind      string    `json:"kind" db:"kind"`
+	Max       int64     `json:"max" db:"max_value"` // cents, or a number of transfers for LimitDailyCount
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// outcome of screening a transfer, kept when a rule matched
+type RiskAssessment struct {
+	ID            int64       `json:"id" db:"id"`
+	TransferID    *int64      `json:"transfer_id,omitempty" db:"transfer_id"` // nil when denied
+	FromAccountID int64       `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id" db:"to_account_id"`
+	Amount        int64       `json:"amount" db:"amount"` // amount in cents
+	Decision      string      `json:"decision" db:"decision"`
+	Reasons       RiskReasons `json:"reasons" db:"reasons"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+}
+
+// transfer held until a second user approves or rejects it, see TransferRequest*
+type TransferRequest struct {
+	ID            int64 `json:"id" db:"id"`
+	FromAccountID int64 `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id" db:"to_account_id"`
+	Amount        int64 `json:"amount" db:"amount"` // amount in cents
+	Details
+	Initiator        string     `json:"initiator" db:"initiator"`
+	HoldReason       string     `json:"hold_reason" db:"hold_reason"`
+	RiskAssessmentID *int64     `json:"risk_assessment_id,omitempty" db:"risk_assessment_id"`
+	Status           string     `json:"status" db:"status"`
+	Reviewer         *string    `json:"reviewer,omitempty" db:"reviewer"`
+	DecidedAt        *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	TransferID       *int64     `json:"transfer_id,omitempty" db:"transfer_id"`
+	Failure          string     `json:"failure,omitempty" db:"failure"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// audit trail entry of a transfer request
+type TransferRequestEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	RequestID int64     `json:"request_id" db:"request_id"`
+	Action    string    `json:"action" db:"action"`
+	Actor     string    `json:"actor" db:"actor"`
+	Note      string    `json:"note,omitempty" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// staff member with a role, see Role*; anyone without a row is a customer
+type User struct {
+	Username  string    `json:"username" db:"username"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// credential of a service calling the API without a user, see package apikey
+type APIKey struct {
+	ID               int64       `json:"id" db:"id"`
+	Name             string      `json:"name" db:"name"`
+	Prefix           string      `json:"prefix" db:"prefix"`
+	KeyHash          string      `json:"-" db:"key_hash"`
+	SigningSecret    string      `json:"-" db:"signing_secret"`
+	RequireSignature bool        `json:"require_signature" db:"require_signature"`
+	Permissions      StringArray `json:"permissions" db:"permissions"`
+	AccountIDs       Int64Array  `json:"account_ids" db:"account_ids"` // every account when empty
+	CreatedBy        string      `json:"created_by" db:"created_by"`
+	ExpiresAt        *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt       *time.Time  `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt        *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+	RotatedTo        *int64      `json:"rotated_to,omitempty" db:"rotated_to"`
+	CreatedAt        time.Time   `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccountEvent(t *testing.T) {
+	account := createRandomAccount(t)
+
+	events, err := testStore(t).GetOutboxEventsByAggregate(context.Background(), AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 1)
+
+	event := (*events)[0]
+	require.Equal(t, EventAccountCreated, event.EventType)
+	require.Nil(t, event.PublishedAt)
+
+	var payload Account
+	require.NoError(t, event.Decode(&payload))
+	require.Equal(t, account.ID, payload.ID)
+	require.Equal(t, account.Owner, payload.Owner)
+	require.Equal(t, account.Balance, payload.Balance)
+	require.Equal(t, account.Currency, payload.Currency)
+}
+
+func TestAccountEventsInOrder(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testStore(t).UpdateAccountOwner(context.Background(), account.ID, "new_owner")
+	require.NoError(t, err)
+	_, err = testStore(t).AddAccountBalance(context.Background(), account.ID, 10)
+	require.NoError(t, err)
+
+	events, err := testStore(t).GetOutboxEventsByAggregate(context.Background(), AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 3)
+	require.Equal(t, EventAccountCreated, (*events)[0].EventType)
+	require.Equal(t, EventAccountOwnerChanged, (*events)[1].EventType)
+	require.Equal(t, EventAccountBalanceChanged, (*events)[2].EventType)
+
+	var payload Account
+	require.NoError(t, (*events)[2].Decode(&payload))
+	require.Equal(t, "new_owner", payload.Owner)
+	require.Equal(t, account.Balance+10, payload.Balance)
+}
+
+func TestTransferMoneyEvent(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	// higher id to lower id, so the balances are updated in reverse order
+	result, err := testStore(t).TransferMoney(context.Background(), account2.ID, account1.ID, 10, Details{})
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, result.FromAccount.ID)
+	require.Equal(t, account1.ID, result.ToAccount.ID)
+
+	events, err := testStore(t).GetOutboxEventsByAggregate(context.Background(), AggregateTransfer, result.TransferRecord.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 1)
+	require.Equal(t, EventTransferCompleted, (*events)[0].EventType)
+
+	var payload TransferTxResult
+	require.NoError(t, (*events)[0].Decode(&payload))
+	require.Equal(t, result.TransferRecord.ID, payload.TransferRecord.ID)
+	require.Equal(t, result.FromAccount.Balance, payload.FromAccount.Balance)
+	require.Equal(t, result.ToAccount.Balance, payload.ToAccount.Balance)
+}
+
+func TestPublishOutboxEventsKeepsAggregateOrder(t *testing.T) {
+	failing := createRandomAccount(t)
+	_, err := testStore(t).AddAccountBalance(context.Background(), failing.ID, 10)
+	require.NoError(t, err)
+	healthy := createRandomAccount(t)
+
+	brokerDown := true
+	var published []OutboxEvent
+	publish := func(ctx context.Context, event OutboxEvent) error {
+		if brokerDown && event.AggregateType == AggregateAccount && event.AggregateID == failing.ID {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, event)
+		return nil
+	}
+
+	_, err = testStore(t).PublishOutboxEvents(context.Background(), 1_000_000, publish)
+	require.Error(t, err)
+
+	// the failing account's first event failed, so its second one must not have been published
+	events, err := testStore(t).GetOutboxEventsByAggregate(context.Background(), AggregateAccount, failing.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 2)
+	for _, event := range *events {
+		require.Nil(t, event.PublishedAt)
+	}
+
+	events, err = testStore(t).GetOutboxEventsByAggregate(context.Background(), AggregateAccount, healthy.ID)
+	require.NoError(t, err)
+	require.NotNil(t, (*events)[0].PublishedAt)
+
+	// once the broker recovers, both events go out in order
+	brokerDown = false
+	published = nil
+	_, err = testStore(t).PublishOutboxEvents(context.Background(), 1_000_000, publish)
+	require.NoError(t, err)
+
+	var order []string
+	for _, event := range published {
+		if event.AggregateType == AggregateAccount && event.AggregateID == failing.ID {
+			order = append(order, event.EventType)
+		}
+	}
+	require.Equal(t, []string{EventAccountCreated, EventAccountBalanceChanged}, order)
+}
+
+// An event should not be read past while an older transaction could still commit before it.
+func TestOutboxEventsAfterInCommitOrder(t *testing.T) {
+	account := createRandomAccount(t)
+	conn := testStore(t).(*SQLStore).conn
+
+	tx := conn.MustBeginTx(context.Background(), nil)
+	defer tx.Rollback()
+	early, err := NewQueries(tx).CreateOutboxEvent(context.Background(), AggregateAccount, account.ID, EventAccountUpdated, account)
+	require.NoError(t, err)
+
+	late, err := NewQueries(conn).CreateOutboxEvent(context.Background(), AggregateAccount, account.ID, EventAccountUpdated, account)
+	require.NoError(t, err)
+	require.Less(t, early.ID, late.ID)
+
+	// late has committed, but early may still commit before anyone reads it
+	events, err := testStore(t).GetOutboxEventsAfter(context.Background(), early.ID-1, 1_000_000)
+	require.NoError(t, err)
+	for _, event := range *events {
+		require.NotEqual(t, late.ID, event.ID)
+	}
+
+	require.NoError(t, tx.Commit())
+
+	events, err = testStore(t).GetOutboxEventsAfter(context.Background(), early.ID-1, 1_000_000)
+	require.NoError(t, err)
+	var ids []int64
+	for _, event := range *events {
+		if event.ID == early.ID || event.ID == late.ID {
+			ids = append(ids, event.ID)
+		}
+	}
+	require.Equal(t, []int64{early.ID, late.ID}, ids)
+
+	last, err := testStore(t).GetLastOutboxEventID(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, last, late.ID)
+}
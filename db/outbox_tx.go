@@ -0,0 +1,74 @@
+// relaying outbox events to a publisher
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// advisory lock key held by the single active relay (replicas included)
+const outboxRelayLockKey = 27_0001
+
+// lock the relay on a connection of its own (another relay holding it means there is nothing to do)
+// read up to limit unpublished events, oldest first
+// publish each event outside any transaction; after a failure the aggregate's later events wait for the next run (per aggregate order)
+// update published_at for the published events
+// unlock the relay
+// the lock is a session's, not a transaction's: nothing stays open while publish waits on a broker or a subscriber.
+// a relay dying between publishing and updating leaves its events to be published again, at least once as before
+// returns the number of published events and the joined publish errors
+func (s *SQLStore) PublishOutboxEvents(ctx context.Context, limit int64, publish func(ctx context.Context, event OutboxEvent) error) (int64, error) {
+	conn, err := s.conn.Connx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	err = conn.GetContext(ctx, &locked, "SELECT pg_try_advisory_lock($1);", outboxRelayLockKey)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", outboxRelayLockKey)
+		if err != nil {
+			// a session still holding the lock must not go back to the pool, closing it releases the lock
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	events, err := s.GetUnpublishedOutboxEvents(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var published []int64
+	var publishErr error
+	blocked := make(map[string]bool)
+
+	for _, event := range *events {
+		aggregate := fmt.Sprintf("%s/%d", event.AggregateType, event.AggregateID)
+		if blocked[aggregate] {
+			continue
+		}
+
+		if err := publish(ctx, event); err != nil {
+			blocked[aggregate] = true
+			publishErr = errors.Join(publishErr, fmt.Errorf("event %d: %w", event.ID, err))
+			continue
+		}
+
+		published = append(published, event.ID)
+	}
+
+	if len(published) > 0 {
+		_, err = s.MarkOutboxEventsPublished(ctx, published)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(published)), publishErr
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// order of a keyset page
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// position in a list ordered by (created_at, id)
+type PageKey struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// whether key comes before other in ascending order
+func (key PageKey) Before(other PageKey) bool {
+	if key.CreatedAt.Equal(other.CreatedAt) {
+		return key.ID < other.ID
+	}
+	return key.CreatedAt.Before(other.CreatedAt)
+}
+
+// keyset page: up to Limit rows ordered by (created_at, id) in Order (SortAsc when empty),
+// starting right after the After key, or at the beginning when After is nil
+type Page struct {
+	Limit int64
+	Order string
+	After *PageKey
+}
+
+func (page Page) descending() bool {
+	return page.Order == SortDesc
+}
+
+// keyset condition (to AND onto the WHERE clause), ordering and limit of page
+// with its arguments numbered from $n on
+func (page Page) clause(n int) (string, []any) {
+	comparison, direction := ">", "ASC"
+	if page.descending() {
+		comparison, direction = "<", "DESC"
+	}
+
+	var condition string
+	var args []any
+	if page.After != nil {
+		condition = fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", comparison, n, n+1)
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		n += 2
+	}
+
+	return fmt.Sprintf("%s ORDER BY created_at %s, id %s LIMIT $%d", condition, direction, direction, n), append(args, page.Limit)
+}
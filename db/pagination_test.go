@@ -0,0 +1,32 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageClause(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	clause, args := Page{Limit: 5}.clause(2)
+	require.Equal(t, " ORDER BY created_at ASC, id ASC LIMIT $2", clause)
+	require.Equal(t, []any{int64(5)}, args)
+
+	clause, args = Page{Limit: 5, Order: SortAsc, After: &PageKey{CreatedAt: createdAt, ID: 7}}.clause(2)
+	require.Equal(t, " AND (created_at, id) > ($2, $3) ORDER BY created_at ASC, id ASC LIMIT $4", clause)
+	require.Equal(t, []any{createdAt, int64(7), int64(5)}, args)
+
+	clause, args = Page{Limit: 5, Order: SortDesc, After: &PageKey{CreatedAt: createdAt, ID: 7}}.clause(3)
+	require.Equal(t, " AND (created_at, id) < ($3, $4) ORDER BY created_at DESC, id DESC LIMIT $5", clause)
+	require.Equal(t, []any{createdAt, int64(7), int64(5)}, args)
+}
+
+func TestPageKeyBefore(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	require.True(t, PageKey{CreatedAt: createdAt, ID: 9}.Before(PageKey{CreatedAt: createdAt.Add(time.Microsecond), ID: 1}))
+	require.True(t, PageKey{CreatedAt: createdAt, ID: 1}.Before(PageKey{CreatedAt: createdAt, ID: 2}))
+	require.False(t, PageKey{CreatedAt: createdAt, ID: 2}.Before(PageKey{CreatedAt: createdAt, ID: 2}))
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// nicknames are 1 to this many characters, the column checks it as well
+const MaxNicknameLength = 64
+
+var ErrDuplicatePayee = errors.New("payee nickname or account is already saved by this owner")
+
+// whether err is either unique constraint on an owner's payees
+func duplicatePayee(err error) bool {
+	return violates(err, "payees_owner_nickname_key") || violates(err, "payees_owner_account_id_key")
+}
+
+// create, sql.ErrNoRows when the account does not exist
+func (s *Queries) CreatePayee(ctx context.Context, owner string, accountID int64, nickname string) (*Payee, error) {
+	var payee Payee
+
+	err := s.db.GetContext(ctx, &payee, "INSERT INTO payees (owner, account_id, nickname) SELECT $1, id, $3 FROM accounts WHERE id = $2 RETURNING id, owner, account_id, nickname, created_at;", owner, accountID, nickname)
+	if err != nil {
+		if duplicatePayee(err) {
+			return nil, ErrDuplicatePayee
+		}
+		return nil, err
+	}
+
+	return &payee, nil
+}
+
+// read (id)
+func (s *Queries) GetPayeeByID(ctx context.Context, id int64) (*Payee, error) {
+	var payee Payee
+
+	err := s.db.GetContext(ctx, &payee, "SELECT id, owner, account_id, nickname, created_at FROM payees WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payee, nil
+}
+
+// read (owner)
+func (s *Queries) GetPayeesByOwner(ctx context.Context, owner string) (*[]Payee, error) {
+	var payees []Payee
+
+	err := s.db.SelectContext(ctx, &payees, "SELECT id, owner, account_id, nickname, created_at FROM payees WHERE owner = $1 ORDER BY id;", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payees, nil
+}
+
+// update nickname
+func (s *Queries) UpdatePayeeNickname(ctx context.Context, id int64, nickname string) (int64, error) {
+	rows, err := rowsAffected(s.db.ExecContext(ctx, "UPDATE payees SET nickname = $1 WHERE id = $2;", nickname, id))
+	if duplicatePayee(err) {
+		return 0, ErrDuplicatePayee
+	}
+	return rows, err
+}
+
+// delete
+func (s *Queries) DeletePayeeByID(ctx context.Context, id int64) (int64, error) {
+	return rowsAffected(s.db.ExecContext(ctx, "DELETE FROM payees WHERE id = $1;", id))
+}
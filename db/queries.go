@@ -3,6 +3,10 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // basic raw database operations
@@ -11,14 +15,27 @@ type Ops interface {
 	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 	MustExecContext(ctx context.Context, query string, args ...interface{}) sql.Result
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
 // provides basic raw database operations
 type Queries struct {
-	db Ops
+	db tracedOps
 }
 
-// generate queries methods for db or tx operations
+// generate queries methods for db or tx operations, every statement is traced
 func NewQueries(db Ops) *Queries {
-	return &Queries{db: db}
+	return newQueries(db, slog.Default())
+}
+
+// statements are logged at debug level to the request's logger, or to logger outside of requests
+func newQueries(db Ops, logger *slog.Logger) *Queries {
+	return &Queries{db: tracedOps{ops: db, logger: logger}}
+}
+
+// whether err is Postgres rejecting a statement on constraint. RAISE EXCEPTION ... USING CONSTRAINT
+// in the triggers names the constraint only here, never in the message
+func violates(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.ConstraintName == constraint
 }
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// take a token from the bucket of key, refilled at rate tokens per second up to burst since it was last taken from.
+// tokens is what is left in the bucket; nothing is taken when it held less than one
+func (s *Queries) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int64) (tokens float64, allowed bool, err error) {
+	// the row lock makes concurrent takes of one key wait for each other, even across replicas
+	row := s.db.QueryRowContext(ctx, `WITH bucket AS (
+			SELECT COALESCE((
+				SELECT LEAST($3::float8, tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - updated_at)::float8) * $2::float8) FROM rate_limit_buckets WHERE key = $1 FOR UPDATE
+			), $3::float8) AS tokens
+		)
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at) SELECT $1, CASE WHEN tokens >= 1 THEN tokens - 1 ELSE tokens END, now() FROM bucket
+		ON CONFLICT (key) DO UPDATE SET tokens = EXCLUDED.tokens, updated_at = EXCLUDED.updated_at
+		RETURNING tokens, (SELECT tokens >= 1 FROM bucket);`, key, rate, float64(burst))
+
+	err = row.Scan(&tokens, &allowed)
+	return tokens, allowed, err
+}
+
+// delete buckets nothing was taken from for idle, they would be full again anyway
+func (s *Queries) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error) {
+	return rowsAffected(s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1 * interval '1 millisecond';", idle.Milliseconds()))
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// decisions of risk screening, from least to most severe
+const (
+	RiskAllow  = "allow"
+	RiskReview = "review"
+	RiskDeny   = "deny"
+)
+
+// RiskSeverity orders decisions, unknown ones count as RiskAllow.
+func RiskSeverity(decision string) int {
+	switch decision {
+	case RiskReview:
+		return 1
+	case RiskDeny:
+		return 2
+	}
+	return 0
+}
+
+// a rule that matched a transfer and what it decided
+type RiskReason struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+	Message  string `json:"message"`
+}
+
+// reasons of an assessment, stored as a jsonb array
+type RiskReasons []RiskReason
+
+func (r *RiskReasons) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(src, r)
+	case string:
+		return json.Unmarshal([]byte(src), r)
+	}
+	return fmt.Errorf("cannot scan %T into RiskReasons", src)
+}
+
+// an empty array rather than null
+func (r RiskReasons) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]RiskReason(r))
+	return string(data), err
+}
+
+// create
+func (s *Queries) CreateRiskAssessment(ctx context.Context, assessment RiskAssessment) (*RiskAssessment, error) {
+	var created RiskAssessment
+
+	err := s.db.GetContext(ctx, &created, "INSERT INTO risk_assessments (transfer_id, from_account_id, to_account_id, amount, decision, reasons) VALUES ($1, $2, $3, $4, $5, $6::jsonb) RETURNING id, transfer_id, from_account_id, to_account_id, amount, decision, reasons, created_at;", assessment.TransferID, assessment.FromAccountID, assessment.ToAccountID, assessment.Amount, assessment.Decision, assessment.Reasons)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// read (id)
+func (s *Queries) GetRiskAssessmentByID(ctx context.Context, id int64) (*RiskAssessment, error) {
+	var assessment RiskAssessment
+
+	err := s.db.GetContext(ctx, &assessment, "SELECT id, transfer_id, from_account_id, to_account_id, amount, decision, reasons, created_at FROM risk_assessments WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &assessment, nil
+}
+
+// read (transfer id)
+func (s *Queries) GetRiskAssessmentByTransferID(ctx context.Context, transferID int64) (*RiskAssessment, error) {
+	var assessment RiskAssessment
+
+	err := s.db.GetContext(ctx, &assessment, "SELECT id, transfer_id, from_account_id, to_account_id, amount, decision, reasons, created_at FROM risk_assessments WHERE transfer_id = $1;", transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &assessment, nil
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// kinds of transactions a search runs over
+const (
+	TransactionTransfer = "transfer"
+	TransactionEntry    = "entry"
+)
+
+// direction of a transaction relative to the searched account
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+var ErrInvalidFilter = errors.New("invalid transaction filter")
+
+// what to search an account's transactions for; nil and empty fields do not filter
+type TransactionFilter struct {
+	AccountID      int64
+	Type           string     // TransactionTransfer or TransactionEntry
+	Direction      string     // DirectionIncoming, DirectionOutgoing or both
+	CounterpartyID *int64     // other account of a transfer
+	CreatedFrom    *time.Time // inclusive
+	CreatedTo      *time.Time // exclusive
+	MinAmount      *int64     // absolute amount in cents, inclusive
+	MaxAmount      *int64     // absolute amount in cents, inclusive
+	Reference      string     // exact external reference
+	Memo           string     // case-insensitive part of the description
+}
+
+// reject filters that cannot match by construction, wrapping ErrInvalidFilter
+func (filter TransactionFilter) Validate() error {
+	switch {
+	case filter.AccountID < 1:
+		return fmt.Errorf("%w: account id must be positive", ErrInvalidFilter)
+	case filter.Type != TransactionTransfer && filter.Type != TransactionEntry:
+		return fmt.Errorf("%w: unknown transaction type %q", ErrInvalidFilter, filter.Type)
+	case filter.Direction != "" && filter.Direction != DirectionIncoming && filter.Direction != DirectionOutgoing:
+		return fmt.Errorf("%w: unknown direction %q", ErrInvalidFilter, filter.Direction)
+	case filter.CounterpartyID != nil && filter.Type != TransactionTransfer:
+		return fmt.Errorf("%w: only transfers have a counterparty", ErrInvalidFilter)
+	case filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo):
+		return fmt.Errorf("%w: date range is empty", ErrInvalidFilter)
+	case filter.MinAmount != nil && *filter.MinAmount < 0 || filter.MaxAmount != nil && *filter.MaxAmount < 0:
+		return fmt.Errorf("%w: amounts must not be negative", ErrInvalidFilter)
+	case filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount:
+		return fmt.Errorf("%w: amount range is empty", ErrInvalidFilter)
+	case len(filter.Reference) > MaxExternalReferenceLength || utf8.RuneCountInString(filter.Memo) > MaxDescriptionLength:
+		return fmt.Errorf("%w: search text is longer than what is stored", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// WHERE clause assembled from fixed SQL fragments,
+// values only ever end up as numbered arguments
+type whereBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// placeholder for value as the next argument
+func (where *whereBuilder) bind(value any) string {
+	where.args = append(where.args, value)
+	return fmt.Sprintf("$%d", len(where.args))
+}
+
+func (where *whereBuilder) and(condition string) {
+	where.conditions = append(where.conditions, condition)
+}
+
+func (where *whereBuilder) String() string {
+	return strings.Join(where.conditions, " AND ")
+}
+
+// conditions shared by both kinds, amount is the column expression compared
+func (where *whereBuilder) common(filter TransactionFilter, amount string) {
+	if filter.CreatedFrom != nil {
+		where.and("created_at >= " + where.bind(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		where.and("created_at < " + where.bind(*filter.CreatedTo))
+	}
+	if filter.MinAmount != nil {
+		where.and(amount + " >= " + where.bind(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		where.and(amount + " <= " + where.bind(*filter.MaxAmount))
+	}
+	if filter.Reference != "" {
+		where.and("external_reference = " + where.bind(filter.Reference))
+	}
+	if filter.Memo != "" {
+		where.and("description ILIKE " + where.bind("%"+likeEscaper.Replace(filter.Memo)+"%"))
+	}
+}
+
+// matches LIKE wildcards literally, backslash being the default escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SQL and arguments for one page of filter's transactions, the account is always $1
+func searchQuery(filter TransactionFilter, page Page) (string, []any) {
+	var where whereBuilder
+	var query string
+
+	account := where.bind(filter.AccountID)
+
+	switch filter.Type {
+	case TransactionTransfer:
+		outgoing, incoming := "from_account_id = "+account, "to_account_id = "+account
+		if filter.CounterpartyID != nil {
+			counterparty := where.bind(*filter.CounterpartyID)
+			outgoing = "(" + outgoing + " AND to_account_id = " + counterparty + ")"
+			incoming = "(" + incoming + " AND from_account_id = " + counterparty + ")"
+		}
+
+		switch filter.Direction {
+		case DirectionOutgoing:
+			where.and(outgoing)
+		case DirectionIncoming:
+			where.and(incoming)
+		default:
+			where.and("(" + outgoing + " OR " + incoming + ")")
+		}
+		where.common(filter, "amount")
+
+		query = `SELECT 'transfer' AS type, id, $1::bigint AS account_id,
+			CASE WHEN from_account_id = $1 THEN to_account_id ELSE from_account_id END AS counterparty_id,
+			CASE WHEN from_account_id = $1 THEN 'outgoing' ELSE 'incoming' END AS direction,
+			CASE WHEN from_account_id = $1 THEN -amount ELSE amount END AS amount,
+			description, external_reference, metadata, created_at FROM transfers WHERE `
+
+	case TransactionEntry:
+		where.and("account_id = " + account)
+		switch filter.Direction {
+		case DirectionOutgoing:
+			where.and("amount < 0")
+		case DirectionIncoming:
+			where.and("amount >= 0")
+		}
+		where.common(filter, "abs(amount)")
+
+		query = `SELECT 'entry' AS type, id, account_id, NULL::bigint AS counterparty_id,
+			CASE WHEN amount < 0 THEN 'outgoing' ELSE 'incoming' END AS direction,
+			amount, description, external_reference, metadata, created_at FROM entries WHERE `
+	}
+
+	clause, args := page.clause(len(where.args) + 1)
+	return query + where.String() + clause + ";", append(where.args, args...)
+}
+
+// read filter's transactions (keyset pagination)
+func (s *Queries) SearchTransactions(ctx context.Context, filter TransactionFilter, page Page) (*[]Transaction, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+
+	query, args := searchQuery(filter, page)
+	err := s.db.SelectContext(ctx, &transactions, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transactions, nil
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Filter values must only ever reach the database as arguments, never as SQL.
+func TestSearchQueryArguments(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	counterparty, minAmount := int64(9), int64(100)
+
+	query, args := searchQuery(TransactionFilter{
+		AccountID:      7,
+		Type:           TransactionTransfer,
+		Direction:      DirectionOutgoing,
+		CounterpartyID: &counterparty,
+		CreatedFrom:    &from,
+		MinAmount:      &minAmount,
+	}, Page{Limit: 5, After: &PageKey{CreatedAt: from, ID: 3}})
+
+	require.Contains(t, query, "FROM transfers WHERE (from_account_id = $1 AND to_account_id = $2) AND created_at >= $3 AND amount >= $4 AND (created_at, id) > ($5, $6) ORDER BY created_at ASC, id ASC LIMIT $7;")
+	require.Equal(t, []any{int64(7), int64(9), from, int64(100), from, int64(3), int64(5)}, args)
+
+	query, args = searchQuery(TransactionFilter{AccountID: 7, Type: TransactionTransfer}, Page{Limit: 5, Order: SortDesc})
+	require.Contains(t, query, "WHERE (from_account_id = $1 OR to_account_id = $1) ORDER BY created_at DESC, id DESC LIMIT $2;")
+	require.Equal(t, []any{int64(7), int64(5)}, args)
+
+	query, args = searchQuery(TransactionFilter{AccountID: 7, Type: TransactionEntry, Direction: DirectionIncoming, MaxAmount: &minAmount}, Page{Limit: 5})
+	require.Contains(t, query, "FROM entries WHERE account_id = $1 AND amount >= 0 AND abs(amount) <= $2 ORDER BY")
+	require.Equal(t, []any{int64(7), int64(100), int64(5)}, args)
+
+	require.False(t, strings.Contains(query, "100"))
+
+	// LIKE wildcards in a memo only match themselves
+	query, args = searchQuery(TransactionFilter{AccountID: 7, Type: TransactionEntry, Memo: `50%_off\`, Reference: "order-1"}, Page{Limit: 5})
+	require.Contains(t, query, "WHERE account_id = $1 AND external_reference = $2 AND description ILIKE $3 ORDER BY")
+	require.Equal(t, []any{int64(7), "order-1", `%50\%\_off\\%`, int64(5)}, args)
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const businessDateLayout = "2006-01-02"
+
+var ErrBusinessDayClosed = errors.New("posting falls into a closed business day")
+
+// truncate t to its calendar date in location (returned as midnight UTC, the way date columns are scanned)
+func BusinessDate(t time.Time, location *time.Location) time.Time {
+	year, month, day := t.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// first instant after businessDate in location
+func businessDayCutoff(businessDate time.Time, location *time.Location) time.Time {
+	year, month, day := businessDate.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, location)
+}
+
+// create (business day marker)
+func (s *Queries) CreateBusinessDay(ctx context.Context, businessDate time.Time, timezone string, cutoff time.Time) (*BusinessDay, error) {
+	var day BusinessDay
+
+	err := s.db.GetContext(ctx, &day, "INSERT INTO business_days (business_date, timezone, account_count) SELECT $1, $2, COUNT(*) FROM accounts WHERE created_at < $3 RETURNING business_date, timezone, account_count, closed_at;", businessDate.Format(businessDateLayout), timezone, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	return &day, nil
+}
+
+// read (business date)
+func (s *Queries) GetBusinessDay(ctx context.Context, businessDate time.Time) (*BusinessDay, error) {
+	var day BusinessDay
+
+	err := s.db.GetContext(ctx, &day, "SELECT business_date, timezone, account_count, closed_at FROM business_days WHERE business_date = $1;", businessDate.Format(businessDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	return &day, nil
+}
+
+// read (most recently closed)
+func (s *Queries) GetLastClosedBusinessDay(ctx context.Context) (*BusinessDay, error) {
+	var day BusinessDay
+
+	err := s.db.GetContext(ctx, &day, "SELECT business_date, timezone, account_count, closed_at FROM business_days ORDER BY business_date DESC LIMIT 1;")
+	if err != nil {
+		return nil, err
+	}
+
+	return &day, nil
+}
+
+// create closing balance of every account that existed before cutoff
+// closing balance = current balance - everything posted at or after cutoff
+func (s *Queries) CreateBalanceSnapshots(ctx context.Context, businessDate, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `INSERT INTO balance_snapshots (account_id, business_date, closing_balance, currency)
+		SELECT a.id, $1, a.balance - COALESCE(SUM(e.amount), 0), a.currency
+		FROM accounts a LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= $2
+		WHERE a.created_at < $2
+		GROUP BY a.id
+		ON CONFLICT DO NOTHING;`, businessDate.Format(businessDateLayout), cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// create per currency totals from the business day's snapshots
+func (s *Queries) CreateCurrencyTotals(ctx context.Context, businessDate time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `INSERT INTO currency_totals (business_date, currency, total_balance, account_count)
+		SELECT business_date, currency, SUM(closing_balance)::bigint, COUNT(*)
+		FROM balance_snapshots WHERE business_date = $1
+		GROUP BY business_date, currency
+		ON CONFLICT DO NOTHING;`, businessDate.Format(businessDateLayout))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// read (account_id, business date)
+func (s *Queries) GetBalanceSnapshot(ctx context.Context, accountID int64, businessDate time.Time) (*BalanceSnapshot, error) {
+	var snapshot BalanceSnapshot
+
+	err := s.db.GetContext(ctx, &snapshot, "SELECT account_id, business_date, closing_balance, currency, created_at FROM balance_snapshots WHERE account_id = $1 AND business_date = $2;", accountID, businessDate.Format(businessDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// read latest snapshot on or before business date (as-of balance)
+func (s *Queries) GetBalanceSnapshotAsOf(ctx context.Context, accountID int64, businessDate time.Time) (*BalanceSnapshot, error) {
+	var snapshot BalanceSnapshot
+
+	err := s.db.GetContext(ctx, &snapshot, "SELECT account_id, business_date, closing_balance, currency, created_at FROM balance_snapshots WHERE account_id = $1 AND business_date <= $2 ORDER BY business_date DESC LIMIT 1;", accountID, businessDate.Format(businessDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// read all currency totals for business date
+func (s *Queries) GetCurrencyTotals(ctx context.Context, businessDate time.Time) (*[]CurrencyTotal, error) {
+	var totals []CurrencyTotal
+
+	err := s.db.SelectContext(ctx, &totals, "SELECT business_date, currency, total_balance, account_count FROM currency_totals WHERE business_date = $1 ORDER BY currency;", businessDate.Format(businessDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	return &totals, nil
+}
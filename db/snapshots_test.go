@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func yesterday() time.Time {
+	return BusinessDate(time.Now(), time.UTC).AddDate(0, 0, -1)
+}
+
+func TestCloseBusinessDay(t *testing.T) {
+	day, err := testStore(t).CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+	require.NotEmpty(t, day)
+
+	require.Equal(t, yesterday(), day.BusinessDate)
+	require.Equal(t, "UTC", day.Timezone)
+	require.NotZero(t, day.ClosedAt)
+
+	closedDay, err := testStore(t).GetBusinessDay(context.Background(), yesterday())
+	require.NoError(t, err)
+	require.Equal(t, day.BusinessDate, closedDay.BusinessDate)
+	require.Equal(t, day.AccountCount, closedDay.AccountCount)
+	require.WithinDuration(t, day.ClosedAt, closedDay.ClosedAt, time.Second)
+
+	lastClosed, err := testStore(t).GetLastClosedBusinessDay(context.Background())
+	require.NoError(t, err)
+	require.False(t, lastClosed.BusinessDate.Before(yesterday()))
+
+	totals, err := testStore(t).GetCurrencyTotals(context.Background(), yesterday())
+	require.NoError(t, err)
+	var accountCount int64
+	for _, total := range *totals {
+		accountCount += total.AccountCount
+	}
+	require.Equal(t, day.AccountCount, accountCount)
+}
+
+func TestCloseBusinessDayRerun(t *testing.T) {
+	first, err := testStore(t).CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	second, err := testStore(t).CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	require.Equal(t, first.BusinessDate, second.BusinessDate)
+	require.Equal(t, first.AccountCount, second.AccountCount)
+	require.WithinDuration(t, first.ClosedAt, second.ClosedAt, time.Second)
+}
+
+func TestCloseBusinessDayNotEnded(t *testing.T) {
+	_, err := testStore(t).CloseBusinessDay(context.Background(), BusinessDate(time.Now(), time.UTC), time.UTC)
+	require.Error(t, err)
+}
+
+func TestBalanceSnapshotSkipsNewAccounts(t *testing.T) {
+	_, err := testStore(t).CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	account := createRandomAccount(t)
+
+	snapshot, err := testStore(t).GetBalanceSnapshot(context.Background(), account.ID, yesterday())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.Empty(t, snapshot)
+}
+
+func TestBackdatedPostingRejected(t *testing.T) {
+	_, err := testStore(t).CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	account := createRandomAccount(t)
+	conn := testStore(t).(*SQLStore).conn
+
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO entries (account_id, amount, created_at) VALUES ($1, $2, $3);", account.ID, 100, yesterday().Add(12*time.Hour))
+	require.True(t, violates(err, "business_day_closed"))
+
+	// postings into the open business day still go through
+	_, err = testStore(t).CreateEntry(context.Background(), account.ID, 100, Details{})
+	require.NoError(t, err)
+
+	// close today in a transaction that never commits, so no other test finds it closed
+	tx := conn.MustBeginTx(context.Background(), nil)
+	defer tx.Rollback()
+	tx.MustExecContext(context.Background(), "INSERT INTO business_days (business_date, timezone, account_count) VALUES ((now() AT TIME ZONE 'UTC')::date, 'UTC', 0);")
+
+	_, err = NewQueries(tx).CreateEntry(context.Background(), account.ID, 100, Details{})
+	require.ErrorIs(t, err, ErrBusinessDayClosed)
+}
@@ -2,6 +2,9 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -12,29 +15,100 @@ type Store interface {
 	GetAccountByID(ctx context.Context, id int64) (*Account, error)
 	GetAccountByIDForUpdate(ctx context.Context, id int64) (*Account, error)
 	GetAccountsByOwner(ctx context.Context, owner string) (*[]Account, error)
-	ListAccounts(ctx context.Context, owner string, limit, offset int64) (*[]Account, error)
+	ListAccounts(ctx context.Context, owner string, page Page) (*[]Account, error)
 	UpdateAccount(ctx context.Context, account *Account) (int64, error)
 	UpdateAccountOwner(ctx context.Context, id int64, newOwner string) (int64, error)
 	UpdateAccountBalance(ctx context.Context, id int64, balance int64) (int64, error)
 	AddAccountBalance(ctx context.Context, id int64, amount int64) (*Account, error)
 	DeleteAccountByID(ctx context.Context, id int64) (int64, error)
-	CreateEntry(ctx context.Context, accountID, amount int64) (*Entry, error)
+	SetAccountFrozen(ctx context.Context, id int64, frozen bool) (int64, error)
+	AdjustAccountBalance(ctx context.Context, accountID, amount int64, reason, actor string) (*BalanceAdjustment, error)
+	CreateEntry(ctx context.Context, accountID, amount int64, details Details) (*Entry, error)
 	GetEntryByID(ctx context.Context, id int64) (*Entry, error)
-	GetEntriesByAccountID(ctx context.Context, account_id, limit, offset int64) (*[]Entry, error)
-	CreateTransfer(ctx context.Context, from_account_id, to_account_id, amount int64) (*Transfer, error)
+	GetEntriesByAccountID(ctx context.Context, account_id int64, page Page) (*[]Entry, error)
+	CreateTransfer(ctx context.Context, from_account_id, to_account_id, amount int64, details Details) (*Transfer, error)
 	GetTransferByID(ctx context.Context, id int64) (*Transfer, error)
-	GetTransfersFromTo(ctx context.Context, from_account_id, to_account_id, limit, offset int64) (*[]Transfer, error)
-	TransferMoney(ctx context.Context, from_account_id, to_account_id, amount int64) (*TransferTxResult, error)
+	GetTransferByExternalReference(ctx context.Context, from_account_id int64, external_reference string) (*Transfer, error)
+	GetTransfersFromTo(ctx context.Context, from_account_id, to_account_id int64, page Page) (*[]Transfer, error)
+	TransferMoney(ctx context.Context, from_account_id, to_account_id, amount int64, details Details) (*TransferTxResult, error)
+	SearchTransactions(ctx context.Context, filter TransactionFilter, page Page) (*[]Transaction, error)
+	CloseBusinessDay(ctx context.Context, businessDate time.Time, location *time.Location) (*BusinessDay, error)
+	GetBusinessDay(ctx context.Context, businessDate time.Time) (*BusinessDay, error)
+	GetLastClosedBusinessDay(ctx context.Context) (*BusinessDay, error)
+	GetBalanceSnapshot(ctx context.Context, accountID int64, businessDate time.Time) (*BalanceSnapshot, error)
+	GetBalanceSnapshotAsOf(ctx context.Context, accountID int64, businessDate time.Time) (*BalanceSnapshot, error)
+	GetCurrencyTotals(ctx context.Context, businessDate time.Time) (*[]CurrencyTotal, error)
+	GetOutboxEventsByAggregate(ctx context.Context, aggregateType string, aggregateID int64) (*[]OutboxEvent, error)
+	PublishOutboxEvents(ctx context.Context, limit int64, publish func(ctx context.Context, event OutboxEvent) error) (int64, error)
+	GetOutboxEventByID(ctx context.Context, id int64) (*OutboxEvent, error)
+	GetOutboxEventsAfter(ctx context.Context, afterID, limit int64) (*[]OutboxEvent, error)
+	GetLastOutboxEventID(ctx context.Context) (int64, error)
+	ListenOutboxEvents(ctx context.Context, handle func(ctx context.Context, event OutboxEvent) error) error
+	GetSchemaVersion(ctx context.Context) (version int64, dirty bool, err error)
+	Ping(ctx context.Context) error
+	Close() error
+	CreateWebhookSubscription(ctx context.Context, owner, url string, eventTypes []string, secret string) (*WebhookSubscription, error)
+	GetWebhookSubscriptionByID(ctx context.Context, id int64) (*WebhookSubscription, error)
+	GetWebhookSubscriptionsByOwner(ctx context.Context, owner string) (*[]WebhookSubscription, error)
+	GetWebhookSubscriptionsForEvent(ctx context.Context, owner, eventType string) (*[]WebhookSubscription, error)
+	DeleteWebhookSubscriptionByID(ctx context.Context, id int64) (int64, error)
+	CreateWebhookDelivery(ctx context.Context, subscriptionID, eventID int64, eventType string, payload json.RawMessage) (int64, error)
+	GetWebhookDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int64, page Page) (*[]WebhookDelivery, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int64, lease time.Duration) (*[]WebhookDelivery, error)
+	MarkWebhookDeliverySucceeded(ctx context.Context, id, statusCode int64) (int64, error)
+	MarkWebhookDeliveryFailed(ctx context.Context, id, statusCode int64, lastError string, nextAttemptAt time.Time, maxAttempts int64) (int64, error)
+	ReplayWebhookDelivery(ctx context.Context, id int64) (*WebhookDelivery, error)
+	CreatePayee(ctx context.Context, owner string, accountID int64, nickname string) (*Payee, error)
+	GetPayeeByID(ctx context.Context, id int64) (*Payee, error)
+	GetPayeesByOwner(ctx context.Context, owner string) (*[]Payee, error)
+	UpdatePayeeNickname(ctx context.Context, id int64, nickname string) (int64, error)
+	DeletePayeeByID(ctx context.Context, id int64) (int64, error)
+	SetTransferLimit(ctx context.Context, limit TransferLimit) (*TransferLimit, error)
+	GetTransferLimitByID(ctx context.Context, id int64) (*TransferLimit, error)
+	GetTransferLimits(ctx context.Context) (*[]TransferLimit, error)
+	DeleteTransferLimitByID(ctx context.Context, id int64) (int64, error)
+	CreateRiskAssessment(ctx context.Context, assessment RiskAssessment) (*RiskAssessment, error)
+	GetRiskAssessmentByID(ctx context.Context, id int64) (*RiskAssessment, error)
+	GetRiskAssessmentByTransferID(ctx context.Context, transferID int64) (*RiskAssessment, error)
+	CreateTransferRequest(ctx context.Context, request TransferRequest) (*TransferRequest, error)
+	GetTransferRequestByID(ctx context.Context, id int64) (*TransferRequest, error)
+	ListTransferRequests(ctx context.Context, status string, page Page) (*[]TransferRequest, error)
+	GetTransferRequestEvents(ctx context.Context, requestID int64) (*[]TransferRequestEvent, error)
+	DecideTransferRequest(ctx context.Context, id int64, reviewer, status, note string) (*TransferRequest, error)
+	FinishTransferRequest(ctx context.Context, id int64, transferID *int64, failure string) (*TransferRequest, error)
+	ApproveTransferRequest(ctx context.Context, id int64, reviewer, note string) (*TransferRequest, error)
+	ExpireTransferRequests(ctx context.Context) (int64, error)
+	SetUserRole(ctx context.Context, username, role string) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUsers(ctx context.Context) (*[]User, error)
+	CreateAPIKey(ctx context.Context, key APIKey) (*APIKey, error)
+	GetAPIKeyByID(ctx context.Context, id int64) (*APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	GetAPIKeys(ctx context.Context) (*[]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (int64, error)
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+	RotateAPIKey(ctx context.Context, id int64, replacement APIKey, oldExpiresAt time.Time) (*APIKey, error)
+	UseAPIKeyNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) error
+	TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int64) (tokens float64, allowed bool, err error)
+	DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error)
 }
 
 type SQLStore struct {
 	*Queries
-	conn *sqlx.DB
+	conn   *sqlx.DB
+	logger *slog.Logger
 }
 
-func NewStore(conn *sqlx.DB) Store {
+func NewStore(conn *sqlx.DB, logger *slog.Logger) Store {
 	return &SQLStore{
-		Queries: NewQueries(conn),
+		Queries: newQueries(conn, logger),
 		conn:    conn,
+		logger:  logger,
 	}
 }
+
+// queries running in tx, logging like the store
+func (s *SQLStore) inTx(tx *sqlx.Tx) *Queries {
+	return newQueries(tx, s.logger)
+}
@@ -0,0 +1,368 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var accountTests = []conformanceTest{
+	{"CreateAndGetAccount", testCreateAndGetAccount},
+	{"GetAccountForUpdate", testGetAccountForUpdate},
+	{"GetMissingAccount", testGetMissingAccount},
+	{"NegativeBalanceRejected", testNegativeBalanceRejected},
+	{"GetAccountsByOwner", testGetAccountsByOwner},
+	{"ListAccountsPages", testListAccountsPages},
+	{"UpdateAccount", testUpdateAccount},
+	{"UpdateAccountOwnerAndBalance", testUpdateAccountOwnerAndBalance},
+	{"UpdateMissingAccount", testUpdateMissingAccount},
+	{"AddAccountBalance", testAddAccountBalance},
+	{"FreezeAccount", testFreezeAccount},
+	{"AdjustAccountBalance", testAdjustAccountBalance},
+	{"DeleteAccount", testDeleteAccount},
+	{"DeleteAccountWithPostings", testDeleteAccountWithPostings},
+}
+
+func testCreateAndGetAccount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner, balance := utils.RandomOwner(), utils.RandomMoney()
+
+	account := createAccount(t, store, owner, balance)
+	require.Equal(t, owner, account.Owner)
+	require.Equal(t, balance, account.Balance)
+	require.Equal(t, currency.USD, account.Currency)
+	require.NotZero(t, account.CreatedAt)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, found.ID)
+	require.Equal(t, account.Owner, found.Owner)
+	require.Equal(t, account.Balance, found.Balance)
+	require.Equal(t, account.Currency, found.Currency)
+	require.True(t, account.CreatedAt.Equal(found.CreatedAt))
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 1)
+	require.Equal(t, db.EventAccountCreated, (*events)[0].EventType)
+}
+
+func testGetAccountForUpdate(t *testing.T, store db.Store) {
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	found, err := store.GetAccountByIDForUpdate(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, found.ID)
+	require.Equal(t, account.Balance, found.Balance)
+
+	_, err = store.GetAccountByIDForUpdate(context.Background(), missingID(account.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testGetMissingAccount(t *testing.T, store db.Store) {
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	_, err := store.GetAccountByID(context.Background(), missingID(account.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	accounts, err := store.GetAccountsByOwner(context.Background(), utils.RandomOwner()+"-nobody")
+	require.NoError(t, err)
+	require.Empty(t, *accounts)
+}
+
+func testNegativeBalanceRejected(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner := utils.RandomOwner()
+
+	_, err := store.CreateAccount(ctx, owner, -1, currency.USD)
+	require.Error(t, err)
+
+	account := createAccount(t, store, owner, 10)
+
+	_, err = store.UpdateAccountBalance(ctx, account.ID, -1)
+	require.Error(t, err)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), found.Balance)
+
+	// the rejected account was never created
+	accounts, err := store.GetAccountsByOwner(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, *accounts, 1)
+}
+
+func testGetAccountsByOwner(t *testing.T, store db.Store) {
+	owner := utils.RandomOwner()
+	first := createAccount(t, store, owner, utils.RandomMoney())
+	createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	second := createAccount(t, store, owner, utils.RandomMoney())
+
+	accounts, err := store.GetAccountsByOwner(context.Background(), owner)
+	require.NoError(t, err)
+	require.Len(t, *accounts, 2)
+
+	var ids []int64
+	for _, account := range *accounts {
+		require.Equal(t, owner, account.Owner)
+		ids = append(ids, account.ID)
+	}
+	require.ElementsMatch(t, []int64{first.ID, second.ID}, ids)
+}
+
+func testListAccountsPages(t *testing.T, store db.Store) {
+	owner := utils.RandomOwner()
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, createAccount(t, store, owner, utils.RandomMoney()).ID)
+	}
+	createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	requirePages(t, ids, func(page db.Page) ([]db.PageKey, error) {
+		accounts, err := store.ListAccounts(context.Background(), owner, page)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []db.PageKey
+		for _, account := range *accounts {
+			require.Equal(t, owner, account.Owner)
+			keys = append(keys, db.PageKey{CreatedAt: account.CreatedAt, ID: account.ID})
+		}
+		return keys, nil
+	})
+}
+
+func testUpdateAccount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	updated := *account
+	updated.Owner = utils.RandomOwner()
+	updated.Balance = account.Balance + 100
+	updated.Currency = currency.INR
+
+	rows, err := store.UpdateAccount(ctx, &updated)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated.Owner, found.Owner)
+	require.Equal(t, updated.Balance, found.Balance)
+	require.Equal(t, currency.INR, found.Currency)
+	require.True(t, account.CreatedAt.Equal(found.CreatedAt))
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 2)
+	require.Equal(t, db.EventAccountUpdated, (*events)[1].EventType)
+}
+
+func testUpdateAccountOwnerAndBalance(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	newOwner := utils.RandomOwner()
+
+	rows, err := store.UpdateAccountOwner(ctx, account.ID, newOwner)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = store.UpdateAccountBalance(ctx, account.ID, 42)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, newOwner, found.Owner)
+	require.Equal(t, int64(42), found.Balance)
+
+	// every change is recorded, in order
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	var types []string
+	for _, event := range *events {
+		types = append(types, event.EventType)
+	}
+	require.Equal(t, []string{db.EventAccountCreated, db.EventAccountOwnerChanged, db.EventAccountBalanceChanged}, types)
+}
+
+// updates of a missing account affect no rows and are not an error
+func testUpdateMissingAccount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	missing := missingID(account.ID)
+
+	rows, err := store.UpdateAccount(ctx, &db.Account{ID: missing, Owner: account.Owner, Currency: currency.USD})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.UpdateAccountOwner(ctx, missing, utils.RandomOwner())
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.UpdateAccountBalance(ctx, missing, 10)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	_, err = store.AddAccountBalance(ctx, missing, 10)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// frozen accounts neither send nor receive transfers until unfrozen
+func testFreezeAccount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 100)
+	other := createAccount(t, store, utils.RandomOwner(), 100)
+	require.False(t, account.Frozen)
+
+	rows, err := store.SetAccountFrozen(ctx, account.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.True(t, found.Frozen)
+
+	_, err = store.TransferMoney(ctx, account.ID, other.ID, 10, db.Details{})
+	require.ErrorIs(t, err, db.ErrAccountFrozen)
+	_, err = store.TransferMoney(ctx, other.ID, account.ID, 10, db.Details{})
+	require.ErrorIs(t, err, db.ErrAccountFrozen)
+
+	found, err = store.GetAccountByID(ctx, other.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), found.Balance)
+
+	rows, err = store.SetAccountFrozen(ctx, account.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.TransferMoney(ctx, account.ID, other.ID, 10, db.Details{})
+	require.NoError(t, err)
+
+	rows, err = store.SetAccountFrozen(ctx, missingID(account.ID), true)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	var types []string
+	for _, event := range *events {
+		types = append(types, event.EventType)
+	}
+	require.Equal(t, []string{db.EventAccountCreated, db.EventAccountFrozen, db.EventAccountUnfrozen, db.EventAccountBalanceChanged}, types)
+
+	var payload db.Account
+	require.NoError(t, (*events)[1].Decode(&payload))
+	require.True(t, payload.Frozen)
+}
+
+// an adjustment is an entry of its own, with who made it and why
+func testAdjustAccountBalance(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 100)
+
+	adjustment, err := store.AdjustAccountBalance(ctx, account.ID, -40, "chargeback 1234", "admin")
+	require.NoError(t, err)
+	require.Equal(t, int64(60), adjustment.Account.Balance)
+	require.Equal(t, account.ID, adjustment.Entry.AccountID)
+	require.Equal(t, int64(-40), adjustment.Entry.Amount)
+	require.Equal(t, "chargeback 1234", adjustment.Entry.Description)
+	require.Equal(t, db.Metadata{db.AdjustedByKey: "admin"}, adjustment.Entry.Metadata)
+
+	entry, err := store.GetEntryByID(ctx, adjustment.Entry.ID)
+	require.NoError(t, err)
+	require.Equal(t, adjustment.Entry.Description, entry.Description)
+
+	// overdrawing fails and leaves no entry behind
+	_, err = store.AdjustAccountBalance(ctx, account.ID, -61, "too much", "admin")
+	require.ErrorIs(t, err, db.ErrAdjustmentOverdraws)
+
+	entries, err := store.GetEntriesByAccountID(ctx, account.ID, db.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, *entries, 1)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), found.Balance)
+
+	_, err = store.AdjustAccountBalance(ctx, missingID(account.ID), 10, "missing", "admin")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testAddAccountBalance(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 10)
+
+	updated, err := store.AddAccountBalance(ctx, account.ID, 5)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, updated.ID)
+	require.Equal(t, int64(15), updated.Balance)
+
+	// overdrawing fails and leaves the balance as it was
+	_, err = store.AddAccountBalance(ctx, account.ID, -16)
+	require.Error(t, err)
+
+	found, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(15), found.Balance)
+
+	updated, err = store.AddAccountBalance(ctx, account.ID, -15)
+	require.NoError(t, err)
+	require.Zero(t, updated.Balance)
+}
+
+func testDeleteAccount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	rows, err := store.DeleteAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetAccountByID(ctx, account.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err = store.DeleteAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 2)
+	require.Equal(t, db.EventAccountDeleted, (*events)[1].EventType)
+}
+
+// postings keep their account
+func testDeleteAccountWithPostings(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	_, err := store.CreateEntry(ctx, account.ID, 10, db.Details{})
+	require.NoError(t, err)
+
+	_, err = store.DeleteAccountByID(ctx, account.ID)
+	require.Error(t, err)
+
+	_, err = store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+
+	from := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	to := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	_, err = store.CreateTransfer(ctx, from.ID, to.ID, 10, db.Details{})
+	require.NoError(t, err)
+
+	for _, id := range []int64{from.ID, to.ID} {
+		_, err = store.DeleteAccountByID(ctx, id)
+		require.Error(t, err)
+
+		_, err = store.GetAccountByID(ctx, id)
+		require.NoError(t, err)
+	}
+}
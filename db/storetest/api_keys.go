@@ -0,0 +1,173 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var apiKeyTests = []conformanceTest{
+	{"CreateAndGetAPIKey", testCreateAndGetAPIKey},
+	{"RevokeAPIKey", testRevokeAPIKey},
+	{"TouchAPIKey", testTouchAPIKey},
+	{"RotateAPIKey", testRotateAPIKey},
+	{"UseAPIKeyNonce", testUseAPIKeyNonce},
+}
+
+func createAPIKey(t *testing.T, store db.Store, accountIDs ...int64) *db.APIKey {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	key, err := store.CreateAPIKey(context.Background(), db.APIKey{
+		Name:          utils.RandomOwner(),
+		Prefix:        utils.RandomString(12),
+		KeyHash:       utils.RandomString(64),
+		SigningSecret: utils.RandomString(32),
+		Permissions:   db.StringArray{"accounts:read", "transfers:create"},
+		AccountIDs:    accountIDs,
+		CreatedBy:     utils.RandomOwner(),
+		ExpiresAt:     &expiresAt,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, key.ID)
+	return key
+}
+
+func testCreateAndGetAPIKey(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 0)
+
+	key := createAPIKey(t, store, account.ID)
+	require.Equal(t, db.Int64Array{account.ID}, key.AccountIDs)
+	require.Nil(t, key.LastUsedAt)
+	require.Nil(t, key.RevokedAt)
+	require.True(t, key.Active(time.Now()))
+	require.False(t, key.Active(key.ExpiresAt.Add(time.Second)))
+
+	found, err := store.GetAPIKeyByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, *key, *found)
+
+	found, err = store.GetAPIKeyByPrefix(ctx, key.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, found.ID)
+	require.Equal(t, key.KeyHash, found.KeyHash)
+
+	// no accounts is every account
+	unrestricted := createAPIKey(t, store)
+	require.Empty(t, unrestricted.AccountIDs)
+
+	_, err = store.GetAPIKeyByID(ctx, missingID(unrestricted.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetAPIKeyByPrefix(ctx, "missing")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	keys, err := store.GetAPIKeys(ctx)
+	require.NoError(t, err)
+	var listed []int64
+	for _, listedKey := range *keys {
+		if listedKey.ID == key.ID || listedKey.ID == unrestricted.ID {
+			listed = append(listed, listedKey.ID)
+		}
+	}
+	require.Equal(t, []int64{key.ID, unrestricted.ID}, listed)
+}
+
+func testRevokeAPIKey(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	key := createAPIKey(t, store)
+
+	rows, err := store.RevokeAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	found, err := store.GetAPIKeyByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.RevokedAt)
+	require.False(t, found.Active(time.Now()))
+
+	rows, err = store.RevokeAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.RevokeAPIKey(ctx, missingID(key.ID))
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+// uses within a minute of the recorded one are not written
+func testTouchAPIKey(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	key := createAPIKey(t, store)
+	usedAt := time.Now().Truncate(time.Microsecond)
+
+	require.NoError(t, store.TouchAPIKey(ctx, key.ID, usedAt))
+	require.NoError(t, store.TouchAPIKey(ctx, key.ID, usedAt.Add(30*time.Second)))
+
+	found, err := store.GetAPIKeyByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.LastUsedAt)
+	require.True(t, usedAt.Equal(*found.LastUsedAt))
+
+	require.NoError(t, store.TouchAPIKey(ctx, key.ID, usedAt.Add(2*time.Minute)))
+	found, err = store.GetAPIKeyByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.True(t, usedAt.Add(2*time.Minute).Equal(*found.LastUsedAt))
+}
+
+// the new key takes everything but the key material over, the old one expires after the grace period
+func testRotateAPIKey(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 0)
+	key := createAPIKey(t, store, account.ID)
+	graceEnd := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+
+	rotated, err := store.RotateAPIKey(ctx, key.ID, db.APIKey{Prefix: utils.RandomString(12), KeyHash: utils.RandomString(64), SigningSecret: utils.RandomString(32), CreatedBy: "rotator"}, graceEnd)
+	require.NoError(t, err)
+	require.NotEqual(t, key.ID, rotated.ID)
+	require.Equal(t, key.Name, rotated.Name)
+	require.Equal(t, key.Permissions, rotated.Permissions)
+	require.Equal(t, key.AccountIDs, rotated.AccountIDs)
+	require.True(t, key.ExpiresAt.Equal(*rotated.ExpiresAt))
+	require.Equal(t, "rotator", rotated.CreatedBy)
+	require.NotEqual(t, key.KeyHash, rotated.KeyHash)
+
+	old, err := store.GetAPIKeyByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, rotated.ID, *old.RotatedTo)
+	require.True(t, graceEnd.Equal(*old.ExpiresAt))
+
+	// a grace period never extends the old key
+	_, err = store.RotateAPIKey(ctx, rotated.ID, db.APIKey{Prefix: utils.RandomString(12), KeyHash: utils.RandomString(64), SigningSecret: utils.RandomString(32)}, time.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+	found, err := store.GetAPIKeyByID(ctx, rotated.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.ExpiresAt.Equal(*found.ExpiresAt))
+
+	_, err = store.RevokeAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	_, err = store.RotateAPIKey(ctx, key.ID, db.APIKey{Prefix: utils.RandomString(12), KeyHash: utils.RandomString(64)}, time.Now())
+	require.ErrorIs(t, err, db.ErrAPIKeyInactive)
+
+	_, err = store.RotateAPIKey(ctx, missingID(key.ID), db.APIKey{Prefix: utils.RandomString(12), KeyHash: utils.RandomString(64)}, time.Now())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testUseAPIKeyNonce(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	key, other := createAPIKey(t, store), createAPIKey(t, store)
+	expiresAt := time.Now().Add(time.Minute)
+
+	require.NoError(t, store.UseAPIKeyNonce(ctx, key.ID, "n1", expiresAt))
+	require.ErrorIs(t, store.UseAPIKeyNonce(ctx, key.ID, "n1", expiresAt), db.ErrNonceReused)
+	require.NoError(t, store.UseAPIKeyNonce(ctx, key.ID, "n2", expiresAt))
+	// nonces are per key
+	require.NoError(t, store.UseAPIKeyNonce(ctx, other.ID, "n1", expiresAt))
+
+	// expired nonces are forgotten
+	require.NoError(t, store.UseAPIKeyNonce(ctx, key.ID, "n3", time.Now().Add(-time.Second)))
+	require.NoError(t, store.UseAPIKeyNonce(ctx, key.ID, "n3", expiresAt))
+}
@@ -0,0 +1,86 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var entryTests = []conformanceTest{
+	{"CreateAndGetEntry", testCreateAndGetEntry},
+	{"EntryForeignKey", testEntryForeignKey},
+	{"EntriesPages", testEntriesPages},
+}
+
+func testCreateAndGetEntry(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	// entries are postings only, they leave the balance alone
+	entry, err := store.CreateEntry(ctx, account.ID, -10, db.Details{})
+	require.NoError(t, err)
+	require.NotZero(t, entry.ID)
+	require.Equal(t, account.ID, entry.AccountID)
+	require.Equal(t, int64(-10), entry.Amount)
+	require.NotZero(t, entry.CreatedAt)
+
+	found, err := store.GetEntryByID(ctx, entry.ID)
+	require.NoError(t, err)
+	require.Equal(t, entry.ID, found.ID)
+	require.Equal(t, entry.AccountID, found.AccountID)
+	require.Equal(t, entry.Amount, found.Amount)
+	require.True(t, entry.CreatedAt.Equal(found.CreatedAt))
+
+	unchanged, err := store.GetAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, unchanged.Balance)
+
+	_, err = store.GetEntryByID(ctx, missingID(entry.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testEntryForeignKey(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	_, err := store.CreateEntry(ctx, missingID(account.ID), 10, db.Details{})
+	require.Error(t, err)
+
+	entries, err := store.GetEntriesByAccountID(ctx, missingID(account.ID), db.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, *entries)
+}
+
+func testEntriesPages(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	other := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		entry, err := store.CreateEntry(ctx, account.ID, utils.RandomMoney(), db.Details{})
+		require.NoError(t, err)
+		ids = append(ids, entry.ID)
+
+		_, err = store.CreateEntry(ctx, other.ID, utils.RandomMoney(), db.Details{})
+		require.NoError(t, err)
+	}
+
+	requirePages(t, ids, func(page db.Page) ([]db.PageKey, error) {
+		entries, err := store.GetEntriesByAccountID(ctx, account.ID, page)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []db.PageKey
+		for _, entry := range *entries {
+			require.Equal(t, account.ID, entry.AccountID)
+			keys = append(keys, db.PageKey{CreatedAt: entry.CreatedAt, ID: entry.ID})
+		}
+		return keys, nil
+	})
+}
@@ -0,0 +1,250 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var limitTests = []conformanceTest{
+	{"SetAndGetTransferLimits", testSetAndGetTransferLimits},
+	{"InvalidTransferLimit", testInvalidTransferLimit},
+	{"PerTransactionLimit", testPerTransactionLimit},
+	{"DailyLimits", testDailyLimits},
+	{"MonthlyLimit", testMonthlyLimit},
+	{"OwnerLimit", testOwnerLimit},
+	{"CurrencyLimit", testCurrencyLimit},
+	{"ConcurrentTransfersKeepLimit", testConcurrentTransfersKeepLimit},
+}
+
+// limit is removed again when the test ends, limits apply to every transfer of a store
+func setLimit(t *testing.T, store db.Store, scope, subject, kind string, max int64) *db.TransferLimit {
+	limit, err := store.SetTransferLimit(context.Background(), db.TransferLimit{Scope: scope, Subject: subject, Kind: kind, Max: max})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		store.DeleteTransferLimitByID(context.Background(), limit.ID)
+	})
+	return limit
+}
+
+func accountLimit(t *testing.T, store db.Store, account *db.Account, kind string, max int64) *db.TransferLimit {
+	return setLimit(t, store, db.LimitScopeAccount, fmt.Sprint(account.ID), kind, max)
+}
+
+// transfer fails on limit, after used of it, with remaining left
+func requireLimitExceeded(t *testing.T, err error, limit *db.TransferLimit, used, remaining int64) {
+	require.ErrorIs(t, err, db.ErrLimitExceeded)
+
+	var exceeded *db.LimitExceededError
+	require.True(t, errors.As(err, &exceeded))
+	require.Equal(t, limit.ID, exceeded.Limit.ID)
+	require.Equal(t, remaining, exceeded.Remaining)
+	require.Equal(t, used, exceeded.Used)
+}
+
+func testSetAndGetTransferLimits(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	limit := accountLimit(t, store, account, db.LimitDailyAmount, 500)
+	require.NotZero(t, limit.ID)
+	require.Equal(t, db.LimitScopeAccount, limit.Scope)
+	require.Equal(t, fmt.Sprint(account.ID), limit.Subject)
+	require.Equal(t, db.LimitDailyAmount, limit.Kind)
+	require.Equal(t, int64(500), limit.Max)
+	require.NotZero(t, limit.CreatedAt)
+
+	// setting it again replaces the maximum
+	replaced, err := store.SetTransferLimit(ctx, db.TransferLimit{Scope: db.LimitScopeAccount, Subject: fmt.Sprint(account.ID), Kind: db.LimitDailyAmount, Max: 700})
+	require.NoError(t, err)
+	require.Equal(t, limit.ID, replaced.ID)
+	require.Equal(t, int64(700), replaced.Max)
+
+	found, err := store.GetTransferLimitByID(ctx, limit.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(700), found.Max)
+
+	other := accountLimit(t, store, account, db.LimitDailyCount, 3)
+
+	limits, err := store.GetTransferLimits(ctx)
+	require.NoError(t, err)
+	var ids []int64
+	for _, limit := range *limits {
+		ids = append(ids, limit.ID)
+	}
+	require.Subset(t, ids, []int64{limit.ID, other.ID})
+
+	rows, err := store.DeleteTransferLimitByID(ctx, limit.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetTransferLimitByID(ctx, limit.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err = store.DeleteTransferLimitByID(ctx, limit.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func testInvalidTransferLimit(t *testing.T, store db.Store) {
+	for _, limit := range []db.TransferLimit{
+		{Scope: "branch", Subject: "x", Kind: db.LimitDailyAmount, Max: 1},
+		{Scope: db.LimitScopeAccount, Subject: "abc", Kind: db.LimitDailyAmount, Max: 1},
+		{Scope: db.LimitScopeAccount, Subject: "007", Kind: db.LimitDailyAmount, Max: 1},
+		{Scope: db.LimitScopeOwner, Subject: "", Kind: db.LimitDailyAmount, Max: 1},
+		{Scope: db.LimitScopeOwner, Subject: "x", Kind: "weekly_amount", Max: 1},
+		{Scope: db.LimitScopeOwner, Subject: "x", Kind: db.LimitDailyAmount, Max: -1},
+	} {
+		_, err := store.SetTransferLimit(context.Background(), limit)
+		require.ErrorIs(t, err, db.ErrInvalidLimit, "%+v", limit)
+	}
+}
+
+func testPerTransactionLimit(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	limit := accountLimit(t, store, from, db.LimitPerTransaction, 100)
+
+	_, err := store.TransferMoney(ctx, from.ID, to.ID, 101, db.Details{})
+	requireLimitExceeded(t, err, limit, 0, 100)
+	requireUntouched(t, store, from, to)
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 100, db.Details{})
+	require.NoError(t, err)
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 100, db.Details{})
+	require.NoError(t, err)
+
+	// limits only hold back what leaves the account
+	_, err = store.TransferMoney(ctx, to.ID, from.ID, 200, db.Details{})
+	require.NoError(t, err)
+}
+
+func testDailyLimits(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	amount := accountLimit(t, store, from, db.LimitDailyAmount, 50)
+	count := accountLimit(t, store, from, db.LimitDailyCount, 2)
+
+	_, err := store.TransferMoney(ctx, from.ID, to.ID, 30, db.Details{})
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 30, db.Details{})
+	requireLimitExceeded(t, err, amount, 30, 20)
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 10, db.Details{})
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 1, db.Details{})
+	requireLimitExceeded(t, err, count, 2, 0)
+
+	found, err := store.GetAccountByID(ctx, to.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), found.Balance)
+}
+
+func testMonthlyLimit(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	limit := accountLimit(t, store, from, db.LimitMonthlyAmount, 40)
+
+	_, err := store.TransferMoney(ctx, from.ID, to.ID, 30, db.Details{})
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 20, db.Details{})
+	requireLimitExceeded(t, err, limit, 30, 10)
+
+	// lowered below what was sent
+	limit = accountLimit(t, store, from, db.LimitMonthlyAmount, 0)
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 1, db.Details{})
+	requireLimitExceeded(t, err, limit, 30, 0)
+}
+
+// an owner's accounts share owner limits
+func testOwnerLimit(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner := utils.RandomOwner()
+	checking := createAccount(t, store, owner, 1000)
+	savings := createAccount(t, store, owner, 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	limit := setLimit(t, store, db.LimitScopeOwner, owner, db.LimitDailyAmount, 50)
+
+	_, err := store.TransferMoney(ctx, checking.ID, to.ID, 30, db.Details{})
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, savings.ID, to.ID, 30, db.Details{})
+	requireLimitExceeded(t, err, limit, 30, 20)
+
+	// the recipient has its own owner
+	_, err = store.TransferMoney(ctx, to.ID, checking.ID, 30, db.Details{})
+	require.NoError(t, err)
+}
+
+// a currency limit holds each account in the currency on its own
+func testCurrencyLimit(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	// a code no other test uses, the limit applies store wide
+	code := "XTS"
+	limit := setLimit(t, store, db.LimitScopeCurrency, code, db.LimitDailyAmount, 50)
+
+	var accounts []*db.Account
+	for i := 0; i < 3; i++ {
+		account, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, code)
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	_, err := store.TransferMoney(ctx, accounts[0].ID, accounts[2].ID, 50, db.Details{})
+	require.NoError(t, err)
+	_, err = store.TransferMoney(ctx, accounts[1].ID, accounts[2].ID, 50, db.Details{})
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, accounts[0].ID, accounts[2].ID, 1, db.Details{})
+	requireLimitExceeded(t, err, limit, 50, 0)
+}
+
+func testConcurrentTransfersKeepLimit(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	accountLimit(t, store, from, db.LimitDailyAmount, 50)
+
+	n := 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.TransferMoney(ctx, from.ID, to.ID, 10, db.Details{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	count := 0
+	for err := range errs {
+		if err == nil {
+			count++
+		} else {
+			require.ErrorIs(t, err, db.ErrLimitExceeded)
+		}
+	}
+	require.Equal(t, 5, count)
+
+	found, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(950), found.Balance)
+}
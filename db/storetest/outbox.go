@@ -0,0 +1,192 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var outboxTests = []conformanceTest{
+	{"OutboxEventsAfter", testOutboxEventsAfter},
+	{"PublishOutboxEventsKeepsAggregateOrder", testPublishOutboxEventsKeepsAggregateOrder},
+	{"PublishOutboxEventsOneRelayAtATime", testPublishOutboxEventsOneRelayAtATime},
+	{"ListenOutboxEvents", testListenOutboxEvents},
+}
+
+func testOutboxEventsAfter(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 10)
+	_, err := store.AddAccountBalance(ctx, account.ID, 10)
+	require.NoError(t, err)
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 2)
+	created, changed := (*events)[0], (*events)[1]
+	require.Less(t, created.ID, changed.ID)
+	require.Nil(t, created.PublishedAt)
+
+	var payload db.Account
+	require.NoError(t, changed.Decode(&payload))
+	require.Equal(t, account.ID, payload.ID)
+	require.Equal(t, int64(20), payload.Balance)
+
+	after, err := store.GetOutboxEventsAfter(ctx, created.ID-1, 1)
+	require.NoError(t, err)
+	require.Len(t, *after, 1)
+	require.Equal(t, created.ID, (*after)[0].ID)
+
+	// in commit order, starting past afterID
+	after, err = store.GetOutboxEventsAfter(ctx, created.ID, 1_000_000)
+	require.NoError(t, err)
+	require.NotEmpty(t, *after)
+	require.Equal(t, changed.ID, (*after)[0].ID)
+	for i := 1; i < len(*after); i++ {
+		previous, event := (*after)[i-1], (*after)[i]
+		require.True(t, previous.TxID < event.TxID || previous.TxID == event.TxID && previous.ID < event.ID)
+	}
+
+	event, err := store.GetOutboxEventByID(ctx, changed.ID)
+	require.NoError(t, err)
+	require.Equal(t, changed, *event)
+
+	last, err := store.GetLastOutboxEventID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, (*after)[len(*after)-1].ID, last)
+}
+
+func testPublishOutboxEventsKeepsAggregateOrder(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	failing := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	_, err := store.AddAccountBalance(ctx, failing.ID, 10)
+	require.NoError(t, err)
+	healthy := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	brokerDown := true
+	var published []db.OutboxEvent
+	publish := func(ctx context.Context, event db.OutboxEvent) error {
+		if brokerDown && event.AggregateType == db.AggregateAccount && event.AggregateID == failing.ID {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, event)
+		return nil
+	}
+
+	_, err = store.PublishOutboxEvents(ctx, 1_000_000, publish)
+	require.Error(t, err)
+
+	// the failing account's first event failed, so its second one must not have been published
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, failing.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 2)
+	for _, event := range *events {
+		require.Nil(t, event.PublishedAt)
+	}
+
+	events, err = store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, healthy.ID)
+	require.NoError(t, err)
+	require.NotNil(t, (*events)[0].PublishedAt)
+
+	// once the broker recovers, both events go out in order and only once
+	brokerDown = false
+	published = nil
+	_, err = store.PublishOutboxEvents(ctx, 1_000_000, publish)
+	require.NoError(t, err)
+
+	var order []string
+	for _, event := range published {
+		require.False(t, event.AggregateType == db.AggregateAccount && event.AggregateID == healthy.ID)
+		if event.AggregateType == db.AggregateAccount && event.AggregateID == failing.ID {
+			order = append(order, event.EventType)
+		}
+	}
+	require.Equal(t, []string{db.EventAccountCreated, db.EventAccountBalanceChanged}, order)
+}
+
+// only one relay publishes at a time, publishing runs outside its transaction
+// so it may use the store, and the relay is taken until it is done
+func testPublishOutboxEventsOneRelayAtATime(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	var relayed, nested int64
+	publish := func(ctx context.Context, event db.OutboxEvent) error {
+		if event.AggregateType != db.AggregateAccount || event.AggregateID != account.ID {
+			return nil
+		}
+		relayed++
+
+		// publish may use the store, and another relay started meanwhile finds the relay taken
+		var err error
+		nested, err = store.PublishOutboxEvents(ctx, 1_000_000, func(context.Context, db.OutboxEvent) error {
+			return errors.New("a second relay must not publish")
+		})
+		return err
+	}
+
+	_, err := store.PublishOutboxEvents(ctx, 1_000_000, publish)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), relayed)
+	require.Zero(t, nested)
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+	require.NoError(t, err)
+	require.NotNil(t, (*events)[0].PublishedAt)
+
+	// the relay is free again once done
+	createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	published, err := store.PublishOutboxEvents(ctx, 1_000_000, func(context.Context, db.OutboxEvent) error { return nil })
+	require.NoError(t, err)
+	require.NotZero(t, published)
+}
+
+// events committed while listening are handed over; the listener may start late,
+// so the account keeps changing until one arrives
+func testListenOutboxEvents(t *testing.T, store db.Store) {
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	received := make(chan db.OutboxEvent, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- store.ListenOutboxEvents(ctx, func(ctx context.Context, event db.OutboxEvent) error {
+			if event.AggregateType == db.AggregateAccount && event.AggregateID == account.ID {
+				received <- event
+				return errors.New("received")
+			}
+			return nil
+		})
+	}()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-received:
+			require.Equal(t, db.EventAccountBalanceChanged, event.EventType)
+
+			// a failing handler stops listening with its error
+			require.EqualError(t, <-done, "received")
+			return
+		case err := <-done:
+			// the handler hands the event over before returning, both may be ready at once
+			if len(received) == 0 {
+				require.FailNow(t, "stopped listening before an event arrived", "%v", err)
+			}
+			require.EqualError(t, err, "received")
+			require.Equal(t, db.EventAccountBalanceChanged, (<-received).EventType)
+			return
+		case <-ticker.C:
+			_, err := store.AddAccountBalance(context.Background(), account.ID, 1)
+			require.NoError(t, err)
+		}
+	}
+}
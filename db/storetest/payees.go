@@ -0,0 +1,140 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var payeeTests = []conformanceTest{
+	{"CreateAndGetPayee", testCreateAndGetPayee},
+	{"PayeeMissingAccount", testPayeeMissingAccount},
+	{"DuplicatePayee", testDuplicatePayee},
+	{"UpdateAndDeletePayee", testUpdateAndDeletePayee},
+	{"PayeesGoWithTheirAccount", testPayeesGoWithTheirAccount},
+}
+
+func testCreateAndGetPayee(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner := utils.RandomOwner()
+	landlord := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	plumber := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	payee, err := store.CreatePayee(ctx, owner, landlord.ID, "Landlord")
+	require.NoError(t, err)
+	require.NotZero(t, payee.ID)
+	require.Equal(t, owner, payee.Owner)
+	require.Equal(t, landlord.ID, payee.AccountID)
+	require.Equal(t, "Landlord", payee.Nickname)
+	require.NotZero(t, payee.CreatedAt)
+
+	found, err := store.GetPayeeByID(ctx, payee.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee.Nickname, found.Nickname)
+	require.True(t, payee.CreatedAt.Equal(found.CreatedAt))
+
+	_, err = store.GetPayeeByID(ctx, missingID(payee.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	second, err := store.CreatePayee(ctx, owner, plumber.ID, "Plumber")
+	require.NoError(t, err)
+
+	payees, err := store.GetPayeesByOwner(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, *payees, 2)
+	require.Equal(t, payee.ID, (*payees)[0].ID)
+	require.Equal(t, second.ID, (*payees)[1].ID)
+
+	payees, err = store.GetPayeesByOwner(ctx, utils.RandomOwner())
+	require.NoError(t, err)
+	require.Empty(t, *payees)
+}
+
+func testPayeeMissingAccount(t *testing.T, store db.Store) {
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	_, err := store.CreatePayee(context.Background(), utils.RandomOwner(), missingID(account.ID), "Nobody")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// nicknames and accounts are unique per owner, others may reuse them
+func testDuplicatePayee(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner := utils.RandomOwner()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	other := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	_, err := store.CreatePayee(ctx, owner, account.ID, "Mom")
+	require.NoError(t, err)
+
+	_, err = store.CreatePayee(ctx, owner, other.ID, "Mom")
+	require.ErrorIs(t, err, db.ErrDuplicatePayee)
+	_, err = store.CreatePayee(ctx, owner, account.ID, "Mother")
+	require.ErrorIs(t, err, db.ErrDuplicatePayee)
+
+	_, err = store.CreatePayee(ctx, utils.RandomOwner(), account.ID, "Mom")
+	require.NoError(t, err)
+
+	_, err = store.CreatePayee(ctx, owner, other.ID, "")
+	require.Error(t, err)
+	_, err = store.CreatePayee(ctx, owner, other.ID, strings.Repeat("x", db.MaxNicknameLength+1))
+	require.Error(t, err)
+}
+
+func testUpdateAndDeletePayee(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner := utils.RandomOwner()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	other := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	payee, err := store.CreatePayee(ctx, owner, account.ID, "Gym")
+	require.NoError(t, err)
+	_, err = store.CreatePayee(ctx, owner, other.ID, "Pool")
+	require.NoError(t, err)
+
+	rows, err := store.UpdatePayeeNickname(ctx, payee.ID, "Climbing gym")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	found, err := store.GetPayeeByID(ctx, payee.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Climbing gym", found.Nickname)
+
+	_, err = store.UpdatePayeeNickname(ctx, payee.ID, "Pool")
+	require.ErrorIs(t, err, db.ErrDuplicatePayee)
+
+	rows, err = store.UpdatePayeeNickname(ctx, missingID(payee.ID), "Anything")
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.DeletePayeeByID(ctx, payee.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetPayeeByID(ctx, payee.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err = store.DeletePayeeByID(ctx, payee.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func testPayeesGoWithTheirAccount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	payee, err := store.CreatePayee(ctx, utils.RandomOwner(), account.ID, "Closing soon")
+	require.NoError(t, err)
+
+	rows, err := store.DeleteAccountByID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetPayeeByID(ctx, payee.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
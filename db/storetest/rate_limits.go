@@ -0,0 +1,84 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var rateLimitTests = []conformanceTest{
+	{"TakeRateLimitToken", testTakeRateLimitToken},
+	{"RateLimitRefill", testRateLimitRefill},
+	{"DeleteIdleRateLimitBuckets", testDeleteIdleRateLimitBuckets},
+}
+
+// a new bucket starts full, every token taken leaves one less until none are left
+func testTakeRateLimitToken(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	key := utils.RandomString(16)
+
+	for i := 2; i >= 0; i-- {
+		tokens, allowed, err := store.TakeRateLimitToken(ctx, key, 0.001, 3)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		require.InDelta(t, float64(i), tokens, 0.01)
+	}
+
+	tokens, allowed, err := store.TakeRateLimitToken(ctx, key, 0.001, 3)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Less(t, tokens, 1.0)
+
+	// other keys have their own bucket
+	_, allowed, err = store.TakeRateLimitToken(ctx, utils.RandomString(16), 0.001, 3)
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
+
+func testRateLimitRefill(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	key := utils.RandomString(16)
+
+	_, allowed, err := store.TakeRateLimitToken(ctx, key, 20, 1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	_, allowed, err = store.TakeRateLimitToken(ctx, key, 20, 1)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	// 20 tokens a second refill one within 50ms, but never past the burst
+	time.Sleep(200 * time.Millisecond)
+	tokens, allowed, err := store.TakeRateLimitToken(ctx, key, 20, 1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.InDelta(t, 0, tokens, 0.01)
+}
+
+func testDeleteIdleRateLimitBuckets(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	key := utils.RandomString(16)
+
+	_, _, err := store.TakeRateLimitToken(ctx, key, 0.001, 1)
+	require.NoError(t, err)
+
+	_, err = store.DeleteIdleRateLimitBuckets(ctx, time.Hour)
+	require.NoError(t, err)
+	_, allowed, err := store.TakeRateLimitToken(ctx, key, 0.001, 1)
+	require.NoError(t, err)
+	require.False(t, allowed, "a bucket in use was deleted")
+
+	time.Sleep(50 * time.Millisecond)
+	deleted, err := store.DeleteIdleRateLimitBuckets(ctx, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	// deleted buckets start full again
+	_, allowed, err = store.TakeRateLimitToken(ctx, key, 0.001, 1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
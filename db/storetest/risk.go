@@ -0,0 +1,106 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var riskTests = []conformanceTest{
+	{"CreateAndGetRiskAssessment", testCreateAndGetRiskAssessment},
+	{"DeniedRiskAssessment", testDeniedRiskAssessment},
+	{"InvalidRiskAssessment", testInvalidRiskAssessment},
+}
+
+func testCreateAndGetRiskAssessment(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	result, err := store.TransferMoney(ctx, from.ID, to.ID, 500, db.Details{})
+	require.NoError(t, err)
+
+	reasons := db.RiskReasons{
+		{Rule: "velocity", Decision: db.RiskReview, Message: "3 transfers in the last 1h0m0s"},
+		{Rule: "round_amount", Decision: db.RiskAllow, Message: "amount is a multiple of 100"},
+	}
+	assessment, err := store.CreateRiskAssessment(ctx, db.RiskAssessment{
+		TransferID:    &result.TransferRecord.ID,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        500,
+		Decision:      db.RiskReview,
+		Reasons:       reasons,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, assessment.ID)
+	require.Equal(t, result.TransferRecord.ID, *assessment.TransferID)
+	require.Equal(t, db.RiskReview, assessment.Decision)
+	require.Equal(t, reasons, assessment.Reasons)
+	require.NotZero(t, assessment.CreatedAt)
+
+	found, err := store.GetRiskAssessmentByTransferID(ctx, result.TransferRecord.ID)
+	require.NoError(t, err)
+	require.Equal(t, assessment.ID, found.ID)
+	require.Equal(t, reasons, found.Reasons)
+	require.True(t, assessment.CreatedAt.Equal(found.CreatedAt))
+
+	found, err = store.GetRiskAssessmentByID(ctx, assessment.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(500), found.Amount)
+
+	// one assessment per transfer
+	_, err = store.CreateRiskAssessment(ctx, db.RiskAssessment{TransferID: &result.TransferRecord.ID, FromAccountID: from.ID, ToAccountID: to.ID, Amount: 500, Decision: db.RiskAllow})
+	require.Error(t, err)
+
+	_, err = store.GetRiskAssessmentByID(ctx, missingID(assessment.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetRiskAssessmentByTransferID(ctx, missingID(result.TransferRecord.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// denied transfers are never made, their assessment stands alone
+func testDeniedRiskAssessment(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	assessment, err := store.CreateRiskAssessment(ctx, db.RiskAssessment{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        900,
+		Decision:      db.RiskDeny,
+		Reasons:       db.RiskReasons{{Rule: "median", Decision: db.RiskDeny, Message: "900 is more than 10 times the median of 10"}},
+	})
+	require.NoError(t, err)
+	require.Nil(t, assessment.TransferID)
+
+	found, err := store.GetRiskAssessmentByID(ctx, assessment.ID)
+	require.NoError(t, err)
+	require.Nil(t, found.TransferID)
+	require.Len(t, found.Reasons, 1)
+
+	// no reasons read back as an empty list
+	assessment, err = store.CreateRiskAssessment(ctx, db.RiskAssessment{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 1, Decision: db.RiskAllow})
+	require.NoError(t, err)
+	found, err = store.GetRiskAssessmentByID(ctx, assessment.ID)
+	require.NoError(t, err)
+	require.Empty(t, found.Reasons)
+}
+
+func testInvalidRiskAssessment(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	_, err := store.CreateRiskAssessment(ctx, db.RiskAssessment{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 1, Decision: "maybe"})
+	require.Error(t, err)
+
+	missing := missingID(0)
+	_, err = store.CreateRiskAssessment(ctx, db.RiskAssessment{TransferID: &missing, FromAccountID: from.ID, ToAccountID: to.ID, Amount: 1, Decision: db.RiskAllow})
+	require.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var searchTests = []conformanceTest{
+	{"SearchTransfers", testSearchTransfers},
+	{"SearchEntries", testSearchEntries},
+	{"SearchDetails", testSearchDetails},
+	{"SearchInvalidFilter", testSearchInvalidFilter},
+}
+
+// search with everything on one page and return the ids found
+func searchIDs(t *testing.T, store db.Store, filter db.TransactionFilter) []int64 {
+	transactions, err := store.SearchTransactions(context.Background(), filter, db.Page{Limit: 100})
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, transaction := range *transactions {
+		ids = append(ids, transaction.ID)
+	}
+	return ids
+}
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func testSearchTransfers(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	payee := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	payer := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	var transfers []*db.Transfer
+	for _, transfer := range []struct{ from, to, amount int64 }{
+		{account.ID, payee.ID, 10},
+		{payee.ID, account.ID, 20},
+		{account.ID, payer.ID, 30},
+		{account.ID, payee.ID, 40},
+		{payer.ID, account.ID, 50},
+	} {
+		created, err := store.CreateTransfer(ctx, transfer.from, transfer.to, transfer.amount, db.Details{})
+		require.NoError(t, err)
+		transfers = append(transfers, created)
+	}
+	id := func(i int) int64 { return transfers[i].ID }
+
+	// signed from the account's side, with the other one as counterparty
+	all, err := store.SearchTransactions(ctx, db.TransactionFilter{AccountID: account.ID, Type: db.TransactionTransfer}, db.Page{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, *all, 5)
+	for i, transaction := range *all {
+		require.Equal(t, db.TransactionTransfer, transaction.Type)
+		require.Equal(t, id(i), transaction.ID)
+		require.Equal(t, account.ID, transaction.AccountID)
+		require.True(t, transfers[i].CreatedAt.Equal(transaction.CreatedAt))
+		require.NotNil(t, transaction.CounterpartyID)
+	}
+	require.Equal(t, db.DirectionOutgoing, (*all)[0].Direction)
+	require.Equal(t, int64(-10), (*all)[0].Amount)
+	require.Equal(t, payee.ID, *(*all)[0].CounterpartyID)
+	require.Equal(t, db.DirectionIncoming, (*all)[4].Direction)
+	require.Equal(t, int64(50), (*all)[4].Amount)
+	require.Equal(t, payer.ID, *(*all)[4].CounterpartyID)
+
+	filter := func(filter db.TransactionFilter) db.TransactionFilter {
+		filter.AccountID, filter.Type = account.ID, db.TransactionTransfer
+		return filter
+	}
+
+	require.Equal(t, []int64{id(0), id(2), id(3)}, searchIDs(t, store, filter(db.TransactionFilter{Direction: db.DirectionOutgoing})))
+	require.Equal(t, []int64{id(1), id(4)}, searchIDs(t, store, filter(db.TransactionFilter{Direction: db.DirectionIncoming})))
+	require.Equal(t, []int64{id(0), id(1), id(3)}, searchIDs(t, store, filter(db.TransactionFilter{CounterpartyID: &payee.ID})))
+	require.Equal(t, []int64{id(0), id(3)}, searchIDs(t, store, filter(db.TransactionFilter{CounterpartyID: &payee.ID, Direction: db.DirectionOutgoing})))
+	require.Equal(t, []int64{id(4)}, searchIDs(t, store, filter(db.TransactionFilter{CounterpartyID: &payer.ID, Direction: db.DirectionIncoming})))
+	require.Equal(t, []int64{id(1), id(2), id(3)}, searchIDs(t, store, filter(db.TransactionFilter{MinAmount: int64Ptr(20), MaxAmount: int64Ptr(40)})))
+	require.Equal(t, []int64{id(2)}, searchIDs(t, store, filter(db.TransactionFilter{MinAmount: int64Ptr(20), MaxAmount: int64Ptr(40), Direction: db.DirectionOutgoing, CounterpartyID: &payer.ID})))
+
+	// the range is inclusive at the start and exclusive at the end
+	first, last := transfers[0].CreatedAt, transfers[4].CreatedAt.Add(time.Microsecond)
+	require.Len(t, searchIDs(t, store, filter(db.TransactionFilter{CreatedFrom: &first, CreatedTo: &last})), 5)
+	require.Empty(t, searchIDs(t, store, filter(db.TransactionFilter{CreatedFrom: &last})))
+	require.Empty(t, searchIDs(t, store, filter(db.TransactionFilter{CreatedTo: &first})))
+
+	// a counterparty without transfers with the account
+	require.Empty(t, searchIDs(t, store, db.TransactionFilter{AccountID: payee.ID, Type: db.TransactionTransfer, CounterpartyID: &payer.ID}))
+
+	requirePages(t, []int64{id(0), id(1), id(2), id(3), id(4)}, func(page db.Page) ([]db.PageKey, error) {
+		transactions, err := store.SearchTransactions(ctx, filter(db.TransactionFilter{}), page)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []db.PageKey
+		for _, transaction := range *transactions {
+			keys = append(keys, db.PageKey{CreatedAt: transaction.CreatedAt, ID: transaction.ID})
+		}
+		return keys, nil
+	})
+}
+
+func testSearchEntries(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	var entries []*db.Entry
+	for _, amount := range []int64{-10, 20, -30, 40} {
+		entry, err := store.CreateEntry(ctx, account.ID, amount, db.Details{})
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+	id := func(i int) int64 { return entries[i].ID }
+
+	transactions, err := store.SearchTransactions(ctx, db.TransactionFilter{AccountID: account.ID, Type: db.TransactionEntry}, db.Page{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, *transactions, 4)
+	for i, transaction := range *transactions {
+		require.Equal(t, db.TransactionEntry, transaction.Type)
+		require.Equal(t, id(i), transaction.ID)
+		require.Equal(t, entries[i].Amount, transaction.Amount)
+		require.Nil(t, transaction.CounterpartyID)
+	}
+
+	filter := func(filter db.TransactionFilter) db.TransactionFilter {
+		filter.AccountID, filter.Type = account.ID, db.TransactionEntry
+		return filter
+	}
+
+	// direction is the sign, amounts compare in absolute value
+	require.Equal(t, []int64{id(0), id(2)}, searchIDs(t, store, filter(db.TransactionFilter{Direction: db.DirectionOutgoing})))
+	require.Equal(t, []int64{id(1), id(3)}, searchIDs(t, store, filter(db.TransactionFilter{Direction: db.DirectionIncoming})))
+	require.Equal(t, []int64{id(1), id(2)}, searchIDs(t, store, filter(db.TransactionFilter{MinAmount: int64Ptr(20), MaxAmount: int64Ptr(30)})))
+	require.Equal(t, []int64{id(2)}, searchIDs(t, store, filter(db.TransactionFilter{MinAmount: int64Ptr(20), MaxAmount: int64Ptr(30), Direction: db.DirectionOutgoing})))
+
+	// only the account's own entries
+	other := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	require.Empty(t, searchIDs(t, store, db.TransactionFilter{AccountID: other.ID, Type: db.TransactionEntry}))
+}
+
+// references match exactly, memos case-insensitively anywhere in the description
+func testSearchDetails(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), 1000)
+	other := createAccount(t, store, utils.RandomOwner(), 1000)
+	reference := "order-" + utils.RandomString(8)
+
+	var ids []int64
+	for _, transfer := range []struct {
+		from, to *db.Account
+		details  db.Details
+	}{
+		{account, other, db.Details{Description: "Rent March", ExternalReference: reference}},
+		{other, account, db.Details{Description: "refund of rent"}},
+		{account, other, db.Details{Description: "100% discount_code"}},
+		{account, other, db.Details{}},
+	} {
+		result, err := store.TransferMoney(ctx, transfer.from.ID, transfer.to.ID, 10, transfer.details)
+		require.NoError(t, err)
+		ids = append(ids, result.TransferRecord.ID)
+	}
+
+	transfers := func(filter db.TransactionFilter) db.TransactionFilter {
+		filter.AccountID, filter.Type = account.ID, db.TransactionTransfer
+		return filter
+	}
+
+	require.Equal(t, []int64{ids[0]}, searchIDs(t, store, transfers(db.TransactionFilter{Reference: reference})))
+	require.Empty(t, searchIDs(t, store, transfers(db.TransactionFilter{Reference: reference[:len(reference)-1]})))
+	require.Equal(t, []int64{ids[0], ids[1]}, searchIDs(t, store, transfers(db.TransactionFilter{Memo: "RENT"})))
+	require.Equal(t, []int64{ids[1]}, searchIDs(t, store, transfers(db.TransactionFilter{Memo: "rent", Direction: db.DirectionIncoming})))
+
+	// wildcards are plain text
+	require.Equal(t, []int64{ids[2]}, searchIDs(t, store, transfers(db.TransactionFilter{Memo: "0% d"})))
+	require.Equal(t, []int64{ids[2]}, searchIDs(t, store, transfers(db.TransactionFilter{Memo: "_"})))
+	require.Empty(t, searchIDs(t, store, transfers(db.TransactionFilter{Memo: "%%"})))
+
+	// entries carry the transfer's details
+	transactions, err := store.SearchTransactions(ctx, db.TransactionFilter{AccountID: account.ID, Type: db.TransactionEntry, Memo: "march"}, db.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, *transactions, 1)
+	require.Equal(t, reference, (*transactions)[0].ExternalReference)
+	require.Equal(t, int64(-10), (*transactions)[0].Amount)
+}
+
+func testSearchInvalidFilter(t *testing.T, store db.Store) {
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	now := time.Now()
+
+	for _, filter := range []db.TransactionFilter{
+		{AccountID: account.ID},
+		{AccountID: account.ID, Type: "payment"},
+		{AccountID: account.ID, Type: db.TransactionTransfer, Direction: "sideways"},
+		{AccountID: account.ID, Type: db.TransactionEntry, CounterpartyID: &account.ID},
+		{AccountID: account.ID, Type: db.TransactionTransfer, MinAmount: int64Ptr(20), MaxAmount: int64Ptr(10)},
+		{AccountID: account.ID, Type: db.TransactionTransfer, MinAmount: int64Ptr(-1)},
+		{AccountID: account.ID, Type: db.TransactionTransfer, CreatedFrom: &now, CreatedTo: &now},
+	} {
+		_, err := store.SearchTransactions(context.Background(), filter, db.Page{Limit: 10})
+		require.ErrorIs(t, err, db.ErrInvalidFilter, "%+v", filter)
+	}
+}
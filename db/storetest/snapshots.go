@@ -0,0 +1,105 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var businessDayTests = []conformanceTest{
+	{"CloseBusinessDay", testCloseBusinessDay},
+	{"CloseBusinessDayRerun", testCloseBusinessDayRerun},
+	{"CloseBusinessDayNotEnded", testCloseBusinessDayNotEnded},
+	{"GetMissingBusinessDay", testGetMissingBusinessDay},
+	{"BalanceSnapshotSkipsNewAccounts", testBalanceSnapshotSkipsNewAccounts},
+}
+
+func yesterday() time.Time {
+	return db.BusinessDate(time.Now(), time.UTC).AddDate(0, 0, -1)
+}
+
+func testCloseBusinessDay(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	day, err := store.CloseBusinessDay(ctx, yesterday(), time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, yesterday(), day.BusinessDate)
+	require.Equal(t, "UTC", day.Timezone)
+	require.NotZero(t, day.ClosedAt)
+
+	closedDay, err := store.GetBusinessDay(ctx, yesterday())
+	require.NoError(t, err)
+	require.Equal(t, day.BusinessDate, closedDay.BusinessDate)
+	require.Equal(t, day.AccountCount, closedDay.AccountCount)
+	require.WithinDuration(t, day.ClosedAt, closedDay.ClosedAt, time.Second)
+
+	lastClosed, err := store.GetLastClosedBusinessDay(ctx)
+	require.NoError(t, err)
+	require.False(t, lastClosed.BusinessDate.Before(yesterday()))
+
+	// totals add up to the snapshotted accounts
+	totals, err := store.GetCurrencyTotals(ctx, yesterday())
+	require.NoError(t, err)
+	var accountCount int64
+	for _, total := range *totals {
+		require.Equal(t, yesterday(), total.BusinessDate)
+		accountCount += total.AccountCount
+	}
+	require.Equal(t, day.AccountCount, accountCount)
+}
+
+// closing a closed day returns it as it was
+func testCloseBusinessDayRerun(t *testing.T, store db.Store) {
+	first, err := store.CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	second, err := store.CloseBusinessDay(context.Background(), yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	require.Equal(t, first.BusinessDate, second.BusinessDate)
+	require.Equal(t, first.AccountCount, second.AccountCount)
+	require.WithinDuration(t, first.ClosedAt, second.ClosedAt, time.Second)
+}
+
+func testCloseBusinessDayNotEnded(t *testing.T, store db.Store) {
+	_, err := store.CloseBusinessDay(context.Background(), db.BusinessDate(time.Now(), time.UTC), time.UTC)
+	require.Error(t, err)
+
+	_, err = store.GetBusinessDay(context.Background(), db.BusinessDate(time.Now(), time.UTC))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testGetMissingBusinessDay(t *testing.T, store db.Store) {
+	never := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := store.GetBusinessDay(context.Background(), never)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	totals, err := store.GetCurrencyTotals(context.Background(), never)
+	require.NoError(t, err)
+	require.Empty(t, *totals)
+}
+
+// accounts opened after the cutoff have no snapshot, and postings into the open day still go through
+func testBalanceSnapshotSkipsNewAccounts(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	_, err := store.CloseBusinessDay(ctx, yesterday(), time.UTC)
+	require.NoError(t, err)
+
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	_, err = store.GetBalanceSnapshot(ctx, account.ID, yesterday())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.GetBalanceSnapshotAsOf(ctx, account.ID, db.BusinessDate(time.Now(), time.UTC))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.CreateEntry(ctx, account.ID, 100, db.Details{})
+	require.NoError(t, err)
+}
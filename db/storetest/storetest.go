@@ -0,0 +1,137 @@
+// Package storetest is the behavior every db.Store implementation has to share.
+// The Postgres and the in-memory store run it, and any other implementation can
+// plug into RunConformance to verify itself:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.RunConformance(t, func(t *testing.T) db.Store {
+//			return mystore.New()
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns the store a single test runs against.
+// It may hand out one shared store, tests only rely on the rows they create themselves.
+// The store is never closed by the suite, register that with t.Cleanup if needed.
+type Factory func(t *testing.T) db.Store
+
+type conformanceTest struct {
+	name string
+	test func(t *testing.T, store db.Store)
+}
+
+// RunConformance runs every conformance test as a subtest of t, one at a time.
+func RunConformance(t *testing.T, factory Factory) {
+	groups := [][]conformanceTest{
+		accountTests,
+		entryTests,
+		transferTests,
+		transferMoneyTests,
+		searchTests,
+		businessDayTests,
+		outboxTests,
+		healthTests,
+		webhookTests,
+		payeeTests,
+		limitTests,
+		riskTests,
+		transferRequestTests,
+		userTests,
+		apiKeyTests,
+		rateLimitTests,
+	}
+
+	for _, tests := range groups {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.test(t, factory(t))
+			})
+		}
+	}
+}
+
+var healthTests = []conformanceTest{
+	{"Ping", testPing},
+	{"SchemaVersion", testSchemaVersion},
+}
+
+func testPing(t *testing.T, store db.Store) {
+	require.NoError(t, store.Ping(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, store.Ping(ctx))
+}
+
+// a store serving requests is on the schema this build expects
+func testSchemaVersion(t *testing.T, store db.Store) {
+	version, dirty, err := store.GetSchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, int64(db.SchemaVersion), version)
+}
+
+func createAccount(t *testing.T, store db.Store, owner string, balance int64) *db.Account {
+	account, err := store.CreateAccount(context.Background(), owner, balance, currency.USD)
+	require.NoError(t, err)
+	require.NotZero(t, account.ID)
+	return account
+}
+
+// an id no row has, far past anything a test creates
+func missingID(id int64) int64 {
+	return id + 1_000_000_000
+}
+
+// walk list page by page in both orders; pages hold at most their limit,
+// follow each other without gaps or overlaps and end in an empty page.
+// ids are the listed rows oldest first
+func requirePages(t *testing.T, ids []int64, list func(page db.Page) ([]db.PageKey, error)) {
+	for _, order := range []string{db.SortAsc, db.SortDesc} {
+		expected := slices.Clone(ids)
+		if order == db.SortDesc {
+			slices.Reverse(expected)
+		}
+
+		var listed []int64
+		page := db.Page{Limit: 2, Order: order}
+		for {
+			keys, err := list(page)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(keys), 2)
+			if len(keys) == 0 {
+				break
+			}
+
+			for _, key := range keys {
+				listed = append(listed, key.ID)
+			}
+			last := keys[len(keys)-1]
+			page.After = &last
+
+			require.Less(t, len(listed), len(ids)+2, "pages do not end")
+		}
+		require.Equal(t, expected, listed, order)
+	}
+
+	// ascending by default
+	keys, err := list(db.Page{Limit: int64(len(ids))})
+	require.NoError(t, err)
+	require.Len(t, keys, len(ids))
+	for i, key := range keys {
+		require.Equal(t, ids[i], key.ID)
+	}
+
+	keys, err = list(db.Page{Limit: 0})
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
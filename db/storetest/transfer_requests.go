@@ -0,0 +1,294 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var transferRequestTests = []conformanceTest{
+	{"ApproveTransferRequest", testApproveTransferRequest},
+	{"RejectTransferRequest", testRejectTransferRequest},
+	{"FailedTransferRequest", testFailedTransferRequest},
+	{"ApproveTransferRequestAtOnce", testApproveTransferRequestAtOnce},
+	{"ExpireTransferRequests", testExpireTransferRequests},
+	{"ListTransferRequests", testListTransferRequests},
+}
+
+func createTransferRequest(t *testing.T, store db.Store, from, to *db.Account, amount int64, expiresIn time.Duration) *db.TransferRequest {
+	request, err := store.CreateTransferRequest(context.Background(), db.TransferRequest{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Details:       db.Details{Description: "rent", Metadata: db.Metadata{"month": "may"}},
+		Initiator:     from.Owner,
+		HoldReason:    "amount above the approval threshold",
+		ExpiresAt:     time.Now().Add(expiresIn),
+	})
+	require.NoError(t, err)
+	return request
+}
+
+func requireRequestActions(t *testing.T, store db.Store, requestID int64, actions ...string) []db.TransferRequestEvent {
+	events, err := store.GetTransferRequestEvents(context.Background(), requestID)
+	require.NoError(t, err)
+
+	var listed []string
+	for _, event := range *events {
+		require.Equal(t, requestID, event.RequestID)
+		listed = append(listed, event.Action)
+	}
+	require.Equal(t, actions, listed)
+	return *events
+}
+
+func testApproveTransferRequest(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	reviewer := utils.RandomOwner()
+
+	assessment, err := store.CreateRiskAssessment(ctx, db.RiskAssessment{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 700, Decision: db.RiskReview, Reasons: db.RiskReasons{{Rule: "first_counterparty", Decision: db.RiskReview, Message: "first transfer"}}})
+	require.NoError(t, err)
+
+	request, err := store.CreateTransferRequest(ctx, db.TransferRequest{
+		FromAccountID:    from.ID,
+		ToAccountID:      to.ID,
+		Amount:           700,
+		Details:          db.Details{Description: "rent"},
+		Initiator:        from.Owner,
+		HoldReason:       "flagged by risk screening",
+		RiskAssessmentID: &assessment.ID,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, request.ID)
+	require.Equal(t, db.TransferRequestPending, request.Status)
+	require.Equal(t, "rent", request.Description)
+	require.Equal(t, assessment.ID, *request.RiskAssessmentID)
+	require.Nil(t, request.Reviewer)
+	require.Nil(t, request.TransferID)
+
+	found, err := store.GetTransferRequestByID(ctx, request.ID)
+	require.NoError(t, err)
+	require.Equal(t, request.Amount, found.Amount)
+	require.True(t, request.ExpiresAt.Equal(found.ExpiresAt))
+
+	_, err = store.GetTransferRequestByID(ctx, missingID(request.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.DecideTransferRequest(ctx, missingID(request.ID), reviewer, db.TransferRequestApproved, "")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// maker and checker are different people
+	_, err = store.DecideTransferRequest(ctx, request.ID, from.Owner, db.TransferRequestApproved, "")
+	require.ErrorIs(t, err, db.ErrSelfReview)
+
+	_, err = store.DecideTransferRequest(ctx, request.ID, reviewer, db.TransferRequestExecuted, "")
+	require.Error(t, err)
+
+	// not approved yet
+	_, err = store.FinishTransferRequest(ctx, request.ID, nil, "too early")
+	require.ErrorIs(t, err, db.ErrRequestNotApproved)
+
+	approved, err := store.DecideTransferRequest(ctx, request.ID, reviewer, db.TransferRequestApproved, "checked the invoice")
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestApproved, approved.Status)
+	require.Equal(t, reviewer, *approved.Reviewer)
+	require.NotNil(t, approved.DecidedAt)
+
+	_, err = store.DecideTransferRequest(ctx, request.ID, utils.RandomOwner(), db.TransferRequestRejected, "")
+	require.ErrorIs(t, err, db.ErrRequestNotPending)
+
+	result, err := store.TransferMoney(ctx, from.ID, to.ID, approved.Amount, approved.Details)
+	require.NoError(t, err)
+
+	executed, err := store.FinishTransferRequest(ctx, request.ID, &result.TransferRecord.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestExecuted, executed.Status)
+	require.Equal(t, result.TransferRecord.ID, *executed.TransferID)
+
+	_, err = store.FinishTransferRequest(ctx, request.ID, &result.TransferRecord.ID, "")
+	require.ErrorIs(t, err, db.ErrRequestNotApproved)
+
+	// the assessment now belongs to the transfer
+	linked, err := store.GetRiskAssessmentByTransferID(ctx, result.TransferRecord.ID)
+	require.NoError(t, err)
+	require.Equal(t, assessment.ID, linked.ID)
+
+	events := requireRequestActions(t, store, request.ID, db.TransferRequestSubmitted, db.TransferRequestApproved, db.TransferRequestExecuted)
+	require.Equal(t, from.Owner, events[0].Actor)
+	require.Equal(t, "flagged by risk screening", events[0].Note)
+	require.Equal(t, reviewer, events[1].Actor)
+	require.Equal(t, "checked the invoice", events[1].Note)
+	require.Equal(t, reviewer, events[2].Actor)
+}
+
+func testRejectTransferRequest(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	request := createTransferRequest(t, store, from, to, 500, time.Hour)
+	reviewer := utils.RandomOwner()
+
+	rejected, err := store.DecideTransferRequest(ctx, request.ID, reviewer, db.TransferRequestRejected, "unknown recipient")
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestRejected, rejected.Status)
+	require.Equal(t, reviewer, *rejected.Reviewer)
+
+	_, err = store.FinishTransferRequest(ctx, request.ID, nil, "rejected requests are never made")
+	require.ErrorIs(t, err, db.ErrRequestNotApproved)
+
+	_, err = store.DecideTransferRequest(ctx, request.ID, reviewer, db.TransferRequestApproved, "")
+	require.ErrorIs(t, err, db.ErrRequestNotPending)
+
+	events := requireRequestActions(t, store, request.ID, db.TransferRequestSubmitted, db.TransferRequestRejected)
+	require.Equal(t, "unknown recipient", events[1].Note)
+}
+
+func testFailedTransferRequest(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	request := createTransferRequest(t, store, from, to, 500, time.Hour)
+	reviewer := utils.RandomOwner()
+
+	_, err := store.DecideTransferRequest(ctx, request.ID, reviewer, db.TransferRequestApproved, "")
+	require.NoError(t, err)
+
+	failed, err := store.FinishTransferRequest(ctx, request.ID, nil, "insufficient funds")
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestFailed, failed.Status)
+	require.Equal(t, "insufficient funds", failed.Failure)
+	require.Nil(t, failed.TransferID)
+
+	events := requireRequestActions(t, store, request.ID, db.TransferRequestSubmitted, db.TransferRequestApproved, db.TransferRequestFailed)
+	require.Equal(t, "insufficient funds", events[2].Note)
+}
+
+func testApproveTransferRequestAtOnce(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	request := createTransferRequest(t, store, from, to, 600, time.Hour)
+	reviewer := utils.RandomOwner()
+
+	_, err := store.ApproveTransferRequest(ctx, request.ID, from.Owner, "")
+	require.ErrorIs(t, err, db.ErrSelfReview)
+
+	executed, err := store.ApproveTransferRequest(ctx, request.ID, reviewer, "checked the invoice")
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestExecuted, executed.Status)
+	require.Equal(t, reviewer, *executed.Reviewer)
+	require.NotNil(t, executed.TransferID)
+
+	transfer, err := store.GetTransferByID(ctx, *executed.TransferID)
+	require.NoError(t, err)
+	require.Equal(t, int64(600), transfer.Amount)
+	require.Equal(t, "rent", transfer.Description)
+	requireRequestActions(t, store, request.ID, db.TransferRequestSubmitted, db.TransferRequestApproved, db.TransferRequestExecuted)
+
+	_, err = store.ApproveTransferRequest(ctx, request.ID, reviewer, "")
+	require.ErrorIs(t, err, db.ErrRequestNotPending)
+
+	// a transfer that cannot be made is undone, the decision and the failure are kept
+	request = createTransferRequest(t, store, from, to, 600, time.Hour)
+	failed, err := store.ApproveTransferRequest(ctx, request.ID, reviewer, "")
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestFailed, failed.Status)
+	require.NotEmpty(t, failed.Failure)
+	require.Nil(t, failed.TransferID)
+	requireRequestActions(t, store, request.ID, db.TransferRequestSubmitted, db.TransferRequestApproved, db.TransferRequestFailed)
+
+	account, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), account.Balance)
+
+	stale := createTransferRequest(t, store, from, to, 100, -time.Second)
+	_, err = store.ApproveTransferRequest(ctx, stale.ID, reviewer, "")
+	require.ErrorIs(t, err, db.ErrRequestExpired)
+	found, err := store.GetTransferRequestByID(ctx, stale.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestExpired, found.Status)
+}
+
+func testExpireTransferRequests(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	// deciding a stale request expires it
+	stale := createTransferRequest(t, store, from, to, 100, -time.Second)
+	_, err := store.DecideTransferRequest(ctx, stale.ID, utils.RandomOwner(), db.TransferRequestApproved, "")
+	require.ErrorIs(t, err, db.ErrRequestExpired)
+
+	found, err := store.GetTransferRequestByID(ctx, stale.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestExpired, found.Status)
+	events := requireRequestActions(t, store, stale.ID, db.TransferRequestSubmitted, db.TransferRequestExpired)
+	require.Equal(t, db.SystemActor, events[1].Actor)
+
+	_, err = store.DecideTransferRequest(ctx, stale.ID, utils.RandomOwner(), db.TransferRequestApproved, "")
+	require.ErrorIs(t, err, db.ErrRequestNotPending)
+
+	stale = createTransferRequest(t, store, from, to, 100, -time.Second)
+	fresh := createTransferRequest(t, store, from, to, 100, time.Hour)
+
+	expired, err := store.ExpireTransferRequests(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, expired, int64(1))
+
+	found, err = store.GetTransferRequestByID(ctx, stale.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestExpired, found.Status)
+	requireRequestActions(t, store, stale.ID, db.TransferRequestSubmitted, db.TransferRequestExpired)
+
+	found, err = store.GetTransferRequestByID(ctx, fresh.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferRequestPending, found.Status)
+
+	// nothing left to expire
+	expired, err = store.ExpireTransferRequests(ctx)
+	require.NoError(t, err)
+	require.Zero(t, expired)
+}
+
+func testListTransferRequests(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		ids = append(ids, createTransferRequest(t, store, from, to, 100, time.Hour).ID)
+	}
+	_, err := store.DecideTransferRequest(ctx, ids[1], utils.RandomOwner(), db.TransferRequestRejected, "")
+	require.NoError(t, err)
+
+	first, err := store.GetTransferRequestByID(ctx, ids[0])
+	require.NoError(t, err)
+	// rows of other tests are older
+	since := &db.PageKey{CreatedAt: first.CreatedAt, ID: first.ID - 1}
+
+	listed := func(status string) []int64 {
+		requests, err := store.ListTransferRequests(ctx, status, db.Page{Limit: 10, After: since})
+		require.NoError(t, err)
+
+		var listed []int64
+		for _, request := range *requests {
+			if status != "" {
+				require.Equal(t, status, request.Status)
+			}
+			listed = append(listed, request.ID)
+		}
+		return listed
+	}
+
+	require.Equal(t, []int64{ids[0], ids[2]}, listed(db.TransferRequestPending))
+	require.Equal(t, []int64{ids[1]}, listed(db.TransferRequestRejected))
+	require.Equal(t, ids, listed(""))
+}
@@ -0,0 +1,377 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var transferTests = []conformanceTest{
+	{"CreateAndGetTransfer", testCreateAndGetTransfer},
+	{"TransferForeignKey", testTransferForeignKey},
+	{"TransfersFromToPages", testTransfersFromToPages},
+}
+
+var transferMoneyTests = []conformanceTest{
+	{"TransferMoney", testTransferMoney},
+	{"TransferMoneyInsufficientFunds", testTransferMoneyInsufficientFunds},
+	{"TransferMoneyMissingAccount", testTransferMoneyMissingAccount},
+	{"TransferMoneyDetails", testTransferMoneyDetails},
+	{"TransferMoneyDuplicateReference", testTransferMoneyDuplicateReference},
+	{"TransferMoneyInvalidDetails", testTransferMoneyInvalidDetails},
+	{"ConcurrentTransfersKeepTotal", testConcurrentTransfersKeepTotal},
+	{"ConcurrentTransfersNeverOverdraw", testConcurrentTransfersNeverOverdraw},
+}
+
+func testCreateAndGetTransfer(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	to := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	transfer, err := store.CreateTransfer(ctx, from.ID, to.ID, 10, db.Details{})
+	require.NoError(t, err)
+	require.NotZero(t, transfer.ID)
+	require.Equal(t, from.ID, transfer.FromAccountID)
+	require.Equal(t, to.ID, transfer.ToAccountID)
+	require.Equal(t, int64(10), transfer.Amount)
+	require.NotZero(t, transfer.CreatedAt)
+
+	found, err := store.GetTransferByID(ctx, transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, found.ID)
+	require.Equal(t, transfer.FromAccountID, found.FromAccountID)
+	require.Equal(t, transfer.ToAccountID, found.ToAccountID)
+	require.Equal(t, transfer.Amount, found.Amount)
+	require.True(t, transfer.CreatedAt.Equal(found.CreatedAt))
+
+	_, err = store.GetTransferByID(ctx, missingID(transfer.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testTransferForeignKey(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	missing := missingID(account.ID)
+
+	_, err := store.CreateTransfer(ctx, account.ID, missing, 10, db.Details{})
+	require.Error(t, err)
+	_, err = store.CreateTransfer(ctx, missing, account.ID, 10, db.Details{})
+	require.Error(t, err)
+
+	transfers, err := store.GetTransfersFromTo(ctx, account.ID, account.ID, db.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, *transfers)
+}
+
+// either side may be -1 to match only the other
+func testTransfersFromToPages(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+	other := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	var outgoing, incoming, all []int64
+	for i := 0; i < 6; i++ {
+		from, to := account, other
+		if i%3 == 2 {
+			from, to = other, account
+		}
+
+		transfer, err := store.CreateTransfer(ctx, from.ID, to.ID, utils.RandomMoney(), db.Details{})
+		require.NoError(t, err)
+
+		if from == account {
+			outgoing = append(outgoing, transfer.ID)
+		} else {
+			incoming = append(incoming, transfer.ID)
+		}
+		all = append(all, transfer.ID)
+	}
+
+	transfers := func(from, to int64) func(page db.Page) ([]db.PageKey, error) {
+		return func(page db.Page) ([]db.PageKey, error) {
+			transfers, err := store.GetTransfersFromTo(ctx, from, to, page)
+			if err != nil {
+				return nil, err
+			}
+
+			var keys []db.PageKey
+			for _, transfer := range *transfers {
+				keys = append(keys, db.PageKey{CreatedAt: transfer.CreatedAt, ID: transfer.ID})
+			}
+			return keys, nil
+		}
+	}
+
+	requirePages(t, outgoing, transfers(account.ID, -1))
+	requirePages(t, incoming, transfers(-1, account.ID))
+	requirePages(t, all, transfers(account.ID, account.ID))
+}
+
+func testTransferMoney(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 100)
+	to := createAccount(t, store, utils.RandomOwner(), 100)
+
+	result, err := store.TransferMoney(ctx, from.ID, to.ID, 30, db.Details{})
+	require.NoError(t, err)
+
+	require.Equal(t, from.ID, result.TransferRecord.FromAccountID)
+	require.Equal(t, to.ID, result.TransferRecord.ToAccountID)
+	require.Equal(t, int64(30), result.TransferRecord.Amount)
+	require.Equal(t, from.ID, result.FromEntryRecord.AccountID)
+	require.Equal(t, int64(-30), result.FromEntryRecord.Amount)
+	require.Equal(t, to.ID, result.ToEntryRecord.AccountID)
+	require.Equal(t, int64(30), result.ToEntryRecord.Amount)
+	require.Equal(t, from.ID, result.FromAccount.ID)
+	require.Equal(t, int64(70), result.FromAccount.Balance)
+	require.Equal(t, to.ID, result.ToAccount.ID)
+	require.Equal(t, int64(130), result.ToAccount.Balance)
+
+	// every record is stored
+	_, err = store.GetTransferByID(ctx, result.TransferRecord.ID)
+	require.NoError(t, err)
+	_, err = store.GetEntryByID(ctx, result.FromEntryRecord.ID)
+	require.NoError(t, err)
+	_, err = store.GetEntryByID(ctx, result.ToEntryRecord.ID)
+	require.NoError(t, err)
+
+	events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateTransfer, result.TransferRecord.ID)
+	require.NoError(t, err)
+	require.Len(t, *events, 1)
+	require.Equal(t, db.EventTransferCompleted, (*events)[0].EventType)
+
+	var payload db.TransferTxResult
+	require.NoError(t, (*events)[0].Decode(&payload))
+	require.Equal(t, result.TransferRecord.ID, payload.TransferRecord.ID)
+	require.Equal(t, result.FromAccount.Balance, payload.FromAccount.Balance)
+	require.Equal(t, result.ToAccount.Balance, payload.ToAccount.Balance)
+
+	// reverse direction takes the other lock order
+	result, err = store.TransferMoney(ctx, to.ID, from.ID, 130, db.Details{})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.Balance)
+	require.Equal(t, int64(200), result.ToAccount.Balance)
+}
+
+// nothing of a failed transfer is left behind
+func requireUntouched(t *testing.T, store db.Store, accounts ...*db.Account) {
+	ctx := context.Background()
+
+	for _, account := range accounts {
+		found, err := store.GetAccountByID(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, found.Balance)
+
+		entries, err := store.GetEntriesByAccountID(ctx, account.ID, db.Page{Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, *entries)
+
+		transfers, err := store.GetTransfersFromTo(ctx, account.ID, account.ID, db.Page{Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, *transfers)
+
+		events, err := store.GetOutboxEventsByAggregate(ctx, db.AggregateAccount, account.ID)
+		require.NoError(t, err)
+		require.Len(t, *events, 1)
+	}
+}
+
+func testTransferMoneyInsufficientFunds(t *testing.T, store db.Store) {
+	from := createAccount(t, store, utils.RandomOwner(), 10)
+	to := createAccount(t, store, utils.RandomOwner(), 10)
+
+	_, err := store.TransferMoney(context.Background(), from.ID, to.ID, 11, db.Details{})
+	require.Error(t, err)
+
+	requireUntouched(t, store, from, to)
+}
+
+func testTransferMoneyMissingAccount(t *testing.T, store db.Store) {
+	account := createAccount(t, store, utils.RandomOwner(), utils.RandomMoney())
+
+	_, err := store.TransferMoney(context.Background(), account.ID, missingID(account.ID), 10, db.Details{})
+	require.Error(t, err)
+	_, err = store.TransferMoney(context.Background(), missingID(account.ID), account.ID, 10, db.Details{})
+	require.Error(t, err)
+
+	requireUntouched(t, store, account)
+}
+
+// the transfer and both entries carry the details, and the reference finds the transfer
+func testTransferMoneyDetails(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 100)
+	to := createAccount(t, store, utils.RandomOwner(), 100)
+
+	details := db.Details{
+		Description:       "Rent for März",
+		ExternalReference: "order-" + utils.RandomString(8),
+		Metadata:          db.Metadata{"invoice": "2024-03", "unit": "4b"},
+	}
+
+	result, err := store.TransferMoney(ctx, from.ID, to.ID, 30, details)
+	require.NoError(t, err)
+	require.Equal(t, details, result.TransferRecord.Details)
+	require.Equal(t, details, result.FromEntryRecord.Details)
+	require.Equal(t, details, result.ToEntryRecord.Details)
+
+	transfer, err := store.GetTransferByID(ctx, result.TransferRecord.ID)
+	require.NoError(t, err)
+	require.Equal(t, details, transfer.Details)
+
+	entry, err := store.GetEntryByID(ctx, result.ToEntryRecord.ID)
+	require.NoError(t, err)
+	require.Equal(t, details, entry.Details)
+
+	transfer, err = store.GetTransferByExternalReference(ctx, from.ID, details.ExternalReference)
+	require.NoError(t, err)
+	require.Equal(t, result.TransferRecord.ID, transfer.ID)
+
+	// references belong to the sending account
+	_, err = store.GetTransferByExternalReference(ctx, to.ID, details.ExternalReference)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// no details at all
+	result, err = store.TransferMoney(ctx, from.ID, to.ID, 10, db.Details{})
+	require.NoError(t, err)
+	require.Empty(t, result.TransferRecord.Description)
+	require.Empty(t, result.TransferRecord.ExternalReference)
+	require.Empty(t, result.TransferRecord.Metadata)
+
+	_, err = store.GetTransferByExternalReference(ctx, from.ID, "")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testTransferMoneyDuplicateReference(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 100)
+	to := createAccount(t, store, utils.RandomOwner(), 100)
+	reference := "order-" + utils.RandomString(8)
+
+	_, err := store.TransferMoney(ctx, from.ID, to.ID, 10, db.Details{ExternalReference: reference})
+	require.NoError(t, err)
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 10, db.Details{ExternalReference: reference})
+	require.ErrorIs(t, err, db.ErrDuplicateReference)
+
+	found, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(90), found.Balance)
+
+	// another sender may use the same reference
+	_, err = store.TransferMoney(ctx, to.ID, from.ID, 10, db.Details{ExternalReference: reference})
+	require.NoError(t, err)
+}
+
+func testTransferMoneyInvalidDetails(t *testing.T, store db.Store) {
+	from := createAccount(t, store, utils.RandomOwner(), 100)
+	to := createAccount(t, store, utils.RandomOwner(), 100)
+
+	tooManyKeys := db.Metadata{}
+	for i := 0; i <= db.MaxMetadataKeys; i++ {
+		tooManyKeys[fmt.Sprint(i)] = "x"
+	}
+
+	for _, details := range []db.Details{
+		{Description: strings.Repeat("x", db.MaxDescriptionLength+1)},
+		{ExternalReference: strings.Repeat("x", db.MaxExternalReferenceLength+1)},
+		{ExternalReference: "order 1"},
+		{Metadata: tooManyKeys},
+		{Metadata: db.Metadata{"": "x"}},
+		{Metadata: db.Metadata{strings.Repeat("k", db.MaxMetadataKeyLength+1): "x"}},
+		{Metadata: db.Metadata{"key": strings.Repeat("v", db.MaxMetadataValueLength+1)}},
+	} {
+		_, err := store.TransferMoney(context.Background(), from.ID, to.ID, 10, details)
+		require.ErrorIs(t, err, db.ErrInvalidDetails)
+	}
+
+	requireUntouched(t, store, from, to)
+}
+
+func testConcurrentTransfersKeepTotal(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	account1 := createAccount(t, store, utils.RandomOwner(), 1000)
+	account2 := createAccount(t, store, utils.RandomOwner(), 1000)
+
+	n := 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		from, to := account1.ID, account2.ID
+		if i%2 == 1 {
+			from, to = to, from
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.TransferMoney(ctx, from, to, 10, db.Details{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// as many transfers went each way
+	for _, account := range []*db.Account{account1, account2} {
+		found, err := store.GetAccountByID(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), found.Balance)
+	}
+}
+
+func testConcurrentTransfersNeverOverdraw(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	from := createAccount(t, store, utils.RandomOwner(), 50)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	n := 10
+	var wg sync.WaitGroup
+	succeeded := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.TransferMoney(ctx, from.ID, to.ID, 10, db.Details{})
+			succeeded <- err == nil
+		}()
+	}
+	wg.Wait()
+	close(succeeded)
+
+	count := 0
+	for ok := range succeeded {
+		if ok {
+			count++
+		}
+	}
+	require.Equal(t, 5, count)
+
+	found, err := store.GetAccountByID(ctx, from.ID)
+	require.NoError(t, err)
+	require.Zero(t, found.Balance)
+
+	found, err = store.GetAccountByID(ctx, to.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), found.Balance)
+
+	// one transfer with its two entries per success
+	transfers, err := store.GetTransfersFromTo(ctx, from.ID, -1, db.Page{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, *transfers, count)
+
+	entries, err := store.GetEntriesByAccountID(ctx, to.ID, db.Page{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, *entries, count)
+}
@@ -0,0 +1,72 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var userTests = []conformanceTest{
+	{"SetAndGetUserRole", testSetAndGetUserRole},
+	{"InvalidRoleRejected", testInvalidRoleRejected},
+	{"GetUsers", testGetUsers},
+}
+
+// setting a role again changes it in place
+func testSetAndGetUserRole(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	username := utils.RandomOwner()
+
+	_, err := store.GetUserByUsername(ctx, username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err := store.SetUserRole(ctx, username, db.RoleTeller)
+	require.NoError(t, err)
+	require.Equal(t, username, user.Username)
+	require.Equal(t, db.RoleTeller, user.Role)
+	require.NotZero(t, user.CreatedAt)
+
+	promoted, err := store.SetUserRole(ctx, username, db.RoleAdmin)
+	require.NoError(t, err)
+	require.Equal(t, db.RoleAdmin, promoted.Role)
+	require.Equal(t, user.CreatedAt, promoted.CreatedAt)
+
+	found, err := store.GetUserByUsername(ctx, username)
+	require.NoError(t, err)
+	require.Equal(t, *promoted, *found)
+}
+
+func testInvalidRoleRejected(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	_, err := store.SetUserRole(ctx, utils.RandomOwner(), "root")
+	require.ErrorIs(t, err, db.ErrInvalidRole)
+
+	_, err = store.SetUserRole(ctx, "", db.RoleAdmin)
+	require.ErrorIs(t, err, db.ErrInvalidRole)
+}
+
+func testGetUsers(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	first, second := "a_"+utils.RandomOwner(), "b_"+utils.RandomOwner()
+
+	_, err := store.SetUserRole(ctx, second, db.RoleTeller)
+	require.NoError(t, err)
+	_, err = store.SetUserRole(ctx, first, db.RoleAdmin)
+	require.NoError(t, err)
+
+	users, err := store.GetUsers(ctx)
+	require.NoError(t, err)
+
+	var listed []string
+	for _, user := range *users {
+		if user.Username == first || user.Username == second {
+			listed = append(listed, user.Username)
+		}
+	}
+	require.Equal(t, []string{first, second}, listed)
+}
@@ -0,0 +1,261 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookTests = []conformanceTest{
+	{"CreateAndGetWebhookSubscription", testCreateAndGetWebhookSubscription},
+	{"WebhookSubscriptionsForEvent", testWebhookSubscriptionsForEvent},
+	{"DeleteWebhookSubscription", testDeleteWebhookSubscription},
+	{"CreateWebhookDeliveryIdempotent", testCreateWebhookDeliveryIdempotent},
+	{"WebhookDeliveryForeignKey", testWebhookDeliveryForeignKey},
+	{"ListWebhookDeliveriesPages", testListWebhookDeliveriesPages},
+	{"ClaimDueWebhookDeliveries", testClaimDueWebhookDeliveries},
+	{"WebhookDeliveryDeadLetter", testWebhookDeliveryDeadLetter},
+	{"UpdateMissingWebhookDelivery", testUpdateMissingWebhookDelivery},
+}
+
+func createWebhookSubscription(t *testing.T, store db.Store, owner string, eventTypes ...string) *db.WebhookSubscription {
+	secret := utils.RandomString(32)
+
+	subscription, err := store.CreateWebhookSubscription(context.Background(), owner, "https://example.com/hooks", eventTypes, secret)
+	require.NoError(t, err)
+	require.NotZero(t, subscription.ID)
+	require.Equal(t, owner, subscription.Owner)
+	require.Equal(t, db.StringArray(eventTypes), subscription.EventTypes)
+	require.Equal(t, secret, subscription.Secret)
+
+	return subscription
+}
+
+func createWebhookDelivery(t *testing.T, store db.Store, subscription *db.WebhookSubscription) *db.WebhookDelivery {
+	eventID := utils.RandomInt(1, 1_000_000_000)
+
+	rowsAffected, err := store.CreateWebhookDelivery(context.Background(), subscription.ID, eventID, db.EventTransferCompleted, json.RawMessage(`{"amount": 10}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), subscription.ID, db.Page{Limit: 1, Order: db.SortDesc})
+	require.NoError(t, err)
+	require.Len(t, *deliveries, 1)
+
+	delivery := (*deliveries)[0]
+	require.Equal(t, subscription.ID, delivery.SubscriptionID)
+	require.Equal(t, eventID, delivery.EventID)
+	require.Equal(t, db.EventTransferCompleted, delivery.EventType)
+	require.JSONEq(t, `{"amount": 10}`, string(delivery.Payload))
+	require.Equal(t, db.WebhookDeliveryPending, delivery.Status)
+	require.Zero(t, delivery.Attempts)
+
+	return &delivery
+}
+
+func testCreateAndGetWebhookSubscription(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	owner := utils.RandomOwner()
+	first := createWebhookSubscription(t, store, owner, db.EventTransferCompleted, db.EventAccountCreated)
+	second := createWebhookSubscription(t, store, owner, db.EventAccountDeleted)
+
+	found, err := store.GetWebhookSubscriptionByID(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, found.ID)
+	require.Equal(t, first.URL, found.URL)
+	require.Equal(t, first.EventTypes, found.EventTypes)
+	require.Equal(t, first.Secret, found.Secret)
+
+	_, err = store.GetWebhookSubscriptionByID(ctx, missingID(second.ID))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	subscriptions, err := store.GetWebhookSubscriptionsByOwner(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, *subscriptions, 2)
+	require.Equal(t, first.ID, (*subscriptions)[0].ID)
+	require.Equal(t, second.ID, (*subscriptions)[1].ID)
+}
+
+func testWebhookSubscriptionsForEvent(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted, db.EventAccountCreated)
+	createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+
+	subscriptions, err := store.GetWebhookSubscriptionsForEvent(ctx, subscription.Owner, db.EventTransferCompleted)
+	require.NoError(t, err)
+	require.Len(t, *subscriptions, 1)
+	require.Equal(t, subscription.ID, (*subscriptions)[0].ID)
+
+	subscriptions, err = store.GetWebhookSubscriptionsForEvent(ctx, subscription.Owner, db.EventAccountOwnerChanged)
+	require.NoError(t, err)
+	require.Empty(t, *subscriptions)
+}
+
+// deliveries go with their subscription
+func testDeleteWebhookSubscription(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+	delivery := createWebhookDelivery(t, store, subscription)
+
+	rows, err := store.DeleteWebhookSubscriptionByID(ctx, subscription.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = store.GetWebhookSubscriptionByID(ctx, subscription.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetWebhookDeliveryByID(ctx, delivery.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err = store.DeleteWebhookSubscriptionByID(ctx, subscription.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+// one delivery per subscription, event and event type
+func testCreateWebhookDeliveryIdempotent(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+	delivery := createWebhookDelivery(t, store, subscription)
+
+	rowsAffected, err := store.CreateWebhookDelivery(ctx, subscription.ID, delivery.EventID, delivery.EventType, delivery.Payload)
+	require.NoError(t, err)
+	require.Zero(t, rowsAffected)
+
+	rowsAffected, err = store.CreateWebhookDelivery(ctx, subscription.ID, delivery.EventID, db.EventAccountCreated, delivery.Payload)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+}
+
+func testWebhookDeliveryForeignKey(t *testing.T, store db.Store) {
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+
+	_, err := store.CreateWebhookDelivery(context.Background(), missingID(subscription.ID), 1, db.EventTransferCompleted, json.RawMessage(`{}`))
+	require.Error(t, err)
+}
+
+func testListWebhookDeliveriesPages(t *testing.T, store db.Store) {
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+	other := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, createWebhookDelivery(t, store, subscription).ID)
+		createWebhookDelivery(t, store, other)
+	}
+
+	requirePages(t, ids, func(page db.Page) ([]db.PageKey, error) {
+		deliveries, err := store.ListWebhookDeliveries(context.Background(), subscription.ID, page)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []db.PageKey
+		for _, delivery := range *deliveries {
+			require.Equal(t, subscription.ID, delivery.SubscriptionID)
+			keys = append(keys, db.PageKey{CreatedAt: delivery.CreatedAt, ID: delivery.ID})
+		}
+		return keys, nil
+	})
+}
+
+func testClaimDueWebhookDeliveries(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+	delivery := createWebhookDelivery(t, store, subscription)
+
+	claimed, err := store.ClaimDueWebhookDeliveries(ctx, 1_000_000, time.Minute)
+	require.NoError(t, err)
+
+	var found bool
+	for _, claim := range *claimed {
+		found = found || claim.ID == delivery.ID
+	}
+	require.True(t, found)
+
+	// leased, so not claimable again right away
+	claimed, err = store.ClaimDueWebhookDeliveries(ctx, 1_000_000, time.Minute)
+	require.NoError(t, err)
+	for _, claim := range *claimed {
+		require.NotEqual(t, delivery.ID, claim.ID)
+	}
+
+	rowsAffected, err := store.MarkWebhookDeliverySucceeded(ctx, delivery.ID, 200)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	succeeded, err := store.GetWebhookDeliveryByID(ctx, delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.WebhookDeliverySucceeded, succeeded.Status)
+	require.Equal(t, int64(1), succeeded.Attempts)
+	require.Equal(t, int64(200), *succeeded.LastStatusCode)
+	require.NotNil(t, succeeded.DeliveredAt)
+
+	// delivered ones are never claimed again
+	claimed, err = store.ClaimDueWebhookDeliveries(ctx, 1_000_000, 0)
+	require.NoError(t, err)
+	for _, claim := range *claimed {
+		require.NotEqual(t, delivery.ID, claim.ID)
+	}
+}
+
+func testWebhookDeliveryDeadLetter(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+	delivery := createWebhookDelivery(t, store, subscription)
+
+	// no response is recorded without a status code
+	rowsAffected, err := store.MarkWebhookDeliveryFailed(ctx, delivery.ID, 0, "connection refused", time.Now(), 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	failed, err := store.GetWebhookDeliveryByID(ctx, delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.WebhookDeliveryPending, failed.Status)
+	require.Nil(t, failed.LastStatusCode)
+	require.Equal(t, "connection refused", *failed.LastError)
+
+	for i := 0; i < 2; i++ {
+		rowsAffected, err := store.MarkWebhookDeliveryFailed(ctx, delivery.ID, 503, "subscriber responded with status 503", time.Now(), 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rowsAffected)
+	}
+
+	dead, err := store.GetWebhookDeliveryByID(ctx, delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.WebhookDeliveryDead, dead.Status)
+	require.Equal(t, int64(3), dead.Attempts)
+	require.Equal(t, int64(503), *dead.LastStatusCode)
+
+	replayed, err := store.ReplayWebhookDelivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, delivery.ID, replayed.ID)
+	require.Equal(t, db.WebhookDeliveryPending, replayed.Status)
+	require.Zero(t, replayed.Attempts)
+	require.Nil(t, replayed.DeliveredAt)
+}
+
+func testUpdateMissingWebhookDelivery(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	subscription := createWebhookSubscription(t, store, utils.RandomOwner(), db.EventTransferCompleted)
+	missing := missingID(createWebhookDelivery(t, store, subscription).ID)
+
+	_, err := store.GetWebhookDeliveryByID(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err := store.MarkWebhookDeliverySucceeded(ctx, missing, 200)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = store.MarkWebhookDeliveryFailed(ctx, missing, 503, "subscriber responded with status 503", time.Now(), 3)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	_, err = store.ReplayWebhookDelivery(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
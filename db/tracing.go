@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/joelpatel/go-bank/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/joelpatel/go-bank/db"
+
+// span attributes semconv v1.21 has no key for
+const (
+	rowsAffectedKey = attribute.Key("db.rows_affected")
+	sqlStateKey     = attribute.Key("db.response.status_code")
+)
+
+// prefix of the functions of this package in stack frames
+var packagePrefix = reflect.TypeOf(Queries{}).PkgPath() + "."
+
+// tracedOps makes a span of every statement, named after the Queries method running it,
+// and logs it at debug level
+type tracedOps struct {
+	ops    Ops
+	logger *slog.Logger
+}
+
+// the statement ends once its row is scanned, pgx reports most failures only then
+func (t tracedOps) QueryRowContext(ctx context.Context, query string, args ...any) *tracedRow {
+	ctx, statement := t.start(ctx, query)
+	return &tracedRow{row: t.ops.QueryRowContext(ctx, query, args...), statement: statement}
+}
+
+// row of a statement still being recorded, scan it exactly once
+type tracedRow struct {
+	row       *sql.Row
+	statement *statement
+}
+
+func (r *tracedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.statement.end(1, err)
+	return err
+}
+
+func (t tracedOps) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, statement := t.start(ctx, query)
+	err := t.ops.GetContext(ctx, dest, query, args...)
+	statement.end(1, err)
+	return err
+}
+
+func (t tracedOps) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, statement := t.start(ctx, query)
+	err := t.ops.SelectContext(ctx, dest, query, args...)
+
+	rows := int64(-1)
+	if value := reflect.Indirect(reflect.ValueOf(dest)); value.Kind() == reflect.Slice {
+		rows = int64(value.Len())
+	}
+	statement.end(rows, err)
+	return err
+}
+
+// panics like sqlx does, once the statement is recorded
+func (t tracedOps) MustExecContext(ctx context.Context, query string, args ...interface{}) sql.Result {
+	result, err := t.ExecContext(ctx, query, args...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func (t tracedOps) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, statement := t.start(ctx, query)
+	result, err := t.ops.ExecContext(ctx, query, args...)
+
+	rows := int64(-1)
+	if err == nil {
+		if affected, err := result.RowsAffected(); err == nil {
+			rows = affected
+		}
+	}
+	statement.end(rows, err)
+	return result, err
+}
+
+// a statement being run, see tracedOps
+type statement struct {
+	ctx    context.Context
+	span   trace.Span
+	logger *slog.Logger
+	name   string
+	start  time.Time
+}
+
+// span of query run by the Queries method calling into tracedOps
+func (t tracedOps) start(ctx context.Context, query string) (context.Context, *statement) {
+	name := queryName()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(name), semconv.DBStatement(query)),
+	)
+	return ctx, &statement{ctx: ctx, span: span, logger: logging.FromContext(ctx, t.logger), name: name, start: time.Now()}
+}
+
+// rows is -1 when unknown; a missing row is an answer, not a failure.
+// arguments are never logged, they may be secrets
+func (s *statement) end(rows int64, err error) {
+	defer s.span.End()
+
+	attrs := []slog.Attr{slog.String("query", s.name), slog.Duration("duration", time.Since(s.start))}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			s.span.SetAttributes(sqlStateKey.String(pgErr.Code))
+			attrs = append(attrs, slog.String("sqlstate", pgErr.Code))
+		}
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else if rows >= 0 && err == nil {
+		s.span.SetAttributes(rowsAffectedKey.Int64(rows))
+		attrs = append(attrs, slog.Int64("rows", rows))
+	}
+
+	s.logger.LogAttrs(s.ctx, slog.LevelDebug, "statement", attrs...)
+}
+
+// first function of this package up the stack outside of tracedOps, e.g. CreateTransfer for
+// github.com/joelpatel/go-bank/db.(*Queries).CreateTransfer
+func queryName() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(4, pcs)])
+	for {
+		frame, more := frames.Next()
+		if function, ok := strings.CutPrefix(frame.Function, packagePrefix); ok && !strings.HasPrefix(function, "tracedOps.") {
+			// (*Queries).Method.func1 or function
+			parts := strings.Split(function, ".")
+			if strings.HasPrefix(parts[0], "(") && len(parts) > 1 {
+				return parts[1]
+			}
+			return parts[0]
+		}
+		if !more {
+			return "query"
+		}
+	}
+}
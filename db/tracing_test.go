@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/joelpatel/go-bank/tracing"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// answers every statement with result or err, no database needed
+type fakeOps struct {
+	Ops
+	result sql.Result
+	err    error
+}
+
+func (f fakeOps) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return f.result, f.err
+}
+
+// Statements should be spans named after their Queries method, with rows affected and the SQLSTATE of failures.
+func TestTracedQueries(t *testing.T) {
+	exporter := tracing.InMemory()
+	ctx := context.Background()
+
+	q := NewQueries(fakeOps{result: driver.RowsAffected(3)})
+	deleted, err := q.DeleteIdleRateLimitBuckets(ctx, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+
+	q = NewQueries(fakeOps{err: &pgconn.PgError{Code: "23503", Message: "violates foreign key constraint"}})
+	_, err = q.DeleteAccountByID(ctx, 1)
+	require.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	attributes := func(index int) map[attribute.Key]attribute.Value {
+		values := map[attribute.Key]attribute.Value{}
+		for _, kv := range spans[index].Attributes {
+			values[kv.Key] = kv.Value
+		}
+		return values
+	}
+
+	require.Equal(t, "db.DeleteIdleRateLimitBuckets", spans[0].Name)
+	require.Equal(t, "DeleteIdleRateLimitBuckets", attributes(0)["db.operation"].AsString())
+	require.Contains(t, attributes(0)["db.statement"].AsString(), "DELETE FROM rate_limit_buckets")
+	require.Equal(t, int64(3), attributes(0)[rowsAffectedKey].AsInt64())
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	require.Equal(t, "db.DeleteAccountByID", spans[1].Name)
+	require.Equal(t, "23503", attributes(1)[sqlStateKey].AsString())
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+}
+
+// a driver whose statements fail on their first row, the way pgx reports errors of the server
+type failingRowsDriver struct{ err error }
+
+func (d failingRowsDriver) Open(name string) (driver.Conn, error) { return failingRowsConn(d), nil }
+
+type failingRowsConn struct{ err error }
+
+func (c failingRowsConn) Prepare(query string) (driver.Stmt, error) { return failingRowsStmt(c), nil }
+func (c failingRowsConn) Close() error                              { return nil }
+func (c failingRowsConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type failingRowsStmt struct{ err error }
+
+func (s failingRowsStmt) Close() error                                    { return nil }
+func (s failingRowsStmt) NumInput() int                                   { return -1 }
+func (s failingRowsStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, s.err }
+func (s failingRowsStmt) Query(args []driver.Value) (driver.Rows, error)  { return failingRows(s), nil }
+
+type failingRows struct{ err error }
+
+func (r failingRows) Columns() []string              { return []string{"version", "dirty"} }
+func (r failingRows) Close() error                   { return nil }
+func (r failingRows) Next(dest []driver.Value) error { return r.err }
+
+// A statement whose row fails to scan should end its span with the failure.
+func TestTracedQueryRow(t *testing.T) {
+	exporter := tracing.InMemory()
+
+	conn := sqlx.NewDb(sql.OpenDB(connector{failingRowsDriver{err: &pgconn.PgError{Code: "42P01", Message: "relation does not exist"}}}), "pgx")
+	defer conn.Close()
+
+	_, _, err := NewQueries(conn).GetSchemaVersion(context.Background())
+	require.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "db.GetSchemaVersion", spans[0].Name)
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+type connector struct{ driver driver.Driver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.driver }
@@ -0,0 +1,291 @@
+// maker-checker approval of held transfers
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// statuses of a transfer request
+const (
+	TransferRequestPending  = "pending"
+	TransferRequestApproved = "approved" // decided, the transfer is being made
+	TransferRequestRejected = "rejected"
+	TransferRequestExpired  = "expired"
+	TransferRequestExecuted = "executed"
+	TransferRequestFailed   = "failed"
+)
+
+// action of the first event of every request, the others are named after the status they lead to
+const TransferRequestSubmitted = "submitted"
+
+// actor of the events nobody caused, like expiry
+const SystemActor = "system"
+
+var (
+	ErrRequestNotPending  = errors.New("transfer request is not pending")
+	ErrRequestNotApproved = errors.New("transfer request is not approved")
+	ErrRequestExpired     = errors.New("transfer request has expired")
+	ErrSelfReview         = errors.New("transfer request cannot be decided by its initiator")
+)
+
+const transferRequestColumns = "id, from_account_id, to_account_id, amount, description, external_reference, metadata, initiator, hold_reason, risk_assessment_id, status, reviewer, decided_at, transfer_id, failure, expires_at, created_at"
+
+// create a pending request and its submitted event
+func (s *SQLStore) CreateTransferRequest(ctx context.Context, request TransferRequest) (*TransferRequest, error) {
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	q := s.inTx(tx)
+
+	var created TransferRequest
+	err := q.db.GetContext(ctx, &created, "INSERT INTO transfer_requests (from_account_id, to_account_id, amount, description, external_reference, metadata, initiator, hold_reason, risk_assessment_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8, $9, $10) RETURNING "+transferRequestColumns+";",
+		request.FromAccountID, request.ToAccountID, request.Amount, request.Description, request.ExternalReference, request.Metadata, request.Initiator, request.HoldReason, request.RiskAssessmentID, request.ExpiresAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = q.createTransferRequestEvent(ctx, created.ID, TransferRequestSubmitted, created.Initiator, created.HoldReason)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// read (id)
+func (s *Queries) GetTransferRequestByID(ctx context.Context, id int64) (*TransferRequest, error) {
+	var request TransferRequest
+
+	err := s.db.GetContext(ctx, &request, "SELECT "+transferRequestColumns+" FROM transfer_requests WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// read (status, keyset page), every status when empty
+func (s *Queries) ListTransferRequests(ctx context.Context, status string, page Page) (*[]TransferRequest, error) {
+	var requests []TransferRequest
+
+	clause, args := page.clause(2)
+	err := s.db.SelectContext(ctx, &requests, "SELECT "+transferRequestColumns+" FROM transfer_requests WHERE ($1 = '' OR status = $1)"+clause+";", append([]any{status}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &requests, nil
+}
+
+// read audit trail, oldest first
+func (s *Queries) GetTransferRequestEvents(ctx context.Context, requestID int64) (*[]TransferRequestEvent, error) {
+	var events []TransferRequestEvent
+
+	err := s.db.SelectContext(ctx, &events, "SELECT id, request_id, action, actor, note, created_at FROM transfer_request_events WHERE request_id = $1 ORDER BY id;", requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
+
+// lock the request
+// refuse requests that are not pending or are decided by their initiator
+// if past its expiry: mark it expired (kept) and return ErrRequestExpired
+// set the status (approved or rejected), reviewer and decision time
+// create the decision's event
+func (s *SQLStore) DecideTransferRequest(ctx context.Context, id int64, reviewer, status, note string) (*TransferRequest, error) {
+	if status != TransferRequestApproved && status != TransferRequestRejected {
+		return nil, fmt.Errorf("transfer requests are approved or rejected, not %s", status)
+	}
+
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	request, err := s.inTx(tx).decideTransferRequest(ctx, id, reviewer, status, note)
+	if err != nil && !errors.Is(err, ErrRequestExpired) {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// an expired request stays expired
+	if commitErr := tx.Commit(); commitErr != nil {
+		tx.Rollback()
+		return nil, commitErr
+	}
+
+	return request, err
+}
+
+// record the outcome of an approved request's transfer: executed with transferID, or failed with failure.
+// an executed request's risk assessment is linked to the transfer
+func (s *SQLStore) FinishTransferRequest(ctx context.Context, id int64, transferID *int64, failure string) (*TransferRequest, error) {
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	request, err := s.inTx(tx).finishTransferRequest(ctx, id, transferID, failure)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// approve a pending request and make its transfer in one transaction, no request is left approved without an outcome
+// decide the request as approved, like DecideTransferRequest
+// make the transfer, like TransferMoney, under a savepoint
+// a transfer that cannot be made is rolled back to the savepoint and fails the request with the reason
+// record the outcome, like FinishTransferRequest
+func (s *SQLStore) ApproveTransferRequest(ctx context.Context, id int64, reviewer, note string) (*TransferRequest, error) {
+	tx := s.conn.MustBeginTx(ctx, nil)
+
+	q := s.inTx(tx)
+
+	request, err := q.decideTransferRequest(ctx, id, reviewer, TransferRequestApproved, note)
+	if errors.Is(err, ErrRequestExpired) {
+		if err := tx.Commit(); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		return nil, ErrRequestExpired
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = q.db.ExecContext(ctx, "SAVEPOINT transfer;")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var transferID *int64
+	var failure string
+	result, err := q.transferMoney(ctx, request.FromAccountID, request.ToAccountID, request.Amount, request.Details)
+	if err != nil {
+		// the decision stands, only the transfer is undone
+		if _, rollbackErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT transfer;"); rollbackErr != nil {
+			tx.Rollback()
+			return nil, rollbackErr
+		}
+		failure = err.Error()
+	} else {
+		transferID = &result.TransferRecord.ID
+	}
+
+	request, err = q.finishTransferRequest(ctx, id, transferID, failure)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// the steps of DecideTransferRequest, in the caller's transaction, which commits an ErrRequestExpired
+func (q *Queries) decideTransferRequest(ctx context.Context, id int64, reviewer, status, note string) (*TransferRequest, error) {
+	var request TransferRequest
+	err := q.db.GetContext(ctx, &request, "SELECT "+transferRequestColumns+" FROM transfer_requests WHERE id = $1 FOR UPDATE;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != TransferRequestPending {
+		return nil, fmt.Errorf("%w: it is %s", ErrRequestNotPending, request.Status)
+	}
+	if reviewer == request.Initiator {
+		return nil, ErrSelfReview
+	}
+
+	expired, err := q.expireTransferRequests(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if expired > 0 {
+		return nil, ErrRequestExpired
+	}
+
+	err = q.db.GetContext(ctx, &request, "UPDATE transfer_requests SET status = $2, reviewer = $3, decided_at = now() WHERE id = $1 RETURNING "+transferRequestColumns+";", id, status, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.createTransferRequestEvent(ctx, id, status, reviewer, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// the steps of FinishTransferRequest, in the caller's transaction
+func (q *Queries) finishTransferRequest(ctx context.Context, id int64, transferID *int64, failure string) (*TransferRequest, error) {
+	status, note := TransferRequestFailed, failure
+	if transferID != nil {
+		status, note = TransferRequestExecuted, fmt.Sprintf("transfer %d", *transferID)
+	}
+
+	var request TransferRequest
+	err := q.db.GetContext(ctx, &request, "UPDATE transfer_requests SET status = $2, transfer_id = $3, failure = $4 WHERE id = $1 AND status = 'approved' RETURNING "+transferRequestColumns+";", id, status, transferID, failure)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRequestNotApproved
+		}
+		return nil, err
+	}
+
+	if transferID != nil && request.RiskAssessmentID != nil {
+		_, err = q.db.ExecContext(ctx, "UPDATE risk_assessments SET transfer_id = $1 WHERE id = $2;", *transferID, *request.RiskAssessmentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = q.createTransferRequestEvent(ctx, id, status, *request.Reviewer, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// expire every pending request past its expiry
+func (s *Queries) ExpireTransferRequests(ctx context.Context) (int64, error) {
+	return s.expireTransferRequests(ctx, 0)
+}
+
+// expire pending requests past their expiry, only the one with id unless it is 0,
+// each with an expired event
+func (s *Queries) expireTransferRequests(ctx context.Context, id int64) (int64, error) {
+	return rowsAffected(s.db.ExecContext(ctx, `WITH expired AS (
+			UPDATE transfer_requests SET status = 'expired' WHERE status = 'pending' AND expires_at <= now() AND ($1 = 0 OR id = $1) RETURNING id
+		)
+		INSERT INTO transfer_request_events (request_id, action, actor) SELECT id, $2, $3 FROM expired;`, id, TransferRequestExpired, SystemActor))
+}
+
+func (s *Queries) createTransferRequestEvent(ctx context.Context, requestID int64, action, actor, note string) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO transfer_request_events (request_id, action, actor, note) VALUES ($1, $2, $3, $4);", requestID, action, actor, note)
+	return err
+}
@@ -5,59 +5,77 @@ import (
 	"context"
 )
 
-// create a transfer record
+// check the transfer limits of from
+// create a transfer record with details
 // create an entry record for: from
 // create an entry record for: to
 // update balance in account: from
 // update balance in account: to
-func (s *SQLStore) TransferMoney(ctx context.Context, from_account_id, to_account_id, amount int64) (*TransferTxResult, error) {
+// create a TransferCompleted outbox event
+func (s *SQLStore) TransferMoney(ctx context.Context, from_account_id, to_account_id, amount int64, details Details) (*TransferTxResult, error) {
 	tx := s.conn.MustBeginTx(ctx, nil)
 
-	q := NewQueries(tx)
-
-	transferRecord, err := q.CreateTransfer(ctx, from_account_id, to_account_id, amount)
+	result, err := s.inTx(tx).transferMoney(ctx, from_account_id, to_account_id, amount, details)
 	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	fromEntry, err := q.CreateEntry(ctx, from_account_id, -amount)
+	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	toEntry, err := q.CreateEntry(ctx, to_account_id, amount)
+	return result, nil
+}
+
+// the steps of TransferMoney, in the caller's transaction
+func (q *Queries) transferMoney(ctx context.Context, from_account_id, to_account_id, amount int64, details Details) (*TransferTxResult, error) {
+	if err := q.checkTransferLimits(ctx, from_account_id, amount); err != nil {
+		return nil, err
+	}
+
+	transferRecord, err := q.CreateTransfer(ctx, from_account_id, to_account_id, amount, details)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	var fromAccount, toAccount *Account
-	if from_account_id < to_account_id {
-		fromAccount, toAccount, err = addAmountInOrder(ctx, q, from_account_id, to_account_id, -amount)
-	} else {
-		fromAccount, toAccount, err = addAmountInOrder(ctx, q, to_account_id, from_account_id, amount)
+	fromEntry, err := q.CreateEntry(ctx, from_account_id, -amount, details)
+	if err != nil {
+		return nil, err
 	}
 
+	toEntry, err := q.CreateEntry(ctx, to_account_id, amount, details)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	err = tx.Commit()
+	var fromAccount, toAccount *Account
+	if from_account_id < to_account_id {
+		fromAccount, toAccount, err = addAmountInOrder(ctx, q, from_account_id, to_account_id, -amount)
+	} else {
+		toAccount, fromAccount, err = addAmountInOrder(ctx, q, to_account_id, from_account_id, amount)
+	}
+
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	return &TransferTxResult{
+	result := &TransferTxResult{
 		TransferRecord:  *transferRecord,
 		FromEntryRecord: *fromEntry,
 		ToEntryRecord:   *toEntry,
 		FromAccount:     *fromAccount,
 		ToAccount:       *toAccount,
-	}, nil
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, AggregateTransfer, transferRecord.ID, EventTransferCompleted, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 // add +amount to first_account
@@ -2,9 +2,14 @@ package db
 
 import (
 	"context"
+	"log/slog"
+	"math/rand"
 	"testing"
 	"time"
 
+	"github.com/joelpatel/go-bank/config"
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,7 +26,7 @@ func TestTransferTx(t *testing.T) {
 	// run n concurrent transfer transactions
 	for i := 0; i < n; i++ {
 		go func() {
-			result, err := testStore.TransferMoney(context.Background(), account1.ID, account2.ID, amount)
+			result, err := testStore(t).TransferMoney(context.Background(), account1.ID, account2.ID, amount, Details{})
 			errs <- err
 			results <- *result
 		}()
@@ -45,7 +50,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, amount, transfer.Amount)
 		require.WithinDuration(t, time.Now(), transfer.CreatedAt, 10*time.Second)
 		require.NotZero(t, transfer.ID)
-		_, err = testStore.GetTransferByID(context.Background(), transfer.ID)
+		_, err = testStore(t).GetTransferByID(context.Background(), transfer.ID)
 		require.NoError(t, err)
 
 		// check entries
@@ -55,7 +60,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account1.ID, fromEntry.AccountID)
 		require.Equal(t, -amount, fromEntry.Amount)
 		require.WithinDuration(t, time.Now(), fromEntry.CreatedAt, 10*time.Second)
-		_, err = testStore.GetEntryByID(context.Background(), fromEntry.ID)
+		_, err = testStore(t).GetEntryByID(context.Background(), fromEntry.ID)
 		require.NoError(t, err)
 
 		toEntry := result.ToEntryRecord
@@ -64,7 +69,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account2.ID, toEntry.AccountID)
 		require.Equal(t, amount, toEntry.Amount)
 		require.WithinDuration(t, time.Now(), toEntry.CreatedAt, 10*time.Second)
-		_, err = testStore.GetEntryByID(context.Background(), toEntry.ID)
+		_, err = testStore(t).GetEntryByID(context.Background(), toEntry.ID)
 		require.NoError(t, err)
 
 		// check accounts
@@ -96,10 +101,10 @@ func TestTransferTx(t *testing.T) {
 	}
 
 	// check the final updated balance
-	updatedAccount1, err := testStore.GetAccountByID(context.Background(), account1.ID)
+	updatedAccount1, err := testStore(t).GetAccountByID(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testStore.GetAccountByID(context.Background(), account2.ID)
+	updatedAccount2, err := testStore(t).GetAccountByID(context.Background(), account2.ID)
 	require.NoError(t, err)
 
 	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance)
@@ -124,7 +129,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 		}
 
 		go func() {
-			_, err := testStore.TransferMoney(context.Background(), fromAccountID, toAccountID, amount)
+			_, err := testStore(t).TransferMoney(context.Background(), fromAccountID, toAccountID, amount, Details{})
 			errs <- err
 		}()
 	}
@@ -135,12 +140,87 @@ func TestTransferTxDeadlock(t *testing.T) {
 	}
 
 	// check the updated final balance
-	updatedAccount1, err := testStore.GetAccountByID(context.Background(), account1.ID)
+	updatedAccount1, err := testStore(t).GetAccountByID(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testStore.GetAccountByID(context.Background(), account2.ID)
+	updatedAccount2, err := testStore(t).GetAccountByID(context.Background(), account2.ID)
 	require.NoError(t, err)
 
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+// a transfer to an older account adds to the accounts in id order, yet reports them as from and to
+func TestTransferTxToLowerID(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := int64(10)
+
+	result, err := testStore(t).TransferMoney(context.Background(), account2.ID, account1.ID, amount, Details{})
+	require.NoError(t, err)
+
+	require.Equal(t, account2.ID, result.FromAccount.ID)
+	require.Equal(t, account2.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account1.ID, result.ToAccount.ID)
+	require.Equal(t, account1.Balance+amount, result.ToAccount.Balance)
+}
+
+// open a store on the test database through the given pool backend
+func openTestStore(tb testing.TB, backend string) Store {
+	settings, _ := testDatabase(tb)
+	database := settings.Database
+	database.Backend = backend
+
+	conn, err := OpenDB(database)
+	require.NoError(tb, err)
+
+	store := NewStore(conn, slog.Default())
+	tb.Cleanup(func() { store.Close() })
+	return store
+}
+
+// Transfers should behave the same on the pgxpool backend.
+func TestTransferTxPgxPool(t *testing.T) {
+	store := openTestStore(t, config.BackendPgxPool)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferMoney(context.Background(), account1.ID, account2.ID, 10, Details{})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+10, result.ToAccount.Balance)
+}
+
+// Compare transfer throughput of the database/sql and pgxpool backends:
+//
+//	go test ./db -run '^$' -bench TransferMoney
+func BenchmarkTransferMoney(b *testing.B) {
+	for _, backend := range []string{config.BackendSQL, config.BackendPgxPool} {
+		b.Run(backend, func(b *testing.B) {
+			store := openTestStore(b, backend)
+			ctx := context.Background()
+
+			// spread transfers over several accounts so the benchmark is not just lock contention
+			accountIDs := make([]int64, 10)
+			for i := range accountIDs {
+				account, err := store.CreateAccount(ctx, utils.RandomOwner(), 1_000_000_000, currency.USD)
+				require.NoError(b, err)
+				accountIDs[i] = account.ID
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := rand.Intn(len(accountIDs))
+					j := (i + 1 + rand.Intn(len(accountIDs)-1)) % len(accountIDs)
+
+					_, err := store.TransferMoney(ctx, accountIDs[i], accountIDs[j], 1, Details{})
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -5,13 +5,26 @@ import (
 )
 
 // create
-func (s *Queries) CreateTransfer(ctx context.Context, from_account_id, to_account_id, amount int64) (*Transfer, error) {
-	row := s.db.QueryRowContext(ctx, "INSERT INTO transfers (from_account_id, to_account_id, amount) VALUES ($1, $2, $3) RETURNING id, from_account_id, to_account_id, amount, created_at;", from_account_id, to_account_id, amount)
+func (s *Queries) CreateTransfer(ctx context.Context, from_account_id, to_account_id, amount int64, details Details) (*Transfer, error) {
+	if err := details.Validate(); err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, "INSERT INTO transfers (from_account_id, to_account_id, amount, description, external_reference, metadata) VALUES ($1, $2, $3, $4, $5, $6::jsonb) RETURNING id, from_account_id, to_account_id, amount, description, external_reference, metadata, created_at;", from_account_id, to_account_id, amount, details.Description, details.ExternalReference, details.Metadata)
 
 	var transfer Transfer
 
-	err := row.Scan(&transfer.ID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount, &transfer.CreatedAt)
+	err := row.Scan(&transfer.ID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount, &transfer.Description, &transfer.ExternalReference, &transfer.Metadata, &transfer.CreatedAt)
 	if err != nil {
+		if violates(err, "business_day_closed") {
+			return nil, ErrBusinessDayClosed
+		}
+		if violates(err, "account_frozen") {
+			return nil, ErrAccountFrozen
+		}
+		if violates(err, "transfers_external_reference_key") {
+			return nil, ErrDuplicateReference
+		}
 		return nil, err
 	}
 
@@ -22,7 +35,7 @@ func (s *Queries) CreateTransfer(ctx context.Context, from_account_id, to_accoun
 func (s *Queries) GetTransferByID(ctx context.Context, id int64) (*Transfer, error) {
 	var transfer Transfer
 
-	err := s.db.GetContext(ctx, &transfer, "SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers WHERE id = $1;", id)
+	err := s.db.GetContext(ctx, &transfer, "SELECT id, from_account_id, to_account_id, amount, description, external_reference, metadata, created_at FROM transfers WHERE id = $1;", id)
 	if err != nil {
 		return nil, err
 	}
@@ -32,14 +45,27 @@ func (s *Queries) GetTransferByID(ctx context.Context, id int64) (*Transfer, err
 
 // read (from_account_id OR to_account_id)
 // (-1 if don't want to search for from exor to)
-// paginated
-func (s *Queries) GetTransfersFromTo(ctx context.Context, from_account_id, to_account_id, limit, offset int64) (*[]Transfer, error) {
+// keyset paginated
+func (s *Queries) GetTransfersFromTo(ctx context.Context, from_account_id, to_account_id int64, page Page) (*[]Transfer, error) {
 	var transfers []Transfer
 
-	err := s.db.SelectContext(ctx, &transfers, "SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers WHERE from_account_id = $1 OR to_account_id = $2 ORDER BY id LIMIT $3 OFFSET $4;", from_account_id, to_account_id, limit, offset)
+	clause, args := page.clause(3)
+	err := s.db.SelectContext(ctx, &transfers, "SELECT id, from_account_id, to_account_id, amount, description, external_reference, metadata, created_at FROM transfers WHERE (from_account_id = $1 OR to_account_id = $2)"+clause+";", append([]any{from_account_id, to_account_id}, args...)...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &transfers, nil
 }
+
+// read (from_account_id, external_reference)
+func (s *Queries) GetTransferByExternalReference(ctx context.Context, from_account_id int64, external_reference string) (*Transfer, error) {
+	var transfer Transfer
+
+	err := s.db.GetContext(ctx, &transfer, "SELECT id, from_account_id, to_account_id, amount, description, external_reference, metadata, created_at FROM transfers WHERE from_account_id = $1 AND external_reference = $2 AND external_reference <> '';", from_account_id, external_reference)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"database/sql/driver"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var typeMap = pgtype.NewMap()
+
+// postgres text[] / varchar[] column
+type StringArray []string
+
+func (a *StringArray) Scan(src any) error {
+	return typeMap.SQLScanner((*[]string)(a)).Scan(src)
+}
+
+func (a StringArray) Value() (driver.Value, error) {
+	return []string(a), nil
+}
+
+// postgres bigint[] column
+type Int64Array []int64
+
+func (a *Int64Array) Scan(src any) error {
+	return typeMap.SQLScanner((*[]int64)(a)).Scan(src)
+}
+
+// empty rather than NULL when nil
+func (a Int64Array) Value() (driver.Value, error) {
+	if a == nil {
+		return []int64{}, nil
+	}
+	return []int64(a), nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// roles of users, ordered by what they may do
+const (
+	RoleCustomer = "customer" // own accounts, transfers, payees and webhooks
+	RoleTeller   = "teller"   // customers' work, plus reviewing transfers and viewing or freezing any account
+	RoleAdmin    = "admin"    // everything, including balances, owners and roles
+)
+
+var Roles = []string{RoleCustomer, RoleTeller, RoleAdmin}
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// create or change the role of username
+func (s *Queries) SetUserRole(ctx context.Context, username, role string) (*User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("%w: username is required", ErrInvalidRole)
+	}
+	if !slices.Contains(Roles, role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidRole, role)
+	}
+
+	var user User
+
+	err := s.db.GetContext(ctx, &user, "INSERT INTO users (username, role) VALUES ($1, $2) ON CONFLICT (username) DO UPDATE SET role = EXCLUDED.role RETURNING username, role, created_at;", username, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// read (username)
+func (s *Queries) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var user User
+
+	err := s.db.GetContext(ctx, &user, "SELECT username, role, created_at FROM users WHERE username = $1;", username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// read all, by username
+func (s *Queries) GetUsers(ctx context.Context) (*[]User, error) {
+	var users []User
+
+	err := s.db.SelectContext(ctx, &users, "SELECT username, role, created_at FROM users ORDER BY username;")
+	if err != nil {
+		return nil, err
+	}
+
+	return &users, nil
+}
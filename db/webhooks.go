@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// create
+func (s *Queries) CreateWebhookSubscription(ctx context.Context, owner, url string, eventTypes []string, secret string) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+
+	err := s.db.GetContext(ctx, &subscription, "INSERT INTO webhook_subscriptions (owner, url, event_types, secret) VALUES ($1, $2, $3, $4) RETURNING id, owner, url, event_types, secret, created_at;", owner, url, eventTypes, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// read (id)
+func (s *Queries) GetWebhookSubscriptionByID(ctx context.Context, id int64) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+
+	err := s.db.GetContext(ctx, &subscription, "SELECT id, owner, url, event_types, secret, created_at FROM webhook_subscriptions WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// read (owner)
+func (s *Queries) GetWebhookSubscriptionsByOwner(ctx context.Context, owner string) (*[]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	err := s.db.SelectContext(ctx, &subscriptions, "SELECT id, owner, url, event_types, secret, created_at FROM webhook_subscriptions WHERE owner = $1 ORDER BY id;", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscriptions, nil
+}
+
+// read (owner subscribed to event type)
+func (s *Queries) GetWebhookSubscriptionsForEvent(ctx context.Context, owner, eventType string) (*[]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	err := s.db.SelectContext(ctx, &subscriptions, "SELECT id, owner, url, event_types, secret, created_at FROM webhook_subscriptions WHERE owner = $1 AND $2 = ANY(event_types) ORDER BY id;", owner, eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscriptions, nil
+}
+
+// delete (deliveries cascade)
+func (s *Queries) DeleteWebhookSubscriptionByID(ctx context.Context, id int64) (int64, error) {
+	return s.db.MustExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1;", id).RowsAffected()
+}
+
+// create (idempotent per subscription, event and event type)
+func (s *Queries) CreateWebhookDelivery(ctx context.Context, subscriptionID, eventID int64, eventType string, payload json.RawMessage) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload) VALUES ($1, $2, $3, $4::jsonb) ON CONFLICT DO NOTHING;", subscriptionID, eventID, eventType, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// read (id)
+func (s *Queries) GetWebhookDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := s.db.GetContext(ctx, &delivery, "SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// read a keyset page of subscription_id's deliveries
+func (s *Queries) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, page Page) (*[]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	clause, args := page.clause(2)
+	err := s.db.SelectContext(ctx, &deliveries, "SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE subscription_id = $1"+clause+";", append([]any{subscriptionID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deliveries, nil
+}
+
+// claim up to limit due deliveries; claimed ones are not due again until lease has passed
+func (s *Queries) ClaimDueWebhookDeliveries(ctx context.Context, limit int64, lease time.Duration) (*[]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	err := s.db.SelectContext(ctx, &deliveries, `UPDATE webhook_deliveries SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= now() ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at;`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+
+	return &deliveries, nil
+}
+
+// update after a successful attempt
+func (s *Queries) MarkWebhookDeliverySucceeded(ctx context.Context, id, statusCode int64) (int64, error) {
+	return s.db.MustExecContext(ctx, "UPDATE webhook_deliveries SET status = 'succeeded', attempts = attempts + 1, last_status_code = $1, last_error = NULL, delivered_at = now() WHERE id = $2;", statusCode, id).RowsAffected()
+}
+
+// update after a failed attempt; dead lettered once attempts reaches maxAttempts
+// statusCode is 0 when no response was received
+func (s *Queries) MarkWebhookDeliveryFailed(ctx context.Context, id, statusCode int64, lastError string, nextAttemptAt time.Time, maxAttempts int64) (int64, error) {
+	return s.db.MustExecContext(ctx, `UPDATE webhook_deliveries SET
+			attempts = attempts + 1,
+			status = CASE WHEN attempts + 1 >= $4 THEN 'dead' ELSE 'pending' END,
+			last_status_code = NULLIF($1, 0),
+			last_error = $2,
+			next_attempt_at = $3
+		WHERE id = $5;`, statusCode, lastError, nextAttemptAt, maxAttempts, id).RowsAffected()
+}
+
+// update to deliver again from scratch
+func (s *Queries) ReplayWebhookDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := s.db.GetContext(ctx, &delivery, "UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL WHERE id = $1 RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at;", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
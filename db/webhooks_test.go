@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookSubscription(t *testing.T) *WebhookSubscription {
+	owner := utils.RandomOwner()
+	secret := utils.RandomString(32)
+
+	subscription, err := testStore(t).CreateWebhookSubscription(context.Background(), owner, "https://example.com/hooks", []string{EventTransferCompleted, EventAccountCreated}, secret)
+	require.NoError(t, err)
+	require.NotEmpty(t, subscription)
+
+	require.NotZero(t, subscription.ID)
+	require.Equal(t, owner, subscription.Owner)
+	require.Equal(t, StringArray{EventTransferCompleted, EventAccountCreated}, subscription.EventTypes)
+	require.Equal(t, secret, subscription.Secret)
+
+	return subscription
+}
+
+func createRandomWebhookDelivery(t *testing.T, subscription *WebhookSubscription) *WebhookDelivery {
+	eventID := utils.RandomInt(1, 1_000_000_000)
+
+	rowsAffected, err := testStore(t).CreateWebhookDelivery(context.Background(), subscription.ID, eventID, EventTransferCompleted, json.RawMessage(`{"amount": 10}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	deliveries, err := testStore(t).ListWebhookDeliveries(context.Background(), subscription.ID, Page{Limit: 1, Order: SortDesc})
+	require.NoError(t, err)
+	require.Len(t, *deliveries, 1)
+
+	delivery := (*deliveries)[0]
+	require.Equal(t, eventID, delivery.EventID)
+	require.Equal(t, WebhookDeliveryPending, delivery.Status)
+	require.Zero(t, delivery.Attempts)
+
+	return &delivery
+}
+
+func TestGetWebhookSubscriptionsForEvent(t *testing.T) {
+	subscription := createRandomWebhookSubscription(t)
+
+	subscriptions, err := testStore(t).GetWebhookSubscriptionsForEvent(context.Background(), subscription.Owner, EventTransferCompleted)
+	require.NoError(t, err)
+	require.Len(t, *subscriptions, 1)
+	require.Equal(t, subscription.ID, (*subscriptions)[0].ID)
+
+	subscriptions, err = testStore(t).GetWebhookSubscriptionsForEvent(context.Background(), subscription.Owner, EventAccountOwnerChanged)
+	require.NoError(t, err)
+	require.Empty(t, *subscriptions)
+}
+
+func TestCreateWebhookDeliveryIdempotent(t *testing.T) {
+	subscription := createRandomWebhookSubscription(t)
+	delivery := createRandomWebhookDelivery(t, subscription)
+
+	rowsAffected, err := testStore(t).CreateWebhookDelivery(context.Background(), subscription.ID, delivery.EventID, delivery.EventType, delivery.Payload)
+	require.NoError(t, err)
+	require.Zero(t, rowsAffected)
+}
+
+func TestWebhookDeliveryDeadLetter(t *testing.T) {
+	subscription := createRandomWebhookSubscription(t)
+	delivery := createRandomWebhookDelivery(t, subscription)
+
+	for i := 0; i < 3; i++ {
+		rowsAffected, err := testStore(t).MarkWebhookDeliveryFailed(context.Background(), delivery.ID, 503, "subscriber responded with status 503", time.Now(), 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rowsAffected)
+	}
+
+	dead, err := testStore(t).GetWebhookDeliveryByID(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryDead, dead.Status)
+	require.Equal(t, int64(3), dead.Attempts)
+	require.Equal(t, int64(503), *dead.LastStatusCode)
+
+	replayed, err := testStore(t).ReplayWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, replayed.Status)
+	require.Zero(t, replayed.Attempts)
+}
+
+func TestClaimDueWebhookDeliveries(t *testing.T) {
+	subscription := createRandomWebhookSubscription(t)
+	delivery := createRandomWebhookDelivery(t, subscription)
+
+	claimed, err := testStore(t).ClaimDueWebhookDeliveries(context.Background(), 1_000_000, time.Minute)
+	require.NoError(t, err)
+
+	var found bool
+	for _, claim := range *claimed {
+		found = found || claim.ID == delivery.ID
+	}
+	require.True(t, found)
+
+	// leased, so not claimable again right away
+	claimed, err = testStore(t).ClaimDueWebhookDeliveries(context.Background(), 1_000_000, time.Minute)
+	require.NoError(t, err)
+	for _, claim := range *claimed {
+		require.NotEqual(t, delivery.ID, claim.ID)
+	}
+
+	rowsAffected, err := testStore(t).MarkWebhookDeliverySucceeded(context.Background(), delivery.ID, 200)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rowsAffected)
+
+	succeeded, err := testStore(t).GetWebhookDeliveryByID(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliverySucceeded, succeeded.Status)
+	require.NotNil(t, succeeded.DeliveredAt)
+}
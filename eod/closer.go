@@ -0,0 +1,91 @@
+package eod
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultGrace    = 5 * time.Minute
+)
+
+// Closer closes every business day that has ended in its timezone.
+type Closer struct {
+	store    db.Store
+	location *time.Location
+	grace    time.Duration // wait after midnight so late in-flight postings can commit
+	interval time.Duration
+	now      func() time.Time
+	logger   *slog.Logger
+}
+
+// NewCloser creates an end-of-day closer for business days in location, logging to logger.
+func NewCloser(store db.Store, location *time.Location, logger *slog.Logger) *Closer {
+	return &Closer{
+		store:    store,
+		location: location,
+		grace:    defaultGrace,
+		interval: defaultInterval,
+		now:      time.Now,
+		logger:   logger,
+	}
+}
+
+// LastDue returns the most recent business day that can be closed.
+func (closer *Closer) LastDue() time.Time {
+	today := db.BusinessDate(closer.now().Add(-closer.grace), closer.location)
+	return today.AddDate(0, 0, -1)
+}
+
+// CloseDue closes, in order, every business day after the last closed one up to LastDue.
+// With no closed day yet, only LastDue is closed.
+func (closer *Closer) CloseDue(ctx context.Context) ([]db.BusinessDay, error) {
+	lastDue := closer.LastDue()
+	next := lastDue
+
+	last, err := closer.store.GetLastClosedBusinessDay(ctx)
+	if err == nil {
+		next = last.BusinessDate.AddDate(0, 0, 1)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var closed []db.BusinessDay
+	for ; !next.After(lastDue); next = next.AddDate(0, 0, 1) {
+		day, err := closer.store.CloseBusinessDay(ctx, next, closer.location)
+		if err != nil {
+			return closed, err
+		}
+		closed = append(closed, *day)
+	}
+
+	return closed, nil
+}
+
+// Run calls CloseDue every interval until ctx is done.
+func (closer *Closer) Run(ctx context.Context) {
+	ticker := time.NewTicker(closer.interval)
+	defer ticker.Stop()
+
+	for {
+		closed, err := closer.CloseDue(ctx)
+		if err != nil {
+			closer.logger.Error("closing business day", "error", err.Error())
+		}
+		for _, day := range closed {
+			closer.logger.Info("closed business day", "business_date", day.BusinessDate.Format("2006-01-02"), "accounts", day.AccountCount)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package eod
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func beforeEach(t *testing.T, now time.Time) (*mockdb.MockStore, *Closer) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	location, err := time.LoadLocation("Asia/Kolkata")
+	assert.NoError(t, err)
+
+	closer := NewCloser(store, location, slog.Default())
+	closer.now = func() time.Time { return now }
+
+	return store, closer
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// The last due business day is yesterday in the business timezone, not in UTC.
+func TestLastDueUsesBusinessTimezone(t *testing.T) {
+	// 2024-03-10 20:00 UTC is already 2024-03-11 01:30 in Kolkata
+	_, closer := beforeEach(t, time.Date(2024, time.March, 10, 20, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, date(2024, time.March, 10), closer.LastDue())
+}
+
+// Within the grace period after midnight the previous day is not due yet.
+func TestLastDueWaitsForGrace(t *testing.T) {
+	// 2024-03-10 18:32 UTC is 2024-03-11 00:02 in Kolkata
+	_, closer := beforeEach(t, time.Date(2024, time.March, 10, 18, 32, 0, 0, time.UTC))
+
+	assert.Equal(t, date(2024, time.March, 9), closer.LastDue())
+}
+
+// When no day was ever closed, only the last due day should be closed.
+func TestCloseDueFirstRun(t *testing.T) {
+	store, closer := beforeEach(t, time.Date(2024, time.March, 10, 20, 0, 0, 0, time.UTC))
+
+	store.EXPECT().
+		GetLastClosedBusinessDay(gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrNoRows)
+	store.EXPECT().
+		CloseBusinessDay(gomock.Any(), gomock.Eq(date(2024, time.March, 10)), gomock.Eq(closer.location)).
+		Times(1).
+		Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 10)}, nil)
+
+	closed, err := closer.CloseDue(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, closed, 1)
+}
+
+// Days missed while the job was down should be closed in order.
+func TestCloseDueCatchesUp(t *testing.T) {
+	store, closer := beforeEach(t, time.Date(2024, time.March, 10, 20, 0, 0, 0, time.UTC))
+
+	store.EXPECT().
+		GetLastClosedBusinessDay(gomock.Any()).
+		Times(1).
+		Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 7)}, nil)
+	gomock.InOrder(
+		store.EXPECT().CloseBusinessDay(gomock.Any(), gomock.Eq(date(2024, time.March, 8)), gomock.Any()).Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 8)}, nil),
+		store.EXPECT().CloseBusinessDay(gomock.Any(), gomock.Eq(date(2024, time.March, 9)), gomock.Any()).Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 9)}, nil),
+		store.EXPECT().CloseBusinessDay(gomock.Any(), gomock.Eq(date(2024, time.March, 10)), gomock.Any()).Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 10)}, nil),
+	)
+
+	closed, err := closer.CloseDue(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, closed, 3)
+}
+
+// When the last due day is already closed, nothing should be closed again.
+func TestCloseDueNothingToDo(t *testing.T) {
+	store, closer := beforeEach(t, time.Date(2024, time.March, 10, 20, 0, 0, 0, time.UTC))
+
+	store.EXPECT().
+		GetLastClosedBusinessDay(gomock.Any()).
+		Times(1).
+		Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 10)}, nil)
+
+	closed, err := closer.CloseDue(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, closed)
+}
+
+// A failure stops the catch up and returns the days closed so far.
+func TestCloseDueStopsOnError(t *testing.T) {
+	store, closer := beforeEach(t, time.Date(2024, time.March, 10, 20, 0, 0, 0, time.UTC))
+
+	store.EXPECT().
+		GetLastClosedBusinessDay(gomock.Any()).
+		Times(1).
+		Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 8)}, nil)
+	gomock.InOrder(
+		store.EXPECT().CloseBusinessDay(gomock.Any(), gomock.Eq(date(2024, time.March, 9)), gomock.Any()).Return(&db.BusinessDay{BusinessDate: date(2024, time.March, 9)}, nil),
+		store.EXPECT().CloseBusinessDay(gomock.Any(), gomock.Eq(date(2024, time.March, 10)), gomock.Any()).Return(nil, sql.ErrConnDone),
+	)
+
+	closed, err := closer.CloseDue(context.Background())
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Len(t, closed, 1)
+}
@@ -0,0 +1,100 @@
+// Package logging builds the service's structured logger: JSON (or text) lines
+// through log/slog, with a level that can change while the service runs and
+// secrets redacted before anything is written.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Logger is a slog.Logger whose level can be changed at runtime through Level.
+type Logger struct {
+	*slog.Logger
+	Level *slog.LevelVar
+}
+
+// New creates a logger writing to w in format, logging level and above.
+func New(w io.Writer, format string, level slog.Level) *Logger {
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+
+	options := &slog.HandlerOptions{Level: levelVar, ReplaceAttr: redact}
+	var handler slog.Handler = slog.NewJSONHandler(w, options)
+	if format == FormatText {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	return &Logger{Logger: slog.New(handler), Level: levelVar}
+}
+
+// ParseLevel parses debug, info, warn or error, optionally with an offset like warn+2.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(name))
+	return level, err
+}
+
+const redacted = "[REDACTED]"
+
+// keys whose values are never written, matched anywhere in the lower cased key
+var secretKeys = []string{"password", "secret", "token", "authorization", "api_key", "apikey", "signature", "cookie"}
+
+// keys holding account numbers, written with only their last 4 digits
+var accountNumberKeys = []string{"account_number", "iban", "card_number"}
+
+// digit runs as long as account and card numbers, wherever they show up in a value
+var accountNumber = regexp.MustCompile(`\d{12,}`)
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	for _, number := range accountNumberKeys {
+		if strings.Contains(key, number) {
+			return slog.String(attr.Key, mask(attr.Value.String()))
+		}
+	}
+
+	if attr.Value.Kind() == slog.KindString {
+		if value := attr.Value.String(); accountNumber.MatchString(value) {
+			return slog.String(attr.Key, accountNumber.ReplaceAllStringFunc(value, mask))
+		}
+	}
+	return attr
+}
+
+// all but the last 4 characters starred out
+func mask(number string) string {
+	if len(number) <= 4 {
+		return strings.Repeat("*", len(number))
+	}
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying logger, see FromContext.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, e.g. one with the request's id, or fallback.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Secrets should never be written and account numbers only by their last digits.
+func TestRedact(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, FormatJSON, slog.LevelInfo)
+
+	logger.Info("signed in",
+		"password", "hunter2",
+		"signing_secret", "gbs_0123",
+		slog.Group("headers", "Authorization", "Bearer abc"),
+		"account_number", "DE89370400440532013000",
+		"path", "/payees/123456789012345/verify",
+		"account_id", 42,
+	)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, redacted, line["password"])
+	assert.Equal(t, redacted, line["signing_secret"])
+	assert.Equal(t, map[string]any{"Authorization": redacted}, line["headers"])
+	assert.Equal(t, "******************3000", line["account_number"])
+	assert.Equal(t, "/payees/***********2345/verify", line["path"])
+	assert.Equal(t, 42.0, line["account_id"])
+}
+
+// The level should be changeable while the logger is in use.
+func TestLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, FormatText, slog.LevelInfo)
+
+	logger.Debug("hidden")
+	assert.Empty(t, out.String())
+
+	level, err := ParseLevel("debug")
+	require.NoError(t, err)
+	logger.Level.Set(level)
+	logger.Debug("shown")
+	assert.Contains(t, out.String(), "msg=shown")
+
+	_, err = ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	fallback := slog.Default()
+	assert.Same(t, fallback, FromContext(context.Background(), fallback))
+
+	logger := slog.Default().With("request_id", "abc")
+	assert.Same(t, logger, FromContext(WithContext(context.Background(), logger), fallback))
+}
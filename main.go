@@ -1,12 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/joelpatel/go-bank/api"
+	"github.com/joelpatel/go-bank/approval"
+	"github.com/joelpatel/go-bank/config"
 	"github.com/joelpatel/go-bank/db"
-	"github.com/joho/godotenv"
+	"github.com/joelpatel/go-bank/eod"
+	"github.com/joelpatel/go-bank/logging"
+	"github.com/joelpatel/go-bank/metrics"
+	"github.com/joelpatel/go-bank/outbox"
+	"github.com/joelpatel/go-bank/risk"
+	schema "github.com/joelpatel/go-bank/sql"
+	"github.com/joelpatel/go-bank/tracing"
+	"github.com/joelpatel/go-bank/webhook"
 )
 
 var (
@@ -15,18 +31,166 @@ var (
 )
 
 func main() {
-	err := godotenv.Load()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		runRole(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		runToken(os.Args[2:])
+		return
+	}
+
+	cfg := loadConfig(os.Args[1:])
+
+	// validated with the config
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logger := logging.New(os.Stdout, cfg.Log.Format, level)
+	// the log package writes through it too, so workers' lines are structured as well
+	slog.SetDefault(logger.Logger)
+
+	businessLocation, err := cfg.Business.Location()
 	if err != nil {
-		log.Fatal("error loading .env file")
+		fatal("loading business timezone", err)
 	}
 
-	serverAddress := os.Getenv("SERVER_ADDRESS")
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("setting up tracing", err)
+	}
+
+	conn, err := db.OpenDB(cfg.Database)
+	if err != nil {
+		fatal("opening database", err)
+	}
 
-	store = db.InitializeDBStore()
-	server = api.NewServer(store)
+	migrator, err := db.NewMigrator(conn, schema.Migrations)
+	if err != nil {
+		fatal("loading migrations", err)
+	}
+
+	// replicas starting together wait for the first one to migrate, then find nothing left to do
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			fatal("migrating", err)
+		}
+		for _, migration := range applied {
+			logger.Info("applied migration", "version", migration.Version, "name", migration.Name)
+		}
+	}
+
+	if err := migrator.Check(context.Background()); err != nil {
+		fatal("checking schema version", err)
+	}
+
+	store = db.NewStore(conn, logger.Logger)
+	server = api.NewServer(store, cfg, logger)
+
+	// the server measures its own store; the workers' use of it is measured alongside
+	server.Metrics().CollectDBStats(conn.DB)
+	store = metrics.NewStore(store, server.Metrics())
+
+	var rulesWatcher *risk.Watcher
+	if cfg.Risk.RulesFile != "" {
+		rulesWatcher = risk.NewWatcher(server.Risk(), cfg.Risk.RulesFile, cfg.Risk.ReloadInterval, logger.Logger)
+		if _, err := rulesWatcher.Reload(); err != nil {
+			fatal("loading risk rules", err)
+		}
+	}
+
+	// background workers outlive the HTTP server so in-flight requests can still emit events
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
 
-	err = server.StartServer(serverAddress)
+	runWorker(eod.NewCloser(store, businessLocation, logger.Logger).Run)
+	runWorker(approval.NewExpirer(store, logger.Logger).Run)
+
+	// webhooks are always fanned out; stdout/file publishing is opt-in for local use
+	publishers := []outbox.Publisher{webhook.NewDispatcher(store)}
+	switch cfg.Outbox.Publisher {
+	case "stdout":
+		publishers = append(publishers, outbox.NewStdoutPublisher())
+	case "file":
+		publisher, file, err := outbox.NewFilePublisher(cfg.Outbox.File)
+		if err != nil {
+			fatal("opening outbox file", err)
+		}
+		defer file.Close()
+		publishers = append(publishers, publisher)
+	}
+
+	runWorker(outbox.NewRelay(store, outbox.NewFanoutPublisher(publishers...), logger.Logger).Run)
+	runWorker(webhook.NewWorker(store, nil, logger.Logger).Run)
+	runWorker(server.Broker().Run)
+	if rulesWatcher != nil {
+		runWorker(rulesWatcher.Run)
+	}
+	if limiter := server.RateLimiter(); limiter != nil {
+		runWorker(limiter.Run)
+	}
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.StartServer()
+	}()
+
+	select {
+	case err = <-serverErr:
+		if err != nil {
+			fatal("serving HTTP", err)
+		}
+	case <-signalCtx.Done():
+		logger.Info("shutting down: draining connections")
+	}
+
+	// drain HTTP first so in-flight transfers complete, then stop workers, then close the pool
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("shutting down", "error", err.Error())
+	}
+
+	stopWorkers()
+	workers.Wait()
+
+	if err := store.Close(); err != nil {
+		logger.Error("closing database", "error", err.Error())
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("flushing spans", "error", err.Error())
+	}
+}
+
+// log what failed and exit, like log.Fatal but structured
+func fatal(doing string, err error) {
+	slog.Error(doing, "error", err.Error())
+	os.Exit(1)
+}
+
+// settings come from defaults, an optional file, env (.env included) and flags
+func loadConfig(args []string) config.Config {
+	cfg, err := config.Load(config.Options{Args: args, DotEnv: ".env"})
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatal(err.Error())
+		log.Fatalf("invalid configuration:\n%s", err.Error())
 	}
+	return cfg
 }
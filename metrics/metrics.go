@@ -0,0 +1,98 @@
+// Package metrics exposes what the service does in the Prometheus format:
+// HTTP requests per route, Store operations, the database pool and business counters.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bank"
+
+// transfer rejection reasons
+const (
+	RejectedInsufficientFunds = "insufficient_funds"
+	RejectedLimitExceeded     = "limit_exceeded"
+	RejectedRiskDenied        = "risk_denied"
+	RejectedAccountFrozen     = "account_frozen"
+)
+
+// Metrics holds the collectors of one process in a registry of its own,
+// so servers created side by side, as in tests, do not collide.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HTTPRequestDuration *prometheus.HistogramVec // method, route, status
+	StoreDuration       *prometheus.HistogramVec // method
+	StoreErrors         *prometheus.CounterVec   // method
+	Transfers           *prometheus.CounterVec   // currency
+	TransferAmount      *prometheus.CounterVec   // currency, in cents
+	TransfersRejected   *prometheus.CounterVec   // reason
+}
+
+// New creates the collectors, along with those of the Go runtime and the process.
+func New() *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		StoreDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Duration of Store methods.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+		StoreErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "store_operation_errors_total",
+			Help:      "Store methods that failed, rows not found are not failures.",
+		}, []string{"method"}),
+		Transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_total",
+			Help:      "Transfers made by currency.",
+		}, []string{"currency"}),
+		TransferAmount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfer_amount_cents_total",
+			Help:      "Amount transferred by currency, in cents.",
+		}, []string{"currency"}),
+		TransfersRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_rejected_total",
+			Help:      "Transfers refused before being made, by reason.",
+		}, []string{"reason"}),
+	}
+
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.HTTPRequestDuration,
+		metrics.StoreDuration,
+		metrics.StoreErrors,
+		metrics.Transfers,
+		metrics.TransferAmount,
+		metrics.TransfersRejected,
+	)
+
+	return metrics
+}
+
+// CollectDBStats exposes the statistics of conn's connection pool.
+func (metrics *Metrics) CollectDBStats(conn *sql.DB) {
+	metrics.registry.MustRegister(collectors.NewDBStatsCollector(conn, namespace))
+}
+
+// Handler serves the collected metrics.
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{Registry: metrics.registry})
+}
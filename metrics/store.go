@@ -0,0 +1,447 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// Store decorates a db.Store, timing every method and counting its failures.
+// Transfers it makes are counted by currency.
+type Store struct {
+	store   db.Store
+	metrics *Metrics
+}
+
+var _ db.Store = (*Store)(nil)
+
+// NewStore wraps store, recording into metrics.
+func NewStore(store db.Store, metrics *Metrics) *Store {
+	return &Store{store: store, metrics: metrics}
+}
+
+// record how long method took since start and whether it failed
+func (s *Store) observe(method string, start time.Time, err *error) {
+	s.metrics.StoreDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil && !errors.Is(*err, sql.ErrNoRows) {
+		s.metrics.StoreErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func (s *Store) TransferMoney(ctx context.Context, from_account_id, to_account_id, amount int64, details db.Details) (result *db.TransferTxResult, err error) {
+	defer s.observe("TransferMoney", time.Now(), &err)
+
+	result, err = s.store.TransferMoney(ctx, from_account_id, to_account_id, amount, details)
+	if err == nil {
+		s.metrics.Transfers.WithLabelValues(result.FromAccount.Currency).Inc()
+		s.metrics.TransferAmount.WithLabelValues(result.FromAccount.Currency).Add(float64(amount))
+	}
+	return result, err
+}
+
+// blocks until ctx is done, only its failures are worth recording
+func (s *Store) ListenOutboxEvents(ctx context.Context, handle func(ctx context.Context, event db.OutboxEvent) error) error {
+	err := s.store.ListenOutboxEvents(ctx, handle)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.metrics.StoreErrors.WithLabelValues("ListenOutboxEvents").Inc()
+	}
+	return err
+}
+
+func (s *Store) Close() error {
+	return s.store.Close()
+}
+
+func (s *Store) CreateAccount(ctx context.Context, owner string, balance int64, currency string) (result *db.Account, err error) {
+	defer s.observe("CreateAccount", time.Now(), &err)
+	return s.store.CreateAccount(ctx, owner, balance, currency)
+}
+
+func (s *Store) GetAccountByID(ctx context.Context, id int64) (result *db.Account, err error) {
+	defer s.observe("GetAccountByID", time.Now(), &err)
+	return s.store.GetAccountByID(ctx, id)
+}
+
+func (s *Store) GetAccountByIDForUpdate(ctx context.Context, id int64) (result *db.Account, err error) {
+	defer s.observe("GetAccountByIDForUpdate", time.Now(), &err)
+	return s.store.GetAccountByIDForUpdate(ctx, id)
+}
+
+func (s *Store) GetAccountsByOwner(ctx context.Context, owner string) (result *[]db.Account, err error) {
+	defer s.observe("GetAccountsByOwner", time.Now(), &err)
+	return s.store.GetAccountsByOwner(ctx, owner)
+}
+
+func (s *Store) ListAccounts(ctx context.Context, owner string, page db.Page) (result *[]db.Account, err error) {
+	defer s.observe("ListAccounts", time.Now(), &err)
+	return s.store.ListAccounts(ctx, owner, page)
+}
+
+func (s *Store) UpdateAccount(ctx context.Context, account *db.Account) (result int64, err error) {
+	defer s.observe("UpdateAccount", time.Now(), &err)
+	return s.store.UpdateAccount(ctx, account)
+}
+
+func (s *Store) UpdateAccountOwner(ctx context.Context, id int64, newOwner string) (result int64, err error) {
+	defer s.observe("UpdateAccountOwner", time.Now(), &err)
+	return s.store.UpdateAccountOwner(ctx, id, newOwner)
+}
+
+func (s *Store) UpdateAccountBalance(ctx context.Context, id int64, balance int64) (result int64, err error) {
+	defer s.observe("UpdateAccountBalance", time.Now(), &err)
+	return s.store.UpdateAccountBalance(ctx, id, balance)
+}
+
+func (s *Store) AddAccountBalance(ctx context.Context, id int64, amount int64) (result *db.Account, err error) {
+	defer s.observe("AddAccountBalance", time.Now(), &err)
+	return s.store.AddAccountBalance(ctx, id, amount)
+}
+
+func (s *Store) DeleteAccountByID(ctx context.Context, id int64) (result int64, err error) {
+	defer s.observe("DeleteAccountByID", time.Now(), &err)
+	return s.store.DeleteAccountByID(ctx, id)
+}
+
+func (s *Store) SetAccountFrozen(ctx context.Context, id int64, frozen bool) (result int64, err error) {
+	defer s.observe("SetAccountFrozen", time.Now(), &err)
+	return s.store.SetAccountFrozen(ctx, id, frozen)
+}
+
+func (s *Store) AdjustAccountBalance(ctx context.Context, accountID, amount int64, reason, actor string) (result *db.BalanceAdjustment, err error) {
+	defer s.observe("AdjustAccountBalance", time.Now(), &err)
+	return s.store.AdjustAccountBalance(ctx, accountID, amount, reason, actor)
+}
+
+func (s *Store) CreateEntry(ctx context.Context, accountID, amount int64, details db.Details) (result *db.Entry, err error) {
+	defer s.observe("CreateEntry", time.Now(), &err)
+	return s.store.CreateEntry(ctx, accountID, amount, details)
+}
+
+func (s *Store) GetEntryByID(ctx context.Context, id int64) (result *db.Entry, err error) {
+	defer s.observe("GetEntryByID", time.Now(), &err)
+	return s.store.GetEntryByID(ctx, id)
+}
+
+func (s *Store) GetEntriesByAccountID(ctx context.Context, account_id int64, page db.Page) (result *[]db.Entry, err error) {
+	defer s.observe("GetEntriesByAccountID", time.Now(), &err)
+	return s.store.GetEntriesByAccountID(ctx, account_id, page)
+}
+
+func (s *Store) CreateTransfer(ctx context.Context, from_account_id, to_account_id, amount int64, details db.Details) (result *db.Transfer, err error) {
+	defer s.observe("CreateTransfer", time.Now(), &err)
+	return s.store.CreateTransfer(ctx, from_account_id, to_account_id, amount, details)
+}
+
+func (s *Store) GetTransferByID(ctx context.Context, id int64) (result *db.Transfer, err error) {
+	defer s.observe("GetTransferByID", time.Now(), &err)
+	return s.store.GetTransferByID(ctx, id)
+}
+
+func (s *Store) GetTransferByExternalReference(ctx context.Context, from_account_id int64, external_reference string) (result *db.Transfer, err error) {
+	defer s.observe("GetTransferByExternalReference", time.Now(), &err)
+	return s.store.GetTransferByExternalReference(ctx, from_account_id, external_reference)
+}
+
+func (s *Store) GetTransfersFromTo(ctx context.Context, from_account_id, to_account_id int64, page db.Page) (result *[]db.Transfer, err error) {
+	defer s.observe("GetTransfersFromTo", time.Now(), &err)
+	return s.store.GetTransfersFromTo(ctx, from_account_id, to_account_id, page)
+}
+
+func (s *Store) SearchTransactions(ctx context.Context, filter db.TransactionFilter, page db.Page) (result *[]db.Transaction, err error) {
+	defer s.observe("SearchTransactions", time.Now(), &err)
+	return s.store.SearchTransactions(ctx, filter, page)
+}
+
+func (s *Store) CloseBusinessDay(ctx context.Context, businessDate time.Time, location *time.Location) (result *db.BusinessDay, err error) {
+	defer s.observe("CloseBusinessDay", time.Now(), &err)
+	return s.store.CloseBusinessDay(ctx, businessDate, location)
+}
+
+func (s *Store) GetBusinessDay(ctx context.Context, businessDate time.Time) (result *db.BusinessDay, err error) {
+	defer s.observe("GetBusinessDay", time.Now(), &err)
+	return s.store.GetBusinessDay(ctx, businessDate)
+}
+
+func (s *Store) GetLastClosedBusinessDay(ctx context.Context) (result *db.BusinessDay, err error) {
+	defer s.observe("GetLastClosedBusinessDay", time.Now(), &err)
+	return s.store.GetLastClosedBusinessDay(ctx)
+}
+
+func (s *Store) GetBalanceSnapshot(ctx context.Context, accountID int64, businessDate time.Time) (result *db.BalanceSnapshot, err error) {
+	defer s.observe("GetBalanceSnapshot", time.Now(), &err)
+	return s.store.GetBalanceSnapshot(ctx, accountID, businessDate)
+}
+
+func (s *Store) GetBalanceSnapshotAsOf(ctx context.Context, accountID int64, businessDate time.Time) (result *db.BalanceSnapshot, err error) {
+	defer s.observe("GetBalanceSnapshotAsOf", time.Now(), &err)
+	return s.store.GetBalanceSnapshotAsOf(ctx, accountID, businessDate)
+}
+
+func (s *Store) GetCurrencyTotals(ctx context.Context, businessDate time.Time) (result *[]db.CurrencyTotal, err error) {
+	defer s.observe("GetCurrencyTotals", time.Now(), &err)
+	return s.store.GetCurrencyTotals(ctx, businessDate)
+}
+
+func (s *Store) GetOutboxEventsByAggregate(ctx context.Context, aggregateType string, aggregateID int64) (result *[]db.OutboxEvent, err error) {
+	defer s.observe("GetOutboxEventsByAggregate", time.Now(), &err)
+	return s.store.GetOutboxEventsByAggregate(ctx, aggregateType, aggregateID)
+}
+
+func (s *Store) GetOutboxEventByID(ctx context.Context, id int64) (result *db.OutboxEvent, err error) {
+	defer s.observe("GetOutboxEventByID", time.Now(), &err)
+	return s.store.GetOutboxEventByID(ctx, id)
+}
+
+func (s *Store) PublishOutboxEvents(ctx context.Context, limit int64, publish func(ctx context.Context, event db.OutboxEvent) error) (published int64, err error) {
+	defer s.observe("PublishOutboxEvents", time.Now(), &err)
+	return s.store.PublishOutboxEvents(ctx, limit, publish)
+}
+
+func (s *Store) GetOutboxEventsAfter(ctx context.Context, afterID, limit int64) (result *[]db.OutboxEvent, err error) {
+	defer s.observe("GetOutboxEventsAfter", time.Now(), &err)
+	return s.store.GetOutboxEventsAfter(ctx, afterID, limit)
+}
+
+func (s *Store) GetLastOutboxEventID(ctx context.Context) (id int64, err error) {
+	defer s.observe("GetLastOutboxEventID", time.Now(), &err)
+	return s.store.GetLastOutboxEventID(ctx)
+}
+
+func (s *Store) GetSchemaVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	defer s.observe("GetSchemaVersion", time.Now(), &err)
+	return s.store.GetSchemaVersion(ctx)
+}
+
+func (s *Store) Ping(ctx context.Context) (err error) {
+	defer s.observe("Ping", time.Now(), &err)
+	return s.store.Ping(ctx)
+}
+
+func (s *Store) CreateWebhookSubscription(ctx context.Context, owner, url string, eventTypes []string, secret string) (result *db.WebhookSubscription, err error) {
+	defer s.observe("CreateWebhookSubscription", time.Now(), &err)
+	return s.store.CreateWebhookSubscription(ctx, owner, url, eventTypes, secret)
+}
+
+func (s *Store) GetWebhookSubscriptionByID(ctx context.Context, id int64) (result *db.WebhookSubscription, err error) {
+	defer s.observe("GetWebhookSubscriptionByID", time.Now(), &err)
+	return s.store.GetWebhookSubscriptionByID(ctx, id)
+}
+
+func (s *Store) GetWebhookSubscriptionsByOwner(ctx context.Context, owner string) (result *[]db.WebhookSubscription, err error) {
+	defer s.observe("GetWebhookSubscriptionsByOwner", time.Now(), &err)
+	return s.store.GetWebhookSubscriptionsByOwner(ctx, owner)
+}
+
+func (s *Store) GetWebhookSubscriptionsForEvent(ctx context.Context, owner, eventType string) (result *[]db.WebhookSubscription, err error) {
+	defer s.observe("GetWebhookSubscriptionsForEvent", time.Now(), &err)
+	return s.store.GetWebhookSubscriptionsForEvent(ctx, owner, eventType)
+}
+
+func (s *Store) DeleteWebhookSubscriptionByID(ctx context.Context, id int64) (result int64, err error) {
+	defer s.observe("DeleteWebhookSubscriptionByID", time.Now(), &err)
+	return s.store.DeleteWebhookSubscriptionByID(ctx, id)
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, subscriptionID, eventID int64, eventType string, payload json.RawMessage) (result int64, err error) {
+	defer s.observe("CreateWebhookDelivery", time.Now(), &err)
+	return s.store.CreateWebhookDelivery(ctx, subscriptionID, eventID, eventType, payload)
+}
+
+func (s *Store) GetWebhookDeliveryByID(ctx context.Context, id int64) (result *db.WebhookDelivery, err error) {
+	defer s.observe("GetWebhookDeliveryByID", time.Now(), &err)
+	return s.store.GetWebhookDeliveryByID(ctx, id)
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, page db.Page) (result *[]db.WebhookDelivery, err error) {
+	defer s.observe("ListWebhookDeliveries", time.Now(), &err)
+	return s.store.ListWebhookDeliveries(ctx, subscriptionID, page)
+}
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, limit int64, lease time.Duration) (result *[]db.WebhookDelivery, err error) {
+	defer s.observe("ClaimDueWebhookDeliveries", time.Now(), &err)
+	return s.store.ClaimDueWebhookDeliveries(ctx, limit, lease)
+}
+
+func (s *Store) MarkWebhookDeliverySucceeded(ctx context.Context, id, statusCode int64) (result int64, err error) {
+	defer s.observe("MarkWebhookDeliverySucceeded", time.Now(), &err)
+	return s.store.MarkWebhookDeliverySucceeded(ctx, id, statusCode)
+}
+
+func (s *Store) MarkWebhookDeliveryFailed(ctx context.Context, id, statusCode int64, lastError string, nextAttemptAt time.Time, maxAttempts int64) (result int64, err error) {
+	defer s.observe("MarkWebhookDeliveryFailed", time.Now(), &err)
+	return s.store.MarkWebhookDeliveryFailed(ctx, id, statusCode, lastError, nextAttemptAt, maxAttempts)
+}
+
+func (s *Store) ReplayWebhookDelivery(ctx context.Context, id int64) (result *db.WebhookDelivery, err error) {
+	defer s.observe("ReplayWebhookDelivery", time.Now(), &err)
+	return s.store.ReplayWebhookDelivery(ctx, id)
+}
+
+func (s *Store) CreatePayee(ctx context.Context, owner string, accountID int64, nickname string) (result *db.Payee, err error) {
+	defer s.observe("CreatePayee", time.Now(), &err)
+	return s.store.CreatePayee(ctx, owner, accountID, nickname)
+}
+
+func (s *Store) GetPayeeByID(ctx context.Context, id int64) (result *db.Payee, err error) {
+	defer s.observe("GetPayeeByID", time.Now(), &err)
+	return s.store.GetPayeeByID(ctx, id)
+}
+
+func (s *Store) GetPayeesByOwner(ctx context.Context, owner string) (result *[]db.Payee, err error) {
+	defer s.observe("GetPayeesByOwner", time.Now(), &err)
+	return s.store.GetPayeesByOwner(ctx, owner)
+}
+
+func (s *Store) UpdatePayeeNickname(ctx context.Context, id int64, nickname string) (result int64, err error) {
+	defer s.observe("UpdatePayeeNickname", time.Now(), &err)
+	return s.store.UpdatePayeeNickname(ctx, id, nickname)
+}
+
+func (s *Store) DeletePayeeByID(ctx context.Context, id int64) (result int64, err error) {
+	defer s.observe("DeletePayeeByID", time.Now(), &err)
+	return s.store.DeletePayeeByID(ctx, id)
+}
+
+func (s *Store) SetTransferLimit(ctx context.Context, limit db.TransferLimit) (result *db.TransferLimit, err error) {
+	defer s.observe("SetTransferLimit", time.Now(), &err)
+	return s.store.SetTransferLimit(ctx, limit)
+}
+
+func (s *Store) GetTransferLimitByID(ctx context.Context, id int64) (result *db.TransferLimit, err error) {
+	defer s.observe("GetTransferLimitByID", time.Now(), &err)
+	return s.store.GetTransferLimitByID(ctx, id)
+}
+
+func (s *Store) GetTransferLimits(ctx context.Context) (result *[]db.TransferLimit, err error) {
+	defer s.observe("GetTransferLimits", time.Now(), &err)
+	return s.store.GetTransferLimits(ctx)
+}
+
+func (s *Store) DeleteTransferLimitByID(ctx context.Context, id int64) (result int64, err error) {
+	defer s.observe("DeleteTransferLimitByID", time.Now(), &err)
+	return s.store.DeleteTransferLimitByID(ctx, id)
+}
+
+func (s *Store) CreateRiskAssessment(ctx context.Context, assessment db.RiskAssessment) (result *db.RiskAssessment, err error) {
+	defer s.observe("CreateRiskAssessment", time.Now(), &err)
+	return s.store.CreateRiskAssessment(ctx, assessment)
+}
+
+func (s *Store) GetRiskAssessmentByID(ctx context.Context, id int64) (result *db.RiskAssessment, err error) {
+	defer s.observe("GetRiskAssessmentByID", time.Now(), &err)
+	return s.store.GetRiskAssessmentByID(ctx, id)
+}
+
+func (s *Store) GetRiskAssessmentByTransferID(ctx context.Context, transferID int64) (result *db.RiskAssessment, err error) {
+	defer s.observe("GetRiskAssessmentByTransferID", time.Now(), &err)
+	return s.store.GetRiskAssessmentByTransferID(ctx, transferID)
+}
+
+func (s *Store) CreateTransferRequest(ctx context.Context, request db.TransferRequest) (result *db.TransferRequest, err error) {
+	defer s.observe("CreateTransferRequest", time.Now(), &err)
+	return s.store.CreateTransferRequest(ctx, request)
+}
+
+func (s *Store) GetTransferRequestByID(ctx context.Context, id int64) (result *db.TransferRequest, err error) {
+	defer s.observe("GetTransferRequestByID", time.Now(), &err)
+	return s.store.GetTransferRequestByID(ctx, id)
+}
+
+func (s *Store) ListTransferRequests(ctx context.Context, status string, page db.Page) (result *[]db.TransferRequest, err error) {
+	defer s.observe("ListTransferRequests", time.Now(), &err)
+	return s.store.ListTransferRequests(ctx, status, page)
+}
+
+func (s *Store) GetTransferRequestEvents(ctx context.Context, requestID int64) (result *[]db.TransferRequestEvent, err error) {
+	defer s.observe("GetTransferRequestEvents", time.Now(), &err)
+	return s.store.GetTransferRequestEvents(ctx, requestID)
+}
+
+func (s *Store) DecideTransferRequest(ctx context.Context, id int64, reviewer, status, note string) (result *db.TransferRequest, err error) {
+	defer s.observe("DecideTransferRequest", time.Now(), &err)
+	return s.store.DecideTransferRequest(ctx, id, reviewer, status, note)
+}
+
+func (s *Store) FinishTransferRequest(ctx context.Context, id int64, transferID *int64, failure string) (result *db.TransferRequest, err error) {
+	defer s.observe("FinishTransferRequest", time.Now(), &err)
+	return s.store.FinishTransferRequest(ctx, id, transferID, failure)
+}
+
+func (s *Store) ApproveTransferRequest(ctx context.Context, id int64, reviewer, note string) (result *db.TransferRequest, err error) {
+	defer s.observe("ApproveTransferRequest", time.Now(), &err)
+	return s.store.ApproveTransferRequest(ctx, id, reviewer, note)
+}
+
+func (s *Store) ExpireTransferRequests(ctx context.Context) (result int64, err error) {
+	defer s.observe("ExpireTransferRequests", time.Now(), &err)
+	return s.store.ExpireTransferRequests(ctx)
+}
+
+func (s *Store) SetUserRole(ctx context.Context, username, role string) (result *db.User, err error) {
+	defer s.observe("SetUserRole", time.Now(), &err)
+	return s.store.SetUserRole(ctx, username, role)
+}
+
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (result *db.User, err error) {
+	defer s.observe("GetUserByUsername", time.Now(), &err)
+	return s.store.GetUserByUsername(ctx, username)
+}
+
+func (s *Store) GetUsers(ctx context.Context) (result *[]db.User, err error) {
+	defer s.observe("GetUsers", time.Now(), &err)
+	return s.store.GetUsers(ctx)
+}
+
+func (s *Store) CreateAPIKey(ctx context.Context, key db.APIKey) (result *db.APIKey, err error) {
+	defer s.observe("CreateAPIKey", time.Now(), &err)
+	return s.store.CreateAPIKey(ctx, key)
+}
+
+func (s *Store) GetAPIKeyByID(ctx context.Context, id int64) (result *db.APIKey, err error) {
+	defer s.observe("GetAPIKeyByID", time.Now(), &err)
+	return s.store.GetAPIKeyByID(ctx, id)
+}
+
+func (s *Store) GetAPIKeyByPrefix(ctx context.Context, prefix string) (result *db.APIKey, err error) {
+	defer s.observe("GetAPIKeyByPrefix", time.Now(), &err)
+	return s.store.GetAPIKeyByPrefix(ctx, prefix)
+}
+
+func (s *Store) GetAPIKeys(ctx context.Context) (result *[]db.APIKey, err error) {
+	defer s.observe("GetAPIKeys", time.Now(), &err)
+	return s.store.GetAPIKeys(ctx)
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, id int64) (result int64, err error) {
+	defer s.observe("RevokeAPIKey", time.Now(), &err)
+	return s.store.RevokeAPIKey(ctx, id)
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) (err error) {
+	defer s.observe("TouchAPIKey", time.Now(), &err)
+	return s.store.TouchAPIKey(ctx, id, usedAt)
+}
+
+func (s *Store) RotateAPIKey(ctx context.Context, id int64, replacement db.APIKey, oldExpiresAt time.Time) (result *db.APIKey, err error) {
+	defer s.observe("RotateAPIKey", time.Now(), &err)
+	return s.store.RotateAPIKey(ctx, id, replacement, oldExpiresAt)
+}
+
+func (s *Store) UseAPIKeyNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) (err error) {
+	defer s.observe("UseAPIKeyNonce", time.Now(), &err)
+	return s.store.UseAPIKeyNonce(ctx, keyID, nonce, expiresAt)
+}
+
+func (s *Store) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int64) (tokens float64, allowed bool, err error) {
+	defer s.observe("TakeRateLimitToken", time.Now(), &err)
+	return s.store.TakeRateLimitToken(ctx, key, rate, burst)
+}
+
+func (s *Store) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (result int64, err error) {
+	defer s.observe("DeleteIdleRateLimitBuckets", time.Now(), &err)
+	return s.store.DeleteIdleRateLimitBuckets(ctx, idle)
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Every call should be timed, failures counted apart from missing rows, and transfers counted by currency.
+func TestStore(t *testing.T) {
+	metrics := New()
+	store := NewStore(memdb.NewStore(), metrics)
+	ctx := context.Background()
+
+	from, err := store.CreateAccount(ctx, utils.RandomOwner(), 1000, currency.USD)
+	require.NoError(t, err)
+	to, err := store.CreateAccount(ctx, utils.RandomOwner(), 0, currency.USD)
+	require.NoError(t, err)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.StoreDuration, "bank_store_operation_duration_seconds"))
+
+	_, err = store.GetAccountByID(ctx, to.ID+100)
+	require.Error(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.StoreErrors.WithLabelValues("GetAccountByID")))
+
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 300, db.Details{})
+	require.NoError(t, err)
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 200, db.Details{})
+	require.NoError(t, err)
+	_, err = store.TransferMoney(ctx, from.ID, to.ID, 5000, db.Details{})
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.StoreErrors.WithLabelValues("TransferMoney")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.Transfers.WithLabelValues(currency.USD)))
+	assert.Equal(t, 500.0, testutil.ToFloat64(metrics.TransferAmount.WithLabelValues(currency.USD)))
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics.StoreDuration))
+}
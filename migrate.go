@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joelpatel/go-bank/db"
+	schema "github.com/joelpatel/go-bank/sql"
+)
+
+const migrateUsage = `usage: go-bank migrate <command> [flags]
+
+commands:
+  up             apply every pending migration
+  down [n|all]   revert the last n migrations (1 by default)
+  status         list the migrations and whether they are applied
+  version        print the schema version
+
+flags are the same as the server's, see go-bank -h`
+
+// go-bank migrate <command>, migrations are embedded in the binary
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	steps := int64(1)
+	if command == "down" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "all" {
+			steps = math.MaxInt64
+		} else {
+			n, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || n < 1 {
+				log.Fatalf("%s is not a number of migrations", args[0])
+			}
+			steps = n
+		}
+		args = args[1:]
+	}
+
+	cfg := loadConfig(args)
+
+	conn, err := db.OpenDB(cfg.Database)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer conn.Close()
+
+	migrator, err := db.NewMigrator(conn, schema.Migrations)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		for _, migration := range status {
+			state := "pending"
+			if migration.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d %-8s %s\n", migration.Version, state, migration.Name)
+		}
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
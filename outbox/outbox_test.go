@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func randomEvent() db.OutboxEvent {
+	return db.OutboxEvent{
+		ID:            utils.RandomInt(1, 1000),
+		AggregateType: db.AggregateAccount,
+		AggregateID:   utils.RandomInt(1, 1000),
+		EventType:     db.EventAccountCreated,
+		Payload:       json.RawMessage(`{"owner":"` + utils.RandomOwner() + `"}`),
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+// The in-memory publisher should hand back every event in publish order.
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	first, second := randomEvent(), randomEvent()
+
+	assert.NoError(t, publisher.Publish(context.Background(), first))
+	assert.NoError(t, publisher.Publish(context.Background(), second))
+
+	assert.Equal(t, []db.OutboxEvent{first, second}, publisher.Events())
+}
+
+// The writer publisher should write one JSON document per line.
+func TestWriterPublisher(t *testing.T) {
+	var buffer bytes.Buffer
+	publisher := NewWriterPublisher(&buffer)
+	first, second := randomEvent(), randomEvent()
+
+	assert.NoError(t, publisher.Publish(context.Background(), first))
+	assert.NoError(t, publisher.Publish(context.Background(), second))
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+
+	var decoded db.OutboxEvent
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	assert.Equal(t, second, decoded)
+}
+
+// The file publisher should append to the file across publishers.
+func TestFilePublisherAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	for i := 0; i < 2; i++ {
+		publisher, file, err := NewFilePublisher(path)
+		assert.NoError(t, err)
+		assert.NoError(t, publisher.Publish(context.Background(), randomEvent()))
+		assert.NoError(t, file.Close())
+	}
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+}
+
+// The relay should hand the store its publisher and batch size.
+func TestRelayOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	publisher := NewMemoryPublisher()
+	event := randomEvent()
+
+	store.EXPECT().
+		PublishOutboxEvents(gomock.Any(), gomock.Eq(int64(defaultBatchSize)), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, limit int64, publish func(context.Context, db.OutboxEvent) error) (int64, error) {
+			return 1, publish(ctx, event)
+		})
+
+	published, err := NewRelay(store, publisher, slog.Default()).RelayOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), published)
+	assert.Equal(t, []db.OutboxEvent{event}, publisher.Events())
+}
+
+// Publish errors reported by the store should be returned by the relay.
+func TestRelayOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	publishErr := errors.New("broker unavailable")
+
+	store.EXPECT().
+		PublishOutboxEvents(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(int64(0), publishErr)
+
+	_, err := NewRelay(store, NewMemoryPublisher(), slog.Default()).RelayOnce(context.Background())
+	assert.ErrorIs(t, err, publishErr)
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	return errors.New("broker unavailable")
+}
+
+// The fanout publisher should forward to every publisher and fail when any of them fails.
+func TestFanoutPublisher(t *testing.T) {
+	first, second := NewMemoryPublisher(), NewMemoryPublisher()
+	event := randomEvent()
+
+	assert.NoError(t, NewFanoutPublisher(first, second).Publish(context.Background(), event))
+	assert.Equal(t, []db.OutboxEvent{event}, first.Events())
+	assert.Equal(t, []db.OutboxEvent{event}, second.Events())
+
+	assert.Error(t, NewFanoutPublisher(first, failingPublisher{}).Publish(context.Background(), event))
+}
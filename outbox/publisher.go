@@ -0,0 +1,109 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// Publisher hands an outbox event to downstream consumers.
+// Delivery is at-least-once: the same event may be published again after a failure.
+type Publisher interface {
+	Publish(ctx context.Context, event db.OutboxEvent) error
+}
+
+// MemoryPublisher keeps published events in memory, for tests and local use.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []db.OutboxEvent
+}
+
+// NewMemoryPublisher creates an empty in-memory publisher.
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish records the event.
+func (publisher *MemoryPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	publisher.events = append(publisher.events, event)
+	return nil
+}
+
+// Events returns a copy of every event published so far, in publish order.
+func (publisher *MemoryPublisher) Events() []db.OutboxEvent {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	events := make([]db.OutboxEvent, len(publisher.events))
+	copy(events, publisher.events)
+	return events
+}
+
+// WriterPublisher writes every event as one JSON line.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+// NewWriterPublisher creates a publisher writing JSON lines to writer.
+func NewWriterPublisher(writer io.Writer) *WriterPublisher {
+	return &WriterPublisher{writer: writer}
+}
+
+// NewStdoutPublisher creates a publisher writing JSON lines to stdout.
+func NewStdoutPublisher() *WriterPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+// NewFilePublisher creates a publisher appending JSON lines to the file at path.
+func NewFilePublisher(path string) (*WriterPublisher, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewWriterPublisher(file), file, nil
+}
+
+// Publish writes the event as a single JSON line.
+func (publisher *WriterPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	_, err = publisher.writer.Write(append(data, '\n'))
+	return err
+}
+
+// FanoutPublisher publishes every event to each of its publishers in turn.
+// A failing publisher fails the event, so all of them see it again on the next relay run.
+type FanoutPublisher struct {
+	publishers []Publisher
+}
+
+// NewFanoutPublisher creates a publisher forwarding to publishers.
+func NewFanoutPublisher(publishers ...Publisher) *FanoutPublisher {
+	return &FanoutPublisher{publishers: publishers}
+}
+
+// Publish forwards the event to every publisher, stopping at the first failure.
+func (publisher *FanoutPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	for _, p := range publisher.publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
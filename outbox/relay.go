@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+const (
+	defaultBatchSize = 100
+	defaultInterval  = time.Second
+)
+
+// Relay moves events from the outbox table to a Publisher.
+type Relay struct {
+	store     db.Store
+	publisher Publisher
+	batchSize int64
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+// NewRelay creates a relay publishing the store's outbox events through publisher.
+// Failed batches are logged to logger.
+func NewRelay(store db.Store, publisher Publisher, logger *slog.Logger) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		batchSize: defaultBatchSize,
+		interval:  defaultInterval,
+		logger:    logger,
+	}
+}
+
+// RelayOnce publishes one batch of unpublished events and returns how many were published.
+func (relay *Relay) RelayOnce(ctx context.Context) (int64, error) {
+	return relay.store.PublishOutboxEvents(ctx, relay.batchSize, relay.publisher.Publish)
+}
+
+// Run relays events every interval until ctx is done.
+// A full batch is followed by another one right away.
+func (relay *Relay) Run(ctx context.Context) {
+	for {
+		published, err := relay.RelayOnce(ctx)
+		if err != nil {
+			relay.logger.Error("relaying outbox events", "error", err.Error())
+		}
+
+		if published == relay.batchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relay.interval):
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// MemoryBackend keeps buckets in the process, every replica limits on its own.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// NewMemoryBackend creates an empty in-process backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{buckets: map[string]bucket{}, now: time.Now}
+}
+
+// Take implements Backend.
+func (backend *MemoryBackend) Take(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	now := backend.now()
+	tokens := float64(limit.Burst)
+	if stored, ok := backend.buckets[key]; ok {
+		tokens = min(tokens, stored.tokens+max(0, now.Sub(stored.updatedAt).Seconds())*limit.Rate)
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	backend.buckets[key] = bucket{tokens: tokens, updatedAt: now}
+
+	return tokens, allowed, nil
+}
+
+// DeleteIdle implements Backend.
+func (backend *MemoryBackend) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	var deleted int64
+	for key, stored := range backend.buckets {
+		if backend.now().Sub(stored.updatedAt) > idle {
+			delete(backend.buckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// StoreBackend keeps buckets in the store, so replicas sharing a database share their limits.
+type StoreBackend struct {
+	store db.Store
+}
+
+// NewStoreBackend creates a backend keeping buckets in store.
+func NewStoreBackend(store db.Store) *StoreBackend {
+	return &StoreBackend{store: store}
+}
+
+// Take implements Backend.
+func (backend *StoreBackend) Take(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	return backend.store.TakeRateLimitToken(ctx, key, limit.Rate, limit.Burst)
+}
+
+// DeleteIdle implements Backend.
+func (backend *StoreBackend) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	return backend.store.DeleteIdleRateLimitBuckets(ctx, idle)
+}
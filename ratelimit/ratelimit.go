@@ -0,0 +1,121 @@
+// Package ratelimit limits how often a caller may make requests with token buckets.
+// Every caller has a bucket per group of routes holding up to Burst tokens, refilled
+// at Rate tokens a second; a request takes one token and is refused when none is left.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const defaultInterval = time.Minute
+
+// Limit is the budget of one group of routes.
+type Limit struct {
+	Rate  float64 // tokens refilled per second
+	Burst int64   // tokens a bucket holds at most, what an idle caller may send at once
+}
+
+// PerMinute is a limit of perMinute requests a minute, burst of them at once.
+func PerMinute(perMinute, burst int) Limit {
+	return Limit{Rate: float64(perMinute) / 60, Burst: int64(burst)}
+}
+
+// untilTokens is how long a bucket holding tokens takes to hold want
+func (limit Limit) untilTokens(tokens, want float64) time.Duration {
+	if tokens >= want {
+		return 0
+	}
+	return time.Duration((want - tokens) / limit.Rate * float64(time.Second))
+}
+
+// Window is how long an empty bucket takes to fill up.
+func (limit Limit) Window() time.Duration {
+	return limit.untilTokens(0, float64(limit.Burst))
+}
+
+// Backend keeps the buckets.
+type Backend interface {
+	// Take takes a token from the bucket of key, refilled by limit since it was last taken from.
+	// tokens is what is left in the bucket; nothing is taken when it held less than one.
+	Take(ctx context.Context, key string, limit Limit) (tokens float64, allowed bool, err error)
+	// DeleteIdle forgets buckets nothing was taken from for idle.
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// Result is the outcome of a request against its limit.
+type Result struct {
+	Limit      Limit
+	Allowed    bool
+	Remaining  int64         // whole tokens left
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, 0 when allowed
+}
+
+// Limiter applies the limit of each group to the callers of its routes.
+type Limiter struct {
+	backend  Backend
+	limits   map[string]Limit
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewLimiter creates a limiter keeping its buckets in backend. Groups without a limit are not limited.
+func NewLimiter(backend Backend, limits map[string]Limit, logger *slog.Logger) *Limiter {
+	return &Limiter{backend: backend, limits: limits, interval: defaultInterval, logger: logger}
+}
+
+// Allow takes a token from the bucket identity has for group.
+// It returns nil when group has no limit.
+func (limiter *Limiter) Allow(ctx context.Context, group, identity string) (*Result, error) {
+	limit, ok := limiter.limits[group]
+	if !ok {
+		return nil, nil
+	}
+
+	tokens, allowed, err := limiter.backend.Take(ctx, group+":"+identity, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Limit:     limit,
+		Allowed:   allowed,
+		Remaining: int64(math.Floor(tokens)),
+		Reset:     limit.untilTokens(tokens, float64(limit.Burst)),
+	}
+	if !allowed {
+		result.RetryAfter = limit.untilTokens(tokens, 1)
+	}
+
+	return result, nil
+}
+
+// Run deletes buckets that would be full again every interval until ctx is done.
+func (limiter *Limiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(limiter.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := limiter.backend.DeleteIdle(ctx, limiter.idle()); err != nil {
+			limiter.logger.Warn("deleting idle rate limit buckets", "error", err.Error())
+		}
+	}
+}
+
+// a bucket left alone as long as the slowest group takes to fill up is as good as a new one
+func (limiter *Limiter) idle() time.Duration {
+	var idle time.Duration
+	for _, limit := range limiter.limits {
+		idle = max(idle, limit.Window())
+	}
+	return idle
+}
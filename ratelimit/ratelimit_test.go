@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A caller should get its burst at once, then one request per refilled token.
+func TestLimiter(t *testing.T) {
+	backend := NewMemoryBackend()
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	limiter := NewLimiter(backend, map[string]Limit{"writes": PerMinute(30, 2)}, slog.Default())
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, "writes", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, Result{Limit: Limit{Rate: 0.5, Burst: 2}, Allowed: true, Remaining: 1, Reset: 2 * time.Second}, *result)
+
+	result, err = limiter.Allow(ctx, "writes", "alice")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(0), result.Remaining)
+	assert.Equal(t, 4*time.Second, result.Reset)
+
+	result, err = limiter.Allow(ctx, "writes", "alice")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 2*time.Second, result.RetryAfter)
+
+	// others have their own bucket
+	result, err = limiter.Allow(ctx, "writes", "bob")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(3 * time.Second)
+	result, err = limiter.Allow(ctx, "writes", "alice")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(0), result.Remaining)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// groups without a limit are not limited
+	result, err = limiter.Allow(ctx, "reads", "alice")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+// Buckets that would be full again should be forgotten.
+func TestDeleteIdle(t *testing.T) {
+	backend := NewMemoryBackend()
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	limiter := NewLimiter(backend, map[string]Limit{"slow": PerMinute(1, 5), "fast": PerMinute(60, 5)}, slog.Default())
+	assert.Equal(t, 5*time.Minute, limiter.idle())
+
+	ctx := context.Background()
+	_, err := limiter.Allow(ctx, "slow", "alice")
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = limiter.Allow(ctx, "fast", "alice")
+	require.NoError(t, err)
+
+	deleted, err := backend.DeleteIdle(ctx, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, backend.buckets, 1)
+}
+
+// The store backend should keep the same buckets as the memory one.
+func TestStoreBackend(t *testing.T) {
+	limiter := NewLimiter(NewStoreBackend(memdb.NewStore()), map[string]Limit{"writes": PerMinute(1, 1)}, slog.Default())
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, "writes", "alice")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "writes", "alice")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, time.Minute, result.RetryAfter, float64(time.Second))
+}
@@ -0,0 +1,88 @@
+// Package risk screens transfers before they are made.
+//
+// An Engine runs a Ruleset: every rule looks at the transfer and the sending account's
+// history and, when it matches, adds a reason with the decision configured for it.
+// The most severe decision of all matching rules is the decision of the transfer:
+// allow, review or deny (see db.RiskAllow and friends).
+//
+// Rules are loaded from a YAML or JSON file (see ParseRules) and can be swapped while
+// the engine is in use, a Watcher does so whenever the file changes.
+// Rule types besides the built-in ones are added with Register.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// Transfer is what rules see of a transfer about to be made.
+type Transfer struct {
+	From   db.Account
+	To     db.Account
+	Amount int64 // amount in cents
+	Now    time.Time
+}
+
+// Rule looks at a transfer and tells why it is risky.
+type Rule interface {
+	// Check returns an empty message when the rule does not match.
+	Check(ctx context.Context, store db.Store, transfer Transfer) (string, error)
+}
+
+// Engine assesses transfers with the rules it was last given.
+type Engine struct {
+	store db.Store
+	rules atomic.Pointer[Ruleset]
+	now   func() time.Time
+}
+
+// NewEngine creates an engine without rules, it allows every transfer until SetRules is called.
+func NewEngine(store db.Store) *Engine {
+	engine := &Engine{store: store, now: time.Now}
+	engine.rules.Store(&Ruleset{})
+	return engine
+}
+
+// SetRules replaces the rules, assessments already running finish with the old ones.
+func (engine *Engine) SetRules(ruleset *Ruleset) {
+	engine.rules.Store(ruleset)
+}
+
+// Rules returns the rules in use.
+func (engine *Engine) Rules() *Ruleset {
+	return engine.rules.Load()
+}
+
+// Assess runs every rule over a transfer of amount from one account to another.
+// The assessment is not stored, its Reasons are empty when no rule matched.
+func (engine *Engine) Assess(ctx context.Context, from, to db.Account, amount int64) (*db.RiskAssessment, error) {
+	transfer := Transfer{From: from, To: to, Amount: amount, Now: engine.now()}
+	assessment := &db.RiskAssessment{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Decision:      db.RiskAllow,
+		Reasons:       db.RiskReasons{},
+	}
+
+	for _, rule := range engine.Rules().Rules {
+		message, err := rule.Rule.Check(ctx, engine.store, transfer)
+		if err != nil {
+			return nil, fmt.Errorf("risk rule %s: %w", rule.Name, err)
+		}
+		if message == "" {
+			continue
+		}
+
+		assessment.Reasons = append(assessment.Reasons, db.RiskReason{Rule: rule.Name, Decision: rule.Decision, Message: message})
+		if db.RiskSeverity(rule.Decision) > db.RiskSeverity(assessment.Decision) {
+			assessment.Decision = rule.Decision
+		}
+	}
+
+	return assessment, nil
+}
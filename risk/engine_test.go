@@ -0,0 +1,163 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccount(t *testing.T, store db.Store, owner string, balance int64) db.Account {
+	account, err := store.CreateAccount(context.Background(), owner, balance, currency.USD)
+	require.NoError(t, err)
+	return *account
+}
+
+func transferMoney(t *testing.T, store db.Store, from, to db.Account, amounts ...int64) {
+	for _, amount := range amounts {
+		_, err := store.TransferMoney(context.Background(), from.ID, to.ID, amount, db.Details{})
+		require.NoError(t, err)
+	}
+}
+
+// the message rule gives for a transfer of amount, empty when it does not match
+func check(t *testing.T, store db.Store, rule Rule, from, to db.Account, amount int64) string {
+	message, err := rule.Check(context.Background(), store, Transfer{From: from, To: to, Amount: amount, Now: time.Now()})
+	require.NoError(t, err)
+	return message
+}
+
+func TestVelocity(t *testing.T) {
+	store := memdb.NewStore()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	rule := &Velocity{Window: time.Hour, MaxCount: 3}
+
+	transferMoney(t, store, from, to, 1, 1)
+	assert.Empty(t, check(t, store, rule, from, to, 1))
+
+	transferMoney(t, store, from, to, 1)
+	assert.NotEmpty(t, check(t, store, rule, from, to, 1))
+
+	// incoming transfers do not count
+	assert.Empty(t, check(t, store, rule, to, from, 1))
+}
+
+func TestNewPayeeAmount(t *testing.T) {
+	store := memdb.NewStore()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	other := createAccount(t, store, utils.RandomOwner(), 0)
+	rule := &NewPayeeAmount{MaxAge: time.Hour, MinAmount: 500}
+
+	_, err := store.CreatePayee(context.Background(), from.Owner, to.ID, "Landlord")
+	require.NoError(t, err)
+
+	assert.Empty(t, check(t, store, rule, from, to, 499))
+	assert.Contains(t, check(t, store, rule, from, to, 500), "Landlord")
+	// not a payee
+	assert.Empty(t, check(t, store, rule, from, other, 500))
+
+	rule.MaxAge = time.Nanosecond
+	assert.Empty(t, check(t, store, rule, from, to, 500))
+}
+
+func TestRoundAmount(t *testing.T) {
+	store := memdb.NewStore()
+	from := createAccount(t, store, utils.RandomOwner(), 10000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+
+	single := &RoundAmount{Unit: 100, MinAmount: 200, Count: 1, Window: time.Hour}
+	assert.NotEmpty(t, check(t, store, single, from, to, 300))
+	assert.Empty(t, check(t, store, single, from, to, 100))
+	assert.Empty(t, check(t, store, single, from, to, 301))
+
+	run := &RoundAmount{Unit: 100, Count: 3, Window: time.Hour}
+	transferMoney(t, store, from, to, 100)
+	assert.Empty(t, check(t, store, run, from, to, 100))
+
+	transferMoney(t, store, from, to, 200)
+	assert.NotEmpty(t, check(t, store, run, from, to, 100))
+
+	// an odd amount breaks the run
+	transferMoney(t, store, from, to, 150)
+	assert.Empty(t, check(t, store, run, from, to, 100))
+}
+
+func TestFirstCounterparty(t *testing.T) {
+	store := memdb.NewStore()
+	from := createAccount(t, store, utils.RandomOwner(), 1000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	rule := &FirstCounterparty{MinAmount: 10}
+
+	assert.NotEmpty(t, check(t, store, rule, from, to, 10))
+	assert.Empty(t, check(t, store, rule, from, to, 9))
+
+	transferMoney(t, store, from, to, 1)
+	assert.Empty(t, check(t, store, rule, from, to, 10))
+
+	// having received from an account is not having sent to it
+	assert.NotEmpty(t, check(t, store, rule, to, from, 10))
+}
+
+func TestMedianDeviation(t *testing.T) {
+	store := memdb.NewStore()
+	from := createAccount(t, store, utils.RandomOwner(), 10000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	rule := &MedianDeviation{Sample: 10, MinHistory: 3, Multiplier: 5}
+
+	transferMoney(t, store, from, to, 10, 20)
+	// too little history
+	assert.Empty(t, check(t, store, rule, from, to, 1000))
+
+	transferMoney(t, store, from, to, 30)
+	assert.Empty(t, check(t, store, rule, from, to, 100))
+	assert.NotEmpty(t, check(t, store, rule, from, to, 101))
+}
+
+func TestMedian(t *testing.T) {
+	assert.Equal(t, 0.0, Median(nil))
+	assert.Equal(t, 20.0, Median([]int64{30, 10, 20}))
+	assert.Equal(t, 15.0, Median([]int64{40, 10, 20, 10}))
+}
+
+// The most severe decision of the matching rules wins, every match is a reason.
+func TestEngineAssess(t *testing.T) {
+	store := memdb.NewStore()
+	from := createAccount(t, store, utils.RandomOwner(), 10000)
+	to := createAccount(t, store, utils.RandomOwner(), 0)
+	engine := NewEngine(store)
+
+	assessment, err := engine.Assess(context.Background(), from, to, 500)
+	require.NoError(t, err)
+	assert.Equal(t, db.RiskAllow, assessment.Decision)
+	assert.Empty(t, assessment.Reasons)
+
+	engine.SetRules(&Ruleset{Rules: []ConfiguredRule{
+		{Name: "round", Decision: db.RiskAllow, Rule: &RoundAmount{Unit: 100, Count: 1, Window: time.Hour}},
+		{Name: "first", Decision: db.RiskReview, Rule: &FirstCounterparty{}},
+		{Name: "big", Decision: db.RiskDeny, Rule: &FirstCounterparty{MinAmount: 1000}},
+	}})
+
+	assessment, err = engine.Assess(context.Background(), from, to, 500)
+	require.NoError(t, err)
+	assert.Equal(t, db.RiskReview, assessment.Decision)
+	require.Len(t, assessment.Reasons, 2)
+	assert.Equal(t, "round", assessment.Reasons[0].Rule)
+	assert.Equal(t, db.RiskAllow, assessment.Reasons[0].Decision)
+	assert.Equal(t, "first", assessment.Reasons[1].Rule)
+	assert.Equal(t, from.ID, assessment.FromAccountID)
+	assert.Equal(t, to.ID, assessment.ToAccountID)
+	assert.Equal(t, int64(500), assessment.Amount)
+
+	assessment, err = engine.Assess(context.Background(), from, to, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, db.RiskDeny, assessment.Decision)
+	assert.Len(t, assessment.Reasons, 3)
+}
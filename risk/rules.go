@@ -0,0 +1,249 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// Factory builds a rule, decode fills a struct with the rule's settings from the file.
+type Factory func(decode func(settings any) error) (Rule, error)
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a rule type available to rule files. It panics when ruleType is taken.
+func Register(ruleType string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, ok := factories[ruleType]; ok {
+		panic("risk: rule type " + ruleType + " is registered twice")
+	}
+	factories[ruleType] = factory
+}
+
+func factory(ruleType string) (Factory, bool) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	factory, ok := factories[ruleType]
+	return factory, ok
+}
+
+func init() {
+	Register("velocity", func(decode func(any) error) (Rule, error) {
+		rule := &Velocity{Window: time.Hour, MaxCount: 10}
+		if err := decode(rule); err != nil {
+			return nil, err
+		}
+		if rule.Window <= 0 || rule.MaxCount < 1 {
+			return nil, errors.New("window must be positive and max_count at least 1")
+		}
+		return rule, nil
+	})
+	Register("new_payee_amount", func(decode func(any) error) (Rule, error) {
+		rule := &NewPayeeAmount{MaxAge: 7 * 24 * time.Hour, MinAmount: 100000}
+		if err := decode(rule); err != nil {
+			return nil, err
+		}
+		if rule.MaxAge <= 0 || rule.MinAmount < 1 {
+			return nil, errors.New("max_age must be positive and min_amount at least 1")
+		}
+		return rule, nil
+	})
+	Register("round_amount", func(decode func(any) error) (Rule, error) {
+		rule := &RoundAmount{Unit: 10000, Count: 3, Window: 24 * time.Hour}
+		if err := decode(rule); err != nil {
+			return nil, err
+		}
+		if rule.Unit < 2 || rule.Count < 1 || rule.Window <= 0 || rule.MinAmount < 0 {
+			return nil, errors.New("unit must be at least 2, count at least 1, window positive and min_amount not negative")
+		}
+		return rule, nil
+	})
+	Register("first_counterparty", func(decode func(any) error) (Rule, error) {
+		rule := &FirstCounterparty{}
+		if err := decode(rule); err != nil {
+			return nil, err
+		}
+		if rule.MinAmount < 0 {
+			return nil, errors.New("min_amount must not be negative")
+		}
+		return rule, nil
+	})
+	Register("median_deviation", func(decode func(any) error) (Rule, error) {
+		rule := &MedianDeviation{Sample: 50, MinHistory: 5, Multiplier: 10}
+		if err := decode(rule); err != nil {
+			return nil, err
+		}
+		if rule.Sample < 1 || rule.MinHistory < 1 || rule.MinHistory > rule.Sample || rule.Multiplier <= 1 {
+			return nil, errors.New("sample and min_history must be at least 1, min_history at most sample and multiplier above 1")
+		}
+		return rule, nil
+	})
+}
+
+// most recent outgoing transfers of account since from (all of them when nil), newest first
+func recentTransfers(ctx context.Context, store db.Store, accountID int64, from *time.Time, limit int64) ([]db.Transaction, error) {
+	transactions, err := store.SearchTransactions(ctx, db.TransactionFilter{
+		AccountID:   accountID,
+		Type:        db.TransactionTransfer,
+		Direction:   db.DirectionOutgoing,
+		CreatedFrom: from,
+	}, db.Page{Limit: limit, Order: db.SortDesc})
+	if err != nil {
+		return nil, err
+	}
+	return *transactions, nil
+}
+
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// Velocity matches when the account already sent MaxCount transfers within Window.
+type Velocity struct {
+	Window   time.Duration `yaml:"window"`
+	MaxCount int64         `yaml:"max_count"`
+}
+
+func (rule *Velocity) Check(ctx context.Context, store db.Store, transfer Transfer) (string, error) {
+	from := transfer.Now.Add(-rule.Window)
+	sent, err := recentTransfers(ctx, store, transfer.From.ID, &from, rule.MaxCount)
+	if err != nil || int64(len(sent)) < rule.MaxCount {
+		return "", err
+	}
+	return fmt.Sprintf("%d or more transfers sent in the last %s", rule.MaxCount, rule.Window), nil
+}
+
+// NewPayeeAmount matches transfers of at least MinAmount to a payee the owner saved less than MaxAge ago.
+type NewPayeeAmount struct {
+	MaxAge    time.Duration `yaml:"max_age"`
+	MinAmount int64         `yaml:"min_amount"`
+}
+
+func (rule *NewPayeeAmount) Check(ctx context.Context, store db.Store, transfer Transfer) (string, error) {
+	if transfer.Amount < rule.MinAmount {
+		return "", nil
+	}
+
+	payees, err := store.GetPayeesByOwner(ctx, transfer.From.Owner)
+	if err != nil {
+		return "", err
+	}
+	for _, payee := range *payees {
+		if payee.AccountID == transfer.To.ID && transfer.Now.Sub(payee.CreatedAt) < rule.MaxAge {
+			return fmt.Sprintf("%d to payee %q saved %s ago", transfer.Amount, payee.Nickname, transfer.Now.Sub(payee.CreatedAt).Round(time.Minute)), nil
+		}
+	}
+	return "", nil
+}
+
+// RoundAmount matches a transfer of a multiple of Unit, at least MinAmount, that ends a run of
+// Count such transfers in a row within Window. A Count of 1 matches every round amount.
+type RoundAmount struct {
+	Unit      int64         `yaml:"unit"`
+	MinAmount int64         `yaml:"min_amount"`
+	Count     int64         `yaml:"count"`
+	Window    time.Duration `yaml:"window"`
+}
+
+func (rule *RoundAmount) round(amount int64) bool {
+	return amount >= rule.MinAmount && amount%rule.Unit == 0
+}
+
+func (rule *RoundAmount) Check(ctx context.Context, store db.Store, transfer Transfer) (string, error) {
+	if !rule.round(transfer.Amount) {
+		return "", nil
+	}
+
+	if rule.Count > 1 {
+		from := transfer.Now.Add(-rule.Window)
+		sent, err := recentTransfers(ctx, store, transfer.From.ID, &from, rule.Count-1)
+		if err != nil || int64(len(sent)) < rule.Count-1 {
+			return "", err
+		}
+		for _, previous := range sent {
+			if !rule.round(abs(previous.Amount)) {
+				return "", nil
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d round amounts in a row, multiples of %d", rule.Count, rule.Unit), nil
+}
+
+// FirstCounterparty matches transfers of at least MinAmount to an account the sender never sent to.
+type FirstCounterparty struct {
+	MinAmount int64 `yaml:"min_amount"`
+}
+
+func (rule *FirstCounterparty) Check(ctx context.Context, store db.Store, transfer Transfer) (string, error) {
+	if transfer.Amount < rule.MinAmount {
+		return "", nil
+	}
+
+	sent, err := store.SearchTransactions(ctx, db.TransactionFilter{
+		AccountID:      transfer.From.ID,
+		Type:           db.TransactionTransfer,
+		Direction:      db.DirectionOutgoing,
+		CounterpartyID: &transfer.To.ID,
+	}, db.Page{Limit: 1})
+	if err != nil || len(*sent) > 0 {
+		return "", err
+	}
+	return fmt.Sprintf("first transfer to account %d", transfer.To.ID), nil
+}
+
+// MedianDeviation matches transfers above Multiplier times the median of the account's last
+// Sample outgoing transfers, once it has sent at least MinHistory.
+type MedianDeviation struct {
+	Sample     int64   `yaml:"sample"`
+	MinHistory int64   `yaml:"min_history"`
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+func (rule *MedianDeviation) Check(ctx context.Context, store db.Store, transfer Transfer) (string, error) {
+	sent, err := recentTransfers(ctx, store, transfer.From.ID, nil, rule.Sample)
+	if err != nil || int64(len(sent)) < rule.MinHistory {
+		return "", err
+	}
+
+	amounts := make([]int64, len(sent))
+	for i, previous := range sent {
+		amounts[i] = abs(previous.Amount)
+	}
+	median := Median(amounts)
+
+	if float64(transfer.Amount) <= rule.Multiplier*median {
+		return "", nil
+	}
+	return fmt.Sprintf("%d is more than %g times the median of %g", transfer.Amount, rule.Multiplier, median), nil
+}
+
+// Median of amounts, the mean of the middle two for an even count and 0 when empty.
+func Median(amounts []int64) float64 {
+	if len(amounts) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(amounts)
+	slices.Sort(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return float64(sorted[middle])
+	}
+	return float64(sorted[middle-1]+sorted[middle]) / 2
+}
@@ -0,0 +1,159 @@
+package risk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/joelpatel/go-bank/db"
+	"gopkg.in/yaml.v3"
+)
+
+// Ruleset is the rules an engine runs, in file order.
+type Ruleset struct {
+	Rules []ConfiguredRule `json:"rules"`
+}
+
+// ConfiguredRule is a rule with the name and decision its file gave it.
+type ConfiguredRule struct {
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	Decision string         `json:"decision"`
+	Settings map[string]any `json:"settings,omitempty"` // as written in the file
+	Rule     Rule           `json:"-"`
+}
+
+// keys every rule has, the others are settings of its type
+type ruleHeader struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Decision string `yaml:"decision"`
+	Disabled bool   `yaml:"disabled"`
+}
+
+var headerKeys = []string{"name", "type", "decision", "disabled"}
+
+// ParseRules reads a rule file, YAML or JSON:
+//
+//	rules:
+//	  - type: velocity      # registered rule type
+//	    name: burst         # defaults to the type, unique within the file
+//	    decision: review    # allow (only record the reason), review or deny; review when empty
+//	    disabled: false
+//	    window: 10m         # the remaining keys are settings of the type
+//	    max_count: 5
+//
+// Unknown keys are errors, so typos do not silently fall back to defaults.
+func ParseRules(data []byte) (*Ruleset, error) {
+	var file struct {
+		Rules []yaml.Node `yaml:"rules"`
+	}
+	// an empty file has no rules
+	if err := decodeStrict(data, &file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	ruleset := &Ruleset{Rules: []ConfiguredRule{}}
+	var errs []error
+	for i := range file.Rules {
+		rule, err := parseRule(&file.Rules[i])
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+		case rule == nil:
+		case slices.ContainsFunc(ruleset.Rules, func(other ConfiguredRule) bool { return other.Name == rule.Name }):
+			errs = append(errs, fmt.Errorf("rule %d: name %q is used twice", i+1, rule.Name))
+		default:
+			ruleset.Rules = append(ruleset.Rules, *rule)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return ruleset, nil
+}
+
+// nil for disabled rules
+func parseRule(node *yaml.Node) (*ConfiguredRule, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.New("must be a mapping")
+	}
+
+	// split the header from the settings
+	settings := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	header := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		target := settings
+		if slices.Contains(headerKeys, node.Content[i].Value) {
+			target = header
+		}
+		target.Content = append(target.Content, node.Content[i], node.Content[i+1])
+	}
+
+	var rule ruleHeader
+	if err := decodeNodeStrict(header, &rule); err != nil {
+		return nil, err
+	}
+	if rule.Disabled {
+		return nil, nil
+	}
+
+	factory, ok := factory(rule.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown rule type %q", rule.Type)
+	}
+	if rule.Name == "" {
+		rule.Name = rule.Type
+	}
+	switch rule.Decision {
+	case "":
+		rule.Decision = db.RiskReview
+	case db.RiskAllow, db.RiskReview, db.RiskDeny:
+	default:
+		return nil, fmt.Errorf("decision %q must be allow, review or deny", rule.Decision)
+	}
+
+	built, err := factory(func(out any) error { return decodeNodeStrict(settings, out) })
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rule.Name, err)
+	}
+
+	configured := &ConfiguredRule{Name: rule.Name, Type: rule.Type, Decision: rule.Decision, Rule: built}
+	if err := settings.Decode(&configured.Settings); err != nil {
+		return nil, err
+	}
+	return configured, nil
+}
+
+// LoadRules reads the rule file at path, see ParseRules.
+func LoadRules(path string) (*Ruleset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleset, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ruleset, nil
+}
+
+func decodeStrict(data []byte, out any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(out)
+}
+
+// yaml.Node.Decode cannot reject unknown keys, a round trip through the decoder can
+func decodeNodeStrict(node *yaml.Node, out any) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return decodeStrict(data, out)
+}
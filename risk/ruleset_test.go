@@ -0,0 +1,144 @@
+package risk
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/memdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	ruleset, err := ParseRules([]byte(`
+rules:
+  - type: velocity
+    window: 10m
+    max_count: 5
+  - type: first_counterparty
+    name: large_first
+    decision: deny
+    min_amount: 100000
+  - type: round_amount
+    disabled: true
+  - type: median_deviation
+    decision: allow
+`))
+	require.NoError(t, err)
+	require.Len(t, ruleset.Rules, 3)
+
+	velocity := ruleset.Rules[0]
+	assert.Equal(t, "velocity", velocity.Name)
+	assert.Equal(t, db.RiskReview, velocity.Decision)
+	assert.Equal(t, &Velocity{Window: 10 * time.Minute, MaxCount: 5}, velocity.Rule)
+	assert.Equal(t, map[string]any{"window": "10m", "max_count": 5}, velocity.Settings)
+
+	assert.Equal(t, "large_first", ruleset.Rules[1].Name)
+	assert.Equal(t, db.RiskDeny, ruleset.Rules[1].Decision)
+	assert.Equal(t, &FirstCounterparty{MinAmount: 100000}, ruleset.Rules[1].Rule)
+
+	// defaults fill what the file leaves out
+	assert.Equal(t, &MedianDeviation{Sample: 50, MinHistory: 5, Multiplier: 10}, ruleset.Rules[2].Rule)
+
+	// JSON is YAML too
+	ruleset, err = ParseRules([]byte(`{"rules": [{"type": "velocity", "max_count": 2}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, &Velocity{Window: time.Hour, MaxCount: 2}, ruleset.Rules[0].Rule)
+
+	ruleset, err = ParseRules(nil)
+	require.NoError(t, err)
+	assert.Empty(t, ruleset.Rules)
+}
+
+func TestParseRulesInvalid(t *testing.T) {
+	for name, file := range map[string]string{
+		"UnknownType":     "rules:\n  - type: astrology\n",
+		"UnknownSetting":  "rules:\n  - type: velocity\n    max_cuont: 5\n",
+		"UnknownKey":      "ruels: []\n",
+		"BadDecision":     "rules:\n  - type: velocity\n    decision: block\n",
+		"BadSetting":      "rules:\n  - type: velocity\n    max_count: 0\n",
+		"BadDuration":     "rules:\n  - type: velocity\n    window: soon\n",
+		"DuplicateName":   "rules:\n  - type: velocity\n  - type: velocity\n",
+		"NotAMapping":     "rules:\n  - velocity\n",
+		"MultiplierOfOne": "rules:\n  - type: median_deviation\n    multiplier: 1\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRules([]byte(file))
+			assert.Error(t, err)
+		})
+	}
+}
+
+type constantRule string
+
+func (rule constantRule) Check(ctx context.Context, store db.Store, transfer Transfer) (string, error) {
+	return string(rule), nil
+}
+
+// Rule types registered from outside the package should be usable in files.
+func TestRegister(t *testing.T) {
+	Register("test_constant", func(decode func(any) error) (Rule, error) {
+		var settings struct {
+			Message string `yaml:"message"`
+		}
+		if err := decode(&settings); err != nil {
+			return nil, err
+		}
+		return constantRule(settings.Message), nil
+	})
+
+	ruleset, err := ParseRules([]byte("rules:\n  - type: test_constant\n    message: always\n"))
+	require.NoError(t, err)
+	assert.Equal(t, constantRule("always"), ruleset.Rules[0].Rule)
+
+	assert.Panics(t, func() {
+		Register("test_constant", nil)
+	})
+}
+
+// The watcher should load changed files and keep the last good rules when a change is broken.
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(content string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	start := time.Now().Add(-time.Hour)
+
+	engine := NewEngine(memdb.NewStore())
+	watcher := NewWatcher(engine, path, time.Second, slog.Default())
+
+	write("rules:\n  - type: velocity\n", start)
+	reloaded, err := watcher.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Len(t, engine.Rules().Rules, 1)
+
+	reloaded, err = watcher.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	write("rules:\n  - type: velocity\n  - type: first_counterparty\n", start.Add(time.Minute))
+	reloaded, err = watcher.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Len(t, engine.Rules().Rules, 2)
+
+	// reported once, the old rules stay
+	write("rules:\n  - type: astrology\n", start.Add(2*time.Minute))
+	_, err = watcher.Reload()
+	assert.Error(t, err)
+	reloaded, err = watcher.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.Len(t, engine.Rules().Rules, 2)
+
+	require.NoError(t, os.Remove(path))
+	_, err = watcher.Reload()
+	assert.Error(t, err)
+}
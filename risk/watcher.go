@@ -0,0 +1,73 @@
+package risk
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Watcher keeps an engine's rules in sync with a rule file.
+type Watcher struct {
+	engine   *Engine
+	path     string
+	interval time.Duration
+	logger   *slog.Logger
+
+	// of the file last read
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher creates a watcher that checks path for changes every interval, logging reloads to logger.
+func NewWatcher(engine *Engine, path string, interval time.Duration, logger *slog.Logger) *Watcher {
+	return &Watcher{engine: engine, path: path, interval: interval, logger: logger}
+}
+
+// Reload loads the file into the engine if it changed since it was last read.
+// A file that does not parse leaves the engine's rules as they are
+// and is reported once, not again until it changes.
+func (watcher *Watcher) Reload() (bool, error) {
+	info, err := os.Stat(watcher.path)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(watcher.modTime) && info.Size() == watcher.size {
+		return false, nil
+	}
+
+	watcher.modTime, watcher.size = info.ModTime(), info.Size()
+
+	ruleset, err := LoadRules(watcher.path)
+	if err != nil {
+		return false, err
+	}
+
+	watcher.engine.SetRules(ruleset)
+	return true, nil
+}
+
+// Run calls Reload every interval until ctx is done, an interval of 0 never reloads.
+func (watcher *Watcher) Run(ctx context.Context) {
+	if watcher.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := watcher.Reload()
+		if err != nil {
+			watcher.logger.Error("reloading risk rules", "path", watcher.path, "error", err.Error())
+		} else if reloaded {
+			watcher.logger.Info("loaded risk rules", "path", watcher.path, "rules", len(watcher.engine.Rules().Rules))
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+const roleUsage = `usage: go-bank role <username> <role> [flags]
+
+gives username one of the roles customer, teller or admin;
+the first admin has to be made this way, admins can change roles over the API after that
+
+flags are the same as the server's, see go-bank -h`
+
+// go-bank role <username> <role>
+func runRole(args []string) {
+	if len(args) < 2 || strings.HasPrefix(args[0], "-") || strings.HasPrefix(args[1], "-") {
+		fmt.Fprintln(os.Stderr, roleUsage)
+		os.Exit(2)
+	}
+	username, role := args[0], args[1]
+
+	cfg := loadConfig(args[2:])
+
+	conn, err := db.OpenDB(cfg.Database)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer conn.Close()
+
+	user, err := db.NewStore(conn, slog.Default()).SetUserRole(context.Background(), username, role)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	fmt.Printf("%s is now %s\n", user.Username, user.Role)
+}
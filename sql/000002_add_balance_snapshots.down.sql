@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS transfers_business_day_open ON transfers;
+DROP TRIGGER IF EXISTS entries_business_day_open ON entries;
+DROP FUNCTION IF EXISTS reject_closed_business_day;
+DROP TABLE IF EXISTS currency_totals;
+DROP TABLE IF EXISTS balance_snapshots;
+DROP TABLE IF EXISTS business_days;
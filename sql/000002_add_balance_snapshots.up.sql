@@ -0,0 +1,57 @@
+CREATE TABLE "business_days" (
+    "business_date" date PRIMARY KEY,
+    "timezone" varchar NOT NULL,
+    "account_count" bigint NOT NULL,
+    "closed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "balance_snapshots" (
+    "account_id" bigint NOT NULL,
+    "business_date" date NOT NULL,
+    "closing_balance" bigint NOT NULL,
+    "currency" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    PRIMARY KEY ("account_id", "business_date")
+);
+
+CREATE TABLE "currency_totals" (
+    "business_date" date NOT NULL,
+    "currency" varchar NOT NULL,
+    "total_balance" bigint NOT NULL,
+    "account_count" bigint NOT NULL,
+
+    PRIMARY KEY ("business_date", "currency")
+);
+
+ALTER TABLE "balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "balance_snapshots" ADD FOREIGN KEY ("business_date") REFERENCES "business_days" ("business_date");
+
+ALTER TABLE "currency_totals" ADD FOREIGN KEY ("business_date") REFERENCES "business_days" ("business_date");
+
+CREATE INDEX ON "balance_snapshots" ("business_date");
+
+COMMENT ON COLUMN "balance_snapshots"."closing_balance" IS 'balance in cents at the end of the business day';
+
+COMMENT ON COLUMN "business_days"."timezone" IS 'IANA timezone the business day was closed in';
+
+-- postings (entries and transfers) must not land in a business day that has already been closed
+CREATE FUNCTION reject_closed_business_day() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM business_days
+        WHERE business_date >= (NEW.created_at AT TIME ZONE timezone)::date
+    ) THEN
+        RAISE EXCEPTION 'posting at % falls into a closed business day', NEW.created_at
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'business_day_closed';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER entries_business_day_open BEFORE INSERT ON "entries"
+    FOR EACH ROW EXECUTE FUNCTION reject_closed_business_day();
+
+CREATE TRIGGER transfers_business_day_open BEFORE INSERT ON "transfers"
+    FOR EACH ROW EXECUTE FUNCTION reject_closed_business_day();
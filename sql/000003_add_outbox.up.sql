@@ -0,0 +1,15 @@
+CREATE TABLE "outbox" (
+    "id" bigserial PRIMARY KEY,
+    "aggregate_type" varchar NOT NULL,
+    "aggregate_id" bigint NOT NULL,
+    "event_type" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "published_at" timestamptz
+);
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+CREATE INDEX ON "outbox" ("aggregate_type", "aggregate_id");
+
+COMMENT ON COLUMN "outbox"."published_at" IS 'null until the relay has handed the event to the publisher';
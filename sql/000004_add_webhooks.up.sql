@@ -0,0 +1,37 @@
+CREATE TABLE "webhook_subscriptions" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "url" varchar NOT NULL,
+    "event_types" varchar[] NOT NULL,
+    "secret" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+    "id" bigserial PRIMARY KEY,
+    "subscription_id" bigint NOT NULL,
+    "event_id" bigint NOT NULL,
+    "event_type" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "attempts" int NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+    "last_status_code" int,
+    "last_error" varchar,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "delivered_at" timestamptz,
+
+    CONSTRAINT webhook_delivery_status CHECK (status IN ('pending', 'succeeded', 'dead'))
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "webhook_subscriptions" ("owner");
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("subscription_id", "event_id", "event_type");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "webhook_subscriptions"."secret" IS 'HMAC-SHA256 key used to sign deliveries';
+
+COMMENT ON COLUMN "webhook_deliveries"."event_id" IS 'outbox event the delivery was fanned out from';
@@ -0,0 +1,10 @@
+-- wake up streaming listeners once the event's transaction commits
+CREATE FUNCTION notify_outbox_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('outbox_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_notify AFTER INSERT ON "outbox"
+    FOR EACH ROW EXECUTE FUNCTION notify_outbox_event();
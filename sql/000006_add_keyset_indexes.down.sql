@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS "accounts_owner_idx" ON "accounts" ("owner");
+CREATE INDEX IF NOT EXISTS "entries_account_id_idx" ON "entries" ("account_id");
+CREATE INDEX IF NOT EXISTS "transfers_from_account_id_idx" ON "transfers" ("from_account_id");
+CREATE INDEX IF NOT EXISTS "transfers_to_account_id_idx" ON "transfers" ("to_account_id");
+DROP INDEX IF EXISTS "transfers_to_account_id_created_at_id_idx";
+DROP INDEX IF EXISTS "transfers_from_account_id_created_at_id_idx";
+DROP INDEX IF EXISTS "entries_account_id_created_at_id_idx";
+DROP INDEX IF EXISTS "accounts_owner_created_at_id_idx";
@@ -0,0 +1,17 @@
+-- keyset pages are ordered by (created_at, id) within the filtered column,
+-- these replace the single column indexes they start with
+CREATE INDEX "accounts_owner_created_at_id_idx" ON "accounts" ("owner", "created_at", "id");
+
+CREATE INDEX "entries_account_id_created_at_id_idx" ON "entries" ("account_id", "created_at", "id");
+
+CREATE INDEX "transfers_from_account_id_created_at_id_idx" ON "transfers" ("from_account_id", "created_at", "id");
+
+CREATE INDEX "transfers_to_account_id_created_at_id_idx" ON "transfers" ("to_account_id", "created_at", "id");
+
+DROP INDEX IF EXISTS "accounts_owner_idx";
+
+DROP INDEX IF EXISTS "entries_account_id_idx";
+
+DROP INDEX IF EXISTS "transfers_from_account_id_idx";
+
+DROP INDEX IF EXISTS "transfers_to_account_id_idx";
@@ -0,0 +1,11 @@
+-- transaction search narrows an account's transfers down to one counterparty
+-- and its postings down to an amount range, both still paged by (created_at, id)
+CREATE INDEX "transfers_from_account_id_to_account_id_created_at_id_idx" ON "transfers" ("from_account_id", "to_account_id", "created_at", "id");
+
+CREATE INDEX "transfers_to_account_id_from_account_id_created_at_id_idx" ON "transfers" ("to_account_id", "from_account_id", "created_at", "id");
+
+CREATE INDEX "transfers_from_account_id_amount_idx" ON "transfers" ("from_account_id", "amount");
+
+CREATE INDEX "transfers_to_account_id_amount_idx" ON "transfers" ("to_account_id", "amount");
+
+CREATE INDEX "entries_account_id_abs_amount_idx" ON "entries" ("account_id", abs("amount"));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "transfers_external_reference_key";
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "metadata", DROP COLUMN IF EXISTS "external_reference", DROP COLUMN IF EXISTS "description";
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "metadata", DROP COLUMN IF EXISTS "external_reference", DROP COLUMN IF EXISTS "description";
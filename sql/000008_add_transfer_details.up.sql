@@ -0,0 +1,20 @@
+ALTER TABLE "transfers"
+    ADD COLUMN "description" varchar NOT NULL DEFAULT '',
+    ADD COLUMN "external_reference" varchar NOT NULL DEFAULT '',
+    ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}',
+    ADD CONSTRAINT transfer_description_length CHECK (char_length(description) <= 255),
+    ADD CONSTRAINT transfer_external_reference_length CHECK (char_length(external_reference) <= 64),
+    ADD CONSTRAINT transfer_metadata_object CHECK (jsonb_typeof(metadata) = 'object');
+
+ALTER TABLE "entries"
+    ADD COLUMN "description" varchar NOT NULL DEFAULT '',
+    ADD COLUMN "external_reference" varchar NOT NULL DEFAULT '',
+    ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}',
+    ADD CONSTRAINT entry_description_length CHECK (char_length(description) <= 255),
+    ADD CONSTRAINT entry_external_reference_length CHECK (char_length(external_reference) <= 64),
+    ADD CONSTRAINT entry_metadata_object CHECK (jsonb_typeof(metadata) = 'object');
+
+-- a client's references identify its transfers, so they are unique per sending account
+CREATE UNIQUE INDEX "transfers_external_reference_key" ON "transfers" ("from_account_id", "external_reference") WHERE "external_reference" <> '';
+
+COMMENT ON COLUMN "transfers"."external_reference" IS 'client supplied id, empty when not given';
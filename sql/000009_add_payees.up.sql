@@ -0,0 +1,17 @@
+CREATE TABLE "payees" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "account_id" bigint NOT NULL,
+    "nickname" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT payee_nickname_length CHECK (char_length(nickname) BETWEEN 1 AND 64)
+);
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "payees_owner_nickname_key" ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX "payees_owner_account_id_key" ON "payees" ("owner", "account_id");
+
+COMMENT ON COLUMN "payees"."created_at" IS 'start of the cooling-off period for large transfers';
@@ -0,0 +1,18 @@
+CREATE TABLE "transfer_limits" (
+    "id" bigserial PRIMARY KEY,
+    "scope" varchar NOT NULL,
+    "subject" varchar NOT NULL,
+    "kind" varchar NOT NULL,
+    "max_value" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT transfer_limit_scope CHECK (scope IN ('account', 'owner', 'currency')),
+    CONSTRAINT transfer_limit_kind CHECK (kind IN ('per_transaction', 'daily_amount', 'monthly_amount', 'daily_count')),
+    CONSTRAINT transfer_limit_max_nonnegative CHECK (max_value >= 0)
+);
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("scope", "subject", "kind");
+
+COMMENT ON COLUMN "transfer_limits"."subject" IS 'account id, owner or currency code, depending on scope';
+
+COMMENT ON COLUMN "transfer_limits"."max_value" IS 'cents, or a number of transfers for daily_count';
@@ -0,0 +1,22 @@
+CREATE TABLE "risk_assessments" (
+    "id" bigserial PRIMARY KEY,
+    "transfer_id" bigint,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "decision" varchar NOT NULL,
+    "reasons" jsonb NOT NULL DEFAULT '[]',
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT risk_assessment_decision CHECK (decision IN ('allow', 'review', 'deny'))
+);
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE UNIQUE INDEX ON "risk_assessments" ("transfer_id");
+
+CREATE INDEX ON "risk_assessments" ("from_account_id", "created_at", "id");
+
+COMMENT ON COLUMN "risk_assessments"."transfer_id" IS 'null when the transfer was denied';
+
+COMMENT ON COLUMN "risk_assessments"."reasons" IS 'rules that matched, as [{"rule", "decision", "message"}]';
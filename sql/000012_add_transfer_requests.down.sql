@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "transfer_request_events";
+DROP TABLE IF EXISTS "transfer_requests";
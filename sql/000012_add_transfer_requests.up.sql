@@ -0,0 +1,55 @@
+CREATE TABLE "transfer_requests" (
+    "id" bigserial PRIMARY KEY,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "description" varchar NOT NULL DEFAULT '',
+    "external_reference" varchar NOT NULL DEFAULT '',
+    "metadata" jsonb NOT NULL DEFAULT '{}',
+    "initiator" varchar NOT NULL,
+    "hold_reason" varchar NOT NULL,
+    "risk_assessment_id" bigint,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "reviewer" varchar,
+    "decided_at" timestamptz,
+    "transfer_id" bigint,
+    "failure" varchar NOT NULL DEFAULT '',
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT transfer_request_amount_positive CHECK (amount > 0),
+    CONSTRAINT transfer_request_status CHECK (status IN ('pending', 'approved', 'rejected', 'expired', 'executed', 'failed'))
+);
+
+CREATE TABLE "transfer_request_events" (
+    "id" bigserial PRIMARY KEY,
+    "request_id" bigint NOT NULL,
+    "action" varchar NOT NULL,
+    "actor" varchar NOT NULL,
+    "note" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT transfer_request_event_action CHECK (action IN ('submitted', 'approved', 'rejected', 'expired', 'executed', 'failed'))
+);
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("risk_assessment_id") REFERENCES "risk_assessments" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_request_events" ADD FOREIGN KEY ("request_id") REFERENCES "transfer_requests" ("id");
+
+CREATE INDEX ON "transfer_requests" ("status", "created_at", "id");
+
+CREATE INDEX ON "transfer_requests" ("expires_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "transfer_request_events" ("request_id", "id");
+
+COMMENT ON COLUMN "transfer_requests"."initiator" IS 'owner of the sending account, who may not decide the request';
+
+COMMENT ON COLUMN "transfer_requests"."status" IS 'pending until decided or expired; approved requests become executed or failed once the transfer is attempted';
+
+COMMENT ON TABLE "transfer_request_events" IS 'append-only audit trail of transfer requests';
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS transfers_accounts_not_frozen ON "transfers";
+DROP FUNCTION IF EXISTS reject_frozen_account();
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "frozen";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,31 @@
+CREATE TABLE "users" (
+    "username" varchar PRIMARY KEY,
+    "role" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT user_role CHECK (role IN ('customer', 'teller', 'admin'))
+);
+
+COMMENT ON TABLE "users" IS 'staff roles; callers without a row are customers';
+
+ALTER TABLE "accounts" ADD COLUMN "frozen" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "accounts"."frozen" IS 'frozen accounts neither send nor receive transfers';
+
+CREATE FUNCTION reject_frozen_account() RETURNS trigger AS $$
+DECLARE
+    frozen_id bigint;
+BEGIN
+    SELECT id INTO frozen_id FROM accounts
+    WHERE id IN (NEW.from_account_id, NEW.to_account_id) AND frozen
+    LIMIT 1;
+    IF frozen_id IS NOT NULL THEN
+        RAISE EXCEPTION 'account % is frozen', frozen_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'account_frozen';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transfers_accounts_not_frozen BEFORE INSERT ON "transfers"
+    FOR EACH ROW EXECUTE FUNCTION reject_frozen_account();
@@ -0,0 +1,38 @@
+CREATE TABLE "api_keys" (
+    "id" bigserial PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "prefix" varchar NOT NULL,
+    "key_hash" varchar NOT NULL,
+    "signing_secret" varchar NOT NULL,
+    "require_signature" boolean NOT NULL DEFAULT false,
+    "permissions" varchar[] NOT NULL,
+    "account_ids" bigint[] NOT NULL DEFAULT '{}',
+    "created_by" varchar NOT NULL,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    "rotated_to" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "api_key_nonces" (
+    "key_id" bigint NOT NULL,
+    "nonce" varchar NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+
+    PRIMARY KEY ("key_id", "nonce")
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("rotated_to") REFERENCES "api_keys" ("id");
+
+ALTER TABLE "api_key_nonces" ADD FOREIGN KEY ("key_id") REFERENCES "api_keys" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX ON "api_keys" ("prefix");
+
+CREATE INDEX ON "api_key_nonces" ("expires_at");
+
+COMMENT ON COLUMN "api_keys"."key_hash" IS 'hex SHA-256 of the whole key, the key itself is only shown once';
+
+COMMENT ON COLUMN "api_keys"."account_ids" IS 'accounts the key is limited to, every account when empty';
+
+COMMENT ON TABLE "api_key_nonces" IS 'nonces of signed requests, kept until their timestamp is out of tolerance';
@@ -0,0 +1,9 @@
+CREATE UNLOGGED TABLE "rate_limit_buckets" (
+    "key" varchar PRIMARY KEY,
+    "tokens" double precision NOT NULL,
+    "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "rate_limit_buckets" ("updated_at");
+
+COMMENT ON TABLE "rate_limit_buckets" IS 'token buckets shared by replicas; unlogged, losing them on a crash only refills them';
@@ -0,0 +1,5 @@
+ALTER TABLE "outbox" ADD COLUMN "txid" bigint NOT NULL DEFAULT (pg_current_xact_id()::text::bigint);
+
+CREATE INDEX ON "outbox" ("txid", "id");
+
+COMMENT ON COLUMN "outbox"."txid" IS 'transaction that wrote the event; ids are taken before commit, (txid, id) below the oldest running transaction is commit safe';
@@ -0,0 +1,2 @@
+-- webhook deliveries are listed in keyset pages ordered by (created_at, id) within their subscription
+CREATE INDEX "webhook_deliveries_subscription_id_created_at_id_idx" ON "webhook_deliveries" ("subscription_id", "created_at", "id");
@@ -0,0 +1,9 @@
+// Package sql embeds the schema migrations so the binary can apply them itself.
+package sql
+
+import "embed"
+
+// Migrations holds the NNNNNN_name.up.sql and NNNNNN_name.down.sql files.
+//
+//go:embed *.sql
+var Migrations embed.FS
@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/joelpatel/go-bank/db"
+	"github.com/stretchr/testify/require"
+)
+
+// The code's expected schema version has to follow the newest migration.
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := db.LoadMigrations(Migrations)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	latest := migrations[len(migrations)-1]
+	require.Equal(t, int64(db.SchemaVersion), latest.Version, "bump db.SchemaVersion along with new migrations")
+
+	for _, migration := range migrations {
+		require.NotEmpty(t, migration.Up)
+		require.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestLoadMigrationsRejectsBrokenSets(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+
+	_, err := db.LoadMigrations(fstest.MapFS{
+		"000001_first.up.sql":   file,
+		"000001_first.down.sql": file,
+		"000003_third.up.sql":   file,
+		"000003_third.down.sql": file,
+	})
+	require.ErrorContains(t, err, "migration 2 is missing")
+
+	_, err = db.LoadMigrations(fstest.MapFS{
+		"000001_first.up.sql": file,
+	})
+	require.ErrorContains(t, err, "needs both an up and a down file")
+}
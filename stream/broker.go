@@ -0,0 +1,296 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+const (
+	defaultBuffer      = 64
+	defaultReplayLimit = 500
+	reconnectDelay     = 3 * time.Second
+	// notifications wake the broker up early; polling catches events held back by a transaction
+	// that was still running, whose end notifies nobody
+	pollInterval = time.Second
+)
+
+// message names sent to streaming clients
+const (
+	MessageBalance = "balance"
+	MessageEntries = "entries"
+)
+
+// Position orders outbox events the way they committed: by the transaction that wrote them, then by id.
+// Event ids alone do not, a transaction may take an id and commit after one that took a later id.
+type Position struct {
+	TxID int64
+	ID   int64
+}
+
+// PositionOf returns the position of event.
+func PositionOf(event db.OutboxEvent) Position {
+	return Position{TxID: event.TxID, ID: event.ID}
+}
+
+// After reports whether position comes after other.
+func (position Position) After(other Position) bool {
+	return position.TxID > other.TxID || position.TxID == other.TxID && position.ID > other.ID
+}
+
+// Message is one streamed notification; ID is the outbox event id, usable as Last-Event-ID.
+type Message struct {
+	ID       int64    `json:"id"`
+	Event    string   `json:"event"`
+	Data     any      `json:"data"`
+	Position Position `json:"-"`
+}
+
+// EntriesMessage is the data of MessageEntries: the caller's side of a completed transfer.
+type EntriesMessage struct {
+	Transfer db.Transfer `json:"transfer"`
+	Entries  []db.Entry  `json:"entries"`
+}
+
+// MessageFor returns what owner should see of event, if anything.
+func MessageFor(owner string, event db.OutboxEvent) (Message, bool) {
+	switch event.EventType {
+	case db.EventAccountBalanceChanged:
+		var account db.Account
+		if err := event.Decode(&account); err != nil || account.Owner != owner {
+			return Message{}, false
+		}
+
+		return Message{ID: event.ID, Event: MessageBalance, Data: account, Position: PositionOf(event)}, true
+
+	case db.EventTransferCompleted:
+		var result db.TransferTxResult
+		if err := event.Decode(&result); err != nil {
+			return Message{}, false
+		}
+
+		var entries []db.Entry
+		if result.FromAccount.Owner == owner {
+			entries = append(entries, result.FromEntryRecord)
+		}
+		if result.ToAccount.Owner == owner {
+			entries = append(entries, result.ToEntryRecord)
+		}
+		if len(entries) == 0 {
+			return Message{}, false
+		}
+
+		return Message{ID: event.ID, Event: MessageEntries, Data: EntriesMessage{Transfer: result.TransferRecord, Entries: entries}, Position: PositionOf(event)}, true
+
+	default:
+		return Message{}, false
+	}
+}
+
+// Subscription receives the live messages of one owner.
+type Subscription struct {
+	owner    string
+	messages chan Message
+	broker   *Broker
+	closed   bool
+}
+
+// Messages is closed when the subscription is closed or fell too far behind;
+// the client then reconnects and resumes from its last event id.
+func (subscription *Subscription) Messages() <-chan Message {
+	return subscription.messages
+}
+
+// Close stops the subscription.
+func (subscription *Subscription) Close() {
+	subscription.broker.mu.Lock()
+	defer subscription.broker.mu.Unlock()
+
+	subscription.broker.remove(subscription)
+}
+
+// Broker fans committed outbox events out to streaming subscribers.
+type Broker struct {
+	store       db.Store
+	logger      *slog.Logger
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker replaying from and listening on store.
+// Lost connections to the store are logged to logger.
+func NewBroker(store db.Store, logger *slog.Logger) *Broker {
+	return &Broker{
+		store:       store,
+		logger:      logger,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe starts receiving owner's live messages.
+func (broker *Broker) Subscribe(owner string) *Subscription {
+	subscription := &Subscription{
+		owner:    owner,
+		messages: make(chan Message, defaultBuffer),
+		broker:   broker,
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	broker.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Publish hands event to every interested subscriber without blocking.
+// Run calls it with the events in commit order.
+func (broker *Broker) Publish(ctx context.Context, event db.OutboxEvent) error {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for subscription := range broker.subscribers {
+		message, ok := MessageFor(subscription.owner, event)
+		if !ok {
+			continue
+		}
+
+		select {
+		case subscription.messages <- message:
+		default:
+			broker.remove(subscription)
+		}
+	}
+
+	return nil
+}
+
+// Replay sends owner's messages for the events committed after the event afterID, in commit order,
+// and returns the position of the last event read; live messages at or before it were already sent.
+func (broker *Broker) Replay(ctx context.Context, owner string, afterID int64, send func(Message) error) (Position, error) {
+	// without newer events the last one read is the client's own
+	var last Position
+	resumed, err := broker.store.GetOutboxEventByID(ctx, afterID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return last, err
+	}
+	if err == nil {
+		last = PositionOf(*resumed)
+	}
+
+	err = broker.read(ctx, afterID, func(event db.OutboxEvent) error {
+		last = PositionOf(event)
+		if message, ok := MessageFor(owner, event); ok {
+			return send(message)
+		}
+		return nil
+	})
+	return last, err
+}
+
+// hand every committed event after the event afterID to handle, in commit order
+func (broker *Broker) read(ctx context.Context, afterID int64, handle func(db.OutboxEvent) error) error {
+	for {
+		events, err := broker.store.GetOutboxEventsAfter(ctx, afterID, defaultReplayLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range *events {
+			afterID = event.ID
+			if err := handle(event); err != nil {
+				return err
+			}
+		}
+
+		if len(*events) < defaultReplayLimit {
+			return nil
+		}
+	}
+}
+
+// Run feeds the broker from the store's committed outbox events, in commit order, until ctx is done.
+// It reads the outbox the way Replay does, so a resumed stream and the live one agree on the order.
+func (broker *Broker) Run(ctx context.Context) {
+	wake := make(chan struct{}, 1)
+	go broker.listen(ctx, wake)
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+
+	var lastID int64
+	started := false
+	for {
+		var err error
+		if !started {
+			// live streams start with what commits from now on
+			lastID, err = broker.store.GetLastOutboxEventID(ctx)
+			started = err == nil
+		} else {
+			err = broker.read(ctx, lastID, func(event db.OutboxEvent) error {
+				lastID = event.ID
+				return broker.Publish(ctx, event)
+			})
+		}
+		if err != nil && ctx.Err() == nil {
+			broker.logger.Warn("reading outbox events", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-poll.C:
+		}
+	}
+}
+
+// wake Run up whenever an outbox event commits, until ctx is done
+func (broker *Broker) listen(ctx context.Context, wake chan<- struct{}) {
+	for {
+		err := broker.store.ListenOutboxEvents(ctx, func(ctx context.Context, event db.OutboxEvent) error {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			broker.logger.Warn("listening for outbox events, reconnecting", "error", err.Error(), "delay", reconnectDelay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// caller holds broker.mu
+func (broker *Broker) remove(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+
+	subscription.closed = true
+	delete(broker.subscribers, subscription)
+	close(subscription.messages)
+}
+
+// CloseAll closes every subscription, ending their streams.
+func (broker *Broker) CloseAll() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for subscription := range broker.subscribers {
+		broker.remove(subscription)
+	}
+}
@@ -0,0 +1,227 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func randomAccount() db.Account {
+	return db.Account{
+		ID:       utils.RandomInt(1, 1000),
+		Owner:    utils.RandomOwner(),
+		Balance:  utils.RandomMoney(),
+		Currency: currency.USD,
+	}
+}
+
+func balanceEvent(t *testing.T, id int64, account db.Account) db.OutboxEvent {
+	payload, err := json.Marshal(account)
+	assert.NoError(t, err)
+
+	return db.OutboxEvent{ID: id, AggregateType: db.AggregateAccount, AggregateID: account.ID, EventType: db.EventAccountBalanceChanged, Payload: payload, TxID: id}
+}
+
+func transferEvent(t *testing.T, id int64, from, to db.Account) db.OutboxEvent {
+	payload, err := json.Marshal(db.TransferTxResult{
+		TransferRecord:  db.Transfer{ID: id, FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10},
+		FromAccount:     from,
+		ToAccount:       to,
+		FromEntryRecord: db.Entry{ID: 1, AccountID: from.ID, Amount: -10},
+		ToEntryRecord:   db.Entry{ID: 2, AccountID: to.ID, Amount: 10},
+	})
+	assert.NoError(t, err)
+
+	return db.OutboxEvent{ID: id, AggregateType: db.AggregateTransfer, AggregateID: id, EventType: db.EventTransferCompleted, Payload: payload, TxID: id}
+}
+
+// Owners should only see their own balance changes.
+func TestMessageForBalance(t *testing.T) {
+	account := randomAccount()
+	event := balanceEvent(t, 1, account)
+
+	message, ok := MessageFor(account.Owner, event)
+	assert.True(t, ok)
+	assert.Equal(t, MessageBalance, message.Event)
+	assert.Equal(t, int64(1), message.ID)
+	assert.Equal(t, account, message.Data)
+
+	_, ok = MessageFor("someone-else", event)
+	assert.False(t, ok)
+}
+
+// Each party of a transfer should only see its own entry.
+func TestMessageForTransfer(t *testing.T) {
+	from, to := randomAccount(), randomAccount()
+	event := transferEvent(t, 1, from, to)
+
+	message, ok := MessageFor(to.Owner, event)
+	assert.True(t, ok)
+	assert.Equal(t, MessageEntries, message.Event)
+	entries := message.Data.(EntriesMessage).Entries
+	assert.Len(t, entries, 1)
+	assert.Equal(t, to.ID, entries[0].AccountID)
+
+	_, ok = MessageFor("someone-else", event)
+	assert.False(t, ok)
+}
+
+// Published events should reach interested subscribers only.
+func TestPublishSubscribe(t *testing.T) {
+	broker := NewBroker(nil, slog.Default())
+	account := randomAccount()
+
+	mine := broker.Subscribe(account.Owner)
+	defer mine.Close()
+	other := broker.Subscribe("someone-else")
+	defer other.Close()
+
+	assert.NoError(t, broker.Publish(context.Background(), balanceEvent(t, 1, account)))
+
+	message := <-mine.Messages()
+	assert.Equal(t, int64(1), message.ID)
+	assert.Empty(t, other.Messages())
+}
+
+// A subscriber that falls behind should be dropped instead of blocking everyone else.
+func TestPublishDropsSlowSubscriber(t *testing.T) {
+	broker := NewBroker(nil, slog.Default())
+	account := randomAccount()
+	subscription := broker.Subscribe(account.Owner)
+
+	for i := 0; i <= defaultBuffer; i++ {
+		assert.NoError(t, broker.Publish(context.Background(), balanceEvent(t, int64(i+1), account)))
+	}
+
+	received := 0
+	for range subscription.Messages() {
+		received++
+	}
+	assert.Equal(t, defaultBuffer, received)
+
+	// closing after being dropped is harmless
+	subscription.Close()
+}
+
+// Replay should page through the outbox and only send the owner's messages.
+func TestReplay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	broker := NewBroker(store, slog.Default())
+	mine, other := randomAccount(), randomAccount()
+
+	firstPage := make([]db.OutboxEvent, defaultReplayLimit)
+	for i := range firstPage {
+		account := other
+		if i%2 == 0 {
+			account = mine
+		}
+		firstPage[i] = balanceEvent(t, int64(10+i), account)
+	}
+	lastID := int64(10 + defaultReplayLimit)
+	secondPage := []db.OutboxEvent{transferEvent(t, lastID, other, mine)}
+
+	resumed := balanceEvent(t, 9, mine)
+
+	gomock.InOrder(
+		store.EXPECT().GetOutboxEventByID(gomock.Any(), gomock.Eq(int64(9))).Return(&resumed, nil),
+		store.EXPECT().GetOutboxEventsAfter(gomock.Any(), gomock.Eq(int64(9)), gomock.Eq(int64(defaultReplayLimit))).Return(&firstPage, nil),
+		store.EXPECT().GetOutboxEventsAfter(gomock.Any(), gomock.Eq(lastID-1), gomock.Eq(int64(defaultReplayLimit))).Return(&secondPage, nil),
+	)
+
+	var sent []Message
+	last, err := broker.Replay(context.Background(), mine.Owner, 9, func(message Message) error {
+		sent = append(sent, message)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Position{TxID: lastID, ID: lastID}, last)
+	assert.Len(t, sent, defaultReplayLimit/2+1)
+	assert.Equal(t, MessageEntries, sent[len(sent)-1].Event)
+}
+
+// Without newer events, Replay should return the position of the event it resumed from.
+func TestReplayNothingNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	broker := NewBroker(store, slog.Default())
+	account := randomAccount()
+	resumed := balanceEvent(t, 9, account)
+	resumed.TxID = 20
+
+	store.EXPECT().GetOutboxEventByID(gomock.Any(), gomock.Eq(int64(9))).Return(&resumed, nil)
+	store.EXPECT().GetOutboxEventsAfter(gomock.Any(), gomock.Eq(int64(9)), gomock.Any()).Return(&[]db.OutboxEvent{}, nil)
+
+	last, err := broker.Replay(context.Background(), account.Owner, 9, func(Message) error {
+		t.Fatal("nothing to send")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Position{TxID: 20, ID: 9}, last)
+}
+
+// Positions should follow the writing transaction first, ids only within one.
+func TestPositionAfter(t *testing.T) {
+	assert.True(t, Position{TxID: 2, ID: 1}.After(Position{TxID: 1, ID: 5}))
+	assert.True(t, Position{TxID: 1, ID: 6}.After(Position{TxID: 1, ID: 5}))
+	assert.False(t, Position{TxID: 1, ID: 5}.After(Position{TxID: 1, ID: 5}))
+	assert.False(t, Position{TxID: 1, ID: 9}.After(Position{TxID: 2, ID: 1}))
+}
+
+// Run should start at the last committed event and publish what commits later in the store's order,
+// even when a transaction that took a smaller id committed last.
+func TestRunPublishesInCommitOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	broker := NewBroker(store, slog.Default())
+	account := randomAccount()
+
+	late := balanceEvent(t, 11, account)
+	late.TxID = 30
+	early := balanceEvent(t, 12, account)
+	early.TxID = 20
+
+	store.EXPECT().GetLastOutboxEventID(gomock.Any()).Return(int64(10), nil)
+	store.EXPECT().ListenOutboxEvents(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, handle func(context.Context, db.OutboxEvent) error) error {
+		assert.NoError(t, handle(ctx, early))
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	store.EXPECT().GetOutboxEventsAfter(gomock.Any(), gomock.Eq(int64(10)), gomock.Any()).Return(&[]db.OutboxEvent{early, late}, nil)
+	store.EXPECT().GetOutboxEventsAfter(gomock.Any(), gomock.Eq(int64(11)), gomock.Any()).Return(&[]db.OutboxEvent{}, nil).AnyTimes()
+
+	subscription := broker.Subscribe(account.Owner)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		broker.Run(ctx)
+		close(done)
+	}()
+
+	assert.Equal(t, int64(12), (<-subscription.Messages()).ID)
+	assert.Equal(t, int64(11), (<-subscription.Messages()).ID)
+
+	cancel()
+	<-done
+}
+
+// CloseAll should end every subscription.
+func TestCloseAll(t *testing.T) {
+	broker := NewBroker(nil, slog.Default())
+	first, second := broker.Subscribe("first"), broker.Subscribe("second")
+
+	broker.CloseAll()
+
+	_, ok := <-first.Messages()
+	assert.False(t, ok)
+	_, ok = <-second.Messages()
+	assert.False(t, ok)
+}
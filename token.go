@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joelpatel/go-bank/usertoken"
+)
+
+const tokenUsage = `usage: go-bank token <username> [flags]
+
+prints a token username can call the API with for auth.token_ttl,
+signed with auth.token_secret; for local use, users normally get theirs from the identity provider
+
+flags are the same as the server's, see go-bank -h`
+
+// go-bank token <username>
+func runToken(args []string) {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, tokenUsage)
+		os.Exit(2)
+	}
+	username := args[0]
+
+	cfg := loadConfig(args[1:])
+	if cfg.Auth.TokenSecret == "" {
+		log.Fatal("auth.token_secret is not set, the server would not accept the token")
+	}
+
+	token, err := usertoken.Issue(cfg.Auth.TokenSecret, username, cfg.Auth.TokenTTL, time.Now())
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,63 @@
+// Package tracing sets up OpenTelemetry: where spans are exported and how
+// trace context is propagated (W3C traceparent and baggage headers).
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/joelpatel/go-bank/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// Setup installs the global tracer provider and propagator for tracing.
+// The returned func flushes spans not exported yet and stops exporting.
+// With no exporter spans are still propagated, but not recorded.
+func Setup(ctx context.Context, tracing config.TracingConfig) (shutdown func(ctx context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch tracing.Exporter {
+	case config.TracingOTLP:
+		options := []otlptracehttp.Option{}
+		if tracing.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(tracing.Endpoint))
+		}
+		if tracing.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case config.TracingStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: creating %s exporter: %w", tracing.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(tracing.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// InMemory installs a global tracer provider keeping every span in the returned exporter,
+// for tests to look at what was traced. Spans are there as soon as they end.
+func InMemory() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return exporter
+}
@@ -0,0 +1,57 @@
+package usertoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	token, err := Issue("secret", "alice", time.Hour, now)
+	require.NoError(t, err)
+
+	username, err := Verify("secret", token, now.Add(59*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", username)
+
+	_, err = Verify("secret", token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidToken, "expired")
+	_, err = Verify("other", token, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "other secret")
+	_, err = Verify("", token, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "no secret")
+
+	parts := strings.Split(token, ".")
+	forged, err := Issue("secret", "mallory", time.Hour, now)
+	require.NoError(t, err)
+	_, err = Verify("secret", parts[0]+"."+strings.Split(forged, ".")[1]+"."+parts[2], now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "swapped claims")
+
+	unsigned := encode([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+	_, err = Verify("secret", unsigned, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "alg none")
+
+	for _, malformed := range []string{"", "a.b", "a.b.c", token + "x"} {
+		_, err := Verify("secret", malformed, now)
+		assert.ErrorIs(t, err, ErrInvalidToken, malformed)
+	}
+}
+
+func TestFromHeader(t *testing.T) {
+	token, ok := FromHeader("Bearer abc.def.ghi")
+	assert.True(t, ok)
+	assert.Equal(t, "abc.def.ghi", token)
+
+	token, ok = FromHeader("bearer abc")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", token)
+
+	for _, value := range []string{"", "Bearer ", "Basic abc", "abc"} {
+		_, ok := FromHeader(value)
+		assert.False(t, ok, value)
+	}
+}
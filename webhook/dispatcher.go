@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+// EventAccountCredited is sent to the owner of the account receiving a transfer.
+const EventAccountCredited = "AccountCredited"
+
+// Return true if webhooks can be subscribed to the event type, else returns false.
+func IsSupportedEventType(eventType string) bool {
+	switch eventType {
+	case EventAccountCredited,
+		db.EventAccountCreated,
+		db.EventAccountUpdated,
+		db.EventAccountOwnerChanged,
+		db.EventAccountBalanceChanged,
+		db.EventAccountFrozen,
+		db.EventAccountUnfrozen,
+		db.EventTransferCompleted:
+		return true
+	default:
+		return false
+	}
+}
+
+// AccountCreditedEvent is the payload of EventAccountCredited.
+type AccountCreditedEvent struct {
+	Account  db.Account  `json:"account"`
+	Entry    db.Entry    `json:"entry"`
+	Transfer db.Transfer `json:"transfer"`
+}
+
+// Dispatcher fans outbox events out into webhook deliveries for the subscribed owners.
+// It is an outbox.Publisher; fanning out the same event twice creates no duplicate deliveries.
+type Dispatcher struct {
+	store db.Store
+}
+
+// NewDispatcher creates a dispatcher storing deliveries in store.
+func NewDispatcher(store db.Store) *Dispatcher {
+	return &Dispatcher{store: store}
+}
+
+type notification struct {
+	owner     string
+	eventType string
+	payload   any
+}
+
+// Publish creates a pending delivery for every subscription interested in the event.
+func (dispatcher *Dispatcher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	notifications, err := notificationsFor(event)
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		subscriptions, err := dispatcher.store.GetWebhookSubscriptionsForEvent(ctx, notification.owner, notification.eventType)
+		if err != nil {
+			return err
+		}
+		if len(*subscriptions) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(notification.payload)
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range *subscriptions {
+			_, err := dispatcher.store.CreateWebhookDelivery(ctx, subscription.ID, event.ID, notification.eventType, payload)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// owners to notify about event, and what to tell them
+func notificationsFor(event db.OutboxEvent) ([]notification, error) {
+	switch event.EventType {
+	case db.EventAccountCreated, db.EventAccountUpdated, db.EventAccountOwnerChanged, db.EventAccountBalanceChanged, db.EventAccountFrozen, db.EventAccountUnfrozen:
+		var account db.Account
+		if err := event.Decode(&account); err != nil {
+			return nil, err
+		}
+
+		return []notification{{owner: account.Owner, eventType: event.EventType, payload: account}}, nil
+
+	case db.EventTransferCompleted:
+		var result db.TransferTxResult
+		if err := event.Decode(&result); err != nil {
+			return nil, err
+		}
+
+		notifications := []notification{
+			{owner: result.FromAccount.Owner, eventType: event.EventType, payload: result.TransferRecord},
+			{owner: result.ToAccount.Owner, eventType: EventAccountCredited, payload: AccountCreditedEvent{
+				Account:  result.ToAccount,
+				Entry:    result.ToEntryRecord,
+				Transfer: result.TransferRecord,
+			}},
+		}
+		if result.ToAccount.Owner != result.FromAccount.Owner {
+			notifications = append(notifications, notification{owner: result.ToAccount.Owner, eventType: event.EventType, payload: result.TransferRecord})
+		}
+
+		return notifications, nil
+
+	default:
+		// nothing an owner can subscribe to (e.g. AccountDeleted carries no owner)
+		return nil, nil
+	}
+}
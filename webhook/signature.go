@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers sent with every delivery
+const (
+	SignatureHeader = "X-Bank-Signature"
+	EventHeader     = "X-Bank-Event"
+	DeliveryHeader  = "X-Bank-Delivery"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header value for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, computeMAC(secret, unix, body))
+}
+
+// Verify checks a signature header produced by Sign and rejects it when older than tolerance.
+// Receivers can use it as the reference implementation.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var unix, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			mac = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || mac == "" {
+		return ErrInvalidSignature
+	}
+
+	if now.Sub(time.Unix(seconds, 0)) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(mac), []byte(computeMAC(secret, unix, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// GenerateSecret returns a random secret for a new subscription.
+func GenerateSecret() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(buffer), nil
+}
+
+func computeMAC(secret, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,341 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/joelpatel/go-bank/currency"
+	"github.com/joelpatel/go-bank/db"
+	"github.com/joelpatel/go-bank/db/mockdb"
+	"github.com/joelpatel/go-bank/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func randomAccount() db.Account {
+	return db.Account{
+		ID:       utils.RandomInt(1, 1000),
+		Owner:    utils.RandomOwner(),
+		Balance:  utils.RandomMoney(),
+		Currency: currency.USD,
+	}
+}
+
+func randomSubscription(url string) *db.WebhookSubscription {
+	return &db.WebhookSubscription{
+		ID:         utils.RandomInt(1, 1000),
+		Owner:      utils.RandomOwner(),
+		URL:        url,
+		EventTypes: db.StringArray{EventAccountCredited},
+		Secret:     utils.RandomString(32),
+	}
+}
+
+func randomDelivery(subscriptionID int64) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID:             utils.RandomInt(1, 1000),
+		SubscriptionID: subscriptionID,
+		EventID:        utils.RandomInt(1, 1000),
+		EventType:      EventAccountCredited,
+		Payload:        json.RawMessage(`{"amount":10}`),
+		Status:         db.WebhookDeliveryPending,
+	}
+}
+
+func transferEvent(t *testing.T, from, to db.Account) db.OutboxEvent {
+	result := db.TransferTxResult{
+		TransferRecord:  db.Transfer{ID: utils.RandomInt(1, 1000), FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10},
+		FromAccount:     from,
+		ToAccount:       to,
+		FromEntryRecord: db.Entry{ID: 1, AccountID: from.ID, Amount: -10},
+		ToEntryRecord:   db.Entry{ID: 2, AccountID: to.ID, Amount: 10},
+	}
+	payload, err := json.Marshal(result)
+	assert.NoError(t, err)
+
+	return db.OutboxEvent{
+		ID:            utils.RandomInt(1, 1000),
+		AggregateType: db.AggregateTransfer,
+		AggregateID:   result.TransferRecord.ID,
+		EventType:     db.EventTransferCompleted,
+		Payload:       payload,
+	}
+}
+
+// A signature should verify with the same secret and body only.
+func TestSignVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"AccountCredited"}`)
+	header := Sign("secret", now, body)
+
+	assert.NoError(t, Verify("secret", header, body, time.Minute, now))
+	assert.ErrorIs(t, Verify("other", header, body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header, []byte(`{}`), time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header, body, time.Minute, now.Add(2*time.Minute)), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", "garbage", body, time.Minute, now), ErrInvalidSignature)
+}
+
+// Backoff should double per failed attempt and stay under the cap.
+func TestBackoff(t *testing.T) {
+	worker := NewWorker(nil, nil, slog.Default())
+
+	assert.Equal(t, 30*time.Second, worker.Backoff(1))
+	assert.Equal(t, 60*time.Second, worker.Backoff(2))
+	assert.Equal(t, 120*time.Second, worker.Backoff(3))
+	assert.Equal(t, 6*time.Hour, worker.Backoff(100))
+}
+
+// A completed transfer should credit the receiving owner and notify both owners of the transfer.
+func TestDispatcherTransferCompleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	from, to := randomAccount(), randomAccount()
+	event := transferEvent(t, from, to)
+	credited := randomSubscription("http://example.com")
+
+	store.EXPECT().
+		GetWebhookSubscriptionsForEvent(gomock.Any(), gomock.Eq(from.Owner), gomock.Eq(db.EventTransferCompleted)).
+		Times(1).
+		Return(&[]db.WebhookSubscription{}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionsForEvent(gomock.Any(), gomock.Eq(to.Owner), gomock.Eq(db.EventTransferCompleted)).
+		Times(1).
+		Return(&[]db.WebhookSubscription{}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionsForEvent(gomock.Any(), gomock.Eq(to.Owner), gomock.Eq(EventAccountCredited)).
+		Times(1).
+		Return(&[]db.WebhookSubscription{*credited}, nil)
+	store.EXPECT().
+		CreateWebhookDelivery(gomock.Any(), gomock.Eq(credited.ID), gomock.Eq(event.ID), gomock.Eq(EventAccountCredited), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, subscriptionID, eventID int64, eventType string, payload json.RawMessage) (int64, error) {
+			var credit AccountCreditedEvent
+			assert.NoError(t, json.Unmarshal(payload, &credit))
+			assert.Equal(t, to.ID, credit.Account.ID)
+			assert.Equal(t, int64(10), credit.Entry.Amount)
+			return 1, nil
+		})
+
+	assert.NoError(t, NewDispatcher(store).Publish(context.Background(), event))
+}
+
+// Events nobody can subscribe to should be ignored.
+func TestDispatcherIgnoresUnsubscribable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	event := db.OutboxEvent{EventType: db.EventAccountDeleted, Payload: json.RawMessage(`{"id":1}`)}
+	assert.NoError(t, NewDispatcher(store).Publish(context.Background(), event))
+}
+
+// A 2xx response should mark the delivery as succeeded, and the receiver should get a verifiable signature.
+func TestWorkerDeliverSucceeded(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	subscription := randomSubscription(receiver.URL)
+	delivery := randomDelivery(subscription.ID)
+
+	store.EXPECT().
+		ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&[]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().
+		MarkWebhookDeliverySucceeded(gomock.Any(), gomock.Eq(delivery.ID), gomock.Eq(int64(http.StatusNoContent))).
+		Times(1).
+		Return(int64(1), nil)
+
+	attempted, err := NewWorker(store, receiver.Client(), slog.Default()).DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+
+	request := <-received
+	assert.Equal(t, EventAccountCredited, request.Header.Get(EventHeader))
+	assert.Equal(t, strconv.FormatInt(delivery.ID, 10), request.Header.Get(DeliveryHeader))
+	assert.NoError(t, Verify(subscription.Secret, request.Header.Get(SignatureHeader), receivedBody, time.Minute, time.Now()))
+
+	var body Body
+	assert.NoError(t, json.Unmarshal(receivedBody, &body))
+	assert.Equal(t, delivery.EventID, body.EventID)
+	assert.JSONEq(t, string(delivery.Payload), string(body.Data))
+}
+
+// A non 2xx response should reschedule the delivery with backoff.
+func TestWorkerDeliverFailed(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	subscription := randomSubscription(receiver.URL)
+	delivery := randomDelivery(subscription.ID)
+	delivery.Attempts = 2
+
+	worker := NewWorker(store, receiver.Client(), slog.Default())
+	now := time.Now()
+	worker.now = func() time.Time { return now }
+
+	store.EXPECT().
+		ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&[]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().
+		MarkWebhookDeliveryFailed(gomock.Any(), gomock.Eq(delivery.ID), gomock.Eq(int64(http.StatusServiceUnavailable)), gomock.Any(), gomock.Eq(now.Add(120*time.Second)), gomock.Eq(int64(defaultMaxAttempts))).
+		Times(1).
+		Return(int64(1), nil)
+
+	_, err := worker.DeliverDue(context.Background())
+	assert.NoError(t, err)
+}
+
+// An unreachable subscriber should be recorded as a failure without a status code.
+func TestWorkerDeliverUnreachable(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	receiver.Close()
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	subscription := randomSubscription(receiver.URL)
+	delivery := randomDelivery(subscription.ID)
+
+	store.EXPECT().
+		ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&[]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().
+		MarkWebhookDeliveryFailed(gomock.Any(), gomock.Eq(delivery.ID), gomock.Eq(int64(0)), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(int64(1), nil)
+
+	_, err := NewWorker(store, &http.Client{Timeout: time.Second}, slog.Default()).DeliverDue(context.Background())
+	assert.NoError(t, err)
+}
+
+// A delivery that cannot be sent should be recorded as failed without holding up the rest of the batch.
+func TestWorkerDeliverBatchContinues(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	subscription := randomSubscription(receiver.URL)
+	missing := randomDelivery(subscription.ID + 1)
+	delivery := randomDelivery(subscription.ID)
+
+	store.EXPECT().
+		ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&[]db.WebhookDelivery{missing, delivery}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(missing.SubscriptionID)).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.EXPECT().
+		MarkWebhookDeliveryFailed(gomock.Any(), gomock.Eq(missing.ID), gomock.Eq(int64(0)), gomock.Eq(sql.ErrConnDone.Error()), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(int64(0), sql.ErrConnDone)
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().
+		MarkWebhookDeliverySucceeded(gomock.Any(), gomock.Eq(delivery.ID), gomock.Eq(int64(http.StatusNoContent))).
+		Times(1).
+		Return(int64(1), nil)
+
+	attempted, err := NewWorker(store, receiver.Client(), slog.Default()).DeliverDue(context.Background())
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Equal(t, 2, attempted)
+}
+
+func TestPublicAddress(t *testing.T) {
+	for address, public := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::":    true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false, // cloud metadata
+		"fd00:ec2::254":        false, // cloud metadata over IPv6
+		"fe80::1":              false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::ffff:127.0.0.1":     false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"224.0.0.1":            false,
+		"255.255.255.255":      false,
+		"::ffff:93.184.216.34": true,
+		"2001:db8::1":          true, // documentation, yet nothing to protect there
+	} {
+		assert.Equal(t, public, publicAddress(netip.MustParseAddr(address)), address)
+	}
+}
+
+// The default client should never reach the bank's own network.
+func TestWorkerDeliverForbiddenAddress(t *testing.T) {
+	reached := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer receiver.Close()
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	subscription := randomSubscription(receiver.URL)
+	delivery := randomDelivery(subscription.ID)
+
+	store.EXPECT().
+		ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&[]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().
+		GetWebhookSubscriptionByID(gomock.Any(), gomock.Eq(subscription.ID)).
+		Times(1).
+		Return(subscription, nil)
+	store.EXPECT().
+		MarkWebhookDeliveryFailed(gomock.Any(), gomock.Eq(delivery.ID), gomock.Eq(int64(0)), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, _ int64, _ int64, failure string, _ time.Time, _ int64) (int64, error) {
+			assert.Contains(t, failure, ErrForbiddenAddress.Error())
+			return 1, nil
+		})
+
+	_, err := NewWorker(store, nil, slog.Default()).DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, reached)
+}
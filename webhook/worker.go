@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joelpatel/go-bank/db"
+)
+
+const (
+	defaultMaxAttempts = 8
+	defaultBaseBackoff = 30 * time.Second
+	defaultMaxBackoff  = 6 * time.Hour
+	defaultBatchSize   = 50
+	defaultInterval    = 5 * time.Second
+	defaultTimeout     = 10 * time.Second
+)
+
+// Body is the JSON document POSTed to the subscriber.
+type Body struct {
+	DeliveryID int64           `json:"delivery_id"`
+	EventID    int64           `json:"event_id"`
+	Type       string          `json:"type"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Worker POSTs due deliveries to their subscriptions and reschedules failed ones.
+type Worker struct {
+	store       db.Store
+	client      *http.Client
+	maxAttempts int64
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	batchSize   int64
+	interval    time.Duration
+	now         func() time.Time
+	logger      *slog.Logger
+}
+
+// NewWorker creates a delivery worker with the default retry policy, logging to logger.
+// A nil client delivers to public addresses only, see ErrForbiddenAddress.
+func NewWorker(store db.Store, client *http.Client, logger *slog.Logger) *Worker {
+	if client == nil {
+		client = newPublicClient()
+	}
+
+	return &Worker{
+		store:       store,
+		client:      client,
+		maxAttempts: defaultMaxAttempts,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		batchSize:   defaultBatchSize,
+		interval:    defaultInterval,
+		now:         time.Now,
+		logger:      logger,
+	}
+}
+
+// Backoff returns how long to wait after the given number of failed attempts.
+func (worker *Worker) Backoff(attempts int64) time.Duration {
+	backoff := worker.baseBackoff
+	for i := int64(1); i < attempts && backoff < worker.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, worker.maxBackoff)
+}
+
+// DeliverDue attempts every due delivery once and returns how many were attempted.
+// A delivery that fails is recorded and the rest of the batch still goes out;
+// the error joins whatever could not be recorded.
+func (worker *Worker) DeliverDue(ctx context.Context) (int, error) {
+	// lease: long enough for every attempt of the batch to time out
+	lease := time.Duration(worker.batchSize)*worker.client.Timeout + time.Minute
+
+	deliveries, err := worker.store.ClaimDueWebhookDeliveries(ctx, worker.batchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[int64]*db.WebhookSubscription)
+
+	var errs []error
+	for i := range *deliveries {
+		delivery := &(*deliveries)[i]
+		if err := worker.deliver(ctx, subscriptions, delivery); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %d: %w", delivery.ID, err))
+		}
+	}
+
+	return len(*deliveries), errors.Join(errs...)
+}
+
+// send delivery and record how it went, subscriptions caches them for the batch.
+// the error is that of recording the outcome, the outcome itself is in the store
+func (worker *Worker) deliver(ctx context.Context, subscriptions map[int64]*db.WebhookSubscription, delivery *db.WebhookDelivery) error {
+	var statusCode int64
+	subscription, err := worker.subscription(ctx, subscriptions, delivery.SubscriptionID)
+	if err == nil {
+		statusCode, err = worker.send(ctx, subscription, delivery)
+	}
+
+	if err == nil {
+		_, err = worker.store.MarkWebhookDeliverySucceeded(ctx, delivery.ID, statusCode)
+		return err
+	}
+
+	nextAttemptAt := worker.now().Add(worker.Backoff(delivery.Attempts + 1))
+	_, err = worker.store.MarkWebhookDeliveryFailed(ctx, delivery.ID, statusCode, err.Error(), nextAttemptAt, worker.maxAttempts)
+	return err
+}
+
+func (worker *Worker) subscription(ctx context.Context, subscriptions map[int64]*db.WebhookSubscription, id int64) (*db.WebhookSubscription, error) {
+	if subscription, ok := subscriptions[id]; ok {
+		return subscription, nil
+	}
+
+	subscription, err := worker.store.GetWebhookSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions[id] = subscription
+	return subscription, nil
+}
+
+// Run delivers due deliveries every interval until ctx is done.
+func (worker *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := worker.DeliverDue(ctx)
+		if err != nil {
+			worker.logger.Error("delivering webhooks", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// POST the delivery; a non 2xx response is an error
+func (worker *Worker) send(ctx context.Context, subscription *db.WebhookSubscription, delivery *db.WebhookDelivery) (int64, error) {
+	body, err := json.Marshal(Body{
+		DeliveryID: delivery.ID,
+		EventID:    delivery.EventID,
+		Type:       delivery.EventType,
+		CreatedAt:  delivery.CreatedAt,
+		Data:       delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(SignatureHeader, Sign(subscription.Secret, worker.now(), body))
+
+	response, err := worker.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 1<<16))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return int64(response.StatusCode), fmt.Errorf("subscriber responded with status %d", response.StatusCode)
+	}
+
+	return int64(response.StatusCode), nil
+}